@@ -99,7 +99,7 @@ func convertContents(mark rune) (err error) {
 		return fmt.Errorf("No source files found")
 	}
 
-	jww.FEEDBACK.Println("processing", len(site.Source.Files()), "content files")
+	feedback("processing", len(site.Source.Files()), "content files")
 	for _, file := range site.Source.Files() {
 		jww.INFO.Println("Attempting to convert", file.LogicalName())
 		page, err := hugolib.NewPage(file.LogicalName())
@@ -140,7 +140,7 @@ func convertContents(mark rune) (err error) {
 			if unsafe {
 				page.SaveSource()
 			} else {
-				jww.FEEDBACK.Println("Unsafe operation not allowed, use --unsafe or set a different output path")
+				feedback("Unsafe operation not allowed, use --unsafe or set a different output path")
 			}
 		}
 	}
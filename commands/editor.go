@@ -0,0 +1,118 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/hugo/hugolib"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// The editor API lets editor plugins ask the server about the last build
+// and about where the content files end up, e.g. to open the preview of
+// the file being edited or to mark errors inline.
+const editorAPIPath = "/__hugo/"
+
+// buildStatus is what the editor API reports about the builds.
+type buildStatus struct {
+	sync.RWMutex
+	Building bool      `json:"building"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Duration float64   `json:"durationMs"`
+	Errors   []string  `json:"errors"`
+	pages    map[string]string
+}
+
+var status = &buildStatus{Errors: []string{}}
+
+func (b *buildStatus) start() {
+	b.Lock()
+	defer b.Unlock()
+	b.Building = true
+	b.Started = time.Now()
+	b.Errors = []string{}
+}
+
+func (b *buildStatus) finish(site *hugolib.Site, err error) {
+	b.Lock()
+	defer b.Unlock()
+	b.Building = false
+	b.Finished = time.Now()
+	b.Duration = float64(b.Finished.Sub(b.Started)) / float64(time.Millisecond)
+	if err != nil {
+		b.Errors = append(b.Errors, err.Error())
+	} else {
+		b.pages = site.SourceMap()
+	}
+}
+
+// Write collects the errors logged during a build.
+func (b *buildStatus) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	b.Errors = append(b.Errors, strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+// serveEditorAPI adds the editor API to the server:
+//
+//	/__hugo/status                  the state of the last build and its errors
+//	/__hugo/pages                   all content files with their URLs
+//	/__hugo/pages?source=post/a.md  the URL of one content file
+func serveEditorAPI() {
+	jww.ERROR.SetOutput(io.MultiWriter(jww.ERROR.Writer(), status))
+
+	http.HandleFunc(editorAPIPath+"status", status.serveStatus)
+	http.HandleFunc(editorAPIPath+"pages", status.servePages)
+}
+
+func (b *buildStatus) serveStatus(w http.ResponseWriter, r *http.Request) {
+	b.RLock()
+	defer b.RUnlock()
+	writeJSON(w, b)
+}
+
+func (b *buildStatus) servePages(w http.ResponseWriter, r *http.Request) {
+	b.RLock()
+	defer b.RUnlock()
+
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		writeJSON(w, b.pages)
+		return
+	}
+
+	source = strings.TrimPrefix(filepath.ToSlash(source), "/")
+	if url, ok := b.pages[source]; ok {
+		writeJSON(w, map[string]string{"source": source, "url": url})
+		return
+	}
+	http.Error(w, "No page for "+source, http.StatusNotFound)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		// not ERROR, which would end up in the status being written
+		jww.WARN.Println("Unable to write the editor API response:", err)
+	}
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEditorAPI(t *testing.T) {
+	b := &buildStatus{Errors: []string{}}
+	b.start()
+	b.Write([]byte("Error while rendering page: boom\n"))
+	b.finish(nil, errors.New("Errors rendering pages"))
+	b.pages = map[string]string{"post/a.md": "http://localhost:1313/post/a/"}
+
+	w := httptest.NewRecorder()
+	b.serveStatus(w, httptest.NewRequest("GET", "/__hugo/status", nil))
+
+	var got struct {
+		Building bool
+		Errors   []string
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid status %q: %s", w.Body.String(), err)
+	}
+	if got.Building || len(got.Errors) != 2 || got.Errors[0] != "Error while rendering page: boom" {
+		t.Errorf("Unexpected status: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	b.servePages(w, httptest.NewRequest("GET", "/__hugo/pages?source=/post/a.md", nil))
+	if !strings.Contains(w.Body.String(), `"url":"http://localhost:1313/post/a/"`) {
+		t.Errorf("Unexpected page: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	b.servePages(w, httptest.NewRequest("GET", "/__hugo/pages?source=missing.md", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing page, got %d", w.Code)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/utils"
+	"github.com/spf13/viper"
+)
+
+var goldenDir string
+var updateGolden bool
+
+func init() {
+	testCmd.Flags().StringVar(&goldenDir, "golden", "golden", "directory of the expected output, relative to the source")
+	testCmd.Flags().BoolVar(&updateGolden, "update", false, "write the built site to the golden directory instead of comparing")
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Compare the built site with a golden copy",
+	Long: `Hugo builds the site in memory and compares it with the golden
+directory, printing the differences of every file as a unified diff.
+It fails when there are any, so that unintended changes from a new theme
+or Hugo version are caught. Run it with --update to accept the current
+output as the golden copy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		InitializeConfig()
+		utils.StopOnErr(testGolden())
+	},
+}
+
+func testGolden() error {
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir"))
+	golden := helpers.AbsPathify(goldenDir)
+
+	disk := hugofs.DestinationFS
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	defer func() { hugofs.DestinationFS = disk }()
+
+	if err := copyStatic(); err != nil {
+		return err
+	}
+	if err := buildSite(); err != nil {
+		return err
+	}
+
+	if updateGolden {
+		n, err := writeGolden(golden, hugofs.SourceFs, publishDir, hugofs.DestinationFS)
+		if err != nil {
+			return err
+		}
+		feedbackf("Updated %d file(s) in %s\n", n, golden)
+		return nil
+	}
+
+	n, err := diffGolden(feedbackOut, golden, hugofs.SourceFs, publishDir, hugofs.DestinationFS)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%d file(s) differ from %s, run hugo test --update to accept them", n, golden)
+	}
+	feedback("The site is the same as", golden)
+	return nil
+}
+
+// diffGolden writes the differences of the site built in dir to the golden
+// copy as unified diffs, and returns the number of files that differ.
+func diffGolden(w io.Writer, golden string, goldenFs afero.Fs, dir string, fs afero.Fs) (int, error) {
+	added, changed, removed, err := helpers.DiffDirs(golden, goldenFs, dir, fs)
+	if err != nil {
+		return 0, err
+	}
+
+	diff := func(name string, before, after []byte) {
+		name = filepath.ToSlash(name)
+		fmt.Fprint(w, helpers.UnifiedDiff("golden/"+name, "site/"+name, before, after))
+	}
+
+	for _, name := range removed {
+		before, err := helpers.ReadFile(filepath.Join(golden, name), goldenFs)
+		if err != nil {
+			return 0, err
+		}
+		diff(name, before, nil)
+	}
+	for _, name := range changed {
+		before, err := helpers.ReadFile(filepath.Join(golden, name), goldenFs)
+		if err != nil {
+			return 0, err
+		}
+		after, err := helpers.ReadFile(filepath.Join(dir, name), fs)
+		if err != nil {
+			return 0, err
+		}
+		diff(name, before, after)
+	}
+	for _, name := range added {
+		after, err := helpers.ReadFile(filepath.Join(dir, name), fs)
+		if err != nil {
+			return 0, err
+		}
+		diff(name, nil, after)
+	}
+
+	return len(added) + len(changed) + len(removed), nil
+}
+
+// writeGolden makes the golden copy the same as the site built in dir, and
+// returns the number of files changed.
+func writeGolden(golden string, goldenFs afero.Fs, dir string, fs afero.Fs) (int, error) {
+	added, changed, removed, err := helpers.DiffDirs(golden, goldenFs, dir, fs)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, name := range removed {
+		if err := goldenFs.Remove(filepath.Join(golden, name)); err != nil {
+			return 0, err
+		}
+	}
+	for _, name := range append(added, changed...) {
+		content, err := helpers.ReadFile(filepath.Join(dir, name), fs)
+		if err != nil {
+			return 0, err
+		}
+		if err := helpers.WriteToDisk(filepath.Join(golden, name), bytes.NewReader(content), goldenFs); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(added) + len(changed) + len(removed), nil
+}
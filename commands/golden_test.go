@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+)
+
+func TestGolden(t *testing.T) {
+	fs := new(afero.MemMapFs)
+	golden := filepath.FromSlash("/site/golden")
+	dir := filepath.FromSlash("/site/public")
+
+	write := func(root, name, content string) {
+		if err := helpers.WriteToDisk(filepath.Join(root, filepath.FromSlash(name)), strings.NewReader(content), fs); err != nil {
+			t.Fatalf("Unable to write %s: %s", name, err)
+		}
+	}
+
+	write(dir, "index.html", "<h1>Home</h1>\n")
+	write(dir, "post/one/index.html", "<h1>One</h1>\n")
+
+	if n, err := writeGolden(golden, fs, dir, fs); err != nil || n != 2 {
+		t.Fatalf("Expected 2 golden files written, got %d %v", n, err)
+	}
+
+	var out bytes.Buffer
+	if n, err := diffGolden(&out, golden, fs, dir, fs); err != nil || n != 0 || out.Len() != 0 {
+		t.Fatalf("Expected no differences after the update, got %d %v:\n%s", n, err, out.String())
+	}
+
+	write(dir, "index.html", "<h1>Welcome</h1>\n")
+	write(dir, "post/two/index.html", "<h1>Two</h1>\n")
+	fs.Remove(filepath.Join(dir, "post", "one", "index.html"))
+
+	n, err := diffGolden(&out, golden, fs, dir, fs)
+	if err != nil || n != 3 {
+		t.Fatalf("Expected 3 files to differ, got %d %v", n, err)
+	}
+	expected := "--- golden/post/one/index.html\n+++ site/post/one/index.html\n@@ -1 +0,0 @@\n-<h1>One</h1>\n" +
+		"--- golden/index.html\n+++ site/index.html\n@@ -1 +1 @@\n-<h1>Home</h1>\n+<h1>Welcome</h1>\n" +
+		"--- golden/post/two/index.html\n+++ site/post/two/index.html\n@@ -0,0 +1 @@\n+<h1>Two</h1>\n"
+	if out.String() != expected {
+		t.Errorf("Got diff\n%s\nexpected\n%s", out.String(), expected)
+	}
+
+	if n, err := writeGolden(golden, fs, dir, fs); err != nil || n != 3 {
+		t.Fatalf("Expected 3 golden files updated, got %d %v", n, err)
+	}
+	out.Reset()
+	if n, _ := diffGolden(&out, golden, fs, dir, fs); n != 0 {
+		t.Errorf("Expected no differences after the second update, got:\n%s", out.String())
+	}
+}
@@ -17,6 +17,8 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -25,6 +27,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/fsync"
 	"github.com/spf13/hugo/helpers"
@@ -56,8 +59,8 @@ Complete documentation is available at http://gohugo.io`,
 var hugoCmdV *cobra.Command
 
 //Flags that are to be added to commands.
-var BuildWatch, IgnoreCache, Draft, Future, UglyURLs, Verbose, Logging, VerboseLog, DisableRSS, DisableSitemap, PluralizeListTitles, NoTimes bool
-var Source, CacheDir, Destination, Theme, BaseURL, CfgFile, LogFile, Editor string
+var BuildWatch, CheckUnchanged, IgnoreCache, Draft, Future, UglyURLs, Verbose, Logging, VerboseLog, DisableRSS, DisableSitemap, PluralizeListTitles, NoTimes, Beautify, Strict bool
+var Source, CacheDir, Destination, Theme, BaseURL, CfgFile, LogFile, LogFormat, Editor string
 
 //Execute adds all child commands to the root command HugoCmd and sets flags appropriately.
 func Execute() {
@@ -75,6 +78,7 @@ func AddCommands() {
 	HugoCmd.AddCommand(convertCmd)
 	HugoCmd.AddCommand(newCmd)
 	HugoCmd.AddCommand(listCmd)
+	HugoCmd.AddCommand(testCmd)
 }
 
 //Initializes flags
@@ -96,10 +100,14 @@ func init() {
 	HugoCmd.PersistentFlags().BoolVar(&Logging, "log", false, "Enable Logging")
 	HugoCmd.PersistentFlags().StringVar(&LogFile, "logFile", "", "Log File path (if set, logging enabled automatically)")
 	HugoCmd.PersistentFlags().BoolVar(&VerboseLog, "verboseLog", false, "verbose logging")
+	HugoCmd.PersistentFlags().StringVar(&LogFormat, "logFormat", "text", "text, or json to print the build events as JSON lines")
 	HugoCmd.PersistentFlags().BoolVar(&nitro.AnalysisOn, "stepAnalysis", false, "display memory and timing of different steps of the program")
 	HugoCmd.PersistentFlags().BoolVar(&PluralizeListTitles, "pluralizeListTitles", true, "Pluralize titles in lists using inflect")
+	HugoCmd.PersistentFlags().BoolVar(&Beautify, "beautify", false, "Indent the generated HTML consistently, e.g. to diff it in version control")
+	HugoCmd.PersistentFlags().BoolVar(&Strict, "strict", false, "fail the build when the output is over the budgets of the site config, or pages collide")
 	HugoCmd.Flags().BoolVarP(&BuildWatch, "watch", "w", false, "watch filesystem for changes and recreate as needed")
 	HugoCmd.Flags().BoolVarP(&NoTimes, "noTimes", "", false, "Don't sync modification time of files")
+	HugoCmd.Flags().BoolVar(&CheckUnchanged, "checkUnchanged", false, "build in memory and exit with an error if the files in the destination would change")
 	hugoCmdV = HugoCmd
 }
 
@@ -117,13 +125,29 @@ func InitializeConfig() {
 	viper.SetDefault("Watch", false)
 	viper.SetDefault("MetaDataFormat", "toml")
 	viper.SetDefault("DisableRSS", false)
+	viper.SetDefault("Feeds", []string{"rss"})
 	viper.SetDefault("DisableSitemap", false)
+	viper.SetDefault("EnableRobotsTXT", false)
+	viper.SetDefault("EnableSearchIndex", false)
+	viper.SetDefault("SearchIndex", "search-index.json")
+	viper.SetDefault("EnableCorpus", false)
+	viper.SetDefault("Corpus", "corpus.txt")
+	viper.SetDefault("EnableSocialCards", false)
+	viper.SetDefault("SocialCardColor", "#ffffff")
+	viper.SetDefault("SocialCardBackground", "#1e293b")
+	viper.SetDefault("SocialCardImage", "")
+	viper.SetDefault("ShortcodesInCode", false)
+	viper.SetDefault("TemplateNilAccess", "error")
 	viper.SetDefault("ContentDir", "content")
 	viper.SetDefault("LayoutDir", "layouts")
 	viper.SetDefault("StaticDir", "static")
 	viper.SetDefault("ArchetypeDir", "archetypes")
 	viper.SetDefault("PublishDir", "public")
 	viper.SetDefault("DataDir", "data")
+	viper.SetDefault("I18nDir", "i18n")
+	viper.SetDefault("IconDir", "icons")
+	viper.SetDefault("AssetDir", "assets")
+	viper.SetDefault("IconSprite", "icons.svg")
 	viper.SetDefault("DefaultLayout", "post")
 	viper.SetDefault("BuildDrafts", false)
 	viper.SetDefault("BuildFuture", false)
@@ -131,12 +155,14 @@ func InitializeConfig() {
 	viper.SetDefault("Verbose", false)
 	viper.SetDefault("IgnoreCache", false)
 	viper.SetDefault("CanonifyURLs", false)
+	viper.SetDefault("RelativeURLs", false)
 	viper.SetDefault("Taxonomies", map[string]string{"tag": "tags", "category": "categories"})
 	viper.SetDefault("Permalinks", make(hugolib.PermalinkOverrides, 0))
 	viper.SetDefault("Sitemap", hugolib.Sitemap{Priority: -1})
 	viper.SetDefault("PygmentsStyle", "monokai")
 	viper.SetDefault("DefaultExtension", "html")
 	viper.SetDefault("PygmentsUseClasses", false)
+	viper.SetDefault("HighlightCodeFences", false)
 	viper.SetDefault("DisableLiveReload", false)
 	viper.SetDefault("PluralizeListTitles", true)
 	viper.SetDefault("FootnoteAnchorPrefix", "")
@@ -144,6 +170,10 @@ func InitializeConfig() {
 	viper.SetDefault("NewContentEditor", "")
 	viper.SetDefault("Paginate", 10)
 	viper.SetDefault("PaginatePath", "page")
+	viper.SetDefault("BuildArchives", false)
+	viper.SetDefault("Beautify", false)
+	viper.SetDefault("Strict", false)
+	viper.SetDefault("BannedWords", []string{})
 	viper.SetDefault("Blackfriday", helpers.NewBlackfriday())
 
 	if hugoCmdV.PersistentFlags().Lookup("buildDrafts").Changed {
@@ -174,6 +204,14 @@ func InitializeConfig() {
 		viper.Set("PluralizeListTitles", PluralizeListTitles)
 	}
 
+	if hugoCmdV.PersistentFlags().Lookup("beautify").Changed {
+		viper.Set("Beautify", Beautify)
+	}
+
+	if hugoCmdV.PersistentFlags().Lookup("strict").Changed {
+		viper.Set("Strict", Strict)
+	}
+
 	if hugoCmdV.PersistentFlags().Lookup("editor").Changed {
 		viper.Set("NewContentEditor", Editor)
 	}
@@ -243,10 +281,23 @@ func InitializeConfig() {
 		jww.SetLogThreshold(jww.LevelInfo)
 	}
 
+	switch LogFormat {
+	case "text":
+	case "json":
+		useJSONBuildLog()
+	default:
+		jww.ERROR.Printf("Unknown log format %q, use text or json\n", LogFormat)
+	}
+
 	jww.INFO.Println("Using config file:", viper.ConfigFileUsed())
 }
 
 func build(watches ...bool) {
+	if CheckUnchanged {
+		utils.StopOnErr(checkUnchanged())
+		return
+	}
+
 	utils.CheckErr(copyStatic(), fmt.Sprintf("Error copying static files to %s", helpers.AbsPathify(viper.GetString("PublishDir"))))
 	watch := false
 	if len(watches) > 0 && watches[0] {
@@ -255,8 +306,8 @@ func build(watches ...bool) {
 	utils.StopOnErr(buildSite(BuildWatch || watch))
 
 	if BuildWatch {
-		jww.FEEDBACK.Println("Watching for changes in", helpers.AbsPathify(viper.GetString("ContentDir")))
-		jww.FEEDBACK.Println("Press Ctrl+C to stop")
+		feedback("Watching for changes in", helpers.AbsPathify(viper.GetString("ContentDir")))
+		feedback("Press Ctrl+C to stop")
 		utils.CheckErr(NewWatcher(0))
 	}
 }
@@ -292,10 +343,36 @@ func copyStatic() error {
 	return syncer.Sync(publishDir, staticDir)
 }
 
+// copyStaticFiles copies the changed static files, by their path relative
+// to the static directories, to the publish directory, instead of syncing
+// all of them. It syncs all of them when a path isn't known.
+func copyStaticFiles(paths map[string]bool) error {
+	if len(paths) == 0 {
+		return copyStatic()
+	}
+
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir"))
+	staticDirs := []string{helpers.GetStaticDirPath()}
+	if themeDir, err := helpers.GetThemeStaticDirPath(); err == nil && themeDir != "" {
+		staticDirs = append(staticDirs, themeDir)
+	}
+
+	for path := range paths {
+		jww.INFO.Println("syncing", path, "to", publishDir)
+		if err := helpers.SyncStaticFile(path, publishDir, staticDirs, hugofs.SourceFs, hugofs.DestinationFS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // getDirList provides NewWatcher() with a list of directories to watch for changes.
 func getDirList() []string {
 	var a []string
 	dataDir := helpers.AbsPathify(viper.GetString("DataDir"))
+	i18nDir := helpers.AbsPathify(viper.GetString("I18nDir"))
+	iconDir := helpers.AbsPathify(viper.GetString("IconDir"))
+	assetDir := helpers.AbsPathify(viper.GetString("AssetDir"))
 	walker := func(path string, fi os.FileInfo, err error) error {
 		if err != nil {
 			if path == dataDir && os.IsNotExist(err) {
@@ -303,6 +380,10 @@ func getDirList() []string {
 				return nil
 
 			}
+			if (path == i18nDir || path == iconDir || path == assetDir) && os.IsNotExist(err) {
+				// translations, icons and assets are optional
+				return nil
+			}
 			jww.ERROR.Println("Walker: ", err)
 			return nil
 		}
@@ -335,6 +416,9 @@ func getDirList() []string {
 	}
 
 	filepath.Walk(dataDir, walker)
+	filepath.Walk(i18nDir, walker)
+	filepath.Walk(iconDir, walker)
+	filepath.Walk(assetDir, walker)
 	filepath.Walk(helpers.AbsPathify(viper.GetString("ContentDir")), walker)
 	filepath.Walk(helpers.AbsPathify(viper.GetString("LayoutDir")), walker)
 	filepath.Walk(helpers.AbsPathify(viper.GetString("StaticDir")), walker)
@@ -347,20 +431,132 @@ func getDirList() []string {
 
 func buildSite(watching ...bool) (err error) {
 	startTime := time.Now()
-	site := &hugolib.Site{}
+	sites := hugolib.NewSites()
 	if len(watching) > 0 && watching[0] {
-		site.RunMode.Watching = true
+		for _, site := range sites {
+			site.RunMode.Watching = true
+		}
 	}
-	err = site.Build()
+	status.start()
+	err = sites.Build()
+	status.finish(sites[0], err)
 	if err != nil {
 		return err
 	}
-	site.Stats()
-	jww.FEEDBACK.Printf("in %v ms\n", int(1000*time.Since(startTime).Seconds()))
+	for _, site := range sites {
+		site.Stats()
+	}
+	if helpers.BuildLog != nil {
+		helpers.BuildLog.Timing("total", time.Since(startTime))
+	} else {
+		feedbackf("in %v ms\n", int(1000*time.Since(startTime).Seconds()))
+	}
 
 	return nil
 }
 
+// feedbackOut is where the plain text output for the user goes, such as
+// "Watching for changes" or the differences found by --checkUnchanged:
+// stdout, or stderr with the JSON build log, which has stdout to itself.
+var feedbackOut io.Writer = os.Stdout
+
+// feedback prints a plain text message for the user, through jww as usual
+// unless the JSON build log has taken stdout.
+func feedback(a ...interface{}) {
+	if feedbackOut == os.Stdout {
+		jww.FEEDBACK.Println(a...)
+		return
+	}
+	fmt.Fprintln(feedbackOut, a...)
+}
+
+func feedbackf(format string, a ...interface{}) {
+	if feedbackOut == os.Stdout {
+		jww.FEEDBACK.Printf(format, a...)
+		return
+	}
+	fmt.Fprintf(feedbackOut, format, a...)
+}
+
+// useJSONBuildLog prints the warnings and errors as JSON lines, next to
+// the other build events.
+func useJSONBuildLog() {
+	helpers.BuildLog = helpers.NewBuildLogger(os.Stdout)
+	feedbackOut = os.Stderr
+
+	loggers := map[string]*log.Logger{
+		"warning":  jww.WARN,
+		"error":    jww.ERROR,
+		"critical": jww.CRITICAL,
+	}
+	if viper.GetBool("verbose") {
+		loggers["info"] = jww.INFO
+	}
+
+	for event, l := range loggers {
+		// the log file, if any, keeps the plain text lines
+		logFile := prefixWriter{jww.LogHandle, l.Prefix()}
+		l.SetOutput(io.MultiWriter(logFile, helpers.BuildLog.Writer(event)))
+		l.SetPrefix("")
+		l.SetFlags(0)
+	}
+}
+
+// prefixWriter writes to w with the prefix in front, for the log lines of
+// loggers that no longer add it themselves.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+}
+
+func (p prefixWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, p.prefix); err != nil {
+		return 0, err
+	}
+	return p.w.Write(b)
+}
+
+// checkUnchanged builds the site in memory and compares the result with
+// what is in the publish directory, e.g. to verify in CI that committed or
+// deployed output is up to date.
+func checkUnchanged() error {
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir"))
+
+	disk := hugofs.DestinationFS
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	defer func() { hugofs.DestinationFS = disk }()
+
+	if err := copyStatic(); err != nil {
+		return err
+	}
+	if err := buildSite(); err != nil {
+		return err
+	}
+
+	added, changed, removed, err := helpers.DiffDir(publishDir, disk, hugofs.DestinationFS)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range added {
+		feedback("added:  ", p)
+	}
+	for _, p := range changed {
+		feedback("changed:", p)
+	}
+	for _, p := range removed {
+		feedback("removed:", p)
+	}
+
+	if n := len(added) + len(changed) + len(removed); n > 0 {
+		return fmt.Errorf("%d file(s) in %s would change: %d added, %d changed, %d removed",
+			n, publishDir, len(added), len(changed), len(removed))
+	}
+
+	feedback("No changes in", publishDir)
+	return nil
+}
+
 // NewWatcher creates a new watcher to watch filesystem events.
 func NewWatcher(port int) error {
 	if runtime.GOOS == "darwin" {
@@ -394,6 +590,7 @@ func NewWatcher(port int) error {
 				staticChanged := false
 				dynamicChanged := false
 				staticFilesChanged := make(map[string]bool)
+				staticSyncAll := false
 
 				for _, ev := range evs {
 					ext := filepath.Ext(ev.Name)
@@ -413,6 +610,8 @@ func NewWatcher(port int) error {
 					if isstatic {
 						if staticPath, err := helpers.MakeStaticPathRelative(ev.Name); err == nil {
 							staticFilesChanged[staticPath] = true
+						} else {
+							staticSyncAll = true
 						}
 					}
 
@@ -425,18 +624,21 @@ func NewWatcher(port int) error {
 				}
 
 				if staticChanged {
-					jww.FEEDBACK.Println("Static file changed, syncing\n")
-					utils.StopOnErr(copyStatic(), fmt.Sprintf("Error copying static files to %s", helpers.AbsPathify(viper.GetString("PublishDir"))))
+					feedback("Static file changed, syncing\n")
+					if staticSyncAll {
+						staticFilesChanged = nil
+					}
+					utils.StopOnErr(copyStaticFiles(staticFilesChanged), fmt.Sprintf("Error copying static files to %s", helpers.AbsPathify(viper.GetString("PublishDir"))))
 
-					if !BuildWatch && !viper.GetBool("DisableLiveReload") {
+					if !BuildWatch && !viper.GetBool("DisableLiveReload") && !dynamicChanged {
 						// Will block forever trying to write to a channel that nobody is reading if livereload isn't initalized
 
-						// force refresh when more than one file
-						if len(staticFilesChanged) == 1 {
+						// the browser applies changed CSS and images in place,
+						// and reloads the page for the other files
+						if len(staticFilesChanged) > 0 {
 							for path := range staticFilesChanged {
 								livereload.RefreshPath(path)
 							}
-
 						} else {
 							livereload.ForceRefresh()
 						}
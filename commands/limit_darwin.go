@@ -36,9 +36,9 @@ var limit = &cobra.Command{
 		if err != nil {
 			jww.ERROR.Println("Error Getting Rlimit ", err)
 		}
-		jww.FEEDBACK.Println("Current rLimit:", rLimit)
+		feedback("Current rLimit:", rLimit)
 
-		jww.FEEDBACK.Println("Attempting to increase limit")
+		feedback("Attempting to increase limit")
 		rLimit.Max = 999999
 		rLimit.Cur = 999999
 		err = syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rLimit)
@@ -49,7 +49,7 @@ var limit = &cobra.Command{
 		if err != nil {
 			jww.ERROR.Println("Error Getting rLimit ", err)
 		}
-		jww.FEEDBACK.Println("rLimit after change:", rLimit)
+		feedback("rLimit after change:", rLimit)
 	},
 }
 
@@ -34,11 +34,13 @@ var configFormat string
 var contentType string
 var contentFormat string
 var contentFrontMatter string
+var forceNew bool
 
 func init() {
 	newSiteCmd.Flags().StringVarP(&configFormat, "format", "f", "toml", "config & frontmatter format")
 	newCmd.Flags().StringVarP(&configFormat, "format", "f", "toml", "frontmatter format")
 	newCmd.Flags().StringVarP(&contentType, "kind", "k", "", "Content type to create")
+	newCmd.Flags().BoolVar(&forceNew, "force", false, "Create the content even in an unknown section or of an unknown kind")
 	newCmd.AddCommand(newSiteCmd)
 	newCmd.AddCommand(newThemeCmd)
 }
@@ -50,6 +52,8 @@ var newCmd = &cobra.Command{
 It will guess which kind of file to create based on the path provided.
 You can also specify the kind with -k KIND
 If archetypes are provided in your theme or site, they will be used.
+Hugo refuses content in a section or of a kind it doesn't know, suggesting
+what you likely meant; use --force to create it anyway.
 `,
 	Run: NewContent,
 }
@@ -101,6 +105,12 @@ func NewContent(cmd *cobra.Command, args []string) {
 		kind = contentType
 	}
 
+	if !forceNew {
+		if err := create.CheckContentPath(contentType, createpath); err != nil {
+			jww.FATAL.Fatalln(err)
+		}
+	}
+
 	err := create.NewContent(kind, createpath)
 	if err != nil {
 		jww.ERROR.Println(err)
@@ -15,10 +15,12 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"runtime"
 	"strconv"
 	"strings"
@@ -28,6 +30,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/hugolib"
 	jww "github.com/spf13/jwalterweatherman"
 	"github.com/spf13/viper"
 )
@@ -36,6 +39,7 @@ var serverPort int
 var serverWatch bool
 var serverAppend bool
 var disableLiveReload bool
+var editorAPI bool
 
 //var serverCmdV *cobra.Command
 
@@ -53,6 +57,7 @@ func init() {
 	serverCmd.Flags().BoolVarP(&serverWatch, "watch", "w", false, "watch filesystem for changes and recreate as needed")
 	serverCmd.Flags().BoolVarP(&serverAppend, "appendPort", "", true, "append port to baseurl")
 	serverCmd.Flags().BoolVar(&disableLiveReload, "disableLiveReload", false, "watch without enabling live browser reload on rebuild")
+	serverCmd.Flags().BoolVar(&editorAPI, "editorAPI", false, "serve the build status and the URLs of the content files as JSON under /__hugo/ for editor plugins")
 	serverCmd.Flags().String("memstats", "", "log memory usage to this file")
 	serverCmd.Flags().Int("meminterval", 100, "interval to poll memory usage (requires --memstats)")
 	serverCmd.Run = server
@@ -98,7 +103,7 @@ func server(cmd *cobra.Command, args []string) {
 
 	// Watch runs its own server as part of the routine
 	if serverWatch {
-		jww.FEEDBACK.Println("Watching for changes in", helpers.AbsPathify(viper.GetString("ContentDir")))
+		feedback("Watching for changes in", helpers.AbsPathify(viper.GetString("ContentDir")))
 		err := NewWatcher(serverPort)
 		if err != nil {
 			fmt.Println(err)
@@ -109,23 +114,29 @@ func server(cmd *cobra.Command, args []string) {
 }
 
 func serve(port int) {
-	jww.FEEDBACK.Println("Serving pages from " + helpers.AbsPathify(viper.GetString("PublishDir")))
+	feedback("Serving pages from " + helpers.AbsPathify(viper.GetString("PublishDir")))
 
 	httpFs := &afero.HttpFs{SourceFs: hugofs.DestinationFS}
-	fileserver := http.FileServer(httpFs.Dir(helpers.AbsPathify(viper.GetString("PublishDir"))))
-
+	publishDir := httpFs.Dir(helpers.AbsPathify(viper.GetString("PublishDir")))
 	u, err := url.Parse(viper.GetString("BaseURL"))
 	if err != nil {
 		jww.ERROR.Fatalf("Invalid BaseURL: %s", err)
 	}
+
+	fileserver := notFoundHandler(publishDir, http.FileServer(publishDir))
+	fileserver = redirectHandler(hugolib.Redirects(), strings.TrimSuffix(u.Path, "/"), fileserver)
 	if u.Path == "" || u.Path == "/" {
 		http.Handle("/", fileserver)
 	} else {
 		http.Handle(u.Path, http.StripPrefix(u.Path, fileserver))
 	}
 
+	if editorAPI {
+		serveEditorAPI()
+	}
+
 	u.Scheme = "http"
-	jww.FEEDBACK.Printf("Web Server is available at %s\n", u.String())
+	feedbackf("Web Server is available at %s\n", u.String())
 	fmt.Println("Press Ctrl+C to stop")
 
 	err = http.ListenAndServe(":"+strconv.Itoa(port), nil)
@@ -135,6 +146,54 @@ func serve(port int) {
 	}
 }
 
+// redirectHandler follows the redirects of the site config, the paths being
+// relative to the root of the site, which is at root on the server.
+func redirectHandler(redirects []hugolib.Redirect, root string, h http.Handler) http.Handler {
+	if len(redirects) == 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		for _, rd := range redirects {
+			if to, ok := rd.Match(p); ok {
+				if strings.HasPrefix(to, "/") {
+					to = root + to
+				}
+				http.Redirect(w, r, to, rd.Status)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// notFoundHandler serves the 404.html of the site, if there is one, for
+// the files that do not exist, as the hosts do.
+func notFoundHandler(fs http.FileSystem, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if f, err := fs.Open(name); err == nil {
+			f.Close()
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		nf, err := fs.Open("/404.html")
+		if err != nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		defer nf.Close()
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		io.Copy(w, nf)
+	})
+}
+
 // fixUrl massages the BaseUrl into a form needed for serving
 // all pages correctly.
 func fixURL(s string) (string, error) {
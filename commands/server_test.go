@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/hugo/hugolib"
 	"github.com/spf13/viper"
 )
 
@@ -41,3 +47,61 @@ func TestFixURL(t *testing.T) {
 		}
 	}
 }
+
+func TestNotFoundHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fs := http.Dir(dir)
+	ioutil.WriteFile(filepath.Join(dir, "page.html"), []byte("Page"), 0644)
+
+	h := notFoundHandler(fs, http.FileServer(fs))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", path, nil)
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := get("/missing.html"); w.Code != http.StatusNotFound {
+		t.Errorf("Without a 404.html the status should be 404, got %d", w.Code)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "404.html"), []byte("Not Found Page"), 0644)
+
+	if w := get("/page.html"); w.Code != http.StatusOK || w.Body.String() != "Page" {
+		t.Errorf("Existing file should be served, got %d %q", w.Code, w.Body.String())
+	}
+	if w := get("/missing.html"); w.Code != http.StatusNotFound || w.Body.String() != "Not Found Page" {
+		t.Errorf("Missing file should get the 404.html, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	redirects := []hugolib.Redirect{
+		{From: "/feed", To: "/index.xml", Status: 301},
+		{From: "/blog/*", To: "/post/:splat", Status: 302},
+	}
+	h := redirectHandler(redirects, "/bub", http.NotFoundHandler())
+
+	for i, this := range []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/feed", 301, "/bub/index.xml"},
+		{"/blog/first/", 302, "/bub/post/first/"},
+		{"/post/first/", 404, ""},
+	} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", this.path, nil)
+		h.ServeHTTP(w, r)
+		if w.Code != this.status || w.Header().Get("Location") != this.location {
+			t.Errorf("[%d] %s got %d %q, expected %d %q", i, this.path, w.Code, w.Header().Get("Location"), this.status, this.location)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package create
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/hugolib"
+	"github.com/spf13/viper"
+)
+
+// CheckContentPath checks that new content at name, of the kind given with
+// --kind if any, ends up where the site expects it, so that a typo in
+// `hugo new psot/first.md` doesn't silently start a new section. The error
+// suggests what was likely meant.
+func CheckContentPath(kind, name string) error {
+	slashed := filepath.ToSlash(name)
+	if filepath.IsAbs(name) || strings.HasPrefix(slashed, "/") || strings.HasPrefix(slashed, "../") || strings.Contains(slashed, "/../") {
+		return fmt.Errorf("%s must be a path inside the content directory, such as post/first.md", name)
+	}
+	if filepath.Ext(name) == "" {
+		return fmt.Errorf("%s has no extension, did you mean %s.md?", name, name)
+	}
+
+	archetypes := archetypeKinds()
+	if kind != "" && !helpers.InStringArray(archetypes, kind) {
+		return unknownError("archetype", kind, archetypes)
+	}
+
+	if !strings.Contains(slashed, "/") {
+		return nil
+	}
+	section := strings.SplitN(slashed, "/", 2)[0]
+	content := contentSections()
+	if len(content) == 0 && len(archetypes) == 0 {
+		// a new site
+		return nil
+	}
+	sections := knownSections(archetypes, content)
+	if helpers.InStringArray(sections, section) {
+		return nil
+	}
+	return unknownError("section", section, sections)
+}
+
+func unknownError(what, name string, known []string) error {
+	msg := fmt.Sprintf("There is no %s %q", what, name)
+	if s := suggest(name, known); s != "" {
+		msg += fmt.Sprintf(", did you mean %q?", s)
+	}
+	if len(known) == 0 {
+		return fmt.Errorf("%s, there are none\nUse --force to create it anyway.", msg)
+	}
+	return fmt.Errorf("%s\nKnown %ss: %s\nUse --force to create it anyway.", msg, what, strings.Join(known, ", "))
+}
+
+// archetypeKinds returns the kinds of content with an archetype in the site
+// or in its theme.
+func archetypeKinds() []string {
+	dirs := []string{helpers.AbsPathify(viper.GetString("archetypeDir"))}
+	if viper.GetString("theme") != "" {
+		dirs = append(dirs, filepath.Join(helpers.AbsPathify("themes/"+viper.GetString("theme")), "archetypes"))
+	}
+
+	var kinds []string
+	for _, dir := range dirs {
+		for _, name := range readDirNames(dir) {
+			kind := helpers.Filename(name)
+			if kind != "default" && !helpers.InStringArray(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// contentSections returns the directories in the content directory.
+func contentSections() []string {
+	var sections []string
+	contentDir := helpers.AbsPathify(viper.GetString("ContentDir"))
+	for _, name := range readDirNames(contentDir) {
+		if isDir, _ := helpers.IsDir(filepath.Join(contentDir, name), hugofs.SourceFs); isDir {
+			sections = append(sections, name)
+		}
+	}
+	return sections
+}
+
+// knownSections returns the sections of the existing content, the ones with
+// an archetype or a permalink pattern and the taxonomies.
+func knownSections(archetypes, content []string) []string {
+	var sections []string
+	add := func(s string) {
+		if s != "" && !helpers.InStringArray(sections, s) {
+			sections = append(sections, s)
+		}
+	}
+
+	for _, section := range archetypes {
+		add(section)
+	}
+	for _, section := range content {
+		add(section)
+	}
+	for section := range viper.GetStringMapString("Permalinks") {
+		add(section)
+	}
+	for _, plural := range hugolib.TaxonomyNames() {
+		add(plural)
+	}
+
+	sort.Strings(sections)
+	return sections
+}
+
+func readDirNames(dir string) []string {
+	f, err := hugofs.SourceFs.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil
+	}
+	return names
+}
+
+// suggest returns the one of known closest to name, if it is close enough
+// to be a typo.
+func suggest(name string, known []string) string {
+	best, bestDistance := "", len(name)/2+1
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(k)); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,64 @@
+package create
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+func TestCheckContentPath(t *testing.T) {
+	defer func() { hugofs.SourceFs = new(afero.OsFs) }()
+	hugofs.SourceFs = new(afero.MemMapFs)
+
+	viper.Set("WorkingDir", filepath.FromSlash("/site"))
+	viper.Set("ContentDir", "content")
+	viper.Set("ArchetypeDir", "archetypes")
+	viper.Set("Permalinks", map[string]interface{}{"blog": "/:year/:title/"})
+	viper.Set("Taxonomies", map[string]interface{}{"tag": "tags"})
+	defer func() {
+		viper.Set("Permalinks", nil)
+		viper.Set("Taxonomies", nil)
+	}()
+
+	// a new site accepts any section
+	if err := CheckContentPath("", filepath.FromSlash("post/first.md")); err != nil {
+		t.Errorf("Expected any section to be fine in a new site, got %s", err)
+	}
+
+	for _, f := range []string{"content/post/first.md", "archetypes/default.md", "archetypes/project.md"} {
+		helpers.WriteToDisk(filepath.FromSlash("/site/"+f), strings.NewReader("+++\n+++\n"), hugofs.SourceFs)
+	}
+
+	for i, this := range []struct {
+		kind, name string
+		expected   string
+	}{
+		{"", "about.md", ""},
+		{"", "post/second.md", ""},
+		{"", "project/hugo.md", ""},
+		{"", "blog/news.md", ""},
+		{"", "tags/golang/_index.md", ""},
+		{"project", "work/hugo.md", `There is no section "work"`},
+		{"", "psot/second.md", `There is no section "psot", did you mean "post"?`},
+		{"", "post/second", "post/second has no extension, did you mean post/second.md?"},
+		{"", "../second.md", "must be a path inside the content directory"},
+		{"projcet", "post/hugo.md", `There is no archetype "projcet", did you mean "project"?`},
+		{"lunch", "post/hugo.md", "Known archetypes: project\n"},
+	} {
+		err := CheckContentPath(this.kind, filepath.FromSlash(this.name))
+		if this.expected == "" {
+			if err != nil {
+				t.Errorf("[%d] Expected %s to be fine, got %s", i, this.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), this.expected) {
+			t.Errorf("[%d] Expected error %q for %s, got %v", i, this.expected, this.name, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BuildLog is set when the build log is written as JSON lines
+// (--logFormat=json). All the BuildLogger methods do nothing when it is nil.
+var BuildLog *BuildLogger
+
+// A BuildEvent is one line of the JSON build log. Event is one of render,
+// timing, info, warning and error.
+type BuildEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Message  string    `json:"message,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Duration float64   `json:"durationMs,omitempty"`
+}
+
+// BuildLogger writes build events as JSON, one per line, so editors and
+// build dashboards can follow the progress of a build.
+type BuildLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewBuildLogger(w io.Writer) *BuildLogger {
+	return &BuildLogger{enc: json.NewEncoder(w)}
+}
+
+// Event writes the event, setting its time if it is missing.
+func (l *BuildLogger) Event(e BuildEvent) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(e)
+}
+
+// Rendered logs that the file at path was written.
+func (l *BuildLogger) Rendered(path, message string) {
+	l.Event(BuildEvent{Event: "render", Path: path, Message: message})
+}
+
+// Timing logs how long a step of the build took.
+func (l *BuildLogger) Timing(step string, d time.Duration) {
+	l.Event(BuildEvent{Event: "timing", Message: step, Duration: float64(d) / float64(time.Millisecond)})
+}
+
+// Writer returns a writer that logs everything written to it as events of
+// the given kind, e.g. to be used as the output of a log.Logger.
+func (l *BuildLogger) Writer(event string) io.Writer {
+	return buildLogWriter{l, event}
+}
+
+type buildLogWriter struct {
+	l     *BuildLogger
+	event string
+}
+
+func (w buildLogWriter) Write(p []byte) (int, error) {
+	w.l.Event(BuildEvent{Event: w.event, Message: strings.TrimSpace(string(p))})
+	return len(p), nil
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewBuildLogger(&b)
+
+	l.Rendered("post/index.html", "page post.md")
+	l.Timing("render pages", 1500*time.Microsecond)
+	log.New(l.Writer("warning"), "", 0).Println("Unable to locate layout")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", b.String())
+	}
+
+	expected := []BuildEvent{
+		{Event: "render", Path: "post/index.html", Message: "page post.md"},
+		{Event: "timing", Message: "render pages", Duration: 1.5},
+		{Event: "warning", Message: "Unable to locate layout"},
+	}
+
+	for i, line := range lines {
+		var e BuildEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("[%d] Invalid JSON %q: %s", i, line, err)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("[%d] Expected the time to be set", i)
+		}
+		e.Time = time.Time{}
+		if e != expected[i] {
+			t.Errorf("[%d] Expected %+v, got %+v", i, expected[i], e)
+		}
+	}
+
+	// logging is off without a logger
+	var nl *BuildLogger
+	nl.Rendered("index.html", "home")
+}
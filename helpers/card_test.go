@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestWrapCardText(t *testing.T) {
+	for i, this := range []struct {
+		text     string
+		expected []string
+	}{
+		{"", nil},
+		{"Hello You", []string{"Hello You"}},
+		{"  The quick brown fox jumps  ", []string{"The quick", "brown fox", "jumps"}},
+		{"Antidisestablishment", []string{"Antidisest", "ablishment"}},
+		{"one two three four five six seven eight nine", []string{"one two", "three four", "five si..."}},
+		{"Grüße", []string{"Gr??e"}},
+	} {
+		if lines := wrapCardText(this.text, 10, 3); !reflect.DeepEqual(lines, this.expected) {
+			t.Errorf("[%d] wrapCardText(%q): got %q, expected %q", i, this.text, lines, this.expected)
+		}
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	for _, this := range []struct {
+		in       string
+		expected color.RGBA
+		err      bool
+	}{
+		{"#1e293b", color.RGBA{0x1e, 0x29, 0x3b, 0xff}, false},
+		{"fff", color.RGBA{0xff, 0xff, 0xff, 0xff}, false},
+		{"#12345", color.RGBA{}, true},
+		{"#gggggg", color.RGBA{}, true},
+	} {
+		c, err := ParseHexColor(this.in)
+		if (err != nil) != this.err {
+			t.Errorf("ParseHexColor(%q): unexpected error %v", this.in, err)
+			continue
+		}
+		if c != this.expected {
+			t.Errorf("ParseHexColor(%q): got %v, expected %v", this.in, c, this.expected)
+		}
+	}
+}
+
+func TestSocialCardPNG(t *testing.T) {
+	background := color.RGBA{0x1e, 0x29, 0x3b, 0xff}
+	fg := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	b, err := SocialCard{Title: "I", SiteName: "Site", Color: fg, Background: background}.PNG()
+	if err != nil {
+		t.Fatalf("Unable to draw the card: %s", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Unable to decode the card: %s", err)
+	}
+	if size := img.Bounds().Size(); size.X != CardWidth || size.Y != CardHeight {
+		t.Errorf("Card size is %v", size)
+	}
+	if c := color.RGBAModel.Convert(img.At(0, 0)); c != background {
+		t.Errorf("Background is %v, expected %v", c, background)
+	}
+	// the middle column of the I
+	if c := color.RGBAModel.Convert(img.At(cardMargin+2*cardTitleScale, cardMargin+3*cardTitleScale)); c != fg {
+		t.Errorf("Title is %v, expected %v", c, fg)
+	}
+}
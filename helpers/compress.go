@@ -0,0 +1,131 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// A Compressor compresses files for one content encoding, to a sibling
+// with its suffix, e.g. index.html.gz for gzip.
+type Compressor struct {
+	Suffix   string
+	Compress func([]byte) ([]byte, error)
+}
+
+// GzipCompressor compresses with gzip at the best compression, and without
+// a file name or time in the header, so the same file always compresses
+// the same.
+var GzipCompressor = Compressor{
+	Suffix: ".gz",
+	Compress: func(b []byte) ([]byte, error) {
+		var out bytes.Buffer
+		w, err := gzip.NewWriterLevel(&out, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	},
+}
+
+// CommandCompressor compresses with the command, which reads a file on
+// its standard input and writes it compressed to its standard output, e.g.
+// "brotli -c -q 11" for brotli, which Go has no package for.
+func CommandCompressor(suffix, command string) (Compressor, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return Compressor{}, fmt.Errorf("No command to compress the %s files with", suffix)
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return Compressor{}, fmt.Errorf("%s not found in $PATH, needed to compress the %s files", args[0], suffix)
+	}
+
+	return Compressor{
+		Suffix: suffix,
+		Compress: func(b []byte) ([]byte, error) {
+			cmd := exec.Command(path, args[1:]...)
+			cmd.Stdin = bytes.NewReader(b)
+			var out, stderr bytes.Buffer
+			cmd.Stdout = &out
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				return nil, fmt.Errorf("%s: %s %s", command, err, strings.TrimSpace(stderr.String()))
+			}
+			return out.Bytes(), nil
+		},
+	}, nil
+}
+
+// Precompress writes a compressed sibling of the files below dir with one
+// of the extensions, e.g. index.html.gz next to index.html, for each of the
+// compressors. A sibling is only written if it is smaller than the file,
+// and is left alone if it is newer than the file. It returns the number of
+// siblings written.
+func Precompress(dir string, fs afero.Fs, extensions []string, compressors ...Compressor) (int, error) {
+	files, err := ListFiles(dir, fs)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, f := range files {
+		if !InStringArray(extensions, strings.ToLower(filepath.Ext(f))) {
+			continue
+		}
+		name := filepath.Join(dir, f)
+		fi, err := fs.Stat(name)
+		if err != nil {
+			return written, err
+		}
+
+		var content []byte
+		for _, c := range compressors {
+			if sfi, err := fs.Stat(name + c.Suffix); err == nil && !sfi.ModTime().Before(fi.ModTime()) {
+				continue
+			}
+			if content == nil {
+				if content, err = ReadFile(name, fs); err != nil {
+					return written, err
+				}
+			}
+			compressed, err := c.Compress(content)
+			if err != nil {
+				return written, fmt.Errorf("Unable to compress %s: %s", f, err)
+			}
+			if len(compressed) >= len(content) {
+				fs.Remove(name + c.Suffix)
+				continue
+			}
+			if err := WriteToDisk(name+c.Suffix, bytes.NewReader(compressed), fs); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestPrecompress(t *testing.T) {
+	fs := new(afero.MemMapFs)
+	page := strings.Repeat("<p>Hello, compressed world.</p>\n", 50)
+	WriteToDisk(filepath.FromSlash("public/index.html"), strings.NewReader(page), fs)
+	WriteToDisk(filepath.FromSlash("public/css/main.css"), strings.NewReader(strings.Repeat("p { margin: 0 }\n", 50)), fs)
+	WriteToDisk(filepath.FromSlash("public/tiny.html"), strings.NewReader("hi"), fs)
+	WriteToDisk(filepath.FromSlash("public/img/logo.png"), strings.NewReader(page), fs)
+
+	head, err := CommandCompressor(".br", "head -c 5")
+	if err != nil {
+		t.Fatalf("Unable to use head as a compressor: %s", err)
+	}
+
+	n, err := Precompress("public", fs, []string{".html", ".css"}, GzipCompressor, head)
+	if err != nil {
+		t.Fatalf("Unable to precompress: %s", err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 compressed files, got %d", n)
+	}
+
+	b, err := ReadFile(filepath.FromSlash("public/index.html.gz"), fs)
+	if err != nil {
+		t.Fatalf("Unable to read the gzipped page: %s", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Invalid gzip: %s", err)
+	}
+	if content := string(ReaderToBytes(r)); content != page {
+		t.Errorf("Unexpected gzipped content %q", content)
+	}
+
+	if b, _ := ReadFile(filepath.FromSlash("public/css/main.css.br"), fs); string(b) != "p { m" {
+		t.Errorf("Expected the output of the command, got %q", b)
+	}
+
+	for _, name := range []string{"public/img/logo.png.gz", "public/tiny.html.gz"} {
+		if exists, _ := Exists(filepath.FromSlash(name), fs); exists {
+			t.Errorf("Expected no %s", name)
+		}
+	}
+
+	// the siblings are newer than the files, so they are up to date
+	if n, _ := Precompress("public", fs, []string{".html", ".css"}, GzipCompressor, head); n != 0 {
+		t.Errorf("Expected the up to date files to be skipped, got %d", n)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	WriteToDisk(filepath.FromSlash("public/index.html"), strings.NewReader(strings.Repeat("<p>Changed.</p>\n", 50)), fs)
+	if n, _ := Precompress("public", fs, []string{".html", ".css"}, GzipCompressor); n != 1 {
+		t.Errorf("Expected the changed file to be compressed again, got %d", n)
+	}
+}
+
+func TestCommandCompressorNotFound(t *testing.T) {
+	if _, err := CommandCompressor(".br", "no-such-brotli -c"); err == nil {
+		t.Errorf("Expected an error for a missing command")
+	}
+	if _, err := CommandCompressor(".br", " "); err == nil {
+		t.Errorf("Expected an error for an empty command")
+	}
+}
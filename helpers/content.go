@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"html/template"
 	"os/exec"
+	"strconv"
 
 	"github.com/russross/blackfriday"
 	bp "github.com/spf13/hugo/bufferpool"
@@ -42,7 +43,9 @@ type Blackfriday struct {
 	AngledQuotes   bool
 	Fractions      bool
 	PlainIDAnchors bool
+	Math           bool
 	Extensions     []string
+	ExtensionsMask []string
 }
 
 // NewBlackfriday creates a new Blackfriday with some sane defaults.
@@ -51,9 +54,39 @@ func NewBlackfriday() *Blackfriday {
 		AngledQuotes:   false,
 		Fractions:      true,
 		PlainIDAnchors: false,
+		Math:           false,
 	}
 }
 
+// Markup holds the options of the content conversion other than those of
+// Blackfriday, which pages can override in the markup block of their front
+// matter.
+type Markup struct {
+	HighlightCodeFences bool
+	PygmentsStyle       string
+	PygmentsUseClasses  bool
+	ShortcodesInCode    bool
+}
+
+// NewMarkup creates a new Markup with the options of the site config.
+func NewMarkup() *Markup {
+	return &Markup{
+		HighlightCodeFences: viper.GetBool("HighlightCodeFences"),
+		PygmentsStyle:       viper.GetString("PygmentsStyle"),
+		PygmentsUseClasses:  viper.GetBool("PygmentsUseClasses"),
+		ShortcodesInCode:    viper.GetBool("ShortcodesInCode"),
+	}
+}
+
+// highlightOptions returns the options of the built-in highlighter.
+func (m *Markup) highlightOptions() map[string]string {
+	opts := map[string]string{"noclasses": strconv.FormatBool(!m.PygmentsUseClasses)}
+	if m.PygmentsStyle != "" {
+		opts["style"] = m.PygmentsStyle
+	}
+	return opts
+}
+
 var blackfridayExtensionMap = map[string]int{
 	"noIntraEmphasis":        blackfriday.EXTENSION_NO_INTRA_EMPHASIS,
 	"tables":                 blackfriday.EXTENSION_TABLES,
@@ -102,6 +135,105 @@ func StripHTML(s string) string {
 	return b.String()
 }
 
+// StripHTMLTags removes the HTML tags from s, keeping the text and the
+// tags named in allowed. The kept tags lose their attributes, so the
+// result is safe to embed. Comments and the content of script and style
+// elements are removed as well.
+func StripHTMLTags(s string, allowed ...string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+
+	keep := make(map[string]bool)
+	for _, a := range allowed {
+		keep[strings.ToLower(strings.Trim(a, "<>/ "))] = true
+	}
+
+	b := bp.GetBuffer()
+	defer bp.PutBuffer(b)
+
+	skipUntil := ""
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			if skipUntil == "" {
+				b.WriteByte(s[i])
+			}
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		name, closing, end := parseHTMLTag(s[i:])
+		if end == 0 {
+			// not a tag, e.g. "a < b"
+			if skipUntil == "" {
+				b.WriteByte('<')
+			}
+			i++
+			continue
+		}
+		i += end
+
+		switch {
+		case skipUntil != "":
+			if closing && name == skipUntil {
+				skipUntil = ""
+			}
+		case !closing && (name == "script" || name == "style"):
+			skipUntil = name
+		case keep[name] && closing:
+			b.WriteString("</" + name + ">")
+		case keep[name]:
+			b.WriteString("<" + name + ">")
+		}
+	}
+
+	return b.String()
+}
+
+// parseHTMLTag parses the tag at the start of s. It returns the lower case
+// tag name, whether it is a closing tag and the length of the tag, which is
+// 0 if s doesn't start with a tag.
+func parseHTMLTag(s string) (name string, closing bool, end int) {
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		closing = true
+		i++
+	}
+	start := i
+	for i < len(s) && (s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || i > start && s[i] >= '0' && s[i] <= '9') {
+		i++
+	}
+	if i == start {
+		return "", false, 0
+	}
+	name = strings.ToLower(s[start:i])
+
+	// find the end of the tag, skipping quoted attribute values
+	var quote byte
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return name, closing, i + 1
+		}
+	}
+	return "", false, 0
+}
+
 // StripEmptyNav strips out empty <nav> tags from content.
 func StripEmptyNav(in []byte) []byte {
 	return bytes.Replace(in, []byte("<nav>\n</nav>\n\n"), []byte(``), -1)
@@ -140,7 +272,15 @@ func GetHTMLRenderer(defaultFlags int, ctx *RenderingContext) blackfriday.Render
 		htmlFlags |= blackfriday.HTML_SMARTYPANTS_FRACTIONS
 	}
 
-	return blackfriday.HtmlRendererWithParameters(htmlFlags, "", "", renderParameters)
+	renderer := blackfriday.HtmlRendererWithParameters(htmlFlags, "", "", renderParameters)
+
+	highlight := ctx.getMarkup().HighlightCodeFences
+	if ctx.RenderHook != nil || highlight {
+		return &HugoHTMLRenderer{Renderer: renderer, hook: ctx.RenderHook, highlight: highlight,
+			highlightOpts: ctx.getMarkup().highlightOptions()}
+	}
+
+	return renderer
 }
 
 func getMarkdownExtensions(ctx *RenderingContext) int {
@@ -154,18 +294,33 @@ func getMarkdownExtensions(ctx *RenderingContext) int {
 			flags |= flag
 		}
 	}
+	for _, extension := range ctx.getConfig().ExtensionsMask {
+		if flag, ok := blackfridayExtensionMap[extension]; ok {
+			flags &^= flag
+		}
+	}
 	return flags
 }
 
 func markdownRender(ctx *RenderingContext) []byte {
-	return blackfriday.Markdown(ctx.Content, GetHTMLRenderer(0, ctx),
-		getMarkdownExtensions(ctx))
+	content, math := ctx.extractMath()
+	return restoreMath(blackfriday.Markdown(content, GetHTMLRenderer(0, ctx),
+		getMarkdownExtensions(ctx)), math)
 }
 
 func markdownRenderWithTOC(ctx *RenderingContext) []byte {
-	return blackfriday.Markdown(ctx.Content,
+	content, math := ctx.extractMath()
+	return restoreMath(blackfriday.Markdown(content,
 		GetHTMLRenderer(blackfriday.HTML_TOC, ctx),
-		getMarkdownExtensions(ctx))
+		getMarkdownExtensions(ctx)), math)
+}
+
+// extractMath hides the math from Blackfriday if enabled in the config.
+func (c *RenderingContext) extractMath() ([]byte, [][]byte) {
+	if !c.getConfig().Math {
+		return c.Content, nil
+	}
+	return extractMath(c.Content)
 }
 
 // ExtractTOC extracts Table of Contents from content.
@@ -211,7 +366,10 @@ type RenderingContext struct {
 	PageFmt    string
 	DocumentID string
 	Config     *Blackfriday
+	Markup     *Markup
+	RenderHook RenderHook
 	configInit sync.Once
+	markupInit sync.Once
 }
 
 func (c *RenderingContext) getConfig() *Blackfriday {
@@ -223,32 +381,34 @@ func (c *RenderingContext) getConfig() *Blackfriday {
 	return c.Config
 }
 
+func (c *RenderingContext) getMarkup() *Markup {
+	c.markupInit.Do(func() {
+		if c.Markup == nil {
+			c.Markup = NewMarkup()
+		}
+	})
+	return c.Markup
+}
+
 // RenderBytesWithTOC renders a []byte with table of contents included.
 func RenderBytesWithTOC(ctx *RenderingContext) []byte {
-	switch ctx.PageFmt {
-	default:
+	f := LookupContentFormat(ctx.PageFmt)
+	switch {
+	case f == nil:
 		return markdownRenderWithTOC(ctx)
-	case "markdown":
-		return markdownRenderWithTOC(ctx)
-	case "asciidoc":
-		return []byte(GetAsciidocContent(ctx.Content))
-	case "rst":
-		return []byte(GetRstContent(ctx.Content))
+	case f.ConvertWithTOC != nil:
+		return f.ConvertWithTOC(ctx)
+	default:
+		return f.Convert(ctx)
 	}
 }
 
 // RenderBytes renders a []byte.
 func RenderBytes(ctx *RenderingContext) []byte {
-	switch ctx.PageFmt {
-	default:
-		return markdownRender(ctx)
-	case "markdown":
-		return markdownRender(ctx)
-	case "asciidoc":
-		return []byte(GetAsciidocContent(ctx.Content))
-	case "rst":
-		return []byte(GetRstContent(ctx.Content))
+	if f := LookupContentFormat(ctx.PageFmt); f != nil {
+		return f.Convert(ctx)
 	}
+	return markdownRender(ctx)
 }
 
 // TotalWords returns an int of the total number of words in a given content.
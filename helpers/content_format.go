@@ -0,0 +1,117 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// A ContentFormat is a markup language of the content files, such as
+// Markdown, converted to HTML by its Convert function.
+type ContentFormat struct {
+	Name string
+
+	// Extensions are the file extensions of the content in the format,
+	// without the dot.
+	Extensions []string
+
+	Convert func(ctx *RenderingContext) []byte
+
+	// ConvertWithTOC converts the content with its table of contents
+	// before it, as a <nav> for ExtractTOC. It is Convert if nil.
+	ConvertWithTOC func(ctx *RenderingContext) []byte
+}
+
+var contentFormats = struct {
+	sync.RWMutex
+	m map[string]*ContentFormat // by name and extension
+}{m: make(map[string]*ContentFormat)}
+
+func init() {
+	RegisterContentFormat(ContentFormat{
+		Name:           "markdown",
+		Extensions:     []string{"md", "markdown", "mdown"},
+		Convert:        markdownRender,
+		ConvertWithTOC: markdownRenderWithTOC,
+	})
+	RegisterContentFormat(ContentFormat{
+		Name:       "asciidoc",
+		Extensions: []string{"asciidoc", "adoc", "ad"},
+		Convert:    func(ctx *RenderingContext) []byte { return []byte(GetAsciidocContent(ctx.Content)) },
+	})
+	RegisterContentFormat(ContentFormat{
+		Name:       "rst",
+		Extensions: []string{"rst", "rest"},
+		Convert:    func(ctx *RenderingContext) []byte { return []byte(GetRstContent(ctx.Content)) },
+	})
+}
+
+// RegisterContentFormat adds the format, or replaces the one of the same
+// name. Its extensions are no longer those of the formats that had them.
+func RegisterContentFormat(f ContentFormat) {
+	contentFormats.Lock()
+	defer contentFormats.Unlock()
+
+	name := strings.ToLower(f.Name)
+	for key, old := range contentFormats.m {
+		if strings.ToLower(old.Name) == name {
+			delete(contentFormats.m, key)
+		}
+	}
+	contentFormats.m[name] = &f
+	for _, ext := range f.Extensions {
+		contentFormats.m[strings.ToLower(strings.TrimPrefix(ext, "."))] = &f
+	}
+}
+
+// LookupContentFormat returns the format with the name or file extension,
+// or nil if there is none.
+func LookupContentFormat(name string) *ContentFormat {
+	contentFormats.RLock()
+	defer contentFormats.RUnlock()
+	return contentFormats.m[strings.ToLower(name)]
+}
+
+// ExternalContentFormat returns a format converted by the command, which
+// reads the content on its standard input and writes the HTML to its
+// standard output, e.g. pandoc with the args "--from", "org".
+func ExternalContentFormat(name string, extensions []string, command string, args ...string) ContentFormat {
+	return ContentFormat{
+		Name:       name,
+		Extensions: extensions,
+		Convert: func(ctx *RenderingContext) []byte {
+			path, err := exec.LookPath(command)
+			if err != nil {
+				jww.ERROR.Printf("%s not found in $PATH: Please install.\n"+
+					"                 Leaving %s content unrendered.", command, name)
+				return ctx.Content
+			}
+
+			cmd := exec.Command(path, args...)
+			cmd.Stdin = bytes.NewReader(bytes.Replace(ctx.Content, SummaryDivider, []byte(""), 1))
+			var out, stderr bytes.Buffer
+			cmd.Stdout = &out
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				jww.ERROR.Printf("Unable to convert %s content with %s: %s %s", name, command, err, strings.TrimSpace(stderr.String()))
+			}
+			return out.Bytes()
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestRegisterContentFormat(t *testing.T) {
+	RegisterContentFormat(ContentFormat{
+		Name:       "Upper",
+		Extensions: []string{".up", "upper"},
+		Convert:    func(ctx *RenderingContext) []byte { return bytes.ToUpper(ctx.Content) },
+	})
+
+	for _, name := range []string{"upper", "UP", "up"} {
+		if GuessType(name) != "Upper" {
+			t.Errorf("GuessType(%q) is %q", name, GuessType(name))
+		}
+	}
+	if result := string(RenderBytes(&RenderingContext{Content: []byte("text"), PageFmt: "up"})); result != "TEXT" {
+		t.Errorf("RenderBytes with the upper format: got %q", result)
+	}
+	if result := string(RenderBytesWithTOC(&RenderingContext{Content: []byte("text"), PageFmt: "upper"})); result != "TEXT" {
+		t.Errorf("RenderBytesWithTOC with the upper format: got %q", result)
+	}
+
+	// a new registration replaces the extensions
+	RegisterContentFormat(ContentFormat{
+		Name:       "upper",
+		Extensions: []string{"upp"},
+		Convert:    func(ctx *RenderingContext) []byte { return bytes.ToUpper(ctx.Content) },
+	})
+	if f := LookupContentFormat("up"); f != nil {
+		t.Errorf("The extension of the replaced format is still registered to %s", f.Name)
+	}
+	if f := LookupContentFormat("upp"); f == nil || f.Name != "upper" {
+		t.Errorf("The extension of the new format is not registered")
+	}
+}
+
+func TestExternalContentFormat(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("Skip test as tr is not installed")
+	}
+
+	f := ExternalContentFormat("shout", []string{"shout"}, "tr", "a-z", "A-Z")
+	if result := string(f.Convert(&RenderingContext{Content: []byte("hello <!--more--> world")})); result != "HELLO  WORLD" {
+		t.Errorf("External format: got %q", result)
+	}
+
+	f = ExternalContentFormat("missing", []string{"missing"}, "hugo-missing-converter")
+	if result := string(f.Convert(&RenderingContext{Content: []byte("text")})); result != "text" {
+		t.Errorf("A missing converter should leave the content as is, got %q", result)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+)
+
+// Blackfriday leaves plain words alone, so the placeholders are letters
+// and digits only.
+const mathPlaceholderPrefix = "HUGOMATHPLACEHOLDER"
+
+func mathPlaceholder(i int) []byte {
+	return []byte(fmt.Sprintf("%s%dX", mathPlaceholderPrefix, i))
+}
+
+// extractMath replaces $...$ and $$...$$ in Markdown with placeholders to
+// keep Blackfriday from turning underscores into emphasis and the like.
+// Code spans, fenced code blocks and escaped dollars (\$) are left alone.
+func extractMath(content []byte) ([]byte, [][]byte) {
+	var math [][]byte
+	out := make([]byte, 0, len(content))
+
+	for i := 0; i < len(content); {
+		c := content[i]
+
+		if (i == 0 || content[i-1] == '\n') && isFence(content[i:]) {
+			end := fencedBlockEnd(content, i)
+			out = append(out, content[i:end]...)
+			i = end
+			continue
+		}
+
+		switch c {
+		case '\\':
+			end := i + 2
+			if end > len(content) {
+				end = len(content)
+			}
+			out = append(out, content[i:end]...)
+			i = end
+			continue
+		case '`':
+			end := codeSpanEnd(content, i)
+			out = append(out, content[i:end]...)
+			i = end
+			continue
+		case '$':
+			if end := mathEnd(content, i); end > 0 {
+				out = append(out, mathPlaceholder(len(math))...)
+				math = append(math, content[i:end])
+				i = end
+				continue
+			}
+		}
+
+		out = append(out, c)
+		i++
+	}
+
+	return out, math
+}
+
+// restoreMath puts the math back into the rendered HTML, escaped as text.
+func restoreMath(content []byte, math [][]byte) []byte {
+	for i := len(math) - 1; i >= 0; i-- {
+		content = bytes.Replace(content, mathPlaceholder(i), []byte(html.EscapeString(string(math[i]))), 1)
+	}
+	return content
+}
+
+// mathEnd returns the end of the math starting at the dollar at i,
+// or 0 if there is none.
+func mathEnd(content []byte, i int) int {
+	if bytes.HasPrefix(content[i:], []byte("$$")) {
+		if j := bytes.Index(content[i+2:], []byte("$$")); j > 0 {
+			return i + 2 + j + 2
+		}
+		return 0
+	}
+
+	// $ 5 and $ 10 are money, not math, so the content must hug the dollars
+	// and the closing one can't be followed by a digit.
+	if i+1 >= len(content) || isMathSpace(content[i+1]) {
+		return 0
+	}
+	for j := i + 1; j < len(content); j++ {
+		switch content[j] {
+		case '\n':
+			return 0
+		case '\\':
+			j++
+		case '$':
+			if isMathSpace(content[j-1]) || (j+1 < len(content) && content[j+1] >= '0' && content[j+1] <= '9') {
+				return 0
+			}
+			return j + 1
+		}
+	}
+	return 0
+}
+
+func isMathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isFence(line []byte) bool {
+	line = bytes.TrimLeft(line, " ")
+	return bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~"))
+}
+
+// fencedBlockEnd returns the end of the fenced code block starting at i,
+// including its closing fence.
+func fencedBlockEnd(content []byte, i int) int {
+	fence := bytes.TrimLeft(content[i:], " ")[:3]
+	pos := i
+	for {
+		nl := bytes.IndexByte(content[pos:], '\n')
+		if nl < 0 {
+			return len(content)
+		}
+		pos += nl + 1
+		if bytes.HasPrefix(bytes.TrimLeft(content[pos:], " "), fence) {
+			if nl := bytes.IndexByte(content[pos:], '\n'); nl >= 0 {
+				return pos + nl + 1
+			}
+			return len(content)
+		}
+	}
+}
+
+// codeSpanEnd returns the end of the code span starting at i, or the end
+// of the run of backticks if the span is never closed.
+func codeSpanEnd(content []byte, i int) int {
+	n := 0
+	for i+n < len(content) && content[i+n] == '`' {
+		n++
+	}
+	ticks := content[i : i+n]
+	if j := bytes.Index(content[i+n:], ticks); j >= 0 {
+		return i + n + j + n
+	}
+	return i + n
+}
@@ -0,0 +1,145 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// LinkContext is handed to the link and image render hooks.
+// For images, Text holds the alt text.
+type LinkContext struct {
+	Page        interface{}
+	Destination string
+	Title       string
+	Text        template.HTML
+}
+
+// HeadingContext is handed to the heading render hook.
+type HeadingContext struct {
+	Page   interface{}
+	Level  int
+	Anchor string
+	Text   template.HTML
+}
+
+// CodeBlockContext is handed to the code block render hook. Code holds
+// the unescaped content of the block.
+type CodeBlockContext struct {
+	Page interface{}
+	Lang string
+	Code string
+}
+
+// RenderHook renders a markup element of the given kind ("link", "image",
+// "heading" or "codeblock") from its context, which is a *LinkContext,
+// a *HeadingContext or a *CodeBlockContext. It reports false if there is no hook for that kind,
+// in which case the default rendering is used.
+type RenderHook func(kind string, ctx interface{}) (string, bool)
+
+// HugoHTMLRenderer wraps the Blackfriday HTML renderer and hands links,
+// images, headings and fenced code blocks over to the render hook.
+// Code blocks without a hook are highlighted if highlight is set.
+type HugoHTMLRenderer struct {
+	blackfriday.Renderer
+	hook          RenderHook
+	highlight     bool
+	highlightOpts map[string]string
+}
+
+func (r *HugoHTMLRenderer) runHook(kind string, ctx interface{}) (string, bool) {
+	if r.hook == nil {
+		return "", false
+	}
+	return r.hook(kind, ctx)
+}
+
+func (r *HugoHTMLRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	ctx := &LinkContext{Destination: string(link), Title: string(title), Text: template.HTML(content)}
+	if s, ok := r.runHook("link", ctx); ok {
+		out.WriteString(s)
+		return
+	}
+	r.Renderer.Link(out, link, title, content)
+}
+
+func (r *HugoHTMLRenderer) Image(out *bytes.Buffer, link []byte, title []byte, alt []byte) {
+	ctx := &LinkContext{Destination: string(link), Title: string(title), Text: template.HTML(alt)}
+	if s, ok := r.runHook("image", ctx); ok {
+		out.WriteString(s)
+		return
+	}
+	r.Renderer.Image(out, link, title, alt)
+}
+
+var renderedHeaderRe = regexp.MustCompile(`(?s)\A\s*<h\d(?: id="([^"]*)")?>(.*)</h\d>\s*\z`)
+
+func (r *HugoHTMLRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) {
+	// Let Blackfriday do the work first; it owns the anchor generation and
+	// the table of contents. The text callback writes straight to out, so
+	// the header has to be rendered in place and taken back out.
+	marker := out.Len()
+	r.Renderer.Header(out, text, level, id)
+
+	m := renderedHeaderRe.FindSubmatch(out.Bytes()[marker:])
+	if m == nil {
+		return
+	}
+
+	ctx := &HeadingContext{Level: level, Anchor: string(m[1]), Text: template.HTML(m[2])}
+	if s, ok := r.runHook("heading", ctx); ok {
+		out.Truncate(marker)
+		// keep the block separation of the default renderer
+		if marker > 0 {
+			out.WriteByte('\n')
+		}
+		out.WriteString(s)
+		out.WriteByte('\n')
+	}
+}
+
+func (r *HugoHTMLRenderer) BlockCode(out *bytes.Buffer, text []byte, lang string) {
+	// the info string may carry more than the language, e.g. "go {linenos=true}"
+	if fields := strings.Fields(lang); len(fields) > 0 {
+		lang = fields[0]
+	}
+
+	ctx := &CodeBlockContext{Lang: lang, Code: string(text)}
+	if s, ok := r.runHook("codeblock", ctx); ok {
+		writeBlock(out, s)
+		return
+	}
+	if r.highlight && lang != "" {
+		if s, ok := highlightBuiltin(string(text), lang, r.highlightOpts); ok {
+			writeBlock(out, s)
+			return
+		}
+	}
+	r.Renderer.BlockCode(out, text, lang)
+}
+
+// writeBlock writes s separated from what comes before like the default
+// renderer does.
+func writeBlock(out *bytes.Buffer, s string) {
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	out.WriteString(strings.TrimRight(s, "\n"))
+	out.WriteByte('\n')
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testRenderHook(kind string, ctx interface{}) (string, bool) {
+	switch c := ctx.(type) {
+	case *LinkContext:
+		if kind == "image" {
+			return fmt.Sprintf(`<img src="%s" alt="%s" loading="lazy">`, c.Destination, c.Text), true
+		}
+		return fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, c.Destination, c.Text), true
+	case *HeadingContext:
+		return fmt.Sprintf(`<h%d id="%s"><a href="#%s">%s</a></h%d>`, c.Level, c.Anchor, c.Anchor, c.Text, c.Level), true
+	case *CodeBlockContext:
+		if c.Lang == "mermaid" {
+			return fmt.Sprintf(`<div class="mermaid">%s</div>`, c.Code), true
+		}
+	}
+	return "", false
+}
+
+func TestRenderHooks(t *testing.T) {
+	for i, this := range []struct {
+		in     string
+		expect string
+	}{
+		{"[Hugo](http://gohugo.io/)", "<p><a href=\"http://gohugo.io/\" target=\"_blank\">Hugo</a></p>\n"},
+		{"[*Hugo*](/about/)", "<p><a href=\"/about/\" target=\"_blank\"><em>Hugo</em></a></p>\n"},
+		{"![Logo](/logo.png)", "<p><img src=\"/logo.png\" alt=\"Logo\" loading=\"lazy\"></p>\n"},
+		{"## The Title", "<h2 id=\"the-title\"><a href=\"#the-title\">The Title</a></h2>\n"},
+		{"text\n\n# One", "<p>text</p>\n\n<h1 id=\"one\"><a href=\"#one\">One</a></h1>\n"},
+		{"```mermaid\ngraph TD;\n  A-->B;\n```", "<div class=\"mermaid\">graph TD;\n  A-->B;\n</div>\n"},
+		{"text\n\n```mermaid {theme=dark}\nA\n```", "<p>text</p>\n\n<div class=\"mermaid\">A\n</div>\n"},
+	} {
+		ctx := &RenderingContext{Content: []byte(this.in), PageFmt: "markdown", RenderHook: testRenderHook}
+		result := string(RenderBytes(ctx))
+		if result != this.expect {
+			t.Errorf("[%d] Render hook output expected:\n%q\ngot:\n%q", i, this.expect, result)
+		}
+	}
+}
+
+func TestRenderHooksFallback(t *testing.T) {
+	none := func(kind string, ctx interface{}) (string, bool) { return "", false }
+	in := []byte("# One\n\n[Hugo](http://gohugo.io/)\n\n```go\nfmt.Println()\n```")
+
+	withHook := RenderBytes(&RenderingContext{Content: in, PageFmt: "markdown", RenderHook: none})
+	without := RenderBytes(&RenderingContext{Content: in, PageFmt: "markdown"})
+
+	if string(withHook) != string(without) {
+		t.Errorf("Expected default rendering when no hook applies, got:\n%q\nexpected:\n%q", withHook, without)
+	}
+}
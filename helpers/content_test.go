@@ -28,6 +28,28 @@ func TestStripHTML(t *testing.T) {
 	}
 }
 
+func TestStripHTMLTags(t *testing.T) {
+	for i, this := range []struct {
+		input    string
+		allowed  []string
+		expected string
+	}{
+		{"no tags", nil, "no tags"},
+		{"<p>Some <em>text</em></p>", nil, "Some text"},
+		{"<p>Some <em class=\"x\">text</em><br/></p>", []string{"em", "<br>"}, "Some <em>text</em><br>"},
+		{`<a href="x" onclick="alert('>')">link</a>`, []string{"a"}, "<a>link</a>"},
+		{"a < b <!-- comment --> and c > d", nil, "a < b  and c > d"},
+		{"<script>var a = '<b>';</script><STYLE>b {}</STYLE>text", []string{"b"}, "text"},
+		{"<H2>Title</H2>", []string{"h2"}, "<h2>Title</h2>"},
+		{"unclosed <b", nil, "unclosed <b"},
+	} {
+		output := StripHTMLTags(this.input, this.allowed...)
+		if output != this.expected {
+			t.Errorf("[%d] Expected %q got %q", i, this.expected, output)
+		}
+	}
+}
+
 func BenchmarkStripHTML(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -70,3 +92,29 @@ func TestTruncateWordsToWholeSentence(t *testing.T) {
 		}
 	}
 }
+
+func TestMathPassthrough(t *testing.T) {
+	for i, this := range []struct {
+		in     string
+		expect string
+	}{
+		{"Euler: $e^{i\\pi} + 1 = 0$ and $a_1 * b_1$.", "<p>Euler: $e^{i\\pi} + 1 = 0$ and $a_1 * b_1$.</p>\n"},
+		{"$$\n\\sum_{i=1}^n x_i < *y*\n$$", "<p>$$\n\\sum_{i=1}^n x_i &lt; *y*\n$$</p>\n"},
+		{"It costs $5 and $10.", "<p>It costs $5 and $10.</p>\n"},
+		{"$x *y* z$, not \\$x *y* z$ or `$x *y* z$`", "<p>$x *y* z$, not \\$x <em>y</em> z$ or <code>$x *y* z$</code></p>\n"},
+		{"```\n$a_b$\n```", "<pre><code>$a_b$\n</code></pre>\n"},
+	} {
+		ctx := &RenderingContext{Content: []byte(this.in), PageFmt: "markdown", Config: &Blackfriday{Math: true}}
+		result := string(RenderBytes(ctx))
+		if result != this.expect {
+			t.Errorf("[%d] Math output expected:\n%q\ngot:\n%q", i, this.expect, result)
+		}
+	}
+}
+
+func TestMathPassthroughDisabled(t *testing.T) {
+	ctx := &RenderingContext{Content: []byte("$x *y* z$"), PageFmt: "markdown", Config: NewBlackfriday()}
+	if result := string(RenderBytes(ctx)); !strings.Contains(result, "<em>y</em>") {
+		t.Errorf("Expected Blackfriday to render the math as Markdown, got %q", result)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	bp "github.com/spf13/hugo/bufferpool"
+)
+
+// the elements separated from the text around them by a blank line
+var textBlocks = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figure": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "nav": true,
+	"p": true, "section": true, "table": true,
+}
+
+// HTMLToText converts rendered content to plain text, e.g. for a text
+// version of a page in an email. Paragraphs, headings and the like are
+// separated by blank lines, list items start with "- " or their number,
+// preformatted text keeps its white space and entities are unescaped.
+// Scripts, styles and comments are left out.
+func HTMLToText(s string) string {
+	b := bp.GetBuffer()
+	defer bp.PutBuffer(b)
+
+	var (
+		newlines  int    // line breaks to write before the next text
+		space     bool   // whether to write a space before the next text
+		prefix    string // the list item marker to write before the next text
+		pre       int
+		skipUntil string
+		lists     []int // the open lists, with the number of the last item, -1 if unordered
+	)
+	breakLines := func(n int) {
+		if b.Len() > 0 && newlines < n {
+			newlines = n
+		}
+	}
+	write := func(text string) {
+		if pre == 0 {
+			collapsed := strings.Join(strings.Fields(text), " ")
+			if collapsed == "" {
+				space = space || text != ""
+				return
+			}
+			space = space || strings.TrimLeft(text, " \t\r\n") != text
+			if newlines == 0 && b.Len() > 0 && space {
+				b.WriteByte(' ')
+			}
+			space = strings.TrimRight(text, " \t\r\n") != text
+			text = collapsed
+		}
+		if b.Len() > 0 {
+			b.WriteString(strings.Repeat("\n", newlines))
+		}
+		newlines = 0
+		b.WriteString(prefix)
+		prefix = ""
+		b.WriteString(html.UnescapeString(text))
+	}
+
+	for i := 0; i < len(s); {
+		end := strings.IndexByte(s[i:], '<')
+		if end < 0 {
+			end = len(s) - i
+		}
+		if end > 0 {
+			if skipUntil == "" {
+				write(s[i : i+end])
+			}
+			i += end
+			continue
+		}
+
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		name, closing, end := parseHTMLTag(s[i:])
+		if end == 0 {
+			if skipUntil == "" {
+				write("<")
+			}
+			i++
+			continue
+		}
+		i += end
+
+		switch {
+		case skipUntil != "":
+			if closing && name == skipUntil {
+				skipUntil = ""
+			}
+		case !closing && (name == "script" || name == "style"):
+			skipUntil = name
+		case name == "br":
+			newlines++
+		case name == "pre":
+			if closing {
+				pre--
+				for b.Len() > 0 && b.Bytes()[b.Len()-1] == '\n' {
+					b.Truncate(b.Len() - 1)
+				}
+			} else {
+				pre++
+			}
+			breakLines(2)
+		case name == "ul" || name == "ol":
+			if closing && len(lists) > 0 {
+				lists = lists[:len(lists)-1]
+			}
+			if len(lists) > 0 {
+				breakLines(1)
+			} else {
+				breakLines(2)
+			}
+			if !closing && name == "ol" {
+				lists = append(lists, 0)
+			} else if !closing {
+				lists = append(lists, -1)
+			}
+		case name == "li" && !closing:
+			breakLines(1)
+			indent := ""
+			if len(lists) > 1 {
+				indent = strings.Repeat("  ", len(lists)-1)
+			}
+			if n := len(lists); n > 0 && lists[n-1] >= 0 {
+				lists[n-1]++
+				prefix = indent + strconv.Itoa(lists[n-1]) + ". "
+			} else {
+				prefix = indent + "- "
+			}
+		case name == "tr":
+			breakLines(1)
+		case textBlocks[name]:
+			breakLines(2)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	for i, this := range []struct {
+		in       string
+		expected string
+	}{
+		{"Hello", "Hello"},
+		{"<p>Hello <b>World</b>!</p>\n<p>Second   paragraph\nwrapped.</p>\n", "Hello World!\n\nSecond paragraph wrapped."},
+		{"<h2 id=\"a\">Title</h2>\n<p>A &amp; B &lt;c&gt;</p>", "Title\n\nA & B <c>"},
+		{"<p>Before</p>\n<ul>\n<li>One</li>\n<li>Two\n<ol>\n<li>Sub</li>\n<li>Sub</li>\n</ol></li>\n</ul>\n<p>After</p>", "Before\n\n- One\n- Two\n  1. Sub\n  2. Sub\n\nAfter"},
+		{"<p>Code:</p>\n<pre><code>if a {\n    b()\n}\n</code></pre>\n<p>Done</p>", "Code:\n\nif a {\n    b()\n}\n\nDone"},
+		{"<p>Line<br />break</p>", "Line\nbreak"},
+		{"<script>var a = '<p>';</script><style>p {}</style><!-- <p>c</p> --><p>Text</p>", "Text"},
+		{"a < b", "a < b"},
+	} {
+		if result := HTMLToText(this.in); result != this.expected {
+			t.Errorf("[%d] HTMLToText: got\n%q\nexpected\n%q", i, result, this.expected)
+		}
+	}
+}
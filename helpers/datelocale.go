@@ -0,0 +1,171 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"strings"
+	"time"
+)
+
+// DateLocale holds the month and day names of a language.
+type DateLocale struct {
+	Months      [12]string
+	ShortMonths [12]string
+	Days        [7]string
+	ShortDays   [7]string
+	RTL         bool
+}
+
+var dateLocales = map[string]*DateLocale{
+	"de": {
+		Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		Days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"es": {
+		Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		Days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"it": {
+		Months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		ShortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		Days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		Months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		ShortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		Days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		ShortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		Months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		ShortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		Days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		ShortDays:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+	"sv": {
+		Months:      [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		ShortMonths: [12]string{"jan", "feb", "mar", "apr", "maj", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		Days:        [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		ShortDays:   [7]string{"sön", "mån", "tis", "ons", "tor", "fre", "lör"},
+	},
+	"ar": {
+		Months:      [12]string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		ShortMonths: [12]string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		Days:        [7]string{"الأحد", "الاثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"},
+		ShortDays:   [7]string{"أحد", "اثنين", "ثلاثاء", "أربعاء", "خميس", "جمعة", "سبت"},
+		RTL:         true,
+	},
+	"he": {
+		Months:      [12]string{"ינואר", "פברואר", "מרץ", "אפריל", "מאי", "יוני", "יולי", "אוגוסט", "ספטמבר", "אוקטובר", "נובמבר", "דצמבר"},
+		ShortMonths: [12]string{"ינו׳", "פבר׳", "מרץ", "אפר׳", "מאי", "יוני", "יולי", "אוג׳", "ספט׳", "אוק׳", "נוב׳", "דצמ׳"},
+		Days:        [7]string{"יום ראשון", "יום שני", "יום שלישי", "יום רביעי", "יום חמישי", "יום שישי", "יום שבת"},
+		ShortDays:   [7]string{"א׳", "ב׳", "ג׳", "ד׳", "ה׳", "ו׳", "ש׳"},
+		RTL:         true,
+	},
+}
+
+// rtlLanguages are written right-to-left, whether or not their date names
+// are known.
+var rtlLanguages = []string{"ar", "fa", "he", "ur", "yi"}
+
+// GetDateLocale returns the date names for the given language code, e.g.
+// "de" or "pt-br". Region subtags fall back to the base language.
+// nil is returned for English and unknown languages.
+func GetDateLocale(lang string) *DateLocale {
+	lang = strings.ToLower(strings.Replace(lang, "_", "-", -1))
+
+	if l, ok := dateLocales[lang]; ok {
+		return l
+	}
+	if i := strings.Index(lang, "-"); i > 0 {
+		return dateLocales[lang[:i]]
+	}
+	return nil
+}
+
+// LanguageDirection returns "rtl" for languages written right-to-left,
+// else "ltr".
+func LanguageDirection(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if InStringArray(rtlLanguages, lang) {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// the longer names must come first so that "January" isn't read as "Jan"
+var dateNameLayouts = []string{"January", "Monday", "Jan", "Mon"}
+
+// FormatDate works like time.Format, but writes the month and day names
+// in the given language. English is used for unknown languages.
+func FormatDate(t time.Time, layout, lang string) string {
+	l := GetDateLocale(lang)
+	if l == nil {
+		return t.Format(layout)
+	}
+
+	var out []string
+	for layout != "" {
+		i, name := nextDateName(layout)
+		if i < 0 {
+			out = append(out, t.Format(layout))
+			break
+		}
+		if i > 0 {
+			out = append(out, t.Format(layout[:i]))
+		}
+		out = append(out, l.name(t, name))
+		layout = layout[i+len(name):]
+	}
+
+	return strings.Join(out, "")
+}
+
+func nextDateName(layout string) (int, string) {
+	first, name := -1, ""
+	for _, n := range dateNameLayouts {
+		if i := strings.Index(layout, n); i >= 0 && (first < 0 || i < first) {
+			first, name = i, n
+		}
+	}
+	return first, name
+}
+
+func (l *DateLocale) name(t time.Time, layout string) string {
+	switch layout {
+	case "January":
+		return l.Months[t.Month()-1]
+	case "Jan":
+		return l.ShortMonths[t.Month()-1]
+	case "Monday":
+		return l.Days[t.Weekday()]
+	case "Mon":
+		return l.ShortDays[t.Weekday()]
+	}
+	return ""
+}
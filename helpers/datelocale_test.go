@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	d := time.Date(2015, time.March, 4, 10, 30, 0, 0, time.UTC)
+
+	for i, this := range []struct {
+		layout string
+		lang   string
+		expect string
+	}{
+		{"Monday, 2 January 2006", "", "Wednesday, 4 March 2015"},
+		{"Monday, 2 January 2006", "en-us", "Wednesday, 4 March 2015"},
+		{"Monday, 2 January 2006", "de", "Mittwoch, 4 März 2015"},
+		{"Mon, 2 Jan 2006 15:04", "de-DE", "Mi, 4 Mär 2015 10:30"},
+		{"2 January 2006", "fr_CA", "4 mars 2015"},
+		{"Jan 2006", "pt-br", "mar 2015"},
+		{"2006-01-02", "nl", "2015-03-04"},
+		{"January January", "it", "marzo marzo"},
+	} {
+		result := FormatDate(d, this.layout, this.lang)
+		if result != this.expect {
+			t.Errorf("[%d] FormatDate got %q but expected %q", i, result, this.expect)
+		}
+	}
+}
+
+func TestLanguageDirection(t *testing.T) {
+	for i, this := range []struct {
+		lang   string
+		expect string
+	}{
+		{"", "ltr"},
+		{"en-us", "ltr"},
+		{"ar", "rtl"},
+		{"he-IL", "rtl"},
+		{"fa_IR", "rtl"},
+	} {
+		result := LanguageDirection(this.lang)
+		if result != this.expect {
+			t.Errorf("[%d] LanguageDirection got %q but expected %q", i, result, this.expect)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	for i, this := range []struct {
+		a, b     string
+		expected string
+	}{
+		{"same\n", "same\n", ""},
+		{"a\nb\nc\n", "a\nB\nc\n", "--- x\n+++ y\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"", "new\n", "--- x\n+++ y\n@@ -0,0 +1 @@\n+new\n"},
+		{"old\n", "", "--- x\n+++ y\n@@ -1 +0,0 @@\n-old\n"},
+		{"a\nb", "a\nc", "--- x\n+++ y\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+		// two hunks, far apart
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"--- x\n+++ y\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n"},
+		// close changes in one hunk
+		{"1\n2\n3\n4\n5\n", "1\nX\n3\n4\nY\n", "--- x\n+++ y\n@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n 4\n-5\n+Y\n"},
+	} {
+		if diff := UnifiedDiff("x", "y", []byte(this.a), []byte(this.b)); diff != this.expected {
+			t.Errorf("[%d] got\n%s\nexpected\n%s", i, diff, this.expected)
+		}
+	}
+}
+
+func TestUnifiedDiffLarge(t *testing.T) {
+	a := strings.Repeat("line\n", 3000) + "a\n" + strings.Repeat("other\n", 3000)
+	b := strings.Repeat("line\n", 3000) + "b\n" + strings.Repeat("other\n", 3000)
+	if diff := UnifiedDiff("x", "y", []byte(a), []byte(b)); !strings.Contains(diff, "@@ -2998,7 +2998,7 @@\n line\n line\n line\n-a\n+b\n other\n") {
+		t.Errorf("Unexpected diff of large files:\n%s", diff)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+)
+
+// EncryptIterations is the number of PBKDF2 iterations used to derive the
+// key from the password.
+const EncryptIterations = 100000
+
+// encryptedContent replaces the content of an encrypted page. The browser
+// derives the key with PBKDF2 and decrypts with AES-GCM, both done by the
+// Web Crypto API, so nothing but the password form works without it.
+const encryptedContent = `<div class="hugo-encrypted" data-salt="%s" data-iv="%s" data-iterations="%d" data-content="%s">
+<form>
+<input type="password" placeholder="Password" autocomplete="current-password" required>
+<button type="submit">Decrypt</button>
+<p class="hugo-encrypted-error" hidden>Wrong password</p>
+</form>
+</div>
+<script>
+(function() {
+  var box = document.currentScript.previousElementSibling;
+  var bytes = function(s) { return Uint8Array.from(atob(s), function(c) { return c.charCodeAt(0); }); };
+  box.querySelector("form").addEventListener("submit", function(e) {
+    e.preventDefault();
+    var password = new TextEncoder().encode(box.querySelector("input").value);
+    crypto.subtle.importKey("raw", password, "PBKDF2", false, ["deriveKey"]).then(function(base) {
+      return crypto.subtle.deriveKey(
+        {name: "PBKDF2", salt: bytes(box.dataset.salt), iterations: +box.dataset.iterations, hash: "SHA-256"},
+        base, {name: "AES-GCM", length: 256}, false, ["decrypt"]);
+    }).then(function(key) {
+      return crypto.subtle.decrypt({name: "AES-GCM", iv: bytes(box.dataset.iv)}, key, bytes(box.dataset.content));
+    }).then(function(content) {
+      box.outerHTML = new TextDecoder().decode(content);
+    }, function() {
+      box.querySelector(".hugo-encrypted-error").hidden = false;
+    });
+  });
+})();
+</script>
+`
+
+// EncryptHTML encrypts content with a key derived from password and returns
+// the HTML to decrypt it in the browser. The output only depends on its
+// arguments, so an unchanged page is published unchanged: the salt is
+// derived from id, the source path of the page, and the IV from an HMAC of
+// the content, which keeps it unique for different content under a key.
+func EncryptHTML(content []byte, password, id string) (template.HTML, error) {
+	salt := hmacSHA256([]byte(password), []byte("salt:"+id))[:16]
+	key := pbkdf2([]byte(password), salt, EncryptIterations, 32)
+	iv := hmacSHA256(key, content)[:12]
+
+	sealed, err := encrypt(content, key, iv)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.StdEncoding.EncodeToString
+	return template.HTML(fmt.Sprintf(encryptedContent, enc(salt), enc(iv), EncryptIterations, enc(sealed))), nil
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// encrypt seals content with AES-GCM, the authentication tag appended as
+// the Web Crypto API expects it.
+func encrypt(content, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nil, iv, content, nil), nil
+}
+
+// pbkdf2 derives a key of keyLen bytes from password with PBKDF2-HMAC-SHA256
+// (RFC 2898).
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	for i, this := range []struct {
+		password, salt string
+		iterations     int
+		keyLen         int
+		expected       string
+	}{
+		// RFC 7914, section 11
+		{"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	} {
+		key := hex.EncodeToString(pbkdf2([]byte(this.password), []byte(this.salt), this.iterations, this.keyLen))
+		if key != this.expected {
+			t.Errorf("[%d] Expected %s, got %s", i, this.expected, key)
+		}
+	}
+}
+
+func TestEncryptHTML(t *testing.T) {
+	content := "<p>The secret</p>"
+	html, err := EncryptHTML([]byte(content), "open sesame", "post/secret.md")
+	if err != nil {
+		t.Fatalf("Unable to encrypt: %s", err)
+	}
+	if strings.Contains(string(html), "secret") {
+		t.Fatalf("Expected the content to be encrypted, got %s", html)
+	}
+
+	attr := func(name string) []byte {
+		m := regexp.MustCompile(`data-` + name + `="([^"]*)"`).FindStringSubmatch(string(html))
+		if m == nil {
+			t.Fatalf("No data-%s in %s", name, html)
+		}
+		b, err := base64.StdEncoding.DecodeString(m[1])
+		if err != nil {
+			t.Fatalf("Invalid data-%s: %s", name, err)
+		}
+		return b
+	}
+
+	block, _ := aes.NewCipher(pbkdf2([]byte("open sesame"), attr("salt"), EncryptIterations, 32))
+	gcm, _ := cipher.NewGCM(block)
+	decrypted, err := gcm.Open(nil, attr("iv"), attr("content"), nil)
+	if err != nil || string(decrypted) != content {
+		t.Errorf("Expected to decrypt %q, got %q (%v)", content, decrypted, err)
+	}
+}
+
+func TestEncryptHTMLIsStable(t *testing.T) {
+	encrypt := func(content, id string) string {
+		html, err := EncryptHTML([]byte(content), "open sesame", id)
+		if err != nil {
+			t.Fatalf("Unable to encrypt: %s", err)
+		}
+		return string(html)
+	}
+	attr := func(name, html string) string {
+		return regexp.MustCompile(`data-` + name + `="([^"]*)"`).FindStringSubmatch(html)[1]
+	}
+
+	first := encrypt("<p>The secret</p>", "post/secret.md")
+	if second := encrypt("<p>The secret</p>", "post/secret.md"); second != first {
+		t.Errorf("Expected the same output for the same page, got\n%s\nand\n%s", first, second)
+	}
+
+	changed := encrypt("<p>Another secret</p>", "post/secret.md")
+	if attr("salt", changed) != attr("salt", first) || attr("iv", changed) == attr("iv", first) {
+		t.Errorf("Expected the same salt and another IV for other content")
+	}
+
+	moved := encrypt("<p>The secret</p>", "post/moved.md")
+	if attr("salt", moved) == attr("salt", first) {
+		t.Errorf("Expected another salt for another page")
+	}
+}
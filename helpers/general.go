@@ -27,6 +27,7 @@ import (
 	"net"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -62,16 +63,13 @@ func InStringArray(arr []string, el string) bool {
 // GuessType attempts to guess the type of file from a given string.
 func GuessType(in string) string {
 	switch strings.ToLower(in) {
-	case "md", "markdown", "mdown":
-		return "markdown"
-	case "asciidoc", "adoc", "ad":
-		return "asciidoc"
-	case "rst":
-		return "rst"
 	case "html", "htm":
 		return "html"
 	}
 
+	if f := LookupContentFormat(in); f != nil {
+		return f.Name
+	}
 	return "unknown"
 }
 
@@ -160,6 +158,29 @@ func Md5String(f string) string {
 	return hex.EncodeToString(h.Sum([]byte{}))
 }
 
+// ParseByteSize parses a size such as 512MB or 2GB, with the units counted
+// in powers of 1024, or a number of bytes.
+func ParseByteSize(size string) (uint64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := uint64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   uint64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 512MB", size)
+	}
+	return uint64(n * float64(multiplier)), nil
+}
+
 // Seq creates a sequence of integers.
 // It's named and used as GNU's seq.
 // Examples:
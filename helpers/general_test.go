@@ -133,6 +133,32 @@ func TestMd5StringEmpty(t *testing.T) {
 	}
 }
 
+func TestParseByteSize(t *testing.T) {
+	for i, this := range []struct {
+		in     string
+		expect interface{}
+	}{
+		{"100", uint64(100)},
+		{"100B", uint64(100)},
+		{"2KB", uint64(2048)},
+		{"512MB", uint64(512 << 20)},
+		{"1.5 gb", uint64(3 << 29)},
+		{"many", false},
+		{"-1MB", false},
+	} {
+		size, err := ParseByteSize(this.in)
+		if b, ok := this.expect.(bool); ok && !b {
+			if err == nil {
+				t.Errorf("[%d] ParseByteSize(%q) didn't return an expected error", i, this.in)
+			}
+			continue
+		}
+		if err != nil || size != this.expect {
+			t.Errorf("[%d] ParseByteSize(%q): expected %d, got %d (%v)", i, this.in, this.expect, size, err)
+		}
+	}
+}
+
 func TestSeq(t *testing.T) {
 	for i, this := range []struct {
 		in     []interface{}
@@ -0,0 +1,426 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"sync"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// The token types are named after the Pygments CSS classes, so a style
+// sheet made for Pygments works with either highlighter.
+const (
+	tokenText     = ""
+	tokenKeyword  = "k"
+	tokenBuiltin  = "nb"
+	tokenString   = "s"
+	tokenNumber   = "m"
+	tokenComment  = "c"
+	tokenOperator = "o"
+)
+
+type highlightLang struct {
+	keywords     map[string]bool
+	builtins     map[string]bool
+	lineComments []string
+	blockComment []string
+	quotes       string
+}
+
+func newHighlightLang(keywords, builtins string, lineComments, blockComment []string, quotes string) *highlightLang {
+	l := &highlightLang{
+		keywords:     make(map[string]bool),
+		builtins:     make(map[string]bool),
+		lineComments: lineComments,
+		blockComment: blockComment,
+		quotes:       quotes,
+	}
+	for _, k := range strings.Fields(keywords) {
+		l.keywords[k] = true
+	}
+	for _, b := range strings.Fields(builtins) {
+		l.builtins[b] = true
+	}
+	return l
+}
+
+var (
+	cComments     = []string{"//"}
+	cBlockComment = []string{"/*", "*/"}
+	hashComments  = []string{"#"}
+)
+
+var highlightLangs = map[string]*highlightLang{
+	"go": newHighlightLang(
+		"break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var",
+		"append bool byte cap close complex copy delete error false float32 float64 imag int int8 int16 int32 int64 iota len make new nil panic print println real recover rune string true uint uint8 uint16 uint32 uint64 uintptr",
+		cComments, cBlockComment, "\"'`"),
+	"javascript": newHighlightLang(
+		"break case catch class const continue debugger default delete do else export extends finally for function if import in instanceof let new return super switch this throw try typeof var void while with yield",
+		"true false null undefined NaN Infinity console window document",
+		cComments, cBlockComment, "\"'`"),
+	"python": newHighlightLang(
+		"and as assert break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield",
+		"True False None self print len range str int float dict list set tuple open",
+		hashComments, nil, "\"'"),
+	"bash": newHighlightLang(
+		"if then else elif fi for while until do done case esac function in return local export",
+		"echo cd exit set unset read source test true false",
+		hashComments, nil, "\"'"),
+	"c": newHighlightLang(
+		"auto break case const continue default do else enum extern for goto if inline register return sizeof static struct switch typedef union volatile while",
+		"char double float int long short signed unsigned void NULL",
+		cComments, cBlockComment, "\"'"),
+	"java": newHighlightLang(
+		"abstract assert break case catch class const continue default do else enum extends final finally for goto if implements import instanceof interface native new package private protected public return static super switch synchronized this throw throws transient try volatile while",
+		"boolean byte char double float int long short void true false null String",
+		cComments, cBlockComment, "\"'"),
+	"json": newHighlightLang("", "true false null", nil, nil, "\""),
+	"toml": newHighlightLang("", "true false", hashComments, nil, "\"'"),
+	"yaml": newHighlightLang("", "true false null yes no on off", hashComments, nil, "\"'"),
+}
+
+var highlightLangAliases = map[string]string{
+	"golang": "go",
+	"js":     "javascript",
+	"py":     "python",
+	"sh":     "bash",
+	"shell":  "bash",
+	"h":      "c",
+	"yml":    "yaml",
+}
+
+func getHighlightLang(lang string) *highlightLang {
+	lang = strings.ToLower(lang)
+	if alias, ok := highlightLangAliases[lang]; ok {
+		lang = alias
+	}
+	return highlightLangs[lang]
+}
+
+// HighlightStyle holds the colors used when the highlighted code is
+// styled inline, i.e. when PygmentsUseClasses is false.
+type HighlightStyle struct {
+	Background    string
+	Text          string
+	LineHighlight string
+	Tokens        map[string]string
+}
+
+var highlightStyles = map[string]*HighlightStyle{
+	"monokai": {
+		Background:    "#272822",
+		Text:          "#f8f8f2",
+		LineHighlight: "#49483e",
+		Tokens: map[string]string{
+			tokenKeyword:  "color: #66d9ef",
+			tokenBuiltin:  "color: #a6e22e",
+			tokenString:   "color: #e6db74",
+			tokenNumber:   "color: #ae81ff",
+			tokenComment:  "color: #75715e",
+			tokenOperator: "color: #f92672",
+		},
+	},
+	"github": {
+		Background:    "#ffffff",
+		Text:          "#333333",
+		LineHighlight: "#ffffcc",
+		Tokens: map[string]string{
+			tokenKeyword:  "color: #000000; font-weight: bold",
+			tokenBuiltin:  "color: #0086b3",
+			tokenString:   "color: #dd1144",
+			tokenNumber:   "color: #009999",
+			tokenComment:  "color: #999988; font-style: italic",
+			tokenOperator: "color: #000000; font-weight: bold",
+		},
+	},
+	"bw": {
+		Background:    "#ffffff",
+		Text:          "#000000",
+		LineHighlight: "#eeeeee",
+		Tokens: map[string]string{
+			tokenKeyword: "font-weight: bold",
+			tokenString:  "font-style: italic",
+			tokenComment: "font-style: italic",
+		},
+	},
+}
+
+var (
+	unknownStylesMu sync.Mutex
+	unknownStyles   = make(map[string]bool)
+)
+
+// ResetHighlightWarnings forgets the unknown highlighting styles warned
+// about, so that the next build warns about them again.
+func ResetHighlightWarnings() {
+	unknownStylesMu.Lock()
+	unknownStyles = make(map[string]bool)
+	unknownStylesMu.Unlock()
+}
+
+// getHighlightStyle returns the named style, falling back to monokai
+// for styles the built-in highlighter doesn't know. It warns about an
+// unknown style once per build, not for every code block using it.
+func getHighlightStyle(name string) *HighlightStyle {
+	if s, ok := highlightStyles[name]; ok {
+		return s
+	}
+
+	unknownStylesMu.Lock()
+	warned := unknownStyles[name]
+	unknownStyles[name] = true
+	unknownStylesMu.Unlock()
+
+	if !warned {
+		jww.WARN.Printf("Highlighting style %q is not built in, using monokai", name)
+	}
+	return highlightStyles["monokai"]
+}
+
+// HighlightBuiltin highlights code with the built-in highlighter, which
+// needs no external program but knows fewer languages than Pygments.
+// It reports false if the language is not supported.
+func HighlightBuiltin(code, lang string) (string, bool) {
+	return highlightBuiltin(code, lang, nil)
+}
+
+// highlightBuiltin understands the style, noclasses, linenos and hl_lines
+// Pygments options.
+func highlightBuiltin(code, lang string, opts map[string]string) (string, bool) {
+	l := getHighlightLang(lang)
+	if l == nil {
+		return "", false
+	}
+
+	useClasses := viper.GetBool("PygmentsUseClasses")
+	if noclasses, ok := opts["noclasses"]; ok {
+		useClasses = noclasses == "false"
+	}
+	var style *HighlightStyle
+	if !useClasses {
+		name := viper.GetString("PygmentsStyle")
+		if s, ok := opts["style"]; ok {
+			name = s
+		}
+		style = getHighlightStyle(name)
+	}
+
+	span := func(b *bytes.Buffer, typ, text string) {
+		switch {
+		case text == "":
+		case typ == tokenText:
+			b.WriteString(text)
+		case useClasses:
+			fmt.Fprintf(b, `<span class="%s">%s</span>`, typ, text)
+		case style.Tokens[typ] != "":
+			fmt.Fprintf(b, `<span style="%s">%s</span>`, style.Tokens[typ], text)
+		default:
+			b.WriteString(text)
+		}
+	}
+
+	linenos := opts["linenos"] != "" && opts["linenos"] != "false"
+	hlLines := parseHighlightLines(opts["hl_lines"], strings.Count(code, "\n")+1)
+
+	// tokens may span lines, so they are split up to be able to number
+	// and highlight the lines
+	lines := []*bytes.Buffer{new(bytes.Buffer)}
+	for _, t := range l.tokenize(code) {
+		parts := []string{t.text}
+		if linenos || len(hlLines) > 0 {
+			parts = strings.Split(t.text, "\n")
+		}
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, new(bytes.Buffer))
+			}
+			span(lines[len(lines)-1], t.typ, template.HTMLEscapeString(part))
+		}
+	}
+
+	var b bytes.Buffer
+	if useClasses {
+		b.WriteString(`<div class="highlight"><pre>`)
+	} else {
+		fmt.Fprintf(&b, `<div class="highlight" style="background: %s"><pre style="line-height: 125%%; color: %s">`, style.Background, style.Text)
+	}
+
+	for i, line := range lines {
+		last := i == len(lines)-1
+		if last && line.Len() == 0 && i > 0 {
+			break
+		}
+		var lineno string
+		if linenos {
+			width := len(strconv.Itoa(len(lines)))
+			if useClasses {
+				lineno = fmt.Sprintf(`<span class="lineno">%*d </span>`, width, i+1)
+			} else {
+				lineno = fmt.Sprintf(`<span style="%s">%*d </span>`, style.Tokens[tokenComment], width, i+1)
+			}
+		}
+		nl := "\n"
+		if last {
+			nl = ""
+		}
+		switch {
+		case !hlLines[i+1]:
+			fmt.Fprintf(&b, "%s%s%s", lineno, line.Bytes(), nl)
+		case useClasses:
+			fmt.Fprintf(&b, `<span class="hll">%s%s%s</span>`, lineno, line.Bytes(), nl)
+		default:
+			fmt.Fprintf(&b, `<span style="background-color: %s">%s%s%s</span>`, style.LineHighlight, lineno, line.Bytes(), nl)
+		}
+	}
+
+	b.WriteString("</pre></div>\n")
+	return b.String(), true
+}
+
+// parseHighlightLines parses the Pygments hl_lines option, a space separated
+// list of line numbers. Ranges such as 3-5 are allowed too, and are cut off
+// at max, the number of lines of the code.
+func parseHighlightLines(s string, max int) map[int]bool {
+	lines := make(map[int]bool)
+	for _, f := range strings.Fields(s) {
+		from, to := f, f
+		if i := strings.Index(f, "-"); i > 0 {
+			from, to = f[:i], f[i+1:]
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || end < start {
+			jww.WARN.Printf("Invalid line number %q in hl_lines", f)
+			continue
+		}
+		if start < 1 {
+			start = 1
+		}
+		if end > max {
+			end = max
+		}
+		for n := start; n <= end; n++ {
+			lines[n] = true
+		}
+	}
+	return lines
+}
+
+type highlightToken struct {
+	typ  string
+	text string
+}
+
+func (l *highlightLang) tokenize(code string) []highlightToken {
+	var tokens []highlightToken
+	add := func(typ, text string) {
+		// merge neighbours of the same type to keep the markup small
+		if n := len(tokens); n > 0 && tokens[n-1].typ == typ {
+			tokens[n-1].text += text
+			return
+		}
+		tokens = append(tokens, highlightToken{typ, text})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		end := 1
+		typ := tokenText
+
+		switch c := code[i]; {
+		case l.blockComment != nil && strings.HasPrefix(rest, l.blockComment[0]):
+			typ = tokenComment
+			end = len(rest)
+			if j := strings.Index(rest[len(l.blockComment[0]):], l.blockComment[1]); j >= 0 {
+				end = len(l.blockComment[0]) + j + len(l.blockComment[1])
+			}
+		case hasAnyPrefix(rest, l.lineComments):
+			typ = tokenComment
+			end = len(rest)
+			if j := strings.IndexByte(rest, '\n'); j >= 0 {
+				end = j
+			}
+		case strings.IndexByte(l.quotes, c) >= 0:
+			typ = tokenString
+			end = stringEnd(rest)
+		case c >= '0' && c <= '9':
+			typ = tokenNumber
+			for end < len(rest) && isNumberPart(rest[end]) {
+				end++
+			}
+		case isIdentStart(c):
+			for end < len(rest) && (isIdentStart(rest[end]) || rest[end] >= '0' && rest[end] <= '9') {
+				end++
+			}
+			if l.keywords[rest[:end]] {
+				typ = tokenKeyword
+			} else if l.builtins[rest[:end]] {
+				typ = tokenBuiltin
+			}
+		case strings.IndexByte("+-*/%=<>!&|^~?:", c) >= 0:
+			typ = tokenOperator
+		}
+
+		add(typ, rest[:end])
+		i += end
+	}
+
+	return tokens
+}
+
+// stringEnd returns the end of the string literal at the start of s.
+// Only backtick strings may span lines.
+func stringEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case '\n':
+			if quote != '`' {
+				return i
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isNumberPart(c byte) bool {
+	return c == '.' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' || c == 'x' || c == 'X'
+}
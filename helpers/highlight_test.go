@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestHighlightBuiltin(t *testing.T) {
+	viper.Set("PygmentsUseClasses", true)
+	defer viper.Set("PygmentsUseClasses", false)
+
+	for i, this := range []struct {
+		code   string
+		lang   string
+		expect string
+	}{
+		{"func main() {\n\tfmt.Println(\"a < b\", 42) // hi\n}", "go",
+			`<div class="highlight"><pre><span class="k">func</span> main() {` + "\n\t" +
+				`fmt.Println(<span class="s">&#34;a &lt; b&#34;</span>, <span class="m">42</span>) <span class="c">// hi</span>` + "\n}</pre></div>\n"},
+		{"x = None # nothing", "py",
+			`<div class="highlight"><pre>x <span class="o">=</span> <span class="nb">None</span> <span class="c"># nothing</span></pre></div>` + "\n"},
+		{"/* a\nb */ var s = `x\ny`", "JS",
+			`<div class="highlight"><pre><span class="c">/* a` + "\n" + `b */</span> <span class="k">var</span> s <span class="o">=</span> <span class="s">` + "`x\ny`" + `</span></pre></div>` + "\n"},
+	} {
+		result, ok := HighlightBuiltin(this.code, this.lang)
+		if !ok {
+			t.Errorf("[%d] Expected %s to be supported", i, this.lang)
+			continue
+		}
+		if result != this.expect {
+			t.Errorf("[%d] Highlight output expected:\n%q\ngot:\n%q", i, this.expect, result)
+		}
+	}
+
+	if _, ok := HighlightBuiltin("code", "cobol"); ok {
+		t.Errorf("Expected cobol to be unsupported")
+	}
+}
+
+func TestHighlightBuiltinInlineStyle(t *testing.T) {
+	viper.Set("PygmentsStyle", "github")
+	defer viper.Set("PygmentsStyle", "monokai")
+
+	result, _ := HighlightBuiltin("true", "json")
+	expect := `<div class="highlight" style="background: #ffffff"><pre style="line-height: 125%; color: #333333"><span style="color: #0086b3">true</span></pre></div>` + "\n"
+	if result != expect {
+		t.Errorf("Highlight output expected:\n%q\ngot:\n%q", expect, result)
+	}
+}
+
+func TestHighlightUnknownStyleWarnsOnce(t *testing.T) {
+	ResetHighlightWarnings()
+	defer ResetHighlightWarnings()
+
+	if s := getHighlightStyle("nosuchstyle"); s != highlightStyles["monokai"] {
+		t.Errorf("Expected monokai for an unknown style")
+	}
+	getHighlightStyle("nosuchstyle")
+
+	if !unknownStyles["nosuchstyle"] || len(unknownStyles) != 1 {
+		t.Errorf("Expected the unknown style to be remembered once, got %v", unknownStyles)
+	}
+
+	ResetHighlightWarnings()
+	if len(unknownStyles) != 0 {
+		t.Errorf("Expected the next build to warn again, got %v", unknownStyles)
+	}
+}
+
+func TestHighlightCodeFences(t *testing.T) {
+	viper.Set("HighlightCodeFences", true)
+	viper.Set("PygmentsUseClasses", true)
+	defer viper.Set("HighlightCodeFences", false)
+	defer viper.Set("PygmentsUseClasses", false)
+
+	ctx := &RenderingContext{Content: []byte("Code:\n\n```go\nreturn nil\n```\n\n```\nplain\n```"), PageFmt: "markdown"}
+	result := string(RenderBytes(ctx))
+	expect := "<p>Code:</p>\n\n" +
+		`<div class="highlight"><pre><span class="k">return</span> <span class="nb">nil</span>` + "\n</pre></div>\n\n" +
+		"<pre><code>plain\n</code></pre>\n"
+
+	if result != expect {
+		t.Errorf("Render output expected:\n%q\ngot:\n%q", expect, result)
+	}
+}
+
+func TestHighlightBuiltinOptions(t *testing.T) {
+	viper.Set("PygmentsUseClasses", true)
+	defer viper.Set("PygmentsUseClasses", false)
+
+	code := "a := 1\n/* b\nc */\nreturn a\n"
+	for i, this := range []struct {
+		opts   string
+		expect string
+	}{
+		{"linenos=inline",
+			`<div class="highlight"><pre><span class="lineno">1 </span>a <span class="o">:=</span> <span class="m">1</span>` + "\n" +
+				`<span class="lineno">2 </span><span class="c">/* b</span>` + "\n" +
+				`<span class="lineno">3 </span><span class="c">c */</span>` + "\n" +
+				`<span class="lineno">4 </span><span class="k">return</span> a` + "\n</pre></div>\n"},
+		{"hl_lines=2-3",
+			`<div class="highlight"><pre>a <span class="o">:=</span> <span class="m">1</span>` + "\n" +
+				`<span class="hll"><span class="c">/* b</span>` + "\n</span>" +
+				`<span class="hll"><span class="c">c */</span>` + "\n</span>" +
+				`<span class="k">return</span> a` + "\n</pre></div>\n"},
+		{"noclasses=true, style=bw",
+			`<div class="highlight" style="background: #ffffff"><pre style="line-height: 125%; color: #000000">a := 1` + "\n" +
+				`<span style="font-style: italic">/* b` + "\nc */</span>\n" +
+				`<span style="font-weight: bold">return</span> a` + "\n</pre></div>\n"},
+	} {
+		result, _ := highlightBuiltin(code, "go", parseHighlightOptions(this.opts))
+		if result != this.expect {
+			t.Errorf("[%d] Highlight output expected:\n%q\ngot:\n%q", i, this.expect, result)
+		}
+	}
+}
+
+func TestExpandHighlightLines(t *testing.T) {
+	if got := expandHighlightLines("5 1-3 x", 10); got != "1 2 3 5" {
+		t.Errorf("Expected 1 2 3 5, got %q", got)
+	}
+	if got := expandHighlightLines("0-2 4-1000000000 3-1", 5); got != "1 2 4 5" {
+		t.Errorf("Expected the lines cut off at 5, got %q", got)
+	}
+}
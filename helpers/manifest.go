@@ -0,0 +1,97 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// A Manifest lists the files of a build with their SHA-256 hashes, so that
+// the deployment can check it publishes exactly what Hugo built.
+type Manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+// NewManifest hashes all files below dir, except for the ones in skip, such
+// as the manifest itself.
+func NewManifest(dir string, fs afero.Fs, skip ...string) (*Manifest, error) {
+	files, err := ListFiles(dir, fs)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Files: make(map[string]string)}
+	for _, f := range files {
+		name := filepath.ToSlash(f)
+		if InStringArray(skip, name) {
+			continue
+		}
+		b, err := ReadFile(filepath.Join(dir, f), fs)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(b)
+		m.Files[name] = hex.EncodeToString(sum[:])
+	}
+	return m, nil
+}
+
+// JSON returns the manifest as indented JSON, with the files sorted by path.
+func (m *Manifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// SignManifest signs content with the PEM encoded RSA or ECDSA private key.
+// The signature is of the SHA-256 hash of content, as made by
+// `openssl dgst -sha256 -sign`, so it can be checked with
+// `openssl dgst -sha256 -verify public.pem -signature manifest.json.sig manifest.json`.
+func SignManifest(content, pemKey []byte) ([]byte, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, errors.New("unsupported key type " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("the key can't sign")
+	}
+	sum := sha256.Sum256(content)
+	return signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+}
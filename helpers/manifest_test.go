@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestNewManifest(t *testing.T) {
+	fs := new(afero.MemMapFs)
+	WriteToDisk(filepath.FromSlash("public/index.html"), strings.NewReader("home"), fs)
+	WriteToDisk(filepath.FromSlash("public/post/a/index.html"), strings.NewReader("a"), fs)
+	WriteToDisk(filepath.FromSlash("public/manifest.json"), strings.NewReader("old"), fs)
+
+	m, err := NewManifest("public", fs, "manifest.json")
+	if err != nil {
+		t.Fatalf("Unable to create the manifest: %s", err)
+	}
+
+	expected := map[string]string{
+		"index.html":        "4ea140588150773ce3aace786aeef7f4049ce100fa649c94fbbddb960f1da942",
+		"post/a/index.html": "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb",
+	}
+	if len(m.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %v", len(expected), m.Files)
+	}
+	for f, sum := range expected {
+		if m.Files[f] != sum {
+			t.Errorf("Expected hash %s for %s, got %s", sum, f, m.Files[f])
+		}
+	}
+}
+
+func TestSignManifest(t *testing.T) {
+	content := []byte(`{"files": {}}`)
+	sum := sha256.Sum256(content)
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	sig, err := SignManifest(content, pemKey)
+	if err != nil {
+		t.Fatalf("Unable to sign with an RSA key: %s", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+		t.Errorf("Invalid RSA signature: %s", err)
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(ecKey)
+	pemKey = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	sig, err = SignManifest(content, pemKey)
+	if err != nil {
+		t.Fatalf("Unable to sign with an ECDSA key: %s", err)
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &rs); err != nil || !ecdsa.Verify(&ecKey.PublicKey, sum[:], rs.R, rs.S) {
+		t.Errorf("Invalid ECDSA signature: %s", err)
+	}
+
+	if _, err := SignManifest(content, []byte("not a key")); err == nil {
+		t.Error("Expected an error for an invalid key")
+	}
+}
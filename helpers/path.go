@@ -14,12 +14,14 @@
 package helpers
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -190,6 +192,18 @@ func GetThemeDataDirPath() (string, error) {
 	return getThemeDirPath("data")
 }
 
+// GetThemeI18nDirPath returns the theme's i18n dir path if theme is set.
+// If theme is set and the i18n dir doesn't exist, an error is returned.
+func GetThemeI18nDirPath() (string, error) {
+	return getThemeDirPath("i18n")
+}
+
+// GetThemeIconDirPath returns the theme's icons dir path if theme is set.
+// If theme is set and the icons dir doesn't exist, an error is returned.
+func GetThemeIconDirPath() (string, error) {
+	return getThemeDirPath("icons")
+}
+
 func getThemeDirPath(path string) (string, error) {
 	var themeDir string
 	if ThemeSet() {
@@ -434,6 +448,157 @@ func WriteToDisk(inpath string, r io.Reader, fs afero.Fs) (err error) {
 	return
 }
 
+// SyncStaticFile copies the static file at the path rel, relative to the
+// static directories, to the same path in publishDir, from the first of
+// staticDirs that has it, e.g. the site's static directory before the
+// theme's. A directory is copied with all its files. The file is removed
+// from publishDir when none of staticDirs has it anymore. It is a sync of
+// one file, for when only a few have changed.
+func SyncStaticFile(rel, publishDir string, staticDirs []string, src, dest afero.Fs) error {
+	rel = filepath.Clean(string(filepath.Separator) + rel)
+	if rel == string(filepath.Separator) {
+		return fmt.Errorf("%q is not a static file", rel)
+	}
+
+	for _, dir := range staticDirs {
+		if dir == "" {
+			continue
+		}
+		from := filepath.Join(dir, rel)
+		fi, err := src.Stat(from)
+		if err != nil {
+			continue
+		}
+		if !fi.IsDir() {
+			return copyFile(from, filepath.Join(publishDir, rel), src, dest)
+		}
+		return afero.Walk(src, from, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			return copyFile(path, filepath.Join(publishDir, rel, strings.TrimPrefix(path, from)), src, dest)
+		})
+	}
+
+	target := filepath.Join(publishDir, rel)
+	if fi, err := dest.Stat(target); err != nil || fi.IsDir() {
+		return nil
+	}
+	return dest.Remove(target)
+}
+
+func copyFile(from, to string, src, dest afero.Fs) error {
+	f, err := src.Open(from)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return WriteToDisk(to, f, dest)
+}
+
+// DiffDir compares the files below dir in two filesystems and returns the
+// paths, relative to dir, that are only in after, that differ, and that
+// are only in before.
+func DiffDir(dir string, before, after afero.Fs) (added, changed, removed []string, err error) {
+	return DiffDirs(dir, before, dir, after)
+}
+
+// DiffDirs is DiffDir for two different directories, with the paths
+// relative to them.
+func DiffDirs(beforeDir string, before afero.Fs, afterDir string, after afero.Fs) (added, changed, removed []string, err error) {
+	old, err := ListFiles(beforeDir, before)
+	if err != nil {
+		return
+	}
+	current, err := ListFiles(afterDir, after)
+	if err != nil {
+		return
+	}
+
+	inOld := make(map[string]bool, len(old))
+	for _, p := range old {
+		inOld[p] = true
+	}
+	inCurrent := make(map[string]bool, len(current))
+	for _, p := range current {
+		inCurrent[p] = true
+	}
+
+	for _, p := range current {
+		if !inOld[p] {
+			added = append(added, p)
+			continue
+		}
+		a, err := ReadFile(filepath.Join(beforeDir, p), before)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		b, err := ReadFile(filepath.Join(afterDir, p), after)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !bytes.Equal(a, b) {
+			changed = append(changed, p)
+		}
+	}
+
+	for _, p := range old {
+		if !inCurrent[p] {
+			removed = append(removed, p)
+		}
+	}
+
+	return
+}
+
+// ListFiles returns the sorted paths of all files below dir, relative to dir.
+func ListFiles(dir string, fs afero.Fs) ([]string, error) {
+	if exists, _ := DirExists(dir, fs); !exists {
+		return nil, nil
+	}
+
+	var files []string
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		f, err := fs.Open(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		fis, err := f.Readdir(-1)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		for _, fi := range fis {
+			p := filepath.Join(rel, fi.Name())
+			if fi.IsDir() {
+				if err := walk(p); err != nil {
+					return err
+				}
+			} else {
+				files = append(files, p)
+			}
+		}
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadFile returns the content of the file at path in fs.
+func ReadFile(path string, fs afero.Fs) ([]byte, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReaderToBytes(f), nil
+}
+
 // GetTempDir returns the OS default temp directory with trailing slash
 // if subPath is not empty then it will be created recursively with mode 777 rwx rwx rwx
 func GetTempDir(subPath string, fs afero.Fs) string {
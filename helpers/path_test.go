@@ -1,11 +1,13 @@
 package helpers
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/spf13/viper"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -675,3 +677,123 @@ func TestGetTempDir(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffDir(t *testing.T) {
+	before := new(afero.MemMapFs)
+	after := new(afero.MemMapFs)
+	dir := filepath.FromSlash("/public")
+
+	write := func(fs afero.Fs, name, content string) {
+		if err := WriteToDisk(filepath.Join(dir, filepath.FromSlash(name)), strings.NewReader(content), fs); err != nil {
+			t.Fatalf("Unable to write %s: %s", name, err)
+		}
+	}
+
+	write(before, "index.html", "home")
+	write(before, "post/one/index.html", "one")
+	write(before, "post/old/index.html", "old")
+	write(after, "index.html", "home")
+	write(after, "post/one/index.html", "one, edited")
+	write(after, "post/two/index.html", "two")
+
+	added, changed, removed, err := DiffDir(dir, before, after)
+	if err != nil {
+		t.Fatalf("DiffDir failed: %s", err)
+	}
+
+	expect := func(what string, got []string, expected ...string) {
+		for i := range expected {
+			expected[i] = filepath.FromSlash(expected[i])
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %s %v, got %v", what, expected, got)
+		}
+	}
+
+	expect("added", added, "post/two/index.html")
+	expect("changed", changed, "post/one/index.html")
+	expect("removed", removed, "post/old/index.html")
+
+	if added, changed, removed, _ := DiffDir(dir, after, after); len(added)+len(changed)+len(removed) != 0 {
+		t.Errorf("Expected no difference when comparing with itself")
+	}
+
+	golden := new(afero.MemMapFs)
+	for _, name := range []string{"index.html", "post/one/index.html", "post/two/index.html"} {
+		content, _ := ReadFile(filepath.Join(dir, filepath.FromSlash(name)), after)
+		if err := WriteToDisk(filepath.Join(filepath.FromSlash("/golden"), filepath.FromSlash(name)), bytes.NewReader(content), golden); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if added, changed, removed, _ := DiffDirs(filepath.FromSlash("/golden"), golden, dir, after); len(added)+len(changed)+len(removed) != 0 {
+		t.Errorf("Expected no difference with a copy in another directory, got %v %v %v", added, changed, removed)
+	}
+}
+
+func TestSyncStaticFile(t *testing.T) {
+	src := new(afero.MemMapFs)
+	dest := new(afero.MemMapFs)
+	staticDir := filepath.FromSlash("/site/static")
+	themeDir := filepath.FromSlash("/site/themes/t/static")
+	publishDir := filepath.FromSlash("/site/public")
+	dirs := []string{staticDir, themeDir}
+
+	write := func(fs afero.Fs, dir, name, content string) {
+		if err := WriteToDisk(filepath.Join(dir, filepath.FromSlash(name)), strings.NewReader(content), fs); err != nil {
+			t.Fatalf("Unable to write %s: %s", name, err)
+		}
+	}
+	published := func(name string) string {
+		content, err := ReadFile(filepath.Join(publishDir, filepath.FromSlash(name)), dest)
+		if err != nil {
+			return "<none>"
+		}
+		return string(content)
+	}
+	sync := func(name string) {
+		if err := SyncStaticFile(filepath.FromSlash(name), publishDir, dirs, src, dest); err != nil {
+			t.Fatalf("Unable to sync %s: %s", name, err)
+		}
+	}
+
+	write(src, staticDir, "css/main.css", "site")
+	write(src, themeDir, "css/main.css", "theme")
+	write(src, themeDir, "css/theme.css", "theme only")
+	write(src, staticDir, "js/a.js", "a")
+	write(src, staticDir, "js/lib/b.js", "b")
+	write(dest, publishDir, "index.html", "home")
+
+	sync("/css/main.css")
+	sync("/css/theme.css")
+	sync("/js")
+	for name, expected := range map[string]string{
+		"css/main.css":  "site",
+		"css/theme.css": "theme only",
+		"js/a.js":       "a",
+		"js/lib/b.js":   "b",
+		"index.html":    "home",
+	} {
+		if content := published(name); content != expected {
+			t.Errorf("Expected %s to be %q, got %q", name, expected, content)
+		}
+	}
+
+	src.Remove(filepath.Join(staticDir, filepath.FromSlash("css/main.css")))
+	sync("/css/main.css")
+	if content := published("css/main.css"); content != "theme" {
+		t.Errorf("Expected the theme's main.css once the site's is removed, got %q", content)
+	}
+
+	src.Remove(filepath.Join(themeDir, filepath.FromSlash("css/theme.css")))
+	sync("/css/theme.css")
+	if content := published("css/theme.css"); content != "<none>" {
+		t.Errorf("Expected theme.css to be removed, got %q", content)
+	}
+
+	if err := SyncStaticFile("/", publishDir, dirs, src, dest); err == nil {
+		t.Errorf("Expected an error syncing the whole static directory")
+	}
+	if content := published("index.html"); content != "home" {
+		t.Errorf("Expected the other files to be left alone, got %q", content)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import "strings"
+
+// pluralRules pick the CLDR plural category of a count, by language.
+// Languages that aren't listed use the English rule.
+var pluralRules = map[string]func(n int) string{}
+
+func init() {
+	for _, lang := range []string{"id", "ja", "ko", "ms", "th", "vi", "zh"} {
+		pluralRules[lang] = func(n int) string { return "other" }
+	}
+	for _, lang := range []string{"fr", "pt"} {
+		pluralRules[lang] = func(n int) string {
+			if n == 0 || n == 1 {
+				return "one"
+			}
+			return "other"
+		}
+	}
+	for _, lang := range []string{"be", "bs", "hr", "ru", "sr", "uk"} {
+		pluralRules[lang] = func(n int) string {
+			switch {
+			case n%10 == 1 && n%100 != 11:
+				return "one"
+			case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+				return "few"
+			}
+			return "many"
+		}
+	}
+	pluralRules["pl"] = func(n int) string {
+		switch {
+		case n == 1:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		}
+		return "many"
+	}
+	for _, lang := range []string{"cs", "sk"} {
+		pluralRules[lang] = func(n int) string {
+			switch {
+			case n == 1:
+				return "one"
+			case n >= 2 && n <= 4:
+				return "few"
+			}
+			return "other"
+		}
+	}
+	pluralRules["ar"] = func(n int) string {
+		switch {
+		case n == 0:
+			return "zero"
+		case n == 1:
+			return "one"
+		case n == 2:
+			return "two"
+		case n%100 >= 3 && n%100 <= 10:
+			return "few"
+		case n%100 >= 11:
+			return "many"
+		}
+		return "other"
+	}
+}
+
+// PluralCategory returns the CLDR plural category of the count n in the
+// given language, e.g. "one" for 1 and "other" for 2 in English, or "few"
+// for 3 in Russian. Region subtags fall back to the base language.
+func PluralCategory(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if rule, ok := pluralRules[lang]; ok {
+		return rule(n)
+	}
+
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
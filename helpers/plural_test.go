@@ -0,0 +1,36 @@
+package helpers
+
+import "testing"
+
+func TestPluralCategory(t *testing.T) {
+	for i, this := range []struct {
+		lang   string
+		n      int
+		expect string
+	}{
+		{"", 1, "one"},
+		{"en-us", 0, "other"},
+		{"en", 2, "other"},
+		{"de", -1, "one"},
+		{"fr", 0, "one"},
+		{"fr_CA", 2, "other"},
+		{"ja", 1, "other"},
+		{"ru", 21, "one"},
+		{"ru", 11, "many"},
+		{"ru", 23, "few"},
+		{"ru", 13, "many"},
+		{"pl", 21, "many"},
+		{"pl", 22, "few"},
+		{"cs", 3, "few"},
+		{"cs", 5, "other"},
+		{"ar", 2, "two"},
+		{"ar", 105, "few"},
+		{"ar", 111, "many"},
+		{"ar", 100, "other"},
+	} {
+		result := PluralCategory(this.lang, this.n)
+		if result != this.expect {
+			t.Errorf("[%d] PluralCategory(%q, %d) got %q but expected %q", i, this.lang, this.n, result, this.expect)
+		}
+	}
+}
@@ -15,8 +15,9 @@ package helpers
 
 import (
 	"bytes"
-	"fmt"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 
 	jww "github.com/spf13/jwalterweatherman"
@@ -34,25 +35,46 @@ func HasPygments() bool {
 	return true
 }
 
-// Highlight takes some code and returns highlighted code.
-func Highlight(code string, lexer string) string {
+// Highlight takes some code and returns highlighted code. The options are
+// Pygments options, e.g. "linenos=table,hl_lines=2 3,style=github", and
+// override the PygmentsStyle and PygmentsUseClasses settings. Without
+// Pygments the built-in highlighter is used for the languages it knows.
+func Highlight(code string, lexer string, optsStr string) string {
+	opts := parseHighlightOptions(optsStr)
 
 	if !HasPygments() {
+		if s, ok := highlightBuiltin(code, lexer, opts); ok {
+			return s
+		}
 		jww.WARN.Println("Highlighting requires Pygments to be installed and in the path")
 		return code
 	}
 
 	var out bytes.Buffer
 	var stderr bytes.Buffer
-	style := viper.GetString("PygmentsStyle")
 
-	noclasses := "true"
+	params := map[string]string{
+		"style":     viper.GetString("PygmentsStyle"),
+		"noclasses": "true",
+		"encoding":  "utf8",
+	}
 	if viper.GetBool("PygmentsUseClasses") {
-		noclasses = "false"
+		params["noclasses"] = "false"
+	}
+	for k, v := range opts {
+		params[k] = v
+	}
+	if hl, ok := params["hl_lines"]; ok {
+		params["hl_lines"] = expandHighlightLines(hl, strings.Count(code, "\n")+1)
 	}
 
-	cmd := exec.Command(pygmentsBin, "-l"+lexer, "-fhtml", "-O",
-		fmt.Sprintf("style=%s,noclasses=%s,encoding=utf8", style, noclasses))
+	var pairs []string
+	for k, v := range params {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+
+	cmd := exec.Command(pygmentsBin, "-l"+lexer, "-fhtml", "-O", strings.Join(pairs, ","))
 	cmd.Stdin = strings.NewReader(code)
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
@@ -64,3 +86,38 @@ func Highlight(code string, lexer string) string {
 
 	return out.String()
 }
+
+// parseHighlightOptions parses comma separated key=value options.
+func parseHighlightOptions(optsStr string) map[string]string {
+	opts := make(map[string]string)
+	for _, opt := range strings.Split(optsStr, ",") {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			jww.WARN.Printf("Invalid highlight option %q, expected key=value", opt)
+			continue
+		}
+		opts[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+	return opts
+}
+
+// expandHighlightLines turns the ranges in hl_lines into the space
+// separated line numbers Pygments wants, up to max.
+func expandHighlightLines(s string, max int) string {
+	lines := parseHighlightLines(s, max)
+	nums := make([]int, 0, len(lines))
+	for n := range lines {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	strs := make([]string, len(nums))
+	for i, n := range nums {
+		strs[i] = strconv.Itoa(n)
+	}
+	return strings.Join(strs, " ")
+}
@@ -0,0 +1,89 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// relativeTimeLocale holds the words of a language for a relative time.
+// Units are the singular and plural names of a minute, an hour, a day, a
+// week, a month and a year.
+type relativeTimeLocale struct {
+	now    string
+	past   string
+	future string
+	units  [6][2]string
+}
+
+var relativeTimeLocales = map[string]*relativeTimeLocale{
+	"en": {"just now", "%s ago", "in %s", [6][2]string{{"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}, {"week", "weeks"}, {"month", "months"}, {"year", "years"}}},
+	"de": {"gerade eben", "vor %s", "in %s", [6][2]string{{"Minute", "Minuten"}, {"Stunde", "Stunden"}, {"Tag", "Tagen"}, {"Woche", "Wochen"}, {"Monat", "Monaten"}, {"Jahr", "Jahren"}}},
+	"es": {"ahora mismo", "hace %s", "dentro de %s", [6][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"día", "días"}, {"semana", "semanas"}, {"mes", "meses"}, {"año", "años"}}},
+	"fr": {"à l’instant", "il y a %s", "dans %s", [6][2]string{{"minute", "minutes"}, {"heure", "heures"}, {"jour", "jours"}, {"semaine", "semaines"}, {"mois", "mois"}, {"an", "ans"}}},
+	"it": {"proprio ora", "%s fa", "tra %s", [6][2]string{{"minuto", "minuti"}, {"ora", "ore"}, {"giorno", "giorni"}, {"settimana", "settimane"}, {"mese", "mesi"}, {"anno", "anni"}}},
+	"nl": {"zojuist", "%s geleden", "over %s", [6][2]string{{"minuut", "minuten"}, {"uur", "uur"}, {"dag", "dagen"}, {"week", "weken"}, {"maand", "maanden"}, {"jaar", "jaar"}}},
+	"pt": {"agora mesmo", "há %s", "em %s", [6][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"dia", "dias"}, {"semana", "semanas"}, {"mês", "meses"}, {"ano", "anos"}}},
+	"sv": {"just nu", "för %s sedan", "om %s", [6][2]string{{"minut", "minuter"}, {"timme", "timmar"}, {"dag", "dagar"}, {"vecka", "veckor"}, {"månad", "månader"}, {"år", "år"}}},
+}
+
+// RelativeTime returns the time t relative to now in words, e.g. "3 days
+// ago" or "in 2 hours", in the given language. Region subtags fall back to
+// the base language, and English is used for unknown languages.
+func RelativeTime(t, now time.Time, lang string) string {
+	lang = strings.ToLower(strings.Replace(lang, "_", "-", -1))
+	l, ok := relativeTimeLocales[lang]
+	if !ok {
+		if i := strings.Index(lang, "-"); i > 0 {
+			l, ok = relativeTimeLocales[lang[:i]]
+		}
+		if !ok {
+			l = relativeTimeLocales["en"]
+		}
+	}
+
+	d := now.Sub(t)
+	format := l.past
+	if d < 0 {
+		d = -d
+		format = l.future
+	}
+
+	var n, unit int
+	days := int(d.Hours() / 24)
+	switch {
+	case d < time.Minute:
+		return l.now
+	case d < time.Hour:
+		n, unit = int(d.Minutes()), 0
+	case d < 24*time.Hour:
+		n, unit = int(d.Hours()), 1
+	case days < 7:
+		n, unit = days, 2
+	case days < 30:
+		n, unit = days/7, 3
+	case days < 365:
+		n, unit = days/30, 4
+	default:
+		n, unit = days/365, 5
+	}
+
+	name := l.units[unit][1]
+	if n == 1 {
+		name = l.units[unit][0]
+	}
+	return fmt.Sprintf(format, fmt.Sprintf("%d %s", n, name))
+}
@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2015, time.March, 4, 10, 30, 0, 0, time.UTC)
+
+	for i, this := range []struct {
+		d      time.Duration
+		lang   string
+		expect string
+	}{
+		{-10 * time.Second, "", "just now"},
+		{-time.Minute, "", "1 minute ago"},
+		{-90 * time.Minute, "", "1 hour ago"},
+		{-3 * 24 * time.Hour, "", "3 days ago"},
+		{-15 * 24 * time.Hour, "en-GB", "2 weeks ago"},
+		{-65 * 24 * time.Hour, "", "2 months ago"},
+		{-800 * 24 * time.Hour, "", "2 years ago"},
+		{2 * time.Hour, "", "in 2 hours"},
+		{-3 * 24 * time.Hour, "de", "vor 3 Tagen"},
+		{-24 * time.Hour, "es_MX", "hace 1 día"},
+		{5 * time.Minute, "fr", "dans 5 minutes"},
+		{-400 * 24 * time.Hour, "sv", "för 1 år sedan"},
+		{-3 * 24 * time.Hour, "xx", "3 days ago"},
+	} {
+		result := RelativeTime(now.Add(this.d), now, this.lang)
+		if result != this.expect {
+			t.Errorf("[%d] RelativeTime got %q but expected %q", i, result, this.expect)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+type svgIcon struct {
+	XMLName xml.Name
+	ViewBox string `xml:"viewBox,attr"`
+	Width   string `xml:"width,attr"`
+	Height  string `xml:"height,attr"`
+	Content string `xml:",innerxml"`
+}
+
+// SVGSprite combines the SVG icons, by name, into one sprite with a
+// <symbol> per icon, to be shown with
+// <svg><use xlink:href="/icons.svg#name"></use></svg>.
+func SVGSprite(icons map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(icons))
+	for name := range icons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">` + "\n")
+	for _, name := range names {
+		var icon svgIcon
+		if err := xml.Unmarshal(icons[name], &icon); err != nil {
+			return nil, fmt.Errorf("icon %s: %s", name, err)
+		}
+		if icon.XMLName.Local != "svg" {
+			return nil, fmt.Errorf("icon %s: not an SVG image", name)
+		}
+
+		viewBox := icon.ViewBox
+		if viewBox == "" && icon.Width != "" && icon.Height != "" {
+			viewBox = fmt.Sprintf("0 0 %s %s", strings.TrimSuffix(icon.Width, "px"), strings.TrimSuffix(icon.Height, "px"))
+		}
+
+		fmt.Fprintf(&b, `<symbol id="%s"`, html.EscapeString(name))
+		if viewBox != "" {
+			fmt.Fprintf(&b, ` viewBox="%s"`, html.EscapeString(viewBox))
+		}
+		fmt.Fprintf(&b, ">%s</symbol>\n", strings.TrimSpace(icon.Content))
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes(), nil
+}
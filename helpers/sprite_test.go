@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSVGSprite(t *testing.T) {
+	sprite, err := SVGSprite(map[string][]byte{
+		"star":    []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path d="M12 2l3 7h7l-6 4 2 8-6-5-6 5 2-8-6-4h7z"/></svg>`),
+		"arrow":   []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="16px" height="16px">  <path d="M0 8h16"/>  </svg>`),
+		"a&b":     []byte(`<svg><circle r="1"/></svg>`),
+		"unsized": []byte(`<svg><circle r="2"/></svg>`),
+	})
+	if err != nil {
+		t.Fatalf("Unable to create the sprite: %s", err)
+	}
+
+	expected := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+<symbol id="a&amp;b"><circle r="1"/></symbol>
+<symbol id="arrow" viewBox="0 0 16 16"><path d="M0 8h16"/></symbol>
+<symbol id="star" viewBox="0 0 24 24"><path d="M12 2l3 7h7l-6 4 2 8-6-5-6 5 2-8-6-4h7z"/></symbol>
+<symbol id="unsized"><circle r="2"/></symbol>
+</svg>
+`
+	if string(sprite) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, sprite)
+	}
+
+	for _, icon := range []string{`<svg><circle r="1"></svg>`, `<path d="M0 0"/>`} {
+		if _, err := SVGSprite(map[string][]byte{"broken": []byte(icon)}); err == nil || !strings.Contains(err.Error(), "broken") {
+			t.Errorf("Expected an error naming the icon for %s, got %v", icon, err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/viper"
+)
+
+// ArchiveMonth holds the dated pages of a single month, newest first.
+type ArchiveMonth struct {
+	Year  int
+	Month time.Month
+	Pages Pages
+}
+
+// Url returns the relative url of the month's archive page, e.g. /2013/05/.
+func (m *ArchiveMonth) Url() string {
+	return helpers.URLizeAndPrep(fmt.Sprintf("/%04d/%02d/", m.Year, m.Month))
+}
+
+// ArchiveYear holds the dated pages of a single year, newest first,
+// along with the months of that year that have content.
+type ArchiveYear struct {
+	Year   int
+	Months []*ArchiveMonth
+	Pages  Pages
+}
+
+// Url returns the relative url of the year's archive page, e.g. /2013/.
+func (y *ArchiveYear) Url() string {
+	return helpers.URLizeAndPrep(fmt.Sprintf("/%04d/", y.Year))
+}
+
+// Calendar is the site's date index: the pages with a date grouped by
+// year and month, newest first. It is built once in BuildSiteMeta and
+// shared by the archive pages and templates (.Site.Calendar).
+type Calendar []*ArchiveYear
+
+func newCalendar(pages Pages) Calendar {
+	years := make(map[int]*ArchiveYear)
+	months := make(map[int]map[time.Month]*ArchiveMonth)
+
+	// the sorts below work in place, so leave the site's order alone
+	sorted := make(Pages, len(pages))
+	copy(sorted, pages)
+
+	for _, p := range sorted.ByDate().Reverse() {
+		if p.Date.IsZero() {
+			continue
+		}
+		y, m := p.Date.Year(), p.Date.Month()
+
+		if _, ok := years[y]; !ok {
+			years[y] = &ArchiveYear{Year: y}
+			months[y] = make(map[time.Month]*ArchiveMonth)
+		}
+		years[y].Pages = append(years[y].Pages, p)
+
+		if _, ok := months[y][m]; !ok {
+			months[y][m] = &ArchiveMonth{Year: y, Month: m}
+			years[y].Months = append(years[y].Months, months[y][m])
+		}
+		months[y][m].Pages = append(months[y][m].Pages, p)
+	}
+
+	var c Calendar
+	for _, y := range years {
+		c = append(c, y)
+	}
+	sort.Sort(sort.Reverse(c))
+
+	return c
+}
+
+func (c Calendar) Len() int           { return len(c) }
+func (c Calendar) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c Calendar) Less(i, j int) bool { return c[i].Year < c[j].Year }
+
+// Get returns the given year, or nil if there is no content dated that year.
+func (c Calendar) Get(year int) *ArchiveYear {
+	for _, y := range c {
+		if y.Year == year {
+			return y
+		}
+	}
+	return nil
+}
+
+// Get returns the given month of the year, or nil if there is no content
+// dated that month.
+func (y *ArchiveYear) Get(month int) *ArchiveMonth {
+	for _, m := range y.Months {
+		if int(m.Month) == month {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Site) newArchiveNode(title, base string, pages Pages) *Node {
+	n := s.NewNode()
+	n.Title = title
+	s.setUrls(n, base)
+	n.Date = pages[0].Date
+	n.Data["Pages"] = pages
+	return n
+}
+
+// archiveCalendar is the calendar the archive pages are rendered from: that
+// of the site, or of the pages of the ArchiveSections only, e.g. ["post"]
+// for a blog with other content.
+func (s *Site) archiveCalendar() Calendar {
+	sections := viper.GetStringSlice("ArchiveSections")
+	if len(sections) == 0 {
+		return s.Calendar
+	}
+
+	var pages Pages
+	for _, p := range s.Pages {
+		if helpers.InStringArray(sections, p.Section()) {
+			pages = append(pages, p)
+		}
+	}
+	return newCalendar(pages)
+}
+
+// RenderArchives renders a list page for every year and month
+// that has dated content, e.g. /2013/ and /2013/05/.
+func (s *Site) RenderArchives() error {
+	if !viper.GetBool("BuildArchives") {
+		return nil
+	}
+
+	calendar := s.archiveCalendar()
+	for _, y := range calendar {
+		year := y
+		base := fmt.Sprintf("%04d", year.Year)
+		layouts := s.appendThemeTemplates(
+			[]string{"archive/year.html", "_default/archive.html", "_default/list.html"})
+
+		newNode := func() *Node {
+			n := s.newArchiveNode(base, base, year.Pages)
+			n.Data["Year"] = year.Year
+			n.Data["Calendar"] = calendar
+			return n
+		}
+
+		if err := s.renderArchive("archive "+base, base, newNode, layouts); err != nil {
+			return err
+		}
+
+		for _, m := range year.Months {
+			month := m
+			base := fmt.Sprintf("%04d/%02d", month.Year, month.Month)
+			layouts := s.appendThemeTemplates(
+				[]string{"archive/month.html", "_default/archive.html", "_default/list.html"})
+
+			newNode := func() *Node {
+				n := s.newArchiveNode(fmt.Sprintf("%s %d", month.Month, month.Year), base, month.Pages)
+				n.Data["Year"] = month.Year
+				n.Data["Month"] = month.Month
+				n.Data["Calendar"] = calendar
+				return n
+			}
+
+			if err := s.renderArchive("archive "+base, base, newNode, layouts); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Site) renderArchive(name, base string, newNode func() *Node, layouts []string) error {
+	n := newNode()
+
+	if err := s.renderAndWritePage(name, filepath.FromSlash("/"+base), n, layouts...); err != nil {
+		return err
+	}
+
+	if n.paginator != nil {
+
+		paginatePath := viper.GetString("paginatePath")
+
+		// write alias for page 1
+		s.WriteDestAlias(filepath.FromSlash(fmt.Sprintf("/%s/%s/%d", base, paginatePath, 1)), s.permalink(base))
+
+		pagers := n.paginator.Pagers()
+
+		for i, pager := range pagers {
+			if i == 0 {
+				// already created
+				continue
+			}
+
+			archivePagerNode := newNode()
+			archivePagerNode.paginator = pager
+			if pager.TotalPages() > 0 {
+				archivePagerNode.Date = pager.Pages()[0].Date
+			}
+			pageNumber := i + 1
+			htmlBase := fmt.Sprintf("/%s/%s/%d", base, paginatePath, pageNumber)
+			archivePagerNode.Permalink = s.permalink(htmlBase)
+			if err := s.renderAndWritePage(fmt.Sprintf("%s_%d", name, pageNumber), filepath.FromSlash(htmlBase), archivePagerNode, layouts...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
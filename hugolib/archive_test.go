@@ -0,0 +1,168 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+var ARCHIVE_SOURCES = []source.ByteSource{
+	{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\ndate: 2013-05-17\n---\ncontent")},
+	{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: Two\ndate: 2013-05-02\n---\ncontent")},
+	{filepath.FromSlash("sect/doc3.md"), []byte("---\ntitle: Three\ndate: 2013-11-30\n---\ncontent")},
+	{filepath.FromSlash("sect/doc4.md"), []byte("---\ntitle: Four\ndate: 2014-01-01\n---\ncontent")},
+	{filepath.FromSlash("sect/doc5.md"), []byte("---\ntitle: Undated\n---\ncontent")},
+}
+
+func TestCalendar(t *testing.T) {
+	s := newTestSite(ARCHIVE_SOURCES)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if len(s.Calendar) != 2 {
+		t.Fatalf("Expected 2 years, got %d", len(s.Calendar))
+	}
+
+	if len(*s.Info.Calendar) != 2 {
+		t.Errorf("Expected the calendar to be available in SiteInfo")
+	}
+
+	y2013 := s.Calendar.Get(2013)
+	if s.Calendar[0].Year != 2014 || s.Calendar[1] != y2013 {
+		t.Errorf("Years in unexpected order: %d, %d", s.Calendar[0].Year, s.Calendar[1].Year)
+	}
+
+	if s.Calendar.Get(2012) != nil {
+		t.Errorf("Expected no entry for 2012")
+	}
+
+	if len(y2013.Pages) != 3 {
+		t.Errorf("Expected 3 pages in 2013, got %d", len(y2013.Pages))
+	}
+
+	if len(y2013.Months) != 2 || y2013.Months[0].Month != time.November || y2013.Months[1].Month != time.May {
+		t.Fatalf("Months in unexpected order: %v", y2013.Months)
+	}
+
+	may := y2013.Get(5)
+	if may == nil || len(may.Pages) != 2 || may.Pages[0].Title != "One" || may.Pages[1].Title != "Two" {
+		t.Errorf("Pages in May 2013 in unexpected order: %v", may)
+	}
+
+	if y2013.Url() != "/2013/" || may.Url() != "/2013/05/" {
+		t.Errorf("Unexpected archive urls: %s, %s", y2013.Url(), may.Url())
+	}
+
+	if s.Pages[0].Title != "Four" {
+		t.Errorf("Building the calendar should not reorder the site pages, got %s first", s.Pages[0].Title)
+	}
+}
+
+func TestRenderArchives(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("BuildArchives", true)
+	defer viper.Set("BuildArchives", false)
+	viper.Set("paginate", 1)
+	viper.Set("paginatePath", "page")
+
+	s := newTestSite(ARCHIVE_SOURCES)
+	s.addTemplate("archive/year.html", "{{ .Title }}:{{ range .Paginator.Pages }}{{ .Title }}{{ end }}")
+	s.addTemplate("archive/month.html", "{{ .Title }}:{{ range .Data.Pages }}{{ .Title }}{{ end }}")
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if err := s.RenderArchives(); err != nil {
+		t.Fatalf("Unable to render archives: %s", err)
+	}
+
+	for _, test := range []struct {
+		doc      string
+		expected string
+	}{
+		{"2013/index.html", "2013:Three"},
+		{"2013/page/2/index.html", "2013:One"},
+		{"2013/page/3/index.html", "2013:Two"},
+		{"2013/05/index.html", "May 2013:OneTwo"},
+		{"2013/11/index.html", "November 2013:Three"},
+		{"2014/index.html", "2014:Four"},
+		{"2014/01/index.html", "January 2014:Four"},
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(test.doc))
+		if err != nil {
+			t.Fatalf("Did not find %s in target: %s", test.doc, err)
+		}
+
+		content := helpers.ReaderToBytes(file)
+		if string(content) != test.expected {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", test.doc, test.expected, string(content))
+		}
+	}
+}
+
+func TestRenderArchivesOfSections(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("BuildArchives", true)
+	defer viper.Set("BuildArchives", false)
+	viper.Set("ArchiveSections", []string{"post"})
+	defer viper.Set("ArchiveSections", nil)
+	viper.Set("paginate", 10)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ndate: 2013-05-17\n---\ncontent")},
+		{filepath.FromSlash("about/me.md"), []byte("---\ntitle: Me\ndate: 2013-05-02\n---\ncontent")},
+		{filepath.FromSlash("about/old.md"), []byte("---\ntitle: Old\ndate: 2012-01-01\n---\ncontent")},
+	})
+	s.addTemplate("_default/archive.html", "{{ .Title }}:{{ range .Data.Pages }}{{ .Title }}{{ end }}:{{ len .Data.Calendar }}")
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderArchives(); err != nil {
+		t.Fatalf("Unable to render archives: %s", err)
+	}
+
+	for doc, expected := range map[string]string{
+		"2013/index.html":    "2013:One:1",
+		"2013/05/index.html": "May 2013:One:1",
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(doc))
+		if err != nil {
+			t.Fatalf("Did not find %s in target: %s", doc, err)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != expected {
+			t.Errorf("%s content expected %q, got %q", doc, expected, content)
+		}
+	}
+
+	if _, err := hugofs.DestinationFS.Open(filepath.FromSlash("2012/index.html")); err == nil {
+		t.Errorf("Expected no archive for 2012, which only has content outside of the archived sections")
+	}
+	if len(s.Calendar) != 2 {
+		t.Errorf("Expected .Site.Calendar to keep all the sections, got %d years", len(s.Calendar))
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// outputBudgets bound the size of the published site, checked once it is
+// written. Exceeding a budget is a warning, or fails the build in strict
+// mode:
+//
+//	strict = true
+//
+//	[budgets]
+//	pageSize = "500KB"    # of every HTML file
+//	totalSize = "100MB"   # of the publish directory
+//	imageWidth = 2400     # of every GIF, JPEG and PNG image, in pixels
+//	imageHeight = 2400
+type outputBudgets struct {
+	pageSize    uint64
+	totalSize   uint64
+	imageWidth  int
+	imageHeight int
+}
+
+func newOutputBudgets() (*outputBudgets, error) {
+	b := &outputBudgets{}
+	for key, v := range viper.GetStringMap("Budgets") {
+		var err error
+		switch strings.ToLower(key) {
+		case "pagesize":
+			b.pageSize, err = helpers.ParseByteSize(cast.ToString(v))
+		case "totalsize":
+			b.totalSize, err = helpers.ParseByteSize(cast.ToString(v))
+		case "imagewidth":
+			b.imageWidth, err = cast.ToIntE(v)
+		case "imageheight":
+			b.imageHeight, err = cast.ToIntE(v)
+		default:
+			jww.WARN.Printf("Unknown budget: %s\n", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s budget: %s", key, err)
+		}
+	}
+	return b, nil
+}
+
+func (b *outputBudgets) isZero() bool {
+	return *b == outputBudgets{}
+}
+
+// check returns the files of dir over budget, as messages. The files in
+// the skipped directories, relative to dir, are left out.
+func (b *outputBudgets) check(dir string, skip ...string) ([]string, error) {
+	files, err := helpers.ListFiles(dir, hugofs.DestinationFS)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []string
+	var total uint64
+files:
+	for _, name := range files {
+		for _, s := range skip {
+			if strings.HasPrefix(name, s+string(filepath.Separator)) {
+				continue files
+			}
+		}
+
+		path := filepath.Join(dir, name)
+		fi, err := hugofs.DestinationFS.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(fi.Size())
+		total += size
+
+		ext := strings.ToLower(filepath.Ext(name))
+		if b.pageSize > 0 && (ext == ".html" || ext == ".htm") && size > b.pageSize {
+			issues = append(issues, fmt.Sprintf("%s is %s, over the page size budget of %s",
+				name, formatByteSize(size), formatByteSize(b.pageSize)))
+		}
+
+		if (b.imageWidth > 0 || b.imageHeight > 0) && (ext == ".gif" || ext == ".jpg" || ext == ".jpeg" || ext == ".png") {
+			f, err := hugofs.DestinationFS.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			conf, _, err := image.DecodeConfig(f)
+			f.Close()
+			if err != nil {
+				jww.WARN.Printf("Unable to read the dimensions of %s: %s\n", name, err)
+				continue
+			}
+			if (b.imageWidth > 0 && conf.Width > b.imageWidth) || (b.imageHeight > 0 && conf.Height > b.imageHeight) {
+				issues = append(issues, fmt.Sprintf("%s is %dx%d pixels, over the image budget of %dx%d",
+					name, conf.Width, conf.Height, b.imageWidth, b.imageHeight))
+			}
+		}
+	}
+
+	if b.totalSize > 0 && total > b.totalSize {
+		issues = append(issues, fmt.Sprintf("The site is %s, over the total size budget of %s",
+			formatByteSize(total), formatByteSize(b.totalSize)))
+	}
+	return issues, nil
+}
+
+// formatByteSize formats a size in the largest unit it has a whole one of,
+// the reverse of helpers.ParseByteSize.
+func formatByteSize(size uint64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   uint64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if size >= unit.size {
+			return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(size)/float64(unit.size)), ".0") + unit.suffix
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
+
+// CheckBudgets checks the published site against the budgets in the site
+// config, warning about the files over budget, or failing with Strict. The
+// other languages of a multilingual site published in a directory of the
+// site, e.g. public/fr, are left to their own check.
+func (s *Site) CheckBudgets() error {
+	b, err := newOutputBudgets()
+	if err != nil {
+		return err
+	}
+	if b.isZero() {
+		return nil
+	}
+
+	dir := s.absPublishDir()
+	var skip []string
+	for _, l := range s.Info.Languages {
+		if l == s.Language {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, l.absPublishDir()); err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			skip = append(skip, rel)
+		}
+	}
+
+	issues, err := b.check(dir, skip...)
+	if err != nil {
+		return fmt.Errorf("Unable to check the budgets: %s", err)
+	}
+
+	strict := viper.GetBool("Strict")
+	for _, issue := range issues {
+		if strict {
+			jww.ERROR.Println(issue)
+		} else {
+			jww.WARN.Println(issue)
+		}
+	}
+
+	if strict && len(issues) > 0 {
+		return fmt.Errorf("Output over budget with %d issue(s)", len(issues))
+	}
+	return nil
+}
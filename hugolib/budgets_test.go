@@ -0,0 +1,130 @@
+package hugolib
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+func writePublished(t *testing.T, name string, content []byte) {
+	path := filepath.Join(helpers.AbsPathify("public"), filepath.FromSlash(name))
+	if err := helpers.WriteToDisk(path, bytes.NewReader(content), hugofs.DestinationFS); err != nil {
+		t.Fatalf("Unable to write %s: %s", name, err)
+	}
+}
+
+func TestCheckBudgets(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	defer viper.Set("PublishDir", viper.Get("PublishDir"))
+	viper.Set("PublishDir", "public")
+	defer viper.Set("Budgets", nil)
+	defer viper.Set("Strict", false)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 300, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	writePublished(t, "index.html", bytes.Repeat([]byte("a"), 100))
+	writePublished(t, "post/big/index.html", bytes.Repeat([]byte("a"), 3000))
+	writePublished(t, "big.css", bytes.Repeat([]byte("a"), 3000))
+	writePublished(t, "images/hero.png", img.Bytes())
+
+	s := &Site{}
+
+	// no budgets
+	if err := s.CheckBudgets(); err != nil {
+		t.Fatalf("Expected no error without budgets, got %s", err)
+	}
+
+	viper.Set("Budgets", map[string]interface{}{"pageSize": "2KB", "totalSize": "4KB", "imageWidth": 200})
+	b, err := newOutputBudgets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := b.check(helpers.AbsPathify("public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"images/hero.png is 300x20 pixels, over the image budget of 200x0",
+		"post/big/index.html is 2.9KB, over the page size budget of 2KB",
+		"The site is 6",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Got %d issues, expected %d: %v", len(issues), len(expected), issues)
+	}
+	for i, issue := range issues {
+		if !strings.HasPrefix(filepath.ToSlash(issue), expected[i]) {
+			t.Errorf("[%d] got %q, expected %q", i, issue, expected[i])
+		}
+	}
+
+	if err := s.CheckBudgets(); err != nil {
+		t.Errorf("Budgets should only warn, got %s", err)
+	}
+	viper.Set("Strict", true)
+	if err := s.CheckBudgets(); err == nil || !strings.Contains(err.Error(), "3 issue(s)") {
+		t.Errorf("Budgets should fail in strict mode, got %v", err)
+	}
+
+	viper.Set("Budgets", map[string]interface{}{"pageSize": "much"})
+	if err := s.CheckBudgets(); err == nil {
+		t.Errorf("Expected an error for an invalid budget")
+	}
+}
+
+func TestCheckBudgetsMultilingual(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	defer viper.Set("PublishDir", viper.Get("PublishDir"))
+	viper.Set("PublishDir", "public")
+	defer viper.Set("Budgets", nil)
+	defer viper.Set("Strict", false)
+
+	writePublished(t, "index.html", bytes.Repeat([]byte("a"), 1500))
+	writePublished(t, "fr/index.html", bytes.Repeat([]byte("a"), 3000))
+
+	en := &Language{Lang: "en", settings: map[string]interface{}{}}
+	fr := &Language{Lang: "fr", settings: map[string]interface{}{"publishdir": filepath.Join("public", "fr")}}
+	s := &Site{Language: en, Info: SiteInfo{Languages: []*Language{en, fr}}}
+
+	viper.Set("Budgets", map[string]interface{}{"pageSize": "2KB", "totalSize": "2KB"})
+	viper.Set("Strict", true)
+	if err := s.CheckBudgets(); err != nil {
+		t.Errorf("The pages in French should be left to the French site, got %s", err)
+	}
+
+	restore := fr.apply()
+	defer restore()
+	s.Language = fr
+	if err := s.CheckBudgets(); err == nil || !strings.Contains(err.Error(), "2 issue(s)") {
+		t.Errorf("Expected the French site over budget, got %v", err)
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	for _, this := range []struct {
+		size     uint64
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{2048, "2KB"},
+		{3000, "2.9KB"},
+		{20 << 20, "20MB"},
+		{3 << 30, "3GB"},
+	} {
+		if s := formatByteSize(this.size); s != this.expected {
+			t.Errorf("%d got %s, expected %s", this.size, s, this.expected)
+		}
+	}
+}
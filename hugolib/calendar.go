@@ -0,0 +1,164 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// calendarFile is the file the calendar of a section is written to in the
+// section's directory.
+const calendarFile = "index.ics"
+
+// isCalendarSection returns whether the pages of the section are events,
+// written as an iCalendar file next to its list:
+//
+//	calendars = ["events", "talks/2015"]
+func isCalendarSection(section string) bool {
+	for _, s := range cast.ToStringSlice(viper.Get("Calendars")) {
+		if strings.Trim(s, "/") == section {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	icsDateTime = "20060102T150405Z"
+	icsDate     = "20060102"
+)
+
+// renderAndWriteCalendar writes the pages of n as the events of an
+// iCalendar file, https://tools.ietf.org/html/rfc5545. An event starts at
+// the date of its page and ends at its enddate, if it has one. A date
+// without a time of day is an all day event.
+func (s *Site) renderAndWriteCalendar(name, dest string, n *Node) error {
+	b := new(bytes.Buffer)
+	w := icsWriter{b}
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//Hugo//"+s.Info.Title+"//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	if n.Title != "" {
+		w.text("X-WR-CALNAME", n.Title+" on "+s.Info.Title)
+	} else {
+		w.text("X-WR-CALNAME", s.Info.Title)
+	}
+	if n.Description != "" {
+		w.text("X-WR-CALDESC", n.Description)
+	}
+
+	pages, _ := n.Data["Pages"].(Pages)
+
+	// The stamp is taken from the content rather than the clock, for the
+	// file to only change with its events.
+	var newest time.Time
+	for _, p := range pages {
+		if p.Date.After(newest) {
+			newest = p.Date
+		}
+	}
+	stamp := newest.UTC().Format(icsDateTime)
+	for _, p := range pages {
+		if p.Date.IsZero() {
+			jww.WARN.Printf("%s has no date and is left out of the calendar of %s\n", p.FullFilePath(), n.Title)
+			continue
+		}
+		permalink, err := p.Permalink()
+		if err != nil {
+			return err
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.text("UID", permalink)
+		w.line("DTSTAMP", stamp)
+		end, err := cast.ToTimeE(p.Params["enddate"])
+		if p.Params["enddate"] != nil && err != nil {
+			jww.ERROR.Printf("Failed to parse enddate '%v' in page %s", p.Params["enddate"], p.File.Path())
+		}
+		if isAllDay(p.Date) && (end.IsZero() || isAllDay(end)) {
+			if end.Before(p.Date) {
+				end = p.Date
+			}
+			// the end of an all day event is the day after it
+			w.line("DTSTART;VALUE=DATE", p.Date.Format(icsDate))
+			w.line("DTEND;VALUE=DATE", end.AddDate(0, 0, 1).Format(icsDate))
+		} else {
+			w.line("DTSTART", p.Date.UTC().Format(icsDateTime))
+			if !end.IsZero() {
+				w.line("DTEND", end.UTC().Format(icsDateTime))
+			}
+		}
+		w.text("SUMMARY", p.Title)
+		if location := cast.ToString(p.Params["location"]); location != "" {
+			w.text("LOCATION", location)
+		}
+		if description := p.Description; description != "" {
+			w.text("DESCRIPTION", description)
+		} else {
+			w.text("DESCRIPTION", helpers.HTMLToText(string(p.Summary)))
+		}
+		w.text("URL", permalink)
+		w.line("END", "VEVENT")
+	}
+	w.line("END", "VCALENDAR")
+
+	if err := s.WriteDestFile(dest, b); err != nil {
+		return err
+	}
+	helpers.BuildLog.Rendered(dest, name)
+	return nil
+}
+
+func isAllDay(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// icsWriter writes the content lines of an iCalendar file.
+type icsWriter struct {
+	b *bytes.Buffer
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// text writes a property of the text value type, escaped.
+func (w icsWriter) text(name, value string) {
+	w.line(name, icsTextEscaper.Replace(value))
+}
+
+// line writes the property, folded into lines of at most 75 octets, the
+// next ones starting with a space, and ended by CRLF.
+func (w icsWriter) line(name, value string) {
+	line := name + ":" + value
+	for max := 75; len(line) > max; max = 74 {
+		i := max
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		w.b.WriteString(line[:i])
+		w.b.WriteString("\r\n ")
+		line = line[i:]
+	}
+	w.b.WriteString(line)
+	w.b.WriteString("\r\n")
+}
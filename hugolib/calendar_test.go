@@ -0,0 +1,95 @@
+package hugolib
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestSectionCalendar(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("Calendars", []string{"events"})
+	defer viper.Set("Calendars", nil)
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("events/meetup.md"), []byte("---\ntitle: Meetup, Spring\ndate: 2015-04-02T18:30:00Z\nenddate: 2015-04-02T21:00:00Z\nlocation: The Pub; Room 2\ndescription: Talks and drinks\n---\nCome along.")},
+		{filepath.FromSlash("events/conference.md"), []byte("---\ntitle: Conference\ndate: 2015-05-10\nenddate: 2015-05-12\n---\nThree days of talks.")},
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ndate: 2015-01-01\n---\nA post.")},
+	})
+	s.addTemplate("_default/list.html", "{{ .CalendarLink }}")
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("events/index.html"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "events/index.html")
+	}
+	if link := string(helpers.ReaderToBytes(file)); link != "http://auth/bub/events/index.ics" {
+		t.Errorf("Expected the section to link to its calendar, got %s", link)
+	}
+
+	if _, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/index.ics")); err == nil {
+		t.Errorf("Expected no calendar for the post section")
+	}
+
+	file, err = hugofs.DestinationFS.Open(filepath.FromSlash("events/index.ics"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "events/index.ics")
+	}
+	ics := string(helpers.ReaderToBytes(file))
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"BEGIN:VEVENT\r\nUID:http://auth/bub/events/meetup/\r\n",
+		"DTSTART:20150402T183000Z\r\nDTEND:20150402T210000Z\r\n",
+		"SUMMARY:Meetup\\, Spring\r\n",
+		"LOCATION:The Pub\\; Room 2\r\n",
+		"DESCRIPTION:Talks and drinks\r\n",
+		"DTSTART;VALUE=DATE:20150510\r\nDTEND;VALUE=DATE:20150513\r\n",
+		"DESCRIPTION:Three days of talks.\r\n",
+		"DTSTAMP:20150510T000000Z\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("Expected %q in the calendar:\n%s", expected, ics)
+		}
+	}
+}
+
+func TestICSLineFolding(t *testing.T) {
+	var w icsWriter
+	w.b = new(bytes.Buffer)
+	w.text("SUMMARY", strings.Repeat("é", 40)+", "+strings.Repeat("x", 60))
+
+	lines := strings.Split(strings.TrimSuffix(w.b.String(), "\r\n"), "\r\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected the line folded in 3, got %q", lines)
+	}
+	for i, l := range lines {
+		if len(l) > 75 {
+			t.Errorf("Line %d is longer than 75 octets: %q", i, l)
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("Expected line %d to start with a space: %q", i, l)
+		}
+	}
+	if unfolded := strings.Replace(w.b.String(), "\r\n ", "", -1); unfolded != "SUMMARY:"+strings.Repeat("é", 40)+"\\, "+strings.Repeat("x", 60)+"\r\n" {
+		t.Errorf("Unexpected unfolded line %q", unfolded)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// targetCollisions returns the pages written to the same file, or to files
+// that differ only by case, e.g. About.md and about.md. These overwrite each
+// other on case-insensitive file systems such as those of macOS and
+// Windows, and on many hosts.
+func (s *Site) targetCollisions() []string {
+	targets := make(map[string][]*Page)
+	for _, p := range s.Pages {
+		key := strings.ToLower(strings.TrimPrefix(filepath.ToSlash(p.TargetPath()), "/"))
+		targets[key] = append(targets[key], p)
+	}
+
+	var issues []string
+	for _, pages := range targets {
+		if len(pages) < 2 {
+			continue
+		}
+		var names []string
+		same := true
+		for _, p := range pages {
+			names = append(names, fmt.Sprintf("%s (%s)", p.Source.Path(), filepath.ToSlash(p.TargetPath())))
+			same = same && p.TargetPath() == pages[0].TargetPath()
+		}
+		sort.Strings(names)
+		if same {
+			issues = append(issues, "The pages are written to the same file: "+strings.Join(names, ", "))
+		} else {
+			issues = append(issues, "The pages are written to files that differ only by case: "+strings.Join(names, ", "))
+		}
+	}
+	sort.Strings(issues)
+	return issues
+}
+
+// CheckTargetCollisions warns about the pages that overwrite each other,
+// or fails with Strict.
+func (s *Site) CheckTargetCollisions() error {
+	issues := s.targetCollisions()
+
+	strict := viper.GetBool("Strict")
+	for _, issue := range issues {
+		if strict {
+			jww.ERROR.Println(issue)
+		} else {
+			jww.WARN.Println(issue)
+		}
+	}
+
+	if strict && len(issues) > 0 {
+		return fmt.Errorf("%d collision(s) of the pages written", len(issues))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestTargetCollisions(t *testing.T) {
+	viper.Set("DefaultExtension", "html")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("About.md"), []byte("---\ntitle: About\n---\nAbout.")},
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: about\n---\nabout.")},
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\nslug: first\n---\nOne.")},
+		{filepath.FromSlash("post/two.md"), []byte("---\ntitle: Two\nslug: first\n---\nTwo.")},
+		{filepath.FromSlash("post/three.md"), []byte("---\ntitle: Three\n---\nThree.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	issues := s.targetCollisions()
+	expected := []string{
+		"The pages are written to files that differ only by case: About.md (About.html), about.md (about.html)",
+		"The pages are written to the same file: post/one.md (post/first.html), post/two.md (post/first.html)",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d collisions, got %v", len(expected), issues)
+	}
+	for i := range expected {
+		if issues[i] != expected[i] {
+			t.Errorf("Got collision\n%s\nexpected\n%s", issues[i], expected[i])
+		}
+	}
+
+	if err := s.CheckTargetCollisions(); err != nil {
+		t.Errorf("Expected the collisions to only be warned about, got %s", err)
+	}
+	viper.Set("Strict", true)
+	defer viper.Set("Strict", false)
+	if err := s.CheckTargetCollisions(); err == nil {
+		t.Errorf("Expected the collisions to fail with Strict")
+	}
+}
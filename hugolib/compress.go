@@ -0,0 +1,75 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+var defaultPrecompressExtensions = []string{".html", ".css", ".js", ".json", ".xml", ".svg", ".txt"}
+
+// Precompress writes the text files of the publish directory compressed
+// next to them, e.g. index.html.gz and index.html.br, for the encodings of
+// the Precompress config, "gzip" and "brotli", so that hosts such as nginx
+// with gzip_static can serve them as they are. Brotli needs the command of
+// BrotliCommand, "brotli -c -q 11" by default.
+func (s *Site) Precompress() error {
+	encodings := cast.ToStringSlice(viper.Get("Precompress"))
+	if len(encodings) == 0 {
+		return nil
+	}
+
+	var compressors []helpers.Compressor
+	for _, encoding := range encodings {
+		switch strings.ToLower(encoding) {
+		case "gzip":
+			compressors = append(compressors, helpers.GzipCompressor)
+		case "brotli", "br":
+			command := viper.GetString("BrotliCommand")
+			if command == "" {
+				command = "brotli -c -q 11"
+			}
+			c, err := helpers.CommandCompressor(".br", command)
+			if err != nil {
+				return err
+			}
+			compressors = append(compressors, c)
+		default:
+			return fmt.Errorf("Unknown precompress encoding %q, must be gzip or brotli", encoding)
+		}
+	}
+
+	extensions := defaultPrecompressExtensions
+	if v := viper.Get("PrecompressExtensions"); v != nil {
+		extensions = nil
+		for _, ext := range cast.ToStringSlice(v) {
+			extensions = append(extensions, "."+strings.TrimPrefix(strings.ToLower(ext), "."))
+		}
+	}
+
+	n, err := helpers.Precompress(s.absPublishDir(), hugofs.DestinationFS, extensions, compressors...)
+	if err != nil {
+		return err
+	}
+	jww.INFO.Printf("%d precompressed files written\n", n)
+	s.timerStep("precompress")
+	return nil
+}
@@ -0,0 +1,44 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+func TestPrecompress(t *testing.T) {
+	defer viper.Set("PublishDir", viper.Get("PublishDir"))
+	viper.Set("PublishDir", "public")
+	viper.Set("Precompress", []string{"gzip"})
+	viper.Set("PrecompressExtensions", []string{"html", ".CSS"})
+	defer viper.Set("Precompress", nil)
+	defer viper.Set("PrecompressExtensions", nil)
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	publishDir := helpers.AbsPathify("public")
+	content := strings.Repeat("compress me ", 100)
+	for _, name := range []string{"index.html", "css/main.css", "js/main.js"} {
+		helpers.WriteToDisk(filepath.Join(publishDir, filepath.FromSlash(name)), strings.NewReader(content), hugofs.DestinationFS)
+	}
+
+	s := &Site{}
+	if err := s.Precompress(); err != nil {
+		t.Fatalf("Unable to precompress: %s", err)
+	}
+
+	for name, expected := range map[string]bool{"index.html.gz": true, "css/main.css.gz": true, "js/main.js.gz": false, "index.html.br": false} {
+		if exists, _ := helpers.Exists(filepath.Join(publishDir, filepath.FromSlash(name)), hugofs.DestinationFS); exists != expected {
+			t.Errorf("Expected %s to exist: %t", name, expected)
+		}
+	}
+
+	viper.Set("Precompress", []string{"zip"})
+	if err := s.Precompress(); err == nil {
+		t.Errorf("Expected an error for an unknown encoding")
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+var contentCheckers []ContentChecker
+
+// ContentChecker inspects the content of every page during the build,
+// e.g. to enforce spelling or editorial style rules. Any issue reported
+// fails the build.
+type ContentChecker interface {
+	Check(p *Page, text *ContentText) []ContentIssue
+}
+
+// ContentCheckPreparer is implemented by checkers with work to do once per
+// build before any page is checked, such as reading their settings.
+// Prepare reports whether the checker has anything to check in the build;
+// when no checker has, the content isn't taken for them at all.
+type ContentCheckPreparer interface {
+	Prepare() bool
+}
+
+// RegisterContentChecker adds a checker that is run on every page.
+func RegisterContentChecker(c ContentChecker) {
+	contentCheckers = append(contentCheckers, c)
+}
+
+func init() {
+	RegisterContentChecker(new(bannedWordsChecker))
+}
+
+// ContentIssue is a problem found by a ContentChecker. Offset is the byte
+// offset into ContentText.Text the issue refers to.
+type ContentIssue struct {
+	Offset  int
+	Message string
+}
+
+// ContentText is the plain text of a page, its rendered content without
+// the HTML, along with the source it was rendered from: the content as
+// written in the source file, without the front matter and before
+// shortcodes are expanded.
+type ContentText struct {
+	Text string
+
+	source     string
+	firstLine  int
+	lineStarts []int
+}
+
+func newContentText(source string, firstLine int) *ContentText {
+	t := &ContentText{source: source, firstLine: firstLine, lineStarts: []int{0}}
+	for i, c := range source {
+		if c == '\n' {
+			t.lineStarts = append(t.lineStarts, i+1)
+		}
+	}
+	return t
+}
+
+// Position maps a byte offset into Text to the line and column in the
+// source file, both starting at 1. The column is counted in characters.
+//
+// The plain text keeps no positions of its own, so the word at offset is
+// looked up in the source instead: its n-th use in Text is taken to be its
+// n-th use in the source. Markup hiding a use of the word, e.g. in a link
+// target, can make this point at an earlier one. Offsets not at a word, or
+// at one not found in the source, map to the start of the content.
+func (t *ContentText) Position(offset int) (line, col int) {
+	return t.sourcePosition(t.sourceOffset(offset))
+}
+
+func (t *ContentText) sourceOffset(offset int) int {
+	if offset < 0 || offset >= len(t.Text) {
+		return 0
+	}
+	end := offset
+	for end < len(t.Text) {
+		r, size := utf8.DecodeRuneInString(t.Text[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	word := t.Text[offset:end]
+	if word == "" {
+		return 0
+	}
+
+	n := 0
+	for i := indexWord(t.Text, word, 0); i >= 0 && i < offset; i = indexWord(t.Text, word, i+len(word)) {
+		n++
+	}
+
+	i := indexWord(t.source, word, 0)
+	for ; i >= 0 && n > 0; n-- {
+		i = indexWord(t.source, word, i+len(word))
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+func (t *ContentText) sourcePosition(offset int) (line, col int) {
+	if offset > len(t.source) {
+		offset = len(t.source)
+	}
+	i := sort.Search(len(t.lineStarts), func(i int) bool { return t.lineStarts[i] > offset }) - 1
+	return t.firstLine + i, utf8.RuneCountInString(t.source[t.lineStarts[i]:offset]) + 1
+}
+
+// isWordRune reports whether r can be part of a word. Unlike \b in a
+// regexp, it counts letters and digits outside ASCII.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isWordAt reports whether s[start:end] is a whole word, i.e. not preceded
+// or followed by a word rune.
+func isWordAt(s string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+// indexWord returns the index of the first whole word use of word in s at
+// or after from, or -1.
+func indexWord(s, word string, from int) int {
+	for from <= len(s) {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			return -1
+		}
+		if isWordAt(s, from+i, from+i+len(word)) {
+			return from + i
+		}
+		_, size := utf8.DecodeRuneInString(s[from+i:])
+		from += i + size
+	}
+	return -1
+}
+
+// activeContentCheckers prepares the registered content checkers for the
+// build and returns those with anything to check.
+func activeContentCheckers() []ContentChecker {
+	var active []ContentChecker
+	for _, c := range contentCheckers {
+		if prep, ok := c.(ContentCheckPreparer); ok && !prep.Prepare() {
+			continue
+		}
+		active = append(active, c)
+	}
+	return active
+}
+
+// contentSources takes the content as written of all pages for the content
+// checkers, before the conversion replaces it.
+func (s *Site) contentSources(checkers []ContentChecker) map[*Page]*ContentText {
+	if len(checkers) == 0 {
+		return nil
+	}
+
+	sources := make(map[*Page]*ContentText, len(s.Pages))
+	for _, p := range s.Pages {
+		sources[p] = newContentText(string(p.rawContent), p.lineNumRawContentStart())
+	}
+	return sources
+}
+
+// checkContent runs the content checkers on the plain text of all pages
+// and logs the issues found with their position in the source file.
+func (s *Site) checkContent(checkers []ContentChecker, sources map[*Page]*ContentText) error {
+	if len(checkers) == 0 {
+		return nil
+	}
+
+	issues := 0
+	for _, p := range s.Pages {
+		text, ok := sources[p]
+		if !ok {
+			continue
+		}
+		text.Text = p.Plain()
+		for _, c := range checkers {
+			for _, issue := range c.Check(p, text) {
+				line, col := text.Position(issue.Offset)
+				jww.ERROR.Printf("%s:%d:%d: %s", p.File.Path(), line, col, issue.Message)
+				issues++
+			}
+		}
+	}
+
+	if issues > 0 {
+		return fmt.Errorf("Content check failed with %d issue(s)", issues)
+	}
+	return nil
+}
+
+// bannedWordsChecker reports every use of the words listed in the
+// BannedWords setting, ignoring case.
+type bannedWordsChecker struct {
+	re *regexp.Regexp
+}
+
+func (c *bannedWordsChecker) Prepare() bool {
+	c.re = nil
+
+	words := viper.GetStringSlice("BannedWords")
+	if len(words) == 0 {
+		return false
+	}
+
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	c.re = regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+	return true
+}
+
+func (c *bannedWordsChecker) Check(p *Page, text *ContentText) []ContentIssue {
+	if c.re == nil {
+		return nil
+	}
+
+	var issues []ContentIssue
+	for from := 0; from < len(text.Text); {
+		m := c.re.FindStringIndex(text.Text[from:])
+		if m == nil {
+			break
+		}
+		start, end := from+m[0], from+m[1]
+		if end == start || !isWordAt(text.Text, start, end) {
+			_, size := utf8.DecodeRuneInString(text.Text[start:])
+			from = start + size
+			continue
+		}
+		issues = append(issues, ContentIssue{
+			Offset:  start,
+			Message: fmt.Sprintf("Banned word %q", text.Text[start:end]),
+		})
+		from = end
+	}
+	return issues
+}
@@ -0,0 +1,134 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestContentTextPosition(t *testing.T) {
+	text := newContentText("first *line*\nsecond [ünïcode](/x) line\n\nlast", 4)
+	text.Text = "first line\nsecond ünïcode line\n\nlast"
+
+	for i, this := range []struct {
+		offset int
+		line   int
+		col    int
+	}{
+		{0, 4, 1},
+		{6, 4, 8},
+		{18, 5, 9},
+		{28, 5, 22},
+		{34, 7, 1},
+		{5, 4, 1},
+		{len(text.Text), 4, 1},
+	} {
+		line, col := text.Position(this.offset)
+		if line != this.line || col != this.col {
+			t.Errorf("[%d] Expected %d:%d for offset %d, got %d:%d", i, this.line, this.col, this.offset, line, col)
+		}
+	}
+}
+
+func TestBannedWordsChecker(t *testing.T) {
+	viper.Set("BannedWords", []string{"simply", "obviously"})
+	defer viper.Set("BannedWords", []string{})
+
+	c := &bannedWordsChecker{}
+	c.Prepare()
+
+	p, _ := NewPage("simple.md")
+	text := newContentText("*Simply* put, it is simple.\n**Obviously**.", 4)
+	text.Text = "Simply put, it is simple.\nObviously."
+	issues := c.Check(p, text)
+
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d: %v", len(issues), issues)
+	}
+
+	if line, col := text.Position(issues[1].Offset); line != 5 || col != 3 {
+		t.Errorf("Expected the second issue at 5:3, got %d:%d", line, col)
+	}
+
+	if issues[0].Message != `Banned word "Simply"` {
+		t.Errorf("Unexpected message: %s", issues[0].Message)
+	}
+}
+
+func TestBannedWordsCheckerUnicodeBoundaries(t *testing.T) {
+	viper.Set("BannedWords", []string{"simply", "über"})
+	defer viper.Set("BannedWords", []string{})
+
+	c := &bannedWordsChecker{}
+	c.Prepare()
+
+	p, _ := NewPage("simple.md")
+	text := newContentText("", 1)
+	text.Text = "ésimply, simplyé, überall, Über alles, simply."
+	issues := c.Check(p, text)
+
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d: %v", len(issues), issues)
+	}
+
+	if issues[0].Message != `Banned word "Über"` || issues[1].Message != `Banned word "simply"` {
+		t.Errorf("Unexpected issues: %v", issues)
+	}
+}
+
+func TestBannedWordsCheckerWithoutWords(t *testing.T) {
+	c := &bannedWordsChecker{}
+	if c.Prepare() {
+		t.Errorf("Expected the checker to be inactive without banned words")
+	}
+	if checkers := activeContentCheckers(); len(checkers) != 0 {
+		t.Errorf("Expected no active checkers, got %v", checkers)
+	}
+	s := &Site{Pages: Pages{&Page{}}}
+	if sources := s.contentSources(activeContentCheckers()); sources != nil {
+		t.Errorf("Expected no content to be taken, got %v", sources)
+	}
+
+	p, _ := NewPage("simple.md")
+	text := newContentText("", 1)
+	text.Text = "Simply put."
+
+	if issues := c.Check(p, text); len(issues) != 0 {
+		t.Errorf("Expected no issues without banned words, got %v", issues)
+	}
+}
+
+func TestContentCheckFailsBuild(t *testing.T) {
+	viper.Set("BannedWords", []string{"simply"})
+	defer viper.Set("BannedWords", []string{})
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\n---\nIt is simply great.")},
+		{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: Two\n---\nNothing to see.")},
+	})
+
+	if err := s.CreatePages(); err == nil {
+		t.Errorf("Expected the content check to fail the build")
+	}
+
+	s = newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\n---\nSee [the guide](http://simply.example.com/).")},
+		{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: Two\n---\n<!-- simply -->\nNothing to see.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Errorf("Expected only the plain text to be checked, got %s", err)
+	}
+
+	viper.Set("BannedWords", []string{})
+
+	s = newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\n---\nIt is simply great.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Errorf("Unable to create pages: %s", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"time"
+
+	"github.com/spf13/cast"
+	bp "github.com/spf13/hugo/bufferpool"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/parser"
+	"github.com/spf13/viper"
+)
+
+// corpusHeader is the front matter of a page in the corpus.
+type corpusHeader struct {
+	Title   string   `yaml:"title"`
+	URL     string   `yaml:"url"`
+	Date    string   `yaml:"date,omitempty"`
+	Section string   `yaml:"section,omitempty"`
+	Lang    string   `yaml:"lang,omitempty"`
+	Tags    []string `yaml:"tags,omitempty"`
+}
+
+// RenderCorpus writes the Corpus file, if EnableCorpus is set, with the
+// content of every page as plain text after a YAML front matter with its
+// title, permalink, date, section and tags, e.g. for search or machine
+// learning pipelines. As in the search index, pages search engines
+// shouldn't index are left out.
+func (s *Site) RenderCorpus() error {
+	if !viper.GetBool("EnableCorpus") {
+		return nil
+	}
+
+	b := bp.GetBuffer()
+	defer bp.PutBuffer(b)
+
+	for _, p := range s.Pages {
+		if p.NoIndex() {
+			continue
+		}
+		permalink, err := p.Permalink()
+		if err != nil {
+			return err
+		}
+		header := corpusHeader{
+			Title:   p.Title,
+			URL:     permalink,
+			Section: p.Section(),
+			Lang:    p.Lang(),
+			Tags:    cast.ToStringSlice(p.GetParam("tags")),
+		}
+		if !p.Date.IsZero() {
+			header.Date = p.Date.Format(time.RFC3339)
+		}
+
+		fm, err := parser.InterfaceToFrontMatter(header, '-')
+		if err != nil {
+			return err
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.Write(fm)
+		b.WriteString(helpers.HTMLToText(string(p.Content)))
+		b.WriteString("\n")
+	}
+
+	dest := viper.GetString("Corpus")
+	if dest == "" {
+		dest = "corpus.txt"
+	}
+	if err := s.WriteDestFile(dest, b); err != nil {
+		return err
+	}
+	helpers.BuildLog.Rendered(dest, "corpus")
+	return nil
+}
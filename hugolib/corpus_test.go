@@ -0,0 +1,48 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestRenderCorpus(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("EnableCorpus", true)
+	viper.Set("Corpus", "export/corpus.txt")
+	defer viper.Set("EnableCorpus", false)
+	defer viper.Set("Corpus", "")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\nweight: 1\ndate: 2015-06-01\ntags: [go, hugo]\n---\nThe *first* post.\n\n- a\n- b")},
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: About\nweight: 2\n---\nAbout <b>us</b>.")},
+		{filepath.FromSlash("thin.md"), []byte("---\ntitle: Thin\nrobots: noindex\n---\nNothing.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderCorpus(); err != nil {
+		t.Fatalf("Unable to render the corpus: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("export/corpus.txt"))
+	if err != nil {
+		t.Fatalf("Unable to locate: export/corpus.txt")
+	}
+	expected := "---\ntitle: One\nurl: http://auth/bub/post/one/\ndate: \"2015-06-01T00:00:00Z\"\nsection: post\ntags:\n- go\n- hugo\n---\nThe first post.\n\n- a\n- b\n" +
+		"\n---\ntitle: About\nurl: http://auth/bub/about/\n---\nAbout us.\n"
+	if content := string(helpers.ReaderToBytes(file)); content != expected {
+		t.Errorf("Corpus expected:\n%q\ngot:\n%q", expected, content)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// A feedFormat is a format of the feeds of the home page, the sections and
+// the taxonomy terms.
+type feedFormat struct {
+	name string // also the name of its templates, e.g. _default/atom.xml
+	file string // the file the feed is written to in the list's directory
+}
+
+var knownFeedFormats = map[string]feedFormat{
+	"rss":  {"rss", "index.xml"},
+	"atom": {"atom", "atom.xml"},
+	"json": {"json", "feed.json"},
+}
+
+// feedFormats returns the formats of the feeds to build, RSS by default:
+//
+//	feeds = ["rss", "atom", "json"]
+func feedFormats() []feedFormat {
+	if kindDisabled(kindRSS) {
+		return nil
+	}
+
+	names := cast.ToStringSlice(viper.Get("Feeds"))
+	if len(names) == 0 {
+		names = []string{"rss"}
+	}
+
+	var formats []feedFormat
+	for _, name := range names {
+		f, ok := knownFeedFormats[name]
+		if !ok {
+			jww.ERROR.Printf("Unknown feed format %q, use rss, atom or json\n", name)
+			continue
+		}
+		formats = append(formats, f)
+	}
+	return formats
+}
+
+func (s *Site) hasFeed(name string) bool {
+	for _, f := range s.feeds {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// feedPermalinkStr returns the permalink of the feed file of the list at in,
+// which is always in the list's directory, even with UglyURLs.
+func (s *Site) feedPermalinkStr(in, file string) string {
+	return helpers.MakePermalink(viper.GetString("BaseURL"), path.Join("/", helpers.URLize(in), file)).String()
+}
+
+// renderFeeds renders the feeds of the list at base, in each format. The
+// layouts have a %s for the name of the format, as in "_default/%s.xml".
+// JSON feeds are made without templates.
+func (s *Site) renderFeeds(name, base string, n *Node, layouts ...string) error {
+	for _, f := range s.feeds {
+		n.Url = s.feedPermalinkStr(base, f.file)
+		if f.name == "json" {
+			if err := s.renderAndWriteJSONFeed(name+" json", path.Join(base, f.file), n); err != nil {
+				return err
+			}
+			continue
+		}
+		formatLayouts := make([]string, len(layouts))
+		for i, layout := range layouts {
+			formatLayouts[i] = fmt.Sprintf(layout, f.name)
+		}
+		if err := s.renderAndWriteXML(name+" "+f.name, path.Join(base, f.file), n, s.appendThemeTemplates(formatLayouts)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonFeed is a feed of the JSON Feed 1.1 format, https://jsonfeed.org/.
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description"`
+	Language    string           `json:"language,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	DatePublished string   `json:"date_published,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// renderAndWriteJSONFeed writes the feed of the pages of n, with the
+// same title, items and descriptions as the embedded RSS template.
+func (s *Site) renderAndWriteJSONFeed(name, dest string, n *Node) error {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       s.Info.RSSTitle,
+		HomePageURL: string(n.Permalink),
+		FeedURL:     n.Url,
+		Description: "Recent content on " + s.Info.Title,
+		Language:    s.Info.LanguageCode,
+		Items:       []jsonFeedItem{},
+	}
+	if n.Title != "" {
+		feed.Title = n.Title + " on " + s.Info.Title
+		feed.Description = "Recent content in " + n.Title + " on " + s.Info.Title
+	}
+	if author := cast.ToString(s.Info.Author["name"]); author != "" {
+		feed.Authors = []jsonFeedAuthor{{Name: author, URL: cast.ToString(s.Info.Author["url"])}}
+	}
+
+	pages, _ := n.Data["Pages"].(Pages)
+	if len(pages) > s.Info.RSSLimit {
+		pages = pages[:s.Info.RSSLimit]
+	}
+	for _, p := range pages {
+		permalink, err := p.Permalink()
+		if err != nil {
+			return err
+		}
+		item := jsonFeedItem{
+			ID:          permalink,
+			URL:         permalink,
+			Title:       p.Title,
+			ContentHTML: string(p.Content),
+			Tags:        cast.ToStringSlice(p.GetParam("tags")),
+		}
+		if s.Info.RSSDescription == "summary" {
+			item.ContentHTML = string(p.Summary)
+		}
+		if !p.Date.IsZero() {
+			item.DatePublished = p.Date.Format(time.RFC3339)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	b, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = minify("application/json", b)
+	if err := s.WriteDestFile(dest, bytes.NewReader(b)); err != nil {
+		return err
+	}
+	helpers.BuildLog.Rendered(dest, name)
+	return nil
+}
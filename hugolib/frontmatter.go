@@ -0,0 +1,168 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// A frontMatterRule adapts the front matter of the pages as it is read, so
+// that content written for another generator or an older theme can be used
+// as is. Keys are case insensitive, with dots for nested keys:
+//
+//	[[frontMatterRules]]
+//	section = "blog"          # the pages of a section only, all without
+//	rename = { headline = "title", "meta.summary" = "description" }
+//	defaults = { type = "post" }
+//	delete = ["wp_id"]
+//
+// The rules apply in order, and in a rule the keys are renamed, then the
+// defaults set and then the keys deleted. A renamed key does not replace
+// one already in the front matter.
+type frontMatterRule struct {
+	section  string
+	rename   map[string]string
+	defaults map[string]interface{}
+	delete   []string
+}
+
+func frontMatterRules() []frontMatterRule {
+	var rules []frontMatterRule
+	for _, v := range cast.ToSlice(viper.Get("FrontMatterRules")) {
+		var r frontMatterRule
+		for key, value := range cast.ToStringMap(v) {
+			switch strings.ToLower(key) {
+			case "section":
+				r.section = cast.ToString(value)
+			case "rename":
+				r.rename = cast.ToStringMapString(value)
+			case "defaults":
+				r.defaults = cast.ToStringMap(value)
+			case "delete":
+				r.delete = cast.ToStringSlice(value)
+			default:
+				jww.WARN.Printf("Unknown FrontMatterRules field: %s\n", key)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// transformFrontMatter applies the front matter rules of the site to the
+// front matter of the page.
+func (p *Page) transformFrontMatter(meta map[string]interface{}) {
+	for _, r := range frontMatterRules() {
+		if r.section != "" && r.section != p.Section() {
+			continue
+		}
+		r.apply(meta)
+	}
+}
+
+func (r frontMatterRule) apply(meta map[string]interface{}) {
+	from := make([]string, 0, len(r.rename))
+	for k := range r.rename {
+		from = append(from, k)
+	}
+	sort.Strings(from)
+	for _, k := range from {
+		v, ok := frontMatterGet(meta, k)
+		if !ok {
+			continue
+		}
+		frontMatterDelete(meta, k)
+		if _, ok := frontMatterGet(meta, r.rename[k]); !ok {
+			frontMatterSet(meta, r.rename[k], v)
+		}
+	}
+
+	for k, v := range r.defaults {
+		if _, ok := frontMatterGet(meta, k); !ok {
+			frontMatterSet(meta, k, v)
+		}
+	}
+
+	for _, k := range r.delete {
+		frontMatterDelete(meta, k)
+	}
+}
+
+// frontMatterKey returns the key of m that is key ignoring case.
+func frontMatterKey(m map[string]interface{}, key string) (string, bool) {
+	if _, ok := m[key]; ok {
+		return key, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return key, false
+}
+
+// frontMatterMap returns the map at the dotted path in meta, creating it
+// if create is set.
+func frontMatterMap(meta map[string]interface{}, path []string, create bool) map[string]interface{} {
+	m := meta
+	for _, name := range path {
+		k, ok := frontMatterKey(m, name)
+		if !ok {
+			if !create {
+				return nil
+			}
+			m[k] = make(map[string]interface{})
+		}
+		next, err := cast.ToStringMapE(m[k])
+		if err != nil {
+			return nil
+		}
+		// nested YAML maps are not keyed by strings, replace them so that
+		// the changes are seen
+		m[k] = next
+		m = next
+	}
+	return m
+}
+
+func frontMatterGet(meta map[string]interface{}, key string) (interface{}, bool) {
+	path := strings.Split(key, ".")
+	m := frontMatterMap(meta, path[:len(path)-1], false)
+	if m == nil {
+		return nil, false
+	}
+	k, ok := frontMatterKey(m, path[len(path)-1])
+	return m[k], ok
+}
+
+func frontMatterSet(meta map[string]interface{}, key string, v interface{}) {
+	path := strings.Split(key, ".")
+	if m := frontMatterMap(meta, path[:len(path)-1], true); m != nil {
+		k, _ := frontMatterKey(m, path[len(path)-1])
+		m[k] = v
+	}
+}
+
+func frontMatterDelete(meta map[string]interface{}, key string) {
+	path := strings.Split(key, ".")
+	if m := frontMatterMap(meta, path[:len(path)-1], false); m != nil {
+		k, _ := frontMatterKey(m, path[len(path)-1])
+		delete(m, k)
+	}
+}
@@ -0,0 +1,74 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+func TestFrontMatterRule(t *testing.T) {
+	r := frontMatterRule{
+		rename:   map[string]string{"headline": "title", "meta.summary": "description", "author_name": "author.name"},
+		defaults: map[string]interface{}{"type": "post", "draft": false},
+		delete:   []string{"wp_id", "meta"},
+	}
+
+	meta := map[string]interface{}{
+		"Headline":    "Hello",
+		"meta":        map[interface{}]interface{}{"summary": "About hello", "other": 1},
+		"author_name": "Jane",
+		"draft":       true,
+		"WP_ID":       42,
+	}
+	r.apply(meta)
+
+	expected := map[string]interface{}{
+		"title":       "Hello",
+		"description": "About hello",
+		"author":      map[string]interface{}{"name": "Jane"},
+		"type":        "post",
+		"draft":       true,
+	}
+	if !reflect.DeepEqual(meta, expected) {
+		t.Errorf("Got front matter\n%#v\nexpected\n%#v", meta, expected)
+	}
+
+	// the key already there wins
+	meta = map[string]interface{}{"headline": "Old", "title": "New"}
+	r.apply(meta)
+	if meta["title"] != "New" || meta["headline"] != nil {
+		t.Errorf("The title should be kept and the headline dropped, got %v", meta)
+	}
+}
+
+func TestFrontMatterRules(t *testing.T) {
+	viper.Set("FrontMatterRules", []interface{}{
+		map[string]interface{}{"rename": map[string]interface{}{"headline": "title"}},
+		map[string]interface{}{"section": "blog", "defaults": map[string]interface{}{"tags": []string{"legacy"}}},
+	})
+	defer viper.Set("FrontMatterRules", nil)
+
+	for _, this := range []struct {
+		path  string
+		title string
+		tags  []string
+	}{
+		{"blog/post.md", "Hello", []string{"legacy"}},
+		{"docs/page.md", "Hello", nil},
+	} {
+		p, err := NewPageFrom(strings.NewReader("---\nheadline: Hello\n---\nContent."), filepath.FromSlash(this.path))
+		if err != nil {
+			t.Fatalf("Unable to create %s: %s", this.path, err)
+		}
+		if p.Title != this.title {
+			t.Errorf("%s got title %q, expected %q", this.path, p.Title, this.title)
+		}
+		if tags := cast.ToStringSlice(p.Params["tags"]); !reflect.DeepEqual(tags, this.tags) && len(tags)+len(this.tags) > 0 {
+			t.Errorf("%s got tags %v, expected %v", this.path, tags, this.tags)
+		}
+	}
+}
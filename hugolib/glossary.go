@@ -0,0 +1,220 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// A glossaryTerm is a term of the glossary, shown as a link to its url or,
+// without one, as an abbreviation with its title.
+type glossaryTerm struct {
+	term  string
+	title string
+	url   string
+	re    *regexp.Regexp
+}
+
+// A glossary holds the terms, the longest first, so that they win over the
+// shorter ones they contain.
+type glossary []*glossaryTerm
+
+// glossaryNoLinkTags are the elements whose text is left as is.
+var glossaryNoLinkTags = map[string]bool{
+	"a": true, "abbr": true, "code": true, "pre": true, "kbd": true, "script": true, "style": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// loadGlossary reads the glossary from the data file given by Glossary,
+// with the terms as keys and their title and url, or just their title:
+//
+//	[HTML]
+//	title = "HyperText Markup Language"
+//	[Hugo]
+//	title = "A static site generator"
+//	url = "/about/"
+func (s *Site) loadGlossary() glossary {
+	key := viper.GetString("Glossary")
+	if key == "" {
+		return nil
+	}
+
+	var data interface{} = s.Data
+	for _, k := range strings.Split(key, ".") {
+		data = cast.ToStringMap(data)[k]
+	}
+	if data == nil {
+		jww.WARN.Printf("No glossary in the data files at %q\n", key)
+		return nil
+	}
+
+	var g glossary
+	for term, v := range cast.ToStringMap(data) {
+		t := &glossaryTerm{term: term}
+		// YAML data files decode maps as map[interface{}]interface{}
+		if m, err := cast.ToStringMapE(v); err == nil {
+			t.title = cast.ToString(m["title"])
+			t.url = cast.ToString(m["url"])
+		} else {
+			t.title = cast.ToString(v)
+		}
+
+		if strings.HasPrefix(t.url, "/") && !rootRelativeURLs() {
+			t.url = helpers.AddContextRoot(string(s.Info.BaseUrl), t.url)
+		}
+
+		t.re = regexp.MustCompile(`(^|[^\pL\pN_])(` + regexp.QuoteMeta(html.EscapeString(term)) + `)($|[^\pL\pN_])`)
+		g = append(g, t)
+	}
+
+	sort.Sort(g)
+	return g
+}
+
+func (g glossary) Len() int      { return len(g) }
+func (g glossary) Swap(i, j int) { g[i], g[j] = g[j], g[i] }
+func (g glossary) Less(i, j int) bool {
+	if len(g[i].term) != len(g[j].term) {
+		return len(g[i].term) > len(g[j].term)
+	}
+	return g[i].term < g[j].term
+}
+
+func (t *glossaryTerm) html() string {
+	term := html.EscapeString(t.term)
+	title := html.EscapeString(t.title)
+	if t.url == "" {
+		return fmt.Sprintf(`<abbr title="%s">%s</abbr>`, title, term)
+	}
+	if title == "" {
+		return fmt.Sprintf(`<a href="%s" class="glossary">%s</a>`, html.EscapeString(t.url), term)
+	}
+	return fmt.Sprintf(`<a href="%s" class="glossary" title="%s">%s</a>`, html.EscapeString(t.url), title, term)
+}
+
+// link wraps the first occurrence of each term in the HTML content, outside
+// of links, code and headings.
+func (g glossary) link(content []byte) []byte {
+	if len(g) == 0 {
+		return content
+	}
+
+	done := make(map[*glossaryTerm]bool)
+	var b bytes.Buffer
+	skip := 0
+
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '<')
+		if i < 0 {
+			i = len(content)
+		}
+		if skip == 0 {
+			g.linkText(&b, content[:i], done)
+		} else {
+			b.Write(content[:i])
+		}
+		content = content[i:]
+		if len(content) == 0 {
+			break
+		}
+
+		// a tag or a comment
+		end := []byte(">")
+		if bytes.HasPrefix(content, []byte("<!--")) {
+			end = []byte("-->")
+		}
+		j := bytes.Index(content, end)
+		if j < 0 {
+			b.Write(content)
+			break
+		}
+		tag := content[:j+len(end)]
+		b.Write(tag)
+		content = content[j+len(end):]
+
+		name, closing := glossaryTagName(tag)
+		if !glossaryNoLinkTags[name] || bytes.HasSuffix(tag, []byte("/>")) {
+			continue
+		}
+		if closing {
+			if skip > 0 {
+				skip--
+			}
+		} else {
+			skip++
+		}
+	}
+
+	return b.Bytes()
+}
+
+func glossaryTagName(tag []byte) (name string, closing bool) {
+	s := strings.TrimPrefix(string(tag), "<")
+	if strings.HasPrefix(s, "/") {
+		closing = true
+		s = s[1:]
+	}
+	if i := strings.IndexAny(s, " \t\n/>"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.ToLower(s), closing
+}
+
+// linkText writes the text with the first occurrences of the terms not done
+// yet wrapped, the earliest first.
+func (g glossary) linkText(b *bytes.Buffer, text []byte, done map[*glossaryTerm]bool) {
+	for {
+		var first *glossaryTerm
+		var loc []int
+		for _, t := range g {
+			if done[t] {
+				continue
+			}
+			if l := t.re.FindSubmatchIndex(text); l != nil && (loc == nil || l[4] < loc[4]) {
+				first, loc = t, l
+			}
+		}
+		if first == nil {
+			b.Write(text)
+			return
+		}
+
+		b.Write(text[:loc[4]])
+		b.WriteString(first.html())
+		text = text[loc[5]:]
+		done[first] = true
+	}
+}
+
+// linkGlossary links the terms of the glossary in the page's content,
+// unless the page has glossary set to false in its front matter.
+func (p *Page) linkGlossary(g glossary) {
+	if len(g) == 0 {
+		return
+	}
+	if v, ok := p.Params["glossary"]; ok && !cast.ToBool(v) {
+		return
+	}
+	p.Content = helpers.BytesToHTML(g.link([]byte(p.Content)))
+}
@@ -0,0 +1,116 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"github.com/spf13/viper"
+)
+
+var glossaryData = map[string]interface{}{
+	"glossary": map[string]interface{}{
+		"HTML":         "HyperText Markup Language",
+		"HTML5":        "The fifth HTML",
+		"Hugo":         map[string]interface{}{"title": "A static site generator", "url": "/about/"},
+		"front matter": map[string]interface{}{"url": "http://example.com/fm/"},
+		"R&D":          "Research & Development",
+	},
+}
+
+func TestGlossaryLink(t *testing.T) {
+	viper.Set("Glossary", "glossary")
+	viper.Set("CanonifyURLs", false)
+	defer viper.Set("Glossary", nil)
+
+	s := &Site{Data: glossaryData}
+	s.initializeSiteInfo()
+	s.Info.BaseUrl = "http://auth/bub/"
+	g := s.loadGlossary()
+
+	for i, this := range []struct {
+		in       string
+		expected string
+	}{
+		{"<p>HTML and HTML.</p>",
+			`<p><abbr title="HyperText Markup Language">HTML</abbr> and HTML.</p>`},
+		{"<p>HTML5 is not HTMLish, but HTML.</p>",
+			`<p><abbr title="The fifth HTML">HTML5</abbr> is not HTMLish, but <abbr title="HyperText Markup Language">HTML</abbr>.</p>`},
+		{"<p>Hugo reads the front matter.</p>",
+			`<p><a href="/bub/about/" class="glossary" title="A static site generator">Hugo</a> reads the <a href="http://example.com/fm/" class="glossary">front matter</a>.</p>`},
+		{"<p>R&amp;D</p>",
+			`<p><abbr title="Research &amp; Development">R&amp;D</abbr></p>`},
+		{`<h1>HTML</h1><p><a href="/">Hugo</a> <code>HTML</code> <img src="HTML.png"/> HTML</p>`,
+			`<h1>HTML</h1><p><a href="/">Hugo</a> <code>HTML</code> <img src="HTML.png"/> <abbr title="HyperText Markup Language">HTML</abbr></p>`},
+		{"<!-- HTML --><pre><code>Hugo</code></pre><p>Hugo</p>",
+			`<!-- HTML --><pre><code>Hugo</code></pre><p><a href="/bub/about/" class="glossary" title="A static site generator">Hugo</a></p>`},
+	} {
+		if out := string(g.link([]byte(this.in))); out != this.expected {
+			t.Errorf("[%d] %s got\n%s\nexpected\n%s", i, this.in, out, this.expected)
+		}
+	}
+}
+
+func TestGlossaryFromYAML(t *testing.T) {
+	viper.Set("Glossary", "glossary")
+	viper.Set("CanonifyURLs", false)
+	defer viper.Set("Glossary", nil)
+
+	data, err := readData(source.NewFileWithContents(filepath.FromSlash("glossary.yaml"), strings.NewReader(
+		"HTML: HyperText Markup Language\nHugo:\n  title: A static site generator\n  url: /about/\n")))
+	if err != nil {
+		t.Fatalf("Unable to read the glossary: %s", err)
+	}
+
+	s := &Site{Data: map[string]interface{}{"glossary": data}}
+	s.initializeSiteInfo()
+	s.Info.BaseUrl = "http://auth/bub/"
+	g := s.loadGlossary()
+
+	expected := `<p><a href="/bub/about/" class="glossary" title="A static site generator">Hugo</a> is <abbr title="HyperText Markup Language">HTML</abbr>.</p>`
+	if out := string(g.link([]byte("<p>Hugo is HTML.</p>"))); out != expected {
+		t.Errorf("Got\n%s\nexpected\n%s", out, expected)
+	}
+}
+
+func TestGlossaryPages(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("Glossary", "glossary")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("CanonifyURLs", false)
+	viper.Set("UglyURLs", false)
+	defer viper.Set("Glossary", nil)
+	defer viper.Set("DefaultExtension", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: Doc1\n---\nHTML and HTML.")},
+		{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: Doc2\nglossary: false\n---\nHTML and HTML.")},
+	})
+	s.Targets.Page = &target.PagePub{}
+	s.Data = glossaryData
+	must(s.addTemplate("_default/single.html", "{{ .Content }}"))
+
+	createAndRenderPages(t, s)
+
+	for _, this := range []struct {
+		file     string
+		expected string
+	}{
+		{"sect/doc1/index.html", "<p><abbr title=\"HyperText Markup Language\">HTML</abbr> and HTML.</p>\n"},
+		{"sect/doc2/index.html", "<p>HTML and HTML.</p>\n"},
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(this.file))
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", this.file)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != this.expected {
+			t.Errorf("%s content is\n%q\nexpected\n%q", this.file, content, this.expected)
+		}
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"errors"
 
 	"fmt"
+	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/hugo/source"
 )
 
@@ -73,9 +74,16 @@ func (mh *MetaHandle) Convert(i interface{}, s *Site, results HandleResults) {
 			return
 		}
 
-		results <- h.PageConvert(p, s.Tmpl)
+		result := h.PageConvert(p, s.Tmpl)
+		if result.err == nil {
+			p.linkGlossary(s.glossary)
+		}
 		p.setSummary()
 		p.analyzePage()
+		if result.err == nil {
+			result.err = p.encryptContent()
+		}
+		results <- result
 	}
 }
 
@@ -86,12 +94,17 @@ func (mh *MetaHandle) Handler() Handler {
 	return mh.handler
 }
 
+// FindHandler returns the handler of the files with the extension, or of
+// the pages in the content format of that name or extension.
 func FindHandler(ext string) Handler {
 	for _, h := range Handlers() {
 		if HandlerMatch(h, ext) {
 			return h
 		}
 	}
+	if f := helpers.LookupContentFormat(ext); f != nil {
+		return contentFormatHandler{format: f}
+	}
 	return nil
 }
 
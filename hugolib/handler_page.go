@@ -14,17 +14,18 @@
 package hugolib
 
 import (
+	"strings"
+
+	"github.com/spf13/cast"
 	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/hugo/source"
 	"github.com/spf13/hugo/tpl"
 	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
 )
 
 func init() {
-	RegisterHandler(new(markdownHandler))
 	RegisterHandler(new(htmlHandler))
-	RegisterHandler(new(asciidocHandler))
-	RegisterHandler(new(rstHandler))
 }
 
 type basicPageHandler Handle
@@ -49,12 +50,76 @@ func (b basicPageHandler) FileConvert(*source.File, *Site) HandledResult {
 	return HandledResult{}
 }
 
-type markdownHandler struct {
+// registerContentFormats registers the content formats of ContentFormats,
+// converted by an external command, or changes the extensions of the
+// built-in ones:
+//
+//	[contentFormats.org]
+//	extensions = ["org"]
+//	command = "pandoc"
+//	args = ["--from", "org", "--to", "html"]
+//
+//	[contentFormats.asciidoc]
+//	extensions = ["adoc"]
+func registerContentFormats() {
+	for name, v := range viper.GetStringMap("ContentFormats") {
+		name = strings.ToLower(name)
+		if name == "html" {
+			jww.ERROR.Println("The html content format is not converted and cannot be changed")
+			continue
+		}
+
+		var extensions, args []string
+		var command string
+		for key, value := range cast.ToStringMap(v) {
+			switch strings.ToLower(key) {
+			case "extensions":
+				extensions = cast.ToStringSlice(value)
+			case "command":
+				command = cast.ToString(value)
+			case "args":
+				args = cast.ToStringSlice(value)
+			default:
+				jww.WARN.Printf("Unknown ContentFormat field: %s\n", key)
+			}
+		}
+
+		existing := helpers.LookupContentFormat(name)
+		if existing != nil && existing.Name != name {
+			// name is an extension of another format
+			existing = nil
+		}
+		if extensions == nil && existing != nil {
+			extensions = existing.Extensions
+		}
+		if extensions == nil {
+			extensions = []string{name}
+		}
+
+		var f helpers.ContentFormat
+		switch {
+		case command != "":
+			f = helpers.ExternalContentFormat(name, extensions, command, args...)
+		case existing != nil:
+			f = *existing
+			f.Extensions = extensions
+		default:
+			jww.ERROR.Printf("The content format %q needs a command\n", name)
+			continue
+		}
+		helpers.RegisterContentFormat(f)
+	}
+}
+
+// contentFormatHandler converts the pages of a content format, such as
+// Markdown, registered with helpers.RegisterContentFormat.
+type contentFormatHandler struct {
 	basicPageHandler
+	format *helpers.ContentFormat
 }
 
-func (h markdownHandler) Extensions() []string { return []string{"mdown", "markdown", "md"} }
-func (h markdownHandler) PageConvert(p *Page, t tpl.Template) HandledResult {
+func (h contentFormatHandler) Extensions() []string { return h.format.Extensions }
+func (h contentFormatHandler) PageConvert(p *Page, t tpl.Template) HandledResult {
 	p.ProcessShortcodes(t)
 
 	tmpContent, tmpTableOfContents := helpers.ExtractTOC(p.renderContent(helpers.RemoveSummaryDivider(p.rawContent)))
@@ -86,58 +151,3 @@ func (h htmlHandler) PageConvert(p *Page, t tpl.Template) HandledResult {
 	p.Content = helpers.BytesToHTML(p.rawContent)
 	return HandledResult{err: nil}
 }
-
-type asciidocHandler struct {
-	basicPageHandler
-}
-
-func (h asciidocHandler) Extensions() []string { return []string{"asciidoc", "ad"} }
-func (h asciidocHandler) PageConvert(p *Page, t tpl.Template) HandledResult {
-	p.ProcessShortcodes(t)
-
-	// TODO(spf13) Add/Refactor AsciiDoc Logic here
-	tmpContent, tmpTableOfContents := helpers.ExtractTOC(p.renderContent(helpers.RemoveSummaryDivider(p.rawContent)))
-
-	if len(p.contentShortCodes) > 0 {
-		tmpContentWithTokensReplaced, err := replaceShortcodeTokens(tmpContent, shortcodePlaceholderPrefix, true, p.contentShortCodes)
-
-		if err != nil {
-			jww.FATAL.Printf("Fail to replace short code tokens in %s:\n%s", p.BaseFileName(), err.Error())
-			return HandledResult{err: err}
-		} else {
-			tmpContent = tmpContentWithTokensReplaced
-		}
-	}
-
-	p.Content = helpers.BytesToHTML(tmpContent)
-	p.TableOfContents = helpers.BytesToHTML(tmpTableOfContents)
-
-	//err := p.Convert()
-	return HandledResult{page: p, err: nil}
-}
-
-type rstHandler struct {
-	basicPageHandler
-}
-
-func (h rstHandler) Extensions() []string { return []string{"rest", "rst"} }
-func (h rstHandler) PageConvert(p *Page, t tpl.Template) HandledResult {
-	p.ProcessShortcodes(t)
-
-	tmpContent, tmpTableOfContents := helpers.ExtractTOC(p.renderContent(helpers.RemoveSummaryDivider(p.rawContent)))
-
-	if len(p.contentShortCodes) > 0 {
-		tmpContentWithTokensReplaced, err := replaceShortcodeTokens(tmpContent, shortcodePlaceholderPrefix, true, p.contentShortCodes)
-
-		if err != nil {
-			jww.FATAL.Printf("Fail to replace short code tokens in %s:\n%s", p.BaseFileName(), err.Error())
-			return HandledResult{err: err}
-		}
-		tmpContent = tmpContentWithTokensReplaced
-	}
-
-	p.Content = helpers.BytesToHTML(tmpContent)
-	p.TableOfContents = helpers.BytesToHTML(tmpTableOfContents)
-
-	return HandledResult{err: nil}
-}
@@ -0,0 +1,56 @@
+package hugolib
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestContentFormatsConfig(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("Skip test as tr is not installed")
+	}
+
+	asciidoc := *helpers.LookupContentFormat("asciidoc")
+	defer helpers.RegisterContentFormat(asciidoc)
+
+	viper.Set("DefaultExtension", "html")
+	viper.Set("ContentFormats", map[string]interface{}{
+		"shout":    map[string]interface{}{"extensions": []string{"shout", "yell"}, "command": "tr", "args": []string{"a-z", "A-Z"}},
+		"asciidoc": map[string]interface{}{"extensions": []string{"adoc"}},
+		"nothing":  map[string]interface{}{"extensions": []string{"nothing"}},
+	})
+	defer viper.Set("ContentFormats", nil)
+
+	registerContentFormats()
+
+	if f := helpers.LookupContentFormat("ad"); f != nil {
+		t.Errorf("The ad extension should no longer be asciidoc")
+	}
+	if f := helpers.LookupContentFormat("adoc"); f == nil || f.Name != "asciidoc" {
+		t.Errorf("The adoc extension should still be asciidoc")
+	}
+	if f := helpers.LookupContentFormat("nothing"); f != nil {
+		t.Errorf("A format without a command should not be registered")
+	}
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.yell"), []byte("---\ntitle: Doc1\n---\nhello")},
+		{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: Doc2\nmarkup: shout\n---\nworld")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	for _, p := range s.Pages {
+		expected := map[string]string{"Doc1": "HELLO", "Doc2": "WORLD"}[p.Title]
+		if string(p.Content) != expected {
+			t.Errorf("%s: content is %q, expected %q", p.Title, p.Content, expected)
+		}
+	}
+}
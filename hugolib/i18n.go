@@ -0,0 +1,78 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/source"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// loadTranslations reads the i18n/<lang>.yaml, .toml or .json files of the
+// sources into s.Translations, by language and id. The translations of the
+// earlier sources win, so a site can override those of its theme.
+func (s *Site) loadTranslations(sources []source.Input) error {
+	s.Translations = make(map[string]map[string]interface{})
+	for _, currentSource := range sources {
+		for _, r := range currentSource.Files() {
+			if r.Dir() != "" {
+				jww.WARN.Printf("Skipping %s: translations must be directly in the i18n directory\n", filepath.Join(r.Dir(), r.LogicalName()))
+				continue
+			}
+
+			data, err := readData(r)
+			if err != nil {
+				return fmt.Errorf("Failed to read translations from %s: %s", r.LogicalName(), err)
+			}
+
+			lang := strings.ToLower(r.BaseFileName())
+			if s.Translations[lang] == nil {
+				s.Translations[lang] = make(map[string]interface{})
+			}
+			for id, t := range cast.ToStringMap(data) {
+				if _, ok := s.Translations[lang][id]; ok {
+					continue
+				}
+				if forms, ok := t.(map[interface{}]interface{}); ok {
+					s.Translations[lang][id] = cast.ToStringMap(forms)
+				} else {
+					s.Translations[lang][id] = t
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// translationLanguage is the language the i18n func translates to: that of
+// the site's Language, else its DefaultContentLanguage or languageCode.
+func (s *Site) translationLanguage() string {
+	if s.Language != nil {
+		return s.Language.Lang
+	}
+	if lang := viper.GetString("DefaultContentLanguage"); lang != "" {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(s.Info.LanguageCode)
+}
+
+func (s *Site) absI18nDir() string {
+	return helpers.AbsPathify(viper.GetString("I18nDir"))
+}
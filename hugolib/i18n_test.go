@@ -0,0 +1,78 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestLoadTranslations(t *testing.T) {
+	s := &Site{}
+	err := s.loadTranslations([]source.Input{
+		&source.InMemorySource{ByteSource: []source.ByteSource{
+			{"fr.yaml", []byte("readMore: Lire la suite\nwordCount:\n  one: \"{{ .Count }} mot\"\n  other: \"{{ .Count }} mots\"\n")},
+		}},
+		&source.InMemorySource{ByteSource: []source.ByteSource{
+			{"fr.yaml", []byte("readMore: Plus\nhome: Accueil\n")},
+			{"en.json", []byte(`{"readMore": "Read more"}`)},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Unable to load the translations: %s", err)
+	}
+
+	if s.Translations["fr"]["readMore"] != "Lire la suite" {
+		t.Errorf("Expected the site's translation over the theme's, got %v", s.Translations["fr"]["readMore"])
+	}
+	if s.Translations["fr"]["home"] != "Accueil" {
+		t.Errorf("Expected the theme's translation when the site has none, got %v", s.Translations["fr"]["home"])
+	}
+	if forms, ok := s.Translations["fr"]["wordCount"].(map[string]interface{}); !ok || forms["one"] != "{{ .Count }} mot" {
+		t.Errorf("Expected the plural forms of wordCount, got %#v", s.Translations["fr"]["wordCount"])
+	}
+	if s.Translations["en"]["readMore"] != "Read more" {
+		t.Errorf("Expected the en translations, got %v", s.Translations["en"])
+	}
+}
+
+func TestRenderTranslations(t *testing.T) {
+	viper.Set("DefaultExtension", "html")
+	viper.Set("DefaultContentLanguage", "fr")
+	defer viper.Set("DefaultContentLanguage", nil)
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := &Site{
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nUn deux trois.")},
+		}},
+		Translations: map[string]map[string]interface{}{
+			"fr": {"wordCount": map[string]interface{}{"one": "{{ .Count }} mot", "other": "{{ .Count }} mots"}},
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	s.addTemplate("_default/single.html", `{{ if .IsPage }}{{ i18n "wordCount" .WordCount }}{{ end }}`)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.Render(); err != nil {
+		t.Fatalf("Unable to render the site: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/one/index.html"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "post/one/index.html")
+	}
+	if content := string(helpers.ReaderToBytes(file)); content != "3 mots" {
+		t.Errorf("Expected the translated word count, got %q", content)
+	}
+}
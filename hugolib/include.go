@@ -0,0 +1,144 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/parser"
+	"github.com/spf13/hugo/tpl"
+	"github.com/spf13/viper"
+)
+
+// Include renders the content file of the include shortcode, given as the
+// file param or the first positional one, with the optional fragment param
+// or second positional one:
+//
+//	{{< include "shared/warning.md" >}}
+//	{{< include file="shared/install.md" fragment="linux" >}}
+func (scp *ShortcodeWithPage) Include() (template.HTML, error) {
+	var file, fragment string
+	switch params := scp.Params.(type) {
+	case map[string]string:
+		file, fragment = params["file"], params["fragment"]
+	case []string:
+		if len(params) > 0 {
+			file = params[0]
+		}
+		if len(params) > 1 {
+			fragment = params[1]
+		}
+	}
+	return scp.ReadInclude(file, fragment)
+}
+
+// ReadInclude renders the content file at path, relative to the content
+// directory, in the page. Given a fragment, only the part of the file
+// between its markers is rendered:
+//
+//	<!-- begin: linux -->
+//	...
+//	<!-- end: linux -->
+//
+// Included files can include others, but not the files including them.
+func (scp *ShortcodeWithPage) ReadInclude(path string, fragment ...string) (template.HTML, error) {
+	var name string
+	if len(fragment) > 0 {
+		name = fragment[0]
+	}
+	return scp.Page.readInclude(path, name)
+}
+
+func (p *Page) readInclude(path, fragment string) (template.HTML, error) {
+	if path == "" {
+		return "", fmt.Errorf("no file to include in %s", p.Source.Path())
+	}
+	path = filepath.Clean(filepath.FromSlash(strings.TrimPrefix(path, "/")))
+	if path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the content directory, included in %s", path, p.Source.Path())
+	}
+
+	trail := append([]string{p.Source.Path()}, p.includes...)
+	for _, f := range trail {
+		if f == path {
+			return "", fmt.Errorf("include cycle: %s -> %s", strings.Join(trail, " -> "), path)
+		}
+	}
+
+	f, err := hugofs.SourceFs.Open(filepath.Join(helpers.AbsPathify(viper.GetString("ContentDir")), path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	psr, err := parser.ReadFrom(f)
+	if err != nil {
+		return "", err
+	}
+	content := psr.Content()
+
+	if fragment != "" {
+		if content, err = includeFragment(content, fragment); err != nil {
+			return "", fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	p.includes = append(p.includes, path)
+	defer func() { p.includes = p.includes[:len(p.includes)-1] }()
+
+	t := p.Tmpl
+	if t == nil {
+		t = tpl.T()
+	}
+	text, shortcodes := extractAndRenderShortcodes(string(content), p, t)
+	rendered := []byte(text)
+
+	if markup := helpers.GuessType(strings.TrimPrefix(filepath.Ext(path), ".")); markup != "html" {
+		rendered = helpers.RenderBytes(&helpers.RenderingContext{Content: rendered, PageFmt: markup,
+			DocumentID: p.UniqueID(), Config: p.getRenderingConfig(), Markup: p.getMarkupConfig(), RenderHook: p.renderHook})
+	}
+
+	if len(shortcodes) > 0 {
+		if rendered, err = replaceShortcodeTokens(rendered, shortcodePlaceholderPrefix, true, shortcodes); err != nil {
+			return "", err
+		}
+	}
+
+	return template.HTML(rendered), nil
+}
+
+// includeFragment returns the part of content between the markers of the
+// fragment.
+func includeFragment(content []byte, fragment string) ([]byte, error) {
+	begin := []byte("<!-- begin: " + fragment + " -->")
+	end := []byte("<!-- end: " + fragment + " -->")
+
+	i := bytes.Index(content, begin)
+	if i < 0 {
+		return nil, fmt.Errorf("no fragment %q", fragment)
+	}
+	content = content[i+len(begin):]
+
+	j := bytes.Index(content, end)
+	if j < 0 {
+		return nil, fmt.Errorf("fragment %q has no end", fragment)
+	}
+	return bytes.TrimSpace(content[:j]), nil
+}
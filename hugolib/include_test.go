@@ -0,0 +1,104 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/tpl"
+	"github.com/spf13/viper"
+)
+
+func setupIncludes(t *testing.T, files map[string]string) (*Page, tpl.Template) {
+	hugofs.SourceFs = new(afero.MemMapFs)
+	viper.Set("ContentDir", "content")
+
+	for name, content := range files {
+		path := filepath.Join(helpers.AbsPathify("content"), filepath.FromSlash(name))
+		if err := helpers.WriteToDisk(path, strings.NewReader(content), hugofs.SourceFs); err != nil {
+			t.Fatalf("Unable to write %s: %s", name, err)
+		}
+	}
+
+	tem := tpl.New()
+	p, _ := pageFromString(SIMPLE_PAGE, filepath.FromSlash("sect/page.md"))
+	p.Tmpl = tem
+	return p, tem
+}
+
+func TestIncludeSC(t *testing.T) {
+	defer func() { hugofs.SourceFs = new(afero.OsFs) }()
+	defer viper.Set("ContentDir", viper.Get("ContentDir"))
+
+	p, tem := setupIncludes(t, map[string]string{
+		"shared/warning.md": "---\ntitle: Warning\n---\n**Careful** with that.",
+		"shared/install.md": "# Install\n\n<!-- begin: linux -->\nRun `apt-get`.\n<!-- end: linux -->\n\n<!-- begin: mac -->\nRun `brew`.\n<!-- end: mac -->\n",
+		"shared/nested.md":  "Before {{< include \"shared/warning.md\" >}} after.",
+		"shared/plain.html": "<b>as is</b>",
+	})
+
+	for i, this := range []struct {
+		in       string
+		expected string
+	}{
+		{`{{< include "shared/warning.md" >}}`, "<p><strong>Careful</strong> with that.</p>\n"},
+		{`{{< include "/shared/warning.md" >}}`, "<p><strong>Careful</strong> with that.</p>\n"},
+		{`{{< include file="shared/install.md" fragment="mac" >}}`, "<p>Run <code>brew</code>.</p>\n"},
+		{`{{< include "shared/install.md" "linux" >}}`, "<p>Run <code>apt-get</code>.</p>\n"},
+		{`{{< include "shared/nested.md" >}}`, "<p>Before <p><strong>Careful</strong> with that.</p>\n after.</p>\n"},
+		{`{{< include "shared/plain.html" >}}`, "<b>as is</b>"},
+	} {
+		if output := ShortcodesHandle(this.in, p, tem); output != this.expected {
+			t.Errorf("[%d] %s got %q, expected %q", i, this.in, output, this.expected)
+		}
+	}
+
+	if len(p.includes) != 0 {
+		t.Errorf("The includes should be done, got %v", p.includes)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	defer func() { hugofs.SourceFs = new(afero.OsFs) }()
+	defer viper.Set("ContentDir", viper.Get("ContentDir"))
+
+	p, _ := setupIncludes(t, map[string]string{
+		"shared/a.md":    "A {{< include \"shared/b.md\" >}}",
+		"shared/b.md":    "B {{< include \"shared/a.md\" >}}",
+		"shared/frag.md": "<!-- begin: open -->\nNo end.",
+	})
+	scp := &ShortcodeWithPage{Page: p}
+	helpers.WriteToDisk(helpers.AbsPathify("secret.md"), strings.NewReader("Secret"), hugofs.SourceFs)
+
+	for i, this := range []struct {
+		path, fragment string
+		err            string
+	}{
+		{"shared/missing.md", "", "missing.md"},
+		{"sect/page.md", "", "include cycle: sect/page.md -> sect/page.md"},
+		{"shared/frag.md", "closed", `no fragment "closed"`},
+		{"shared/frag.md", "open", `fragment "open" has no end`},
+		{"../secret.md", "", "../secret.md is outside the content directory"},
+		{"shared/../../secret.md", "", "../secret.md is outside the content directory"},
+		{"..", "", ".. is outside the content directory"},
+	} {
+		_, err := scp.ReadInclude(this.path, this.fragment)
+		if err == nil || !strings.Contains(err.Error(), filepath.FromSlash(this.err)) {
+			t.Errorf("[%d] %s got error %v, expected %q", i, this.path, err, this.err)
+		}
+	}
+
+	// the cycle is reported by the innermost include and the rest renders
+	p.includes = []string{filepath.FromSlash("shared/b.md")}
+	if _, err := scp.ReadInclude("shared/b.md"); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("Including a file being included should fail, got %v", err)
+	}
+	p.includes = nil
+
+	if out, err := scp.ReadInclude("shared/a.md"); err != nil || !strings.Contains(string(out), "A") || !strings.Contains(string(out), "B") {
+		t.Errorf("Include with a cycle further down should render, got %q %v", out, err)
+	}
+}
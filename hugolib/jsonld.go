@@ -0,0 +1,223 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"encoding/json"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+const schemaContext = "https://schema.org"
+
+// JSONLD returns the schema.org structured data of the page as JSON-LD: an
+// Article, or the schemaType of its front matter such as BlogPosting, and
+// the BreadcrumbList of the sections it is in, e.g. for
+//
+//	<script type="application/ld+json">{{ .JSONLD }}</script>
+func (p *Page) JSONLD() template.JS {
+	permalink, _ := p.Permalink()
+	canonical, _ := p.CanonicalURL()
+
+	schemaType := cast.ToString(p.Params["schematype"])
+	if schemaType == "" {
+		schemaType = "Article"
+	}
+	article := map[string]interface{}{
+		"@type":            schemaType,
+		"headline":         p.Title,
+		"url":              permalink,
+		"mainEntityOfPage": canonical,
+		"wordCount":        p.WordCount,
+	}
+
+	if p.Description != "" {
+		article["description"] = p.Description
+	} else if summary := strings.TrimSpace(helpers.StripHTML(string(p.Summary))); summary != "" {
+		article["description"] = summary
+	}
+	if !p.PublishDate.IsZero() {
+		article["datePublished"] = p.PublishDate.Format(time.RFC3339)
+	} else if !p.Date.IsZero() {
+		article["datePublished"] = p.Date.Format(time.RFC3339)
+	}
+	if !p.Date.IsZero() {
+		article["dateModified"] = p.Date.Format(time.RFC3339)
+	}
+	if images := cast.ToStringSlice(p.Params["images"]); len(images) > 0 {
+		article["image"] = images
+	} else if card := p.SocialCard(); card != "" {
+		article["image"] = []string{card}
+	}
+	if authors := p.schemaAuthors(); len(authors) > 0 {
+		article["author"] = authors
+	}
+	if publisher := p.Site.schemaPublisher(); publisher != nil {
+		article["publisher"] = publisher
+	}
+	if keywords := p.Keywords; len(keywords) > 0 {
+		article["keywords"] = strings.Join(keywords, ", ")
+	} else if tags := cast.ToStringSlice(p.Params["tags"]); len(tags) > 0 {
+		article["keywords"] = strings.Join(tags, ", ")
+	}
+	if lang := p.LanguageCode(); lang != "" {
+		article["inLanguage"] = lang
+	}
+
+	graph := []interface{}{article}
+	if crumbs := p.Site.breadcrumbList(p.Ancestors(), p.Title, permalink); crumbs != nil {
+		graph = append(graph, crumbs)
+	}
+	return marshalJSONLD(graph)
+}
+
+// JSONLD returns the schema.org structured data of a list page as JSON-LD:
+// the WebSite on the home page, the BreadcrumbList of its sections on the
+// others.
+func (n *Node) JSONLD() template.JS {
+	permalink := string(n.Permalink)
+	if n.Url != "/" {
+		if crumbs := n.Site.breadcrumbList(n.Ancestors(), n.Title, permalink); crumbs != nil {
+			return marshalJSONLD([]interface{}{crumbs})
+		}
+		return ""
+	}
+
+	website := map[string]interface{}{
+		"@type": "WebSite",
+		"name":  n.Site.Title,
+		"url":   permalink,
+	}
+	if n.Description != "" {
+		website["description"] = n.Description
+	} else if description := cast.ToString(n.Site.Params["description"]); description != "" {
+		website["description"] = description
+	}
+	if lang := n.LanguageCode(); lang != "" {
+		website["inLanguage"] = lang
+	}
+	if publisher := n.Site.schemaPublisher(); publisher != nil {
+		website["publisher"] = publisher
+	}
+	return marshalJSONLD([]interface{}{website})
+}
+
+// schemaAuthors are the Persons of the authors of the page, else of the
+// site's author.
+func (p *Page) schemaAuthors() []interface{} {
+	var authors []interface{}
+	pageAuthors := p.Authors()
+	keys := make([]string, 0, len(pageAuthors))
+	for key := range pageAuthors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		a := pageAuthors[key]
+		name := a.DisplayName
+		if name == "" {
+			name = strings.TrimSpace(a.GivenName + " " + a.FamilyName)
+		}
+		if name == "" {
+			name = key
+		}
+		person := map[string]interface{}{"@type": "Person", "name": name}
+		if website := a.Social["website"]; website != "" {
+			person["url"] = website
+		}
+		authors = append(authors, person)
+	}
+
+	if len(authors) == 0 {
+		if name := cast.ToString(p.Site.Author["name"]); name != "" {
+			person := map[string]interface{}{"@type": "Person", "name": name}
+			if url := cast.ToString(p.Site.Author["url"]); url != "" {
+				person["url"] = url
+			}
+			authors = append(authors, person)
+		}
+	}
+	return authors
+}
+
+// schemaPublisher is the Organization of the site, nil if it has no title.
+func (s *SiteInfo) schemaPublisher() map[string]interface{} {
+	if s.Title == "" {
+		return nil
+	}
+	publisher := map[string]interface{}{
+		"@type": "Organization",
+		"name":  s.Title,
+		"url":   string(s.BaseUrl),
+	}
+	if logo := cast.ToString(s.Params["logo"]); logo != "" {
+		publisher["logo"] = map[string]interface{}{"@type": "ImageObject", "url": logo}
+	}
+	return publisher
+}
+
+// breadcrumbList is the BreadcrumbList from the home page through the
+// sections above, the nearest first as Ancestors returns them, to the
+// page itself. It is nil when there is nothing above the page.
+func (s *SiteInfo) breadcrumbList(sections Pages, name, url string) map[string]interface{} {
+	var items []interface{}
+	add := func(name, url string) {
+		items = append(items, map[string]interface{}{
+			"@type":    "ListItem",
+			"position": len(items) + 1,
+			"name":     name,
+			"item":     url,
+		})
+	}
+
+	home := string(s.BaseUrl)
+	add(s.Title, home)
+	for i := len(sections) - 1; i >= 0; i-- {
+		link, _ := sections[i].Permalink()
+		if link == home {
+			continue
+		}
+		add(sections[i].Title, link)
+	}
+	if url == home {
+		return nil
+	}
+	add(name, url)
+
+	return map[string]interface{}{
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
+
+func marshalJSONLD(graph []interface{}) template.JS {
+	var data interface{} = map[string]interface{}{"@context": schemaContext, "@graph": graph}
+	if len(graph) == 1 {
+		single := graph[0].(map[string]interface{})
+		single["@context"] = schemaContext
+		data = single
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		jww.ERROR.Println("Unable to write the JSON-LD:", err)
+		return ""
+	}
+	return template.JS(b)
+}
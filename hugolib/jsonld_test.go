@@ -0,0 +1,125 @@
+package hugolib
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestJSONLD(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("Title", "Bub")
+	defer viper.Set("Title", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/_index.md"), []byte("---\ntitle: Posts\n---\n")},
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One <b>\ndate: 2015-01-02\nschemaType: BlogPosting\ntags: [go, web]\nauthors: [jo]\n---\nThe first post.")},
+	})
+	s.Info.Authors = AuthorList{"jo": Author{DisplayName: "Jo Doe"}}
+	s.prepTemplates()
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	var one *Page
+	for _, p := range s.Pages {
+		if p.Title == "One <b>" {
+			one = p
+		}
+	}
+	if one == nil {
+		t.Fatalf("Page One not found")
+	}
+
+	ld := string(one.JSONLD())
+	if strings.Contains(ld, "<b>") {
+		t.Errorf("Expected < and > to be escaped in %s", ld)
+	}
+	var data struct {
+		Context string                   `json:"@context"`
+		Graph   []map[string]interface{} `json:"@graph"`
+	}
+	if err := json.Unmarshal([]byte(ld), &data); err != nil {
+		t.Fatalf("Invalid JSON-LD %s: %s", ld, err)
+	}
+	if data.Context != "https://schema.org" || len(data.Graph) != 2 {
+		t.Fatalf("Expected an article and its breadcrumbs, got %s", ld)
+	}
+
+	article := data.Graph[0]
+	for key, expected := range map[string]interface{}{
+		"@type":         "BlogPosting",
+		"headline":      "One <b>",
+		"url":           "http://auth/bub/post/one/",
+		"description":   "The first post.",
+		"datePublished": "2015-01-02T00:00:00Z",
+		"keywords":      "go, web",
+	} {
+		if article[key] != expected {
+			t.Errorf("Expected %s %q, got %v", key, expected, article[key])
+		}
+	}
+	if authors, ok := article["author"].([]interface{}); !ok || len(authors) != 1 || authors[0].(map[string]interface{})["name"] != "Jo Doe" {
+		t.Errorf("Expected the author Jo Doe, got %v", article["author"])
+	}
+	if publisher, ok := article["publisher"].(map[string]interface{}); !ok || publisher["name"] != "Bub" {
+		t.Errorf("Expected the site as publisher, got %v", article["publisher"])
+	}
+
+	var names []string
+	for _, item := range data.Graph[1]["itemListElement"].([]interface{}) {
+		names = append(names, item.(map[string]interface{})["name"].(string))
+	}
+	if strings.Join(names, " > ") != "Bub > Posts > One <b>" {
+		t.Errorf("Got the breadcrumbs %v", names)
+	}
+
+	var website map[string]interface{}
+	if err := json.Unmarshal([]byte(s.newHomeNode().JSONLD()), &website); err != nil {
+		t.Fatalf("Invalid JSON-LD for the home page: %s", err)
+	}
+	if website["@type"] != "WebSite" || website["name"] != "Bub" || website["url"] != "http://auth/bub/" {
+		t.Errorf("Expected the WebSite on the home page, got %v", website)
+	}
+}
+
+func TestJSONLDOfSyndicatedPage(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/cross.md"), []byte("---\ntitle: Cross\ncanonicalURL: http://elsewhere.org/original/\n---\nTheirs.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	var data struct {
+		Graph []map[string]interface{} `json:"@graph"`
+	}
+	ld := string(s.Pages[0].JSONLD())
+	if err := json.Unmarshal([]byte(ld), &data); err != nil {
+		t.Fatalf("Invalid JSON-LD %s: %s", ld, err)
+	}
+
+	article := data.Graph[0]
+	if article["url"] != "http://auth/bub/post/cross/" {
+		t.Errorf("Expected the url of the page, got %v", article["url"])
+	}
+	if article["mainEntityOfPage"] != "http://elsewhere.org/original/" {
+		t.Errorf("Expected the canonical URL as mainEntityOfPage, got %v", article["mainEntityOfPage"])
+	}
+}
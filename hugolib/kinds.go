@@ -0,0 +1,73 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// The kinds of output Hugo renders besides the pages of the content, which
+// a site can turn off with DisableKinds, e.g.
+//
+//	disableKinds = ["taxonomy", "taxonomyTerm", "RSS"]
+const (
+	kindHome         = "home"
+	kindSection      = "section"
+	kindTaxonomy     = "taxonomy"
+	kindTaxonomyTerm = "taxonomyTerm"
+	kindRSS          = "RSS"
+	kindSitemap      = "sitemap"
+	kindRobotsTXT    = "robotsTXT"
+	kind404          = "404"
+)
+
+var allKinds = []string{kindHome, kindSection, kindTaxonomy, kindTaxonomyTerm, kindRSS, kindSitemap, kindRobotsTXT, kind404}
+
+// kindDisabled returns whether the kind is in DisableKinds, in any case.
+// DisableRSS and DisableSitemap still turn off the feeds and the sitemap.
+func kindDisabled(kind string) bool {
+	switch {
+	case kind == kindRSS && viper.GetBool("DisableRSS"):
+		return true
+	case kind == kindSitemap && viper.GetBool("DisableSitemap"):
+		return true
+	}
+	for _, k := range cast.ToStringSlice(viper.Get("DisableKinds")) {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDisabledKinds warns about the kinds of DisableKinds Hugo doesn't
+// know, which are likely misspelled.
+func checkDisabledKinds() {
+	for _, k := range cast.ToStringSlice(viper.Get("DisableKinds")) {
+		known := false
+		for _, kind := range allKinds {
+			if strings.EqualFold(k, kind) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			jww.WARN.Printf("Unknown kind %q in disableKinds, must be one of %s\n", k, strings.Join(allKinds, ", "))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestDisableKinds(t *testing.T) {
+	viper.Set("DefaultExtension", "html")
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("taxonomies", map[string]string{"tag": "tags"})
+	defer viper.Set("taxonomies", nil)
+	viper.Set("DisableKinds", []string{"Taxonomy", "taxonomyTerm", "rss"})
+	defer viper.Set("DisableKinds", nil)
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ntags: [go]\n---\nOne.")},
+	})
+	for _, name := range []string{"_default/single.html", "_default/list.html", "index.html"} {
+		s.addTemplate(name, "{{ .Title }}")
+	}
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.Render(); err != nil {
+		t.Fatalf("Unable to render the site: %s", err)
+	}
+
+	for doc, expected := range map[string]bool{
+		"index.html":          true,
+		"post/one/index.html": true,
+		"post/index.html":     true,
+		"sitemap.xml":         true,
+		"tags/go/index.html":  false,
+		"tags/index.html":     false,
+		"index.xml":           false,
+		"post/index.xml":      false,
+	} {
+		if _, err := hugofs.DestinationFS.Open(filepath.FromSlash(doc)); (err == nil) != expected {
+			t.Errorf("Expected %s to be written: %t", doc, expected)
+		}
+	}
+
+	viper.Set("DisableKinds", []string{"home", "section", "sitemap"})
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	if err := s.Render(); err != nil {
+		t.Fatalf("Unable to render the site: %s", err)
+	}
+	for doc, expected := range map[string]bool{
+		"post/one/index.html": true,
+		"tags/go/index.html":  true,
+		"index.html":          false,
+		"post/index.html":     false,
+		"sitemap.xml":         false,
+	} {
+		if _, err := hugofs.DestinationFS.Open(filepath.FromSlash(doc)); (err == nil) != expected {
+			t.Errorf("Expected %s to be written: %t", doc, expected)
+		}
+	}
+}
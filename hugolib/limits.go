@@ -0,0 +1,130 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// buildLimits bounds how much of a build happens at the same time, so that
+// builds in small containers don't run out of memory:
+//
+//	workers = 8            # goroutines per build step, 4 per CPU by default
+//	maxInFlightPages = 4   # pages converted or rendered at the same time
+//	memoryLimit = "512MB"  # render one page at a time above this heap size
+type buildLimits struct {
+	workers int
+	slots   chan struct{}
+
+	memory   uint64
+	gc       func()
+	mu       sync.Mutex
+	cond     *sync.Cond
+	running  int
+	releases int // pages released so far
+	gcAt     int // releases when the garbage was last collected, or -1
+}
+
+func newBuildLimits() *buildLimits {
+	l := &buildLimits{workers: viper.GetInt("Workers")}
+	if l.workers <= 0 {
+		l.workers = 4 * runtime.GOMAXPROCS(0)
+	}
+
+	if n := viper.GetInt("MaxInFlightPages"); n > 0 {
+		l.slots = make(chan struct{}, n)
+	}
+
+	if limit := viper.GetString("MemoryLimit"); limit != "" {
+		memory, err := helpers.ParseByteSize(limit)
+		if err != nil {
+			jww.ERROR.Println("Invalid MemoryLimit:", err)
+		}
+		l.memory = memory
+		l.gc = runtime.GC
+		l.gcAt = -1
+		l.cond = sync.NewCond(&l.mu)
+	}
+	return l
+}
+
+// acquire blocks until another page may be converted or rendered, and
+// release must be called when it is done. Above the memory limit, it waits
+// for the pages in progress to finish, so that their memory can be freed.
+func (l *buildLimits) acquire() {
+	if l.memory > 0 {
+		over := l.overMemory()
+		l.mu.Lock()
+		for over && l.running > 0 {
+			l.cond.Wait()
+			l.mu.Unlock()
+			over = l.overMemory()
+			l.mu.Lock()
+		}
+		l.running++
+		l.mu.Unlock()
+	}
+	if l.slots != nil {
+		l.slots <- struct{}{}
+	}
+}
+
+func (l *buildLimits) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+	if l.memory > 0 {
+		l.mu.Lock()
+		l.running--
+		l.releases++
+		l.mu.Unlock()
+		l.cond.Broadcast()
+	}
+}
+
+// overMemory tells whether the heap is above the limit, after a garbage
+// collection unless there was one since a page was last released, which is
+// what frees most memory. Reading the memory stats and collecting stop the
+// world, so it must be called without holding l.mu.
+func (l *buildLimits) overMemory() bool {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapAlloc <= l.memory {
+		return false
+	}
+
+	l.mu.Lock()
+	collect := l.gcAt != l.releases
+	l.gcAt = l.releases
+	l.mu.Unlock()
+	if !collect {
+		return true
+	}
+
+	l.gc()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc > l.memory
+}
+
+func (s *Site) limits() *buildLimits {
+	s.limitsInit.Do(func() {
+		s.buildLimits = newBuildLimits()
+	})
+	return s.buildLimits
+}
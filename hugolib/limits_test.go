@@ -0,0 +1,91 @@
+package hugolib
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func maxConcurrency(l *buildLimits) int32 {
+	var running, max int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.acquire()
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			l.release()
+		}()
+	}
+	wg.Wait()
+	return max
+}
+
+func TestBuildLimits(t *testing.T) {
+	defer func() {
+		viper.Set("Workers", 0)
+		viper.Set("MaxInFlightPages", 0)
+		viper.Set("MemoryLimit", "")
+	}()
+
+	if l := newBuildLimits(); l.workers != 4*runtime.GOMAXPROCS(0) {
+		t.Errorf("Expected 4 workers per CPU by default, got %d", l.workers)
+	}
+
+	viper.Set("Workers", 3)
+	viper.Set("MaxInFlightPages", 2)
+	l := newBuildLimits()
+	if l.workers != 3 {
+		t.Errorf("Expected 3 workers, got %d", l.workers)
+	}
+	if max := maxConcurrency(l); max > 2 {
+		t.Errorf("Expected at most 2 pages in flight, got %d", max)
+	}
+
+	// always above the limit, so one page at a time
+	viper.Set("MaxInFlightPages", 0)
+	viper.Set("MemoryLimit", "1KB")
+	l = newBuildLimits()
+	if l.memory != 1024 {
+		t.Errorf("Expected a memory limit of 1024 bytes, got %d", l.memory)
+	}
+	if max := maxConcurrency(l); max != 1 {
+		t.Errorf("Expected one page at a time above the memory limit, got %d", max)
+	}
+}
+
+func TestBuildLimitsCollectOncePerRelease(t *testing.T) {
+	viper.Set("MemoryLimit", "1KB")
+	defer viper.Set("MemoryLimit", "")
+
+	l := newBuildLimits()
+	var collections int
+	l.gc = func() { collections++ }
+
+	l.overMemory()
+	l.overMemory()
+	if collections != 1 {
+		t.Errorf("Expected one collection before a release, got %d", collections)
+	}
+
+	// acquiring doesn't collect again, releasing makes it worth it
+	l.acquire()
+	l.release()
+	l.overMemory()
+	if collections != 2 {
+		t.Errorf("Expected another collection after the release, got %d", collections)
+	}
+}
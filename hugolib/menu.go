@@ -46,6 +46,17 @@ func (me *MenuEntry) HasChildren() bool {
 	return me.Children != nil
 }
 
+// hasDescendant returns whether any of the entries below the entry, at any
+// depth, matches.
+func (me *MenuEntry) hasDescendant(match func(*MenuEntry) bool) bool {
+	for _, child := range me.Children {
+		if match(child) || child.hasDescendant(match) {
+			return true
+		}
+	}
+	return false
+}
+
 func (me *MenuEntry) KeyName() string {
 	if me.Identifier != "" {
 		return me.Identifier
@@ -181,3 +192,65 @@ func (m Menu) Reverse() Menu {
 
 	return m
 }
+
+// A NavMenuEntry is a menu entry as seen from the page being rendered, for
+// the navigation of multi-level menus.
+type NavMenuEntry struct {
+	*MenuEntry
+	// Active is true for the entry of the page.
+	Active bool
+	// InTrail is true for the entry of the page and for its ancestors.
+	InTrail  bool
+	Level    int
+	Children NavMenu
+}
+
+type NavMenu []*NavMenuEntry
+
+// newNavMenu returns the entries of menu, from level on, with the ones
+// for which current is true active.
+func newNavMenu(menu Menu, level int, current func(me *MenuEntry) bool) NavMenu {
+	if menu == nil {
+		return nil
+	}
+	nav := make(NavMenu, len(menu))
+	for i, me := range menu {
+		e := &NavMenuEntry{MenuEntry: me, Active: current(me), Level: level}
+		e.Children = newNavMenu(me.Children, level+1, current)
+		e.InTrail = e.Active
+		for _, child := range e.Children {
+			e.InTrail = e.InTrail || child.InTrail
+		}
+		nav[i] = e
+	}
+	return nav
+}
+
+func (n *Node) navMenu(menuID string, current func(me *MenuEntry) bool) NavMenu {
+	if n.Site == nil || n.Site.Menus == nil {
+		return nil
+	}
+	if menu, ok := (*n.Site.Menus)[menuID]; ok {
+		return newNavMenu(*menu, 1, current)
+	}
+	return nil
+}
+
+// NavMenu returns the menu with the entry of the node active and its
+// ancestors in the active trail, e.g. for
+// {{ template "_internal/menu.html" (.NavMenu "main") }}.
+func (n *Node) NavMenu(menuID string) NavMenu {
+	me := &MenuEntry{Url: n.Url}
+	return n.navMenu(menuID, me.IsSameResource)
+}
+
+// NavMenu returns the menu with the entry of the page active, be it added
+// in the front matter or in the site config, and its ancestors in the
+// active trail.
+func (p *Page) NavMenu(menuID string) NavMenu {
+	link, _ := p.RelPermalink()
+	me := &MenuEntry{Url: link}
+	return p.navMenu(menuID, func(inme *MenuEntry) bool {
+		return p.IsMenuCurrent(menuID, inme) || me.IsSameResource(inme)
+	})
+}
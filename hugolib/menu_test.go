@@ -1,6 +1,7 @@
 package hugolib
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/hugo/hugofs"
 	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/tpl"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -266,6 +268,42 @@ func TestPageMenu(t *testing.T) {
 
 }
 
+func TestPageMenuAncestors(t *testing.T) {
+	four := []byte(`+++
+title = "Four"
+[menu]
+	[menu.p_two]
+		Parent = "Three"
++++
+Front Matter with Menu Pages`)
+	ts := setupMenuTests(t, append(MENU_PAGE_SOURCES, source.ByteSource{Name: "sect/doc4.md", Content: four}))
+	defer resetMenuTestState(ts)
+
+	var fourth *Page
+	for _, p := range ts.site.Pages {
+		if p.Title == "Four" {
+			fourth = p
+		}
+	}
+	if fourth == nil {
+		t.Fatal("Page Four not created")
+	}
+
+	pTwo := ts.findTestMenuEntryByID("p_two", "Two")
+	assert.True(t, fourth.HasMenuCurrent("p_two", pTwo), "the grandparent should have the page below")
+	assert.False(t, fourth.IsMenuCurrent("p_two", pTwo))
+	assert.False(t, fourth.HasMenuCurrent("p_one", ts.findTestMenuEntryByName("p_one", "One")))
+
+	// entries of the site config are matched on the URL of the page
+	link, _ := fourth.RelPermalink()
+	configured := &MenuEntry{Name: "Docs", Menu: "main", Children: Menu{
+		{Name: "Section", Menu: "main", Children: Menu{{Name: "Four", Menu: "main", Url: link}}},
+	}}
+	assert.True(t, fourth.HasMenuCurrent("main", configured))
+	assert.False(t, fourth.HasMenuCurrent("main", configured.Children[0].Children[0]))
+	assert.False(t, fourth.HasMenuCurrent("footer", configured))
+}
+
 // issue #888
 func TestMenuWithHashInURL(t *testing.T) {
 	ts := setupMenuTests(t, MENU_PAGE_SOURCES)
@@ -370,7 +408,7 @@ func TestHomeNodeMenu(t *testing.T) {
 		{"main", homeMenuEntry, true, false},
 		{"doesnotexist", homeMenuEntry, false, false},
 		{"main", &MenuEntry{Name: "Somewhere else", Url: "/somewhereelse"}, false, false},
-		{"grandparent", ts.findTestMenuEntryByID("grandparent", "grandparentId"), false, false},
+		{"grandparent", ts.findTestMenuEntryByID("grandparent", "grandparentId"), false, true},
 		{"grandparent", ts.findTestMenuEntryByID("grandparent", "parentId"), false, true},
 		{"grandparent", ts.findTestMenuEntryByID("grandparent", "grandchildId"), true, false},
 	} {
@@ -388,6 +426,61 @@ func TestHomeNodeMenu(t *testing.T) {
 	}
 }
 
+func TestNavMenu(t *testing.T) {
+	ts := setupMenuTests(t, MENU_PAGE_SOURCES)
+	defer resetMenuTestState(ts)
+
+	home := ts.site.newHomeNode()
+	nav := home.NavMenu("grandparent")
+
+	assert.Equal(t, 1, len(nav))
+	grandparent := nav[0]
+	assert.True(t, grandparent.InTrail)
+	assert.False(t, grandparent.Active)
+	assert.Equal(t, 1, grandparent.Level)
+	parent := grandparent.Children[0]
+	assert.True(t, parent.InTrail)
+	assert.False(t, parent.Active)
+	grandchild := parent.Children[0]
+	assert.True(t, grandchild.InTrail)
+	assert.True(t, grandchild.Active)
+	assert.Equal(t, 3, grandchild.Level)
+	assert.Nil(t, grandchild.Children)
+
+	assert.Nil(t, home.NavMenu("doesnotexist"))
+
+	var three *Page
+	for _, p := range ts.site.Pages {
+		if p.Title == "Three" {
+			three = p
+		}
+	}
+	nav = three.NavMenu("p_two")
+	assert.Equal(t, 1, len(nav))
+	assert.True(t, nav[0].InTrail)
+	assert.False(t, nav[0].Active)
+	assert.True(t, nav[0].Children[0].Active)
+	assert.False(t, home.NavMenu("p_two")[0].InTrail)
+
+	var b bytes.Buffer
+	if err := tpl.New().ExecuteTemplate(&b, "_internal/menu.html", home.NavMenu("grandparent")); err != nil {
+		t.Fatalf("Unable to render the menu: %s", err)
+	}
+	menu := b.String()
+	for _, expected := range []string{
+		`<li class="level-1 active-trail"><a href="/grandparent/">grandparent</a>`,
+		`<li class="level-2 active-trail"><a href="/parent/">parent</a>`,
+		`<li class="level-3 active active-trail"><a href="/">Go Home3</a>`,
+	} {
+		if !strings.Contains(menu, expected) {
+			t.Errorf("Expected %s in the menu, got %s", expected, menu)
+		}
+	}
+	if strings.Count(menu, "<ul>") != 3 {
+		t.Errorf("Expected 3 levels of lists, got %s", menu)
+	}
+}
+
 var testMenuIdentityMatcher = func(me *MenuEntry, id string) bool { return me.Identifier == id }
 var testMenuNameMatcher = func(me *MenuEntry, id string) bool { return me.Name == id }
 
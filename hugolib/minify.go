@@ -0,0 +1,49 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/transform"
+	"github.com/spf13/viper"
+)
+
+// minifier returns the minification of the output of the media type, or
+// nil if the Minify config doesn't enable it. Minify is true for all the
+// media types that can be minified, or set by media type:
+//
+//	[minify]
+//	"text/html" = true
+//	"application/json" = true
+func minifier(mediaType string) func([]byte) []byte {
+	if types := viper.GetStringMap("Minify"); len(types) > 0 {
+		if !cast.ToBool(types[strings.ToLower(mediaType)]) {
+			return nil
+		}
+	} else if !viper.GetBool("Minify") {
+		return nil
+	}
+	return transform.Minifier(mediaType)
+}
+
+// minify returns the output of the media type minified, if the Minify
+// config enables it.
+func minify(mediaType string, b []byte) []byte {
+	if m := minifier(mediaType); m != nil {
+		return m(b)
+	}
+	return b
+}
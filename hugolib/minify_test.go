@@ -0,0 +1,57 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestMinifier(t *testing.T) {
+	defer viper.Set("Minify", nil)
+
+	for i, this := range []struct {
+		config    interface{}
+		mediaType string
+		expected  bool
+	}{
+		{nil, "text/html", false},
+		{true, "text/html", true},
+		{true, "application/json", true},
+		{true, "text/plain", false},
+		{map[string]interface{}{"application/json": true}, "application/json", true},
+		{map[string]interface{}{"application/json": true}, "text/html", false},
+		{map[string]interface{}{"text/html": false}, "text/html", false},
+	} {
+		viper.Set("Minify", this.config)
+		if m := minifier(this.mediaType); (m != nil) != this.expected {
+			t.Errorf("[%d] Expected minification of %s to be %t with %v", i, this.mediaType, this.expected, this.config)
+		}
+	}
+}
+
+func TestRenderMinified(t *testing.T) {
+	viper.Set("DefaultExtension", "html")
+	viper.Set("Minify", map[string]interface{}{"text/html": true})
+	defer viper.Set("Minify", nil)
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nOne.")},
+	})
+	s.addTemplate("_default/single.html", "<html>\n  <body>\n    <h1>{{ .Title }}</h1>\n    <!-- content -->\n    {{ .Content }}\n  </body>\n</html>\n")
+
+	createAndRenderPages(t, s)
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/one/index.html"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "post/one/index.html")
+	}
+	if content := string(helpers.ReaderToBytes(file)); content != "<html><body><h1>One</h1><p>One.</p></body></html>" {
+		t.Errorf("Expected the page minified, got %q", content)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// A Language is one of the languages of a multilingual site. The site is
+// built once per language, with the settings of the language in place of
+// those of the site config:
+//
+//	defaultContentLanguage = "en"
+//
+//	[languages.en]
+//	weight = 1
+//	languageName = "English"
+//	contentDir = "content/en"
+//
+//	[languages.fr]
+//	weight = 2
+//	languageName = "Français"
+//	title = "Mon blog"
+//	contentDir = "content/fr"
+//	[languages.fr.params]
+//	subtitle = "Des nouvelles"
+//
+// Each language needs a contentDir of its own, not nested in that of
+// another, the default one included: with the default language reading
+// all of content/, the pages in content/fr would be built in English too.
+//
+// The params of a language are added to those of the site. The languages
+// other than the default one are published in a directory named after
+// them, e.g. public/fr for http://example.com/fr/, unless they set their
+// own publishDir and baseURL.
+type Language struct {
+	Lang         string
+	LanguageName string
+	Weight       int
+	BaseURL      string
+	settings     map[string]interface{}
+}
+
+// Languages returns the languages of the site config, by weight, or nil if
+// the site isn't multilingual.
+func Languages() []*Language {
+	config := viper.GetStringMap("Languages")
+	if len(config) == 0 {
+		return nil
+	}
+
+	var languages []*Language
+	for lang, v := range config {
+		l := &Language{Lang: strings.ToLower(lang), settings: make(map[string]interface{})}
+		for key, value := range cast.ToStringMap(v) {
+			l.settings[strings.ToLower(key)] = value
+		}
+		l.Weight = cast.ToInt(l.settings["weight"])
+		l.LanguageName = cast.ToString(l.settings["languagename"])
+		if l.LanguageName == "" {
+			l.LanguageName = l.Lang
+		}
+		languages = append(languages, l)
+	}
+	sort.Sort(languagesByWeight(languages))
+
+	def := strings.ToLower(viper.GetString("DefaultContentLanguage"))
+	if def != "" && config[def] == nil {
+		jww.ERROR.Printf("The defaultContentLanguage %q is not in the languages, using %q\n", def, languages[0].Lang)
+		def = ""
+	}
+	if def == "" {
+		def = languages[0].Lang
+	}
+
+	for _, l := range languages {
+		if l.Lang != def {
+			if _, ok := l.settings["publishdir"]; !ok {
+				l.settings["publishdir"] = filepath.Join(viper.GetString("PublishDir"), l.Lang)
+			}
+			if _, ok := l.settings["baseurl"]; !ok {
+				l.settings["baseurl"] = strings.TrimSuffix(viper.GetString("BaseURL"), "/") + "/" + l.Lang + "/"
+			}
+		}
+		l.BaseURL = cast.ToString(l.settings["baseurl"])
+		if l.BaseURL == "" {
+			l.BaseURL = viper.GetString("BaseURL")
+		}
+	}
+	return languages
+}
+
+// absPublishDir returns the directory the language is published in.
+func (l *Language) absPublishDir() string {
+	if dir, ok := l.settings["publishdir"]; ok {
+		return helpers.AbsPathify(cast.ToString(dir))
+	}
+	return helpers.AbsPathify(viper.GetString("PublishDir"))
+}
+
+type languagesByWeight []*Language
+
+func (l languagesByWeight) Len() int      { return len(l) }
+func (l languagesByWeight) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l languagesByWeight) Less(i, j int) bool {
+	if l[i].Weight != l[j].Weight {
+		return l[i].Weight < l[j].Weight
+	}
+	return l[i].Lang < l[j].Lang
+}
+
+// apply changes the site config to the one of the language, until the
+// func it returns restores it.
+func (l *Language) apply() func() {
+	old := make(map[string]interface{})
+	set := func(key string, value interface{}) {
+		if _, ok := old[key]; !ok {
+			old[key] = viper.Get(key)
+		}
+		viper.Set(key, value)
+	}
+
+	for key, value := range l.settings {
+		switch key {
+		case "weight", "languagename":
+		case "params":
+			params := make(map[string]interface{})
+			for k, v := range viper.GetStringMap("Params") {
+				params[k] = v
+			}
+			for k, v := range cast.ToStringMap(value) {
+				params[k] = v
+			}
+			set("Params", params)
+		default:
+			set(key, value)
+		}
+	}
+
+	return func() {
+		for key, value := range old {
+			viper.Set(key, value)
+		}
+	}
+}
+
+// Sites are the sites of the languages of a multilingual site, or the only
+// site of the others.
+type Sites []*Site
+
+// NewSites returns a site for each of the Languages, or a single site if
+// there are none.
+func NewSites() Sites {
+	languages := Languages()
+	if len(languages) == 0 {
+		return Sites{&Site{}}
+	}
+
+	sites := make(Sites, len(languages))
+	for i, l := range languages {
+		sites[i] = &Site{Language: l}
+	}
+	return sites
+}
+
+// Build builds the sites, each with the config of its language. The pages
+// of all the languages are read before any is rendered, so they can link
+// to their translations.
+func (sites Sites) Build() error {
+	if len(sites) == 1 && sites[0].Language == nil {
+		return sites[0].Build()
+	}
+
+	for _, s := range sites {
+		restore := s.Language.apply()
+		s.stepStart = time.Now()
+		err := s.Process()
+		restore()
+		if err != nil {
+			return err
+		}
+	}
+
+	sites.linkTranslations()
+
+	for _, s := range sites {
+		restore := s.Language.apply()
+		err := s.renderProcessed()
+		restore()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkTranslations sets the translations of every page, the pages of the
+// other languages with the same translation key, and links the sites to
+// each other for CrossRef.
+func (sites Sites) linkTranslations() {
+	languages := make([]*Language, len(sites))
+	siblings := make(map[string]*SiteInfo)
+	byKey := make(map[string]Pages)
+	for i, s := range sites {
+		languages[i] = s.Language
+		siblings[s.Language.Lang] = &s.Info
+		for _, p := range s.Pages {
+			key := p.translationKey()
+			byKey[key] = append(byKey[key], p)
+		}
+	}
+
+	for _, s := range sites {
+		s.Info.Languages = languages
+		s.Info.siblings = siblings
+	}
+
+	for _, pages := range byKey {
+		for _, p := range pages {
+			p.translations = nil
+			for _, t := range pages {
+				if t != p {
+					p.translations = append(p.translations, t)
+				}
+			}
+		}
+	}
+}
+
+// translationKey returns the translationKey of the page's front matter, or
+// else its path in the content directory without the extension, e.g.
+// post/first for content/fr/post/first.md.
+func (p *Page) translationKey() string {
+	if p.transKey != "" {
+		return p.transKey
+	}
+	return filepath.ToSlash(filepath.Join(p.Source.Dir(), p.Source.BaseFileName()))
+}
+
+// Lang returns the language of the page, or "" if the site isn't
+// multilingual.
+func (p *Page) Lang() string {
+	if p.Site == nil || p.Site.Language == nil {
+		return ""
+	}
+	return p.Site.Language.Lang
+}
+
+// Translations returns the page in the other languages, in the order of
+// the languages, e.g. for a language switcher:
+//
+//	{{ range .Translations }}<a href="{{ .Permalink }}" hreflang="{{ .Lang }}">{{ .Site.Language.LanguageName }}</a>{{ end }}
+func (p *Page) Translations() Pages {
+	return p.translations
+}
+
+// IsTranslated returns whether the page is in other languages.
+func (p *Page) IsTranslated() bool {
+	return len(p.translations) > 0
+}
+
+// IsMultilingual returns whether the site is built in several languages.
+func (s *SiteInfo) IsMultilingual() bool {
+	return len(s.Languages) > 1
+}
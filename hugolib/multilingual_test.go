@@ -0,0 +1,208 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func setupLanguages() func() {
+	old := make(map[string]interface{})
+	for _, key := range []string{"BaseURL", "PublishDir", "Title", "Params", "DefaultContentLanguage", "Languages"} {
+		old[key] = viper.Get(key)
+	}
+
+	viper.Set("BaseURL", "http://example.com/")
+	viper.Set("PublishDir", "public")
+	viper.Set("Title", "My blog")
+	viper.Set("Params", map[string]interface{}{"subtitle": "News", "author": "Jo"})
+	viper.Set("DefaultContentLanguage", "en")
+	viper.Set("Languages", map[string]interface{}{
+		"fr": map[string]interface{}{
+			"weight":       2,
+			"languageName": "Français",
+			"title":        "Mon blog",
+			"params":       map[string]interface{}{"subtitle": "Nouvelles"},
+		},
+		"en": map[string]interface{}{"weight": 1},
+		"de": map[string]interface{}{"weight": 3, "baseURL": "http://example.de/"},
+	})
+	return func() {
+		for key, value := range old {
+			viper.Set(key, value)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	defer setupLanguages()()
+
+	languages := Languages()
+	if len(languages) != 3 {
+		t.Fatalf("Expected 3 languages, got %d", len(languages))
+	}
+	for i, this := range []struct {
+		lang, name, baseURL, publishDir string
+	}{
+		{"en", "en", "http://example.com/", ""},
+		{"fr", "Français", "http://example.com/fr/", filepath.Join("public", "fr")},
+		{"de", "de", "http://example.de/", filepath.Join("public", "de")},
+	} {
+		l := languages[i]
+		if l.Lang != this.lang || l.LanguageName != this.name || l.BaseURL != this.baseURL {
+			t.Errorf("[%d] Got the language %s %s %s, expected %s %s %s", i, l.Lang, l.LanguageName, l.BaseURL, this.lang, this.name, this.baseURL)
+		}
+		if publishDir, _ := l.settings["publishdir"].(string); publishDir != this.publishDir {
+			t.Errorf("[%d] Got the publishDir %q, expected %q", i, publishDir, this.publishDir)
+		}
+	}
+
+	restore := languages[1].apply()
+	if viper.GetString("Title") != "Mon blog" || viper.GetString("BaseURL") != "http://example.com/fr/" {
+		t.Errorf("Expected the config of the fr language, got %s %s", viper.GetString("Title"), viper.GetString("BaseURL"))
+	}
+	if params := viper.GetStringMap("Params"); params["subtitle"] != "Nouvelles" || params["author"] != "Jo" {
+		t.Errorf("Expected the params of the fr language over those of the site, got %v", params)
+	}
+	restore()
+	if viper.GetString("Title") != "My blog" || viper.GetString("BaseURL") != "http://example.com/" || viper.GetStringMap("Params")["subtitle"] != "News" {
+		t.Errorf("Expected the config of the site back, got %s %s %v", viper.GetString("Title"), viper.GetString("BaseURL"), viper.GetStringMap("Params"))
+	}
+}
+
+// processSites reads the sources of each language into its site, as
+// Sites.Build does before rendering them.
+func processSites(t *testing.T, sources map[string][]source.ByteSource) Sites {
+	sites := NewSites()
+	if len(sites) != 3 {
+		t.Fatalf("Expected a site per language, got %d", len(sites))
+	}
+	for _, s := range sites {
+		restore := s.Language.apply()
+		s.Source = &source.InMemorySource{ByteSource: sources[s.Language.Lang]}
+		if err := s.ProcessSource(); err != nil {
+			t.Fatalf("Unable to process the %s site: %s", s.Language.Lang, err)
+		}
+		restore()
+	}
+	sites.linkTranslations()
+	return sites
+}
+
+func TestLinkTranslations(t *testing.T) {
+	defer setupLanguages()()
+	viper.Set("DefaultExtension", "html")
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	sources := map[string][]source.ByteSource{
+		"en": {
+			{filepath.FromSlash("post/first.md"), []byte("---\ntitle: First\n---\nHello.")},
+			{filepath.FromSlash("post/second.md"), []byte("---\ntitle: Second\ntranslationKey: two\n---\nAgain.")},
+			{filepath.FromSlash("about.md"), []byte("---\ntitle: About\n---\nUs.")},
+		},
+		"fr": {
+			{filepath.FromSlash("post/first.md"), []byte("---\ntitle: Premier\n---\nBonjour.")},
+			{filepath.FromSlash("post/deuxieme.md"), []byte("---\ntitle: Deuxième\ntranslationKey: two\n---\nEncore.")},
+		},
+		"de": {
+			{filepath.FromSlash("post/first.md"), []byte("---\ntitle: Erste\n---\nHallo.")},
+		},
+	}
+
+	sites := processSites(t, sources)
+
+	pages := make(map[string]*Page)
+	for _, s := range sites {
+		if !s.Info.IsMultilingual() || len(s.Info.Languages) != 3 {
+			t.Errorf("Expected the %s site to have the 3 languages, got %v", s.Language.Lang, s.Info.Languages)
+		}
+		for _, p := range s.Pages {
+			pages[p.Lang()+":"+p.Title] = p
+		}
+	}
+
+	for _, this := range []struct {
+		page         string
+		translations []string
+	}{
+		{"en:First", []string{"fr:Premier", "de:Erste"}},
+		{"fr:Premier", []string{"en:First", "de:Erste"}},
+		{"de:Erste", []string{"en:First", "fr:Premier"}},
+		{"en:Second", []string{"fr:Deuxième"}},
+		{"fr:Deuxième", []string{"en:Second"}},
+		{"en:About", nil},
+	} {
+		p := pages[this.page]
+		if p == nil {
+			t.Fatalf("No page %s in %v", this.page, pages)
+		}
+		var translations []string
+		for _, tp := range p.Translations() {
+			translations = append(translations, tp.Lang()+":"+tp.Title)
+		}
+		if len(translations) != len(this.translations) || p.IsTranslated() != (len(this.translations) > 0) {
+			t.Errorf("%s has the translations %v, expected %v", this.page, translations, this.translations)
+			continue
+		}
+		for i := range translations {
+			if translations[i] != this.translations[i] {
+				t.Errorf("%s has the translations %v, expected %v", this.page, translations, this.translations)
+			}
+		}
+	}
+
+	if permalink, _ := pages["fr:Premier"].Permalink(); permalink != "http://example.com/fr/post/first/" {
+		t.Errorf("Expected the fr page below the fr baseURL, got %s", permalink)
+	}
+	if subtitle := pages["fr:Premier"].Site.Params["subtitle"]; subtitle != "Nouvelles" {
+		t.Errorf("Expected the params of the fr language, got %v", subtitle)
+	}
+}
+
+func TestCrossRef(t *testing.T) {
+	defer setupLanguages()()
+	viper.Set("DefaultExtension", "html")
+
+	sites := processSites(t, map[string][]source.ByteSource{
+		"en": {{filepath.FromSlash("post/first.md"), []byte("---\ntitle: First\n---\nHello.")}},
+		"fr": {{filepath.FromSlash("post/premier.md"), []byte("---\ntitle: Premier\n---\n## Bonjour\n")}},
+		"de": {{filepath.FromSlash("about.md"), []byte("---\ntitle: Über\nurl: /ueber/\n---\nWir.")}},
+	})
+	en := sites[0].Pages[0]
+
+	for i, this := range []struct {
+		site, ref, expected string
+	}{
+		{"fr", "post/premier.md", "http://example.com/fr/post/premier/"},
+		{"FR", "premier.md", "http://example.com/fr/post/premier/"},
+		{"de", "about.md", "http://example.de/ueber/"},
+		{"en", "post/first.md", "http://example.com/post/first/"},
+	} {
+		link, err := en.CrossRef(this.site, this.ref)
+		if err != nil {
+			t.Errorf("[%d] Unable to link to %s in %s: %s", i, this.ref, this.site, err)
+			continue
+		}
+		if link != this.expected {
+			t.Errorf("[%d] Got the link %s, expected %s", i, link, this.expected)
+		}
+	}
+
+	for i, this := range []struct{ site, ref string }{
+		{"es", "post/first.md"},
+		{"fr", "post/first.md"},
+		{"fr", "#bonjour"},
+	} {
+		if link, err := en.CrossRef(this.site, this.ref); err == nil {
+			t.Errorf("[%d] Expected an error linking to %s in %s, got %s", i, this.ref, this.site, link)
+		}
+	}
+
+	if link, err := (&SiteInfo{}).CrossRef("fr", "post/premier.md"); err == nil {
+		t.Errorf("Expected an error outside of a multi-site build, got %s", link)
+	}
+}
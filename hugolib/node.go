@@ -17,11 +17,16 @@ import (
 	"html/template"
 	"sync"
 	"time"
+
+	"github.com/spf13/hugo/helpers"
 )
 
 type Node struct {
-	RSSLink template.HTML
-	Site    *SiteInfo
+	RSSLink      template.HTML
+	AtomLink     template.HTML
+	JSONFeedLink template.HTML
+	CalendarLink template.HTML
+	Site         *SiteInfo
 	//	layout      string
 	Data        map[string]interface{}
 	Title       string
@@ -30,28 +35,23 @@ type Node struct {
 	Params      map[string]interface{}
 	Date        time.Time
 	Sitemap     Sitemap
+	Robots      string
 	UrlPath
 	paginator     *pager
 	paginatorInit sync.Once
 	scratch       *Scratch
+	section       *Page
 }
 
 func (n *Node) Now() time.Time {
 	return time.Now()
 }
 
+// HasMenuCurrent returns whether the node is below the menu entry, at any
+// depth.
 func (n *Node) HasMenuCurrent(menuID string, inme *MenuEntry) bool {
-	if inme.HasChildren() {
-		me := MenuEntry{Name: n.Title, Url: n.Url}
-
-		for _, child := range inme.Children {
-			if me.IsSameResource(child) {
-				return true
-			}
-		}
-	}
-
-	return false
+	me := MenuEntry{Name: n.Title, Url: n.Url}
+	return inme.hasDescendant(me.IsSameResource)
 }
 
 func (n *Node) IsMenuCurrent(menuID string, inme *MenuEntry) bool {
@@ -80,6 +80,23 @@ func (n *Node) IsMenuCurrent(menuID string, inme *MenuEntry) bool {
 	return false
 }
 
+// LanguageCode returns the language of the site.
+func (n *Node) LanguageCode() string {
+	return n.Site.LanguageCode
+}
+
+// LanguageDirection returns "rtl" if the site's language is written
+// right-to-left, else "ltr".
+func (n *Node) LanguageDirection() string {
+	return helpers.LanguageDirection(n.LanguageCode())
+}
+
+// FormatDate formats the Node's Date with month and day names in the
+// site's language.
+func (n *Node) FormatDate(layout string) string {
+	return helpers.FormatDate(n.Date, layout, n.LanguageCode())
+}
+
 func (n *Node) Hugo() *HugoInfo {
 	return hugoInfo
 }
@@ -103,6 +120,11 @@ func (n *Node) RSSlink() template.HTML {
 	return n.RSSLink
 }
 
+// CanonicalURL returns the permalink of the node.
+func (n *Node) CanonicalURL() string {
+	return string(n.Permalink)
+}
+
 func (n *Node) IsNode() bool {
 	return true
 }
@@ -119,6 +141,10 @@ func (n *Node) RelRef(ref string) (string, error) {
 	return n.Site.RelRef(ref, nil)
 }
 
+func (n *Node) CrossRef(site, ref string) (string, error) {
+	return n.Site.CrossRef(site, ref)
+}
+
 type UrlPath struct {
 	Url       string
 	Permalink template.HTML
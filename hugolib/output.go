@@ -0,0 +1,282 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+	bp "github.com/spf13/hugo/bufferpool"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/target"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// An OutputFormat is a format the pages are written in, from its own
+// templates, e.g. _default/single.json for the json format.
+type OutputFormat struct {
+	Name      string
+	MediaType string
+
+	// Suffix is the extension of the files and of the templates.
+	Suffix string
+
+	// BaseName is the name of the file in the page's directory, e.g.
+	// post/first/index.json.
+	BaseName string
+
+	// Ugly formats are written next to the page's directory instead, e.g.
+	// post/first.txt.
+	Ugly bool
+}
+
+var htmlOutputFormat = OutputFormat{Name: "html", MediaType: "text/html", Suffix: "html", BaseName: "index"}
+
+var builtinOutputFormats = map[string]OutputFormat{
+	"html": htmlOutputFormat,
+	"json": {Name: "json", MediaType: "application/json", Suffix: "json", BaseName: "index"},
+	"txt":  {Name: "txt", MediaType: "text/plain", Suffix: "txt", BaseName: "index"},
+}
+
+// outputFormats returns the formats the pages are written in, HTML first
+// and then those of the formats in Outputs. OutputFormats defines new
+// formats or changes the built-in ones:
+//
+//	outputs = ["json", "txt"]
+//
+//	[outputFormats.json]
+//	baseName = "page"
+//	[outputFormats.txt]
+//	ugly = true
+func outputFormats(formats map[string]OutputFormat) []OutputFormat {
+	outputs, unknown := selectOutputFormats(formats, cast.ToStringSlice(viper.Get("Outputs")))
+	for _, name := range unknown {
+		jww.ERROR.Printf("Unknown output format %q\n", name)
+	}
+	return outputs
+}
+
+// definedOutputFormats returns the built-in formats and those of
+// OutputFormats, by name.
+func definedOutputFormats() map[string]OutputFormat {
+	formats := make(map[string]OutputFormat)
+	for name, f := range builtinOutputFormats {
+		formats[name] = f
+	}
+
+	for name, v := range viper.GetStringMap("OutputFormats") {
+		name = strings.ToLower(name)
+		if name == "html" {
+			jww.ERROR.Println("The html output format follows UglyURLs and cannot be changed")
+			continue
+		}
+		f, ok := formats[name]
+		if !ok {
+			f = OutputFormat{Name: name, Suffix: name, BaseName: "index"}
+		}
+		for key, value := range cast.ToStringMap(v) {
+			switch strings.ToLower(key) {
+			case "mediatype":
+				f.MediaType = cast.ToString(value)
+			case "suffix":
+				f.Suffix = strings.TrimPrefix(cast.ToString(value), ".")
+			case "basename":
+				f.BaseName = cast.ToString(value)
+			case "ugly":
+				f.Ugly = cast.ToBool(value)
+			default:
+				jww.WARN.Printf("Unknown OutputFormat field: %s\n", key)
+			}
+		}
+		formats[name] = f
+	}
+	return formats
+}
+
+// selectOutputFormats returns HTML and the formats named, and the names
+// that are not formats.
+func selectOutputFormats(formats map[string]OutputFormat, names []string) (outputs []OutputFormat, unknown []string) {
+	outputs = []OutputFormat{htmlOutputFormat}
+	for _, name := range names {
+		f, ok := formats[strings.ToLower(name)]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if f.Name != "html" {
+			outputs = append(outputs, f)
+		}
+	}
+	return outputs, unknown
+}
+
+// targetPath returns the file of a page in the format, from the file of its
+// HTML, e.g. post/first/index.html, or post/first.html with UglyURLs.
+func (f OutputFormat) targetPath(htmlPath string) string {
+	if f.Name == "html" {
+		return htmlPath
+	}
+
+	dir, file := filepath.Split(htmlPath)
+	if strings.TrimSuffix(file, filepath.Ext(file)) != "index" {
+		return strings.TrimSuffix(htmlPath, filepath.Ext(htmlPath)) + "." + f.Suffix
+	}
+
+	dir = strings.TrimSuffix(dir, helpers.FilePathSeparator)
+	if f.Ugly && dir != "" {
+		return dir + "." + f.Suffix
+	}
+	return filepath.Join(dir, f.BaseName+"."+f.Suffix)
+}
+
+// layouts returns the templates of the format for the HTML templates, e.g.
+// post/single.json for post/single.html.
+func (f OutputFormat) layouts(htmlLayouts []string) []string {
+	layouts := make([]string, len(htmlLayouts))
+	for i, l := range htmlLayouts {
+		layouts[i] = strings.TrimSuffix(l, ".html") + "." + f.Suffix
+	}
+	return layouts
+}
+
+// A PageOutputFormat is a page in one of its output formats.
+type PageOutputFormat struct {
+	OutputFormat
+	page *Page
+}
+
+// PageOutputFormats are the output formats of a page.
+type PageOutputFormats []*PageOutputFormat
+
+// Get returns the output format with the name, or nil.
+func (o PageOutputFormats) Get(name string) *PageOutputFormat {
+	name = strings.ToLower(name)
+	for _, f := range o {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// OutputFormats returns the formats the page is written in, e.g. for
+// alternate links:
+//
+//	{{ with .OutputFormats.Get "json" }}<link rel="alternate" type="{{ .MediaType }}" href="{{ .Permalink }}">{{ end }}
+func (p *Page) OutputFormats() PageOutputFormats {
+	formats := p.outputFormats()
+	o := make(PageOutputFormats, len(formats))
+	for i, f := range formats {
+		o[i] = &PageOutputFormat{OutputFormat: f, page: p}
+	}
+	return o
+}
+
+// outputFormats returns the formats of the page, those of the site unless
+// the page lists its own in its outputs front matter.
+func (p *Page) outputFormats() []OutputFormat {
+	p.outputFormatsInit.Do(func() {
+		switch {
+		case p.Site == nil:
+			p.formats = []OutputFormat{htmlOutputFormat}
+		case p.outputs != nil:
+			var unknown []string
+			p.formats, unknown = selectOutputFormats(p.Site.formatDefs, p.outputs)
+			for _, name := range unknown {
+				jww.ERROR.Printf("Unknown output format %q in %s\n", name, p.FullFilePath())
+			}
+		case len(p.Site.outputFormats) > 0:
+			p.formats = p.Site.outputFormats
+		default:
+			p.formats = []OutputFormat{htmlOutputFormat}
+		}
+	})
+	return p.formats
+}
+
+// htmlTargetPath returns the file the page's HTML is written to.
+func (p *Page) htmlTargetPath() string {
+	pub := &target.PagePub{UglyURLs: viper.GetBool("UglyURLs")}
+	dest, _ := pub.Translate(p.TargetPath())
+	return dest
+}
+
+// Permalink returns the permalink of the page in the format.
+func (o *PageOutputFormat) Permalink() string {
+	if o.Name == "html" {
+		permalink, _ := o.page.Permalink()
+		return permalink
+	}
+	return helpers.MakePermalink(string(o.page.Site.BaseUrl), filepath.ToSlash(o.targetPath(o.page.htmlTargetPath()))).String()
+}
+
+// RelPermalink returns the permalink of the page in the format, relative
+// to the host.
+func (o *PageOutputFormat) RelPermalink() string {
+	if o.Name == "html" {
+		permalink, _ := o.page.RelPermalink()
+		return permalink
+	}
+	rel := "/" + strings.TrimPrefix(filepath.ToSlash(o.targetPath(o.page.htmlTargetPath())), "/")
+	if rootRelativeURLs() {
+		return rel
+	}
+	return helpers.AddContextRoot(string(o.page.Site.BaseUrl), rel)
+}
+
+// renderPageFormat writes the page in the format from its templates, e.g.
+// _default/single.json, if there are any. Without templates, the txt format
+// is the title and the content of the page as plain text.
+func (s *Site) renderPageFormat(p *Page, f OutputFormat) error {
+	layouts := s.appendThemeTemplates(f.layouts(append(p.Layout(), "_default/single.html")))
+
+	b := bp.GetBuffer()
+	defer bp.PutBuffer(b)
+
+	name := "page " + p.FullFilePath() + " as " + f.Name
+	switch {
+	case s.layoutExists(layouts...):
+		if err := s.render(name, p, b, layouts...); err != nil {
+			if err == errRenderSkipped {
+				return nil
+			}
+			return err
+		}
+	case f.Name == "txt":
+		b.WriteString(p.plainText())
+	default:
+		return nil
+	}
+
+	dest := f.targetPath(p.htmlTargetPath())
+	if err := s.WriteDestFile(dest, bytes.NewReader(minify(f.MediaType, b.Bytes()))); err != nil {
+		return err
+	}
+	helpers.BuildLog.Rendered(dest, name)
+	return nil
+}
+
+// plainText returns the page as plain text, its title underlined and then
+// its content.
+func (p *Page) plainText() string {
+	text := helpers.HTMLToText(string(p.Content)) + "\n"
+	if p.Title == "" {
+		return text
+	}
+	return p.Title + "\n" + strings.Repeat("=", utf8.RuneCountInString(p.Title)) + "\n\n" + text
+}
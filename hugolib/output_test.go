@@ -0,0 +1,182 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"github.com/spf13/viper"
+)
+
+func TestOutputFormatTargetPath(t *testing.T) {
+	json := builtinOutputFormats["json"]
+	page := OutputFormat{Name: "json", Suffix: "json", BaseName: "page"}
+	txt := OutputFormat{Name: "txt", Suffix: "txt", BaseName: "index", Ugly: true}
+
+	for i, this := range []struct {
+		format   OutputFormat
+		html     string
+		expected string
+	}{
+		{json, "post/first/index.html", "post/first/index.json"},
+		{json, "post/first.html", "post/first.json"},
+		{page, "post/first/index.html", "post/first/page.json"},
+		{page, "post/first.html", "post/first.json"},
+		{txt, "post/first/index.html", "post/first.txt"},
+		{txt, "index.html", "index.txt"},
+		{htmlOutputFormat, "post/first/index.html", "post/first/index.html"},
+	} {
+		if path := this.format.targetPath(filepath.FromSlash(this.html)); path != filepath.FromSlash(this.expected) {
+			t.Errorf("[%d] %s of %s got %s, expected %s", i, this.format.Name, this.html, path, this.expected)
+		}
+	}
+}
+
+func TestOutputFormatsConfig(t *testing.T) {
+	viper.Set("Outputs", []string{"json", "html", "ics", "txt"})
+	viper.Set("OutputFormats", map[string]interface{}{
+		"json": map[string]interface{}{"baseName": "page"},
+		"ics":  map[string]interface{}{"mediaType": "text/calendar"},
+	})
+	defer viper.Set("Outputs", nil)
+	defer viper.Set("OutputFormats", nil)
+
+	formats := outputFormats(definedOutputFormats())
+	expected := []OutputFormat{
+		htmlOutputFormat,
+		{Name: "json", MediaType: "application/json", Suffix: "json", BaseName: "page"},
+		{Name: "ics", MediaType: "text/calendar", Suffix: "ics", BaseName: "index"},
+		builtinOutputFormats["txt"],
+	}
+	if len(formats) != len(expected) {
+		t.Fatalf("Got %d output formats, expected %d: %v", len(formats), len(expected), formats)
+	}
+	for i, f := range formats {
+		if f != expected[i] {
+			t.Errorf("[%d] got %v, expected %v", i, f, expected[i])
+		}
+	}
+}
+
+func TestPageOutputFormats(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("CanonifyURLs", false)
+	viper.Set("Outputs", []string{"json", "txt"})
+	viper.Set("OutputFormats", map[string]interface{}{
+		"json": map[string]interface{}{"baseName": "page"},
+		"txt":  map[string]interface{}{"ugly": true},
+	})
+	defer viper.Set("DefaultExtension", nil)
+	defer viper.Set("Outputs", nil)
+	defer viper.Set("OutputFormats", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: Doc1\n---\nDoc1.")},
+	})
+	s.Targets.Page = &target.PagePub{}
+
+	must(s.addTemplate("_default/single.html", `{{ range .OutputFormats }}{{ .Name }} {{ .MediaType }} {{ .Permalink }} {{ .RelPermalink }}
+{{ end }}`))
+	must(s.addTemplate("_default/single.json", `{"title":{{ jsonify .Title }},"html":{{ jsonify (.OutputFormats.Get "html").Permalink }}}`))
+
+	createAndRenderPages(t, s)
+
+	for _, this := range []struct {
+		file     string
+		expected string
+	}{
+		{"sect/doc1/index.html", "html text/html http://auth/bub/sect/doc1/ /bub/sect/doc1/\n" +
+			"json application/json http://auth/bub/sect/doc1/page.json /bub/sect/doc1/page.json\n" +
+			"txt text/plain http://auth/bub/sect/doc1.txt /bub/sect/doc1.txt\n"},
+		{"sect/doc1/page.json", `{"title":"Doc1","html":"http://auth/bub/sect/doc1/"}`},
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(this.file))
+		if err != nil {
+			t.Errorf("Unable to locate: %s", this.file)
+			continue
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != this.expected {
+			t.Errorf("%s content is\n%q\nexpected\n%q", this.file, content, this.expected)
+		}
+	}
+
+	// there is no txt template, the page is written as plain text
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("sect/doc1.txt"))
+	if err != nil {
+		t.Fatalf("Unable to locate: sect/doc1.txt")
+	}
+	if content := string(helpers.ReaderToBytes(file)); content != "Doc1\n====\n\nDoc1.\n" {
+		t.Errorf("sect/doc1.txt content is %q", content)
+	}
+}
+
+func TestPageOutputsFrontMatter(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("Outputs", []string{"json"})
+	viper.Set("OutputFormats", map[string]interface{}{
+		"ics": map[string]interface{}{"mediaType": "text/calendar"},
+	})
+	defer viper.Set("DefaultExtension", nil)
+	defer viper.Set("Outputs", nil)
+	defer viper.Set("OutputFormats", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/site.md"), []byte("---\ntitle: Site\n---\nSite.")},
+		{filepath.FromSlash("sect/own.md"), []byte("---\ntitle: Own\noutputs: [txt, ics]\n---\nOwn.")},
+		{filepath.FromSlash("sect/none.md"), []byte("---\ntitle: None\noutputs: []\n---\nNone.")},
+	})
+	s.Targets.Page = &target.PagePub{}
+
+	must(s.addTemplate("_default/single.html", `{{ range .OutputFormats }}{{ .Name }} {{ end }}`))
+	must(s.addTemplate("_default/single.json", `json`))
+	must(s.addTemplate("_default/single.txt", `txt`))
+	must(s.addTemplate("_default/single.ics", `ics`))
+
+	createAndRenderPages(t, s)
+
+	for _, this := range []struct {
+		file     string
+		expected string
+	}{
+		{"sect/site/index.html", "html json "},
+		{"sect/site/index.json", "json"},
+		{"sect/own/index.html", "html txt ics "},
+		{"sect/own/index.txt", "txt"},
+		{"sect/own/index.ics", "ics"},
+		{"sect/none/index.html", "html "},
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(this.file))
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", this.file)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != this.expected {
+			t.Errorf("%s content is %q, expected %q", this.file, content, this.expected)
+		}
+	}
+
+	for _, file := range []string{"sect/site/index.txt", "sect/own/index.json", "sect/none/index.json"} {
+		if _, err := hugofs.DestinationFS.Open(filepath.FromSlash(file)); err == nil {
+			t.Errorf("%s should not be written", file)
+		}
+	}
+}
+
+func TestSelectOutputFormatsUnknown(t *testing.T) {
+	outputs, unknown := selectOutputFormats(definedOutputFormats(), []string{"txt", "nosuch", "HTML"})
+	if len(outputs) != 2 || outputs[0] != htmlOutputFormat || outputs[1] != builtinOutputFormats["txt"] {
+		t.Errorf("Unexpected output formats %v", outputs)
+	}
+	if len(unknown) != 1 || unknown[0] != "nosuch" {
+		t.Errorf("Expected nosuch to be unknown, got %v", unknown)
+	}
+}
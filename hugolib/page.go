@@ -58,23 +58,37 @@ type Page struct {
 
 	extension           string
 	contentType         string
+	canonicalURL        string
+	encrypt             bool
+	password            string
 	renderable          bool
 	layout              string
 	linkTitle           string
 	frontmatter         []byte
 	rawContent          []byte
 	contentShortCodes   map[string]string
-	plain               string // TODO should be []byte
+	includes            []string // the files being included, for the include shortcode
+	plain               string   // TODO should be []byte
 	plainWords          []string
 	plainInit           sync.Once
 	renderingConfig     *helpers.Blackfriday
 	renderingConfigInit sync.Once
+	markupParams        map[string]interface{} // the markup block of the front matter
+	markupConfig        *helpers.Markup
+	markupConfigInit    sync.Once
 	PageMeta
 	Source
 	Position
 	Node
-	pageMenus     PageMenus
-	pageMenusInit sync.Once
+	pageMenus         PageMenus
+	pageMenusInit     sync.Once
+	outputs           []string // the outputs front matter, nil if unset
+	formats           []OutputFormat
+	outputFormatsInit sync.Once
+	parentSection     *Page
+	subSections       Pages
+	transKey          string // the translationKey front matter
+	translations      Pages
 }
 
 type Source struct {
@@ -133,9 +147,8 @@ func (p *Page) Author() Author {
 }
 
 func (p *Page) Authors() AuthorList {
-	authorKeys, ok := p.Params["authors"]
-	authors := authorKeys.([]string)
-	if !ok || len(authors) < 1 || len(p.Site.Authors) < 1 {
+	authors := cast.ToStringSlice(p.Params["authors"])
+	if len(authors) < 1 || len(p.Site.Authors) < 1 {
 		return AuthorList{}
 	}
 
@@ -149,6 +162,27 @@ func (p *Page) Authors() AuthorList {
 	return al
 }
 
+// LanguageCode returns the language of the page: the languagecode
+// set in front matter, else the site's.
+func (p *Page) LanguageCode() string {
+	if lang, ok := p.Params["languagecode"].(string); ok && lang != "" {
+		return lang
+	}
+	return p.Site.LanguageCode
+}
+
+// LanguageDirection returns "rtl" if the page's language is written
+// right-to-left, else "ltr".
+func (p *Page) LanguageDirection() string {
+	return helpers.LanguageDirection(p.LanguageCode())
+}
+
+// FormatDate formats the Page's Date with month and day names in the
+// page's language.
+func (p *Page) FormatDate(layout string) string {
+	return helpers.FormatDate(p.Date, layout, p.LanguageCode())
+}
+
 func (p *Page) UniqueID() string {
 	return p.Source.UniqueID()
 }
@@ -161,6 +195,10 @@ func (p *Page) RelRef(ref string) (string, error) {
 	return p.Node.Site.RelRef(ref, p)
 }
 
+func (p *Page) CrossRef(site, ref string) (string, error) {
+	return p.Node.Site.CrossRef(site, ref)
+}
+
 // for logging
 func (p *Page) lineNumRawContentStart() int {
 	return bytes.Count(p.frontmatter, []byte("\n")) + 1
@@ -200,35 +238,69 @@ func (p *Page) setSummary() {
 func (p *Page) renderBytes(content []byte) []byte {
 	return helpers.RenderBytes(
 		&helpers.RenderingContext{Content: content, PageFmt: p.guessMarkupType(),
-			DocumentID: p.UniqueID(), Config: p.getRenderingConfig()})
+			DocumentID: p.UniqueID(), Config: p.getRenderingConfig(), Markup: p.getMarkupConfig(), RenderHook: p.renderHook})
 }
 
 func (p *Page) renderContent(content []byte) []byte {
 	return helpers.RenderBytesWithTOC(&helpers.RenderingContext{Content: content, PageFmt: p.guessMarkupType(),
-		DocumentID: p.UniqueID(), Config: p.getRenderingConfig()})
+		DocumentID: p.UniqueID(), Config: p.getRenderingConfig(), Markup: p.getMarkupConfig(), RenderHook: p.renderHook})
 }
 
-func (p *Page) getRenderingConfig() *helpers.Blackfriday {
-
-	p.renderingConfigInit.Do(func() {
-		pageParam := p.GetParam("blackfriday")
-		siteParam := viper.GetStringMap("blackfriday")
+// renderHook executes the _markup/render-{kind}.html template, if the
+// site or theme has one, to render links, images and headings in markdown.
+func (p *Page) renderHook(kind string, ctx interface{}) (string, bool) {
+	if p.Tmpl == nil {
+		return "", false
+	}
 
-		combinedParam := siteParam
+	switch c := ctx.(type) {
+	case *helpers.LinkContext:
+		// shortcode placeholders would not survive the url escaping
+		if strings.Contains(c.Destination, shortcodePlaceholderPrefix) {
+			return "", false
+		}
+		c.Page = p
+	case *helpers.HeadingContext:
+		c.Page = p
+	case *helpers.CodeBlockContext:
+		c.Page = p
+	}
 
-		if pageParam != nil {
-			combinedParam = make(map[string]interface{})
+	names := []string{"_markup/render-" + kind + ".html"}
+	// a hook for the language of a code block wins, e.g. render-codeblock-mermaid.html
+	if c, ok := ctx.(*helpers.CodeBlockContext); ok && c.Lang != "" {
+		names = append([]string{"_markup/render-" + kind + "-" + strings.ToLower(c.Lang) + ".html"}, names...)
+	}
 
-			for k, v := range siteParam {
-				combinedParam[k] = v
+	for _, name := range names {
+		for _, layout := range []string{name, "theme/" + name} {
+			if t := p.Tmpl.Lookup(layout); t != nil {
+				b := bp.GetBuffer()
+				defer bp.PutBuffer(b)
+				if err := t.Execute(b, ctx); err != nil {
+					jww.ERROR.Printf("Failed to execute %s for %s: %s", layout, p.BaseFileName(), err)
+					return "", false
+				}
+				return strings.TrimSpace(b.String()), true
 			}
+		}
+	}
+
+	return "", false
+}
 
-			pageConfig := cast.ToStringMap(pageParam)
+func (p *Page) getRenderingConfig() *helpers.Blackfriday {
 
-			for key, value := range pageConfig {
-				combinedParam[key] = value
+	p.renderingConfigInit.Do(func() {
+		// the blackfriday options of the page, then those of its markup
+		// block, replace those of the site
+		combinedParam := make(map[string]interface{})
+		for _, param := range []interface{}{viper.GetStringMap("blackfriday"), p.GetParam("blackfriday"), p.markupParams["blackfriday"]} {
+			for key, value := range cast.ToStringMap(param) {
+				combinedParam[strings.ToLower(key)] = value
 			}
 		}
+
 		p.renderingConfig = helpers.NewBlackfriday()
 		if err := mapstructure.Decode(combinedParam, p.renderingConfig); err != nil {
 			jww.FATAL.Printf("Failed to get rendering config for %s:\n%s", p.BaseFileName(), err.Error())
@@ -238,6 +310,30 @@ func (p *Page) getRenderingConfig() *helpers.Blackfriday {
 	return p.renderingConfig
 }
 
+// getMarkupConfig returns the markup options of the site, overridden by
+// those of the markup block of the front matter, e.g.
+//
+//	markup:
+//	  highlightCodeFences: true
+//	  pygmentsStyle: github
+//	  blackfriday:
+//	    extensions: [hardLineBreak]
+func (p *Page) getMarkupConfig() *helpers.Markup {
+	p.markupConfigInit.Do(func() {
+		p.markupConfig = helpers.NewMarkup()
+		params := make(map[string]interface{})
+		for key, value := range p.markupParams {
+			if key = strings.ToLower(key); key != "format" && key != "blackfriday" {
+				params[key] = value
+			}
+		}
+		if err := mapstructure.WeakDecode(params, p.markupConfig); err != nil {
+			jww.ERROR.Printf("Invalid markup options in %s: %s", p.BaseFileName(), err)
+		}
+	})
+	return p.markupConfig
+}
+
 func newPage(filename string) *Page {
 	page := Page{contentType: "",
 		Source: Source{File: *source.NewFile(filename)},
@@ -268,32 +364,48 @@ func (p *Page) Section() string {
 	return p.Source.Section()
 }
 
+// Layout returns the templates looked up to render the page, of which the
+// first found is used: the given layout, e.g. a view, else the layout of
+// the front matter or single, for the type of the page, its section when
+// the type is set in the front matter, then _default. A layout of the
+// front matter falls back to the single templates.
 func (p *Page) Layout(l ...string) []string {
-	if p.layout != "" {
-		return layouts(p.Type(), p.layout)
+	types := []string{p.Type()}
+	if p.contentType != "" && p.Section() != "" {
+		types = append(types, p.Section())
 	}
 
-	layout := ""
-	if len(l) == 0 {
-		layout = "single"
-	} else {
-		layout = l[0]
+	if len(l) > 0 && l[0] != "" {
+		return layouts(types, l[0])
 	}
-
-	return layouts(p.Type(), layout)
+	if p.layout != "" && p.layout != "single" {
+		return layouts(types, p.layout, "single")
+	}
+	return layouts(types, "single")
 }
 
-func layouts(types string, layout string) (layouts []string) {
-	t := strings.Split(types, "/")
-
-	// Add type/layout.html
-	for i := range t {
-		search := t[:len(t)-i]
-		layouts = append(layouts, fmt.Sprintf("%s/%s.html", strings.ToLower(path.Join(search...)), layout))
+func layouts(types []string, names ...string) (layouts []string) {
+	seen := make(map[string]bool)
+	add := func(layout string) {
+		if !seen[layout] {
+			seen[layout] = true
+			layouts = append(layouts, layout)
+		}
 	}
 
-	// Add _default/layout.html
-	layouts = append(layouts, fmt.Sprintf("_default/%s.html", layout))
+	for _, layout := range names {
+		// Add type/layout.html, for each of the types
+		for _, types := range types {
+			t := strings.Split(types, "/")
+			for i := range t {
+				search := t[:len(t)-i]
+				add(fmt.Sprintf("%s/%s.html", strings.ToLower(path.Join(search...)), layout))
+			}
+		}
+
+		// Add _default/layout.html
+		add(fmt.Sprintf("_default/%s.html", layout))
+	}
 
 	// Add theme/type/layout.html & theme/_default/layout.html
 	for _, l := range layouts {
@@ -340,6 +452,39 @@ func (p *Page) analyzePage() {
 	p.ReadingTime = int((p.WordCount + 212) / 213)
 }
 
+// encryptContent replaces the content of a page with a password by the
+// encrypted content, leaving nothing of it in the summary, the plain text
+// or the word count and reading time derived from it.
+// Pages with encrypt set use the EncryptPassword of the site.
+func (p *Page) encryptContent() error {
+	if !p.encrypt && p.password == "" {
+		return nil
+	}
+
+	password := p.password
+	if password == "" {
+		password = viper.GetString("EncryptPassword")
+	}
+	if password == "" {
+		return fmt.Errorf("No password to encrypt %s with, set one in its front matter or EncryptPassword in the site config", p.File.Path())
+	}
+
+	content, err := helpers.EncryptHTML([]byte(p.Content), password, p.File.Path())
+	if err != nil {
+		return err
+	}
+	p.Content = content
+	p.TableOfContents = ""
+	p.Summary = ""
+	p.Truncated = true
+	p.plain = ""
+	p.plainWords = nil
+	p.WordCount = 0
+	p.FuzzyWordCount = 0
+	p.ReadingTime = 0
+	return nil
+}
+
 func (p *Page) permalink() (*url.URL, error) {
 	baseURL := string(p.Site.BaseUrl)
 	dir := strings.TrimSpace(filepath.ToSlash(p.Source.Dir()))
@@ -352,12 +497,13 @@ func (p *Page) permalink() (*url.URL, error) {
 		return helpers.MakePermalink(baseURL, pURL), nil
 	}
 
-	if override, ok := p.Site.Permalinks[p.Section()]; ok {
+	if override, ok := p.Site.Permalinks.patternFor(p); ok {
 		permalink, err = override.Expand(p)
 
 		if err != nil {
 			return nil, err
 		}
+		permalink = p.uglifyPattern(permalink)
 		// fmt.Printf("have a section override for %q in section %s → %s\n", p.Title, p.Section, permalink)
 	} else {
 		if len(pSlug) > 0 {
@@ -413,20 +559,47 @@ func (p *Page) Permalink() (string, error) {
 	return link.String(), nil
 }
 
+// CanonicalURL returns the canonicalURL set in the front matter, e.g. for
+// content first published elsewhere, or else the page's permalink.
+func (p *Page) CanonicalURL() (string, error) {
+	if p.canonicalURL == "" {
+		return p.Permalink()
+	}
+	if strings.Contains(p.canonicalURL, "://") || strings.HasPrefix(p.canonicalURL, "//") {
+		return p.canonicalURL, nil
+	}
+	return helpers.MakePermalink(string(p.Site.BaseUrl), p.canonicalURL).String(), nil
+}
+
+// canonicalElsewhere reports whether the canonical version of the page
+// lives at another URL.
+func (p *Page) canonicalElsewhere() bool {
+	if p.canonicalURL == "" {
+		return false
+	}
+	canonical, _ := p.CanonicalURL()
+	permalink, _ := p.Permalink()
+	return canonical != permalink
+}
+
 func (p *Page) RelPermalink() (string, error) {
 	link, err := p.permalink()
 	if err != nil {
 		return "", err
 	}
 
-	if viper.GetBool("CanonifyURLs") {
+	if rootRelativeURLs() {
 		// replacements for relpermalink with baseUrl on the form http://myhost.com/sub/ will fail later on
 		// have to return the Url relative from baseUrl
 		relpath, err := helpers.GetRelativePath(link.String(), string(p.Site.BaseUrl))
 		if err != nil {
 			return "", err
 		}
-		return "/" + filepath.ToSlash(relpath), nil
+		relpath = "/" + filepath.ToSlash(relpath)
+		if strings.HasSuffix(link.Path, "/") && !strings.HasSuffix(relpath, "/") {
+			relpath += "/"
+		}
+		return relpath, nil
 	}
 
 	link.Scheme = ""
@@ -442,6 +615,7 @@ func (p *Page) update(f interface{}) error {
 	}
 	m := f.(map[string]interface{})
 	var err error
+	var noIndex bool
 	for k, v := range m {
 		loki := strings.ToLower(k)
 		switch loki {
@@ -458,6 +632,12 @@ func (p *Page) update(f interface{}) error {
 				return fmt.Errorf("Only relative urls are supported, %v provided", url)
 			}
 			p.Url = helpers.URLize(cast.ToString(v))
+		case "canonicalurl":
+			p.canonicalURL = cast.ToString(v)
+		case "encrypt":
+			p.encrypt = cast.ToBool(v)
+		case "password":
+			p.password = cast.ToString(v)
 		case "type":
 			p.contentType = cast.ToString(v)
 		case "extension", "ext":
@@ -479,7 +659,16 @@ func (p *Page) update(f interface{}) error {
 		case "layout":
 			p.layout = cast.ToString(v)
 		case "markup":
-			p.Markup = cast.ToString(v)
+			// the format, or a block with the format and markup options
+			if _, ok := v.(string); ok {
+				p.Markup = cast.ToString(v)
+				break
+			}
+			p.markupParams = make(map[string]interface{})
+			for key, value := range cast.ToStringMap(v) {
+				p.markupParams[strings.ToLower(key)] = value
+			}
+			p.Markup = cast.ToString(p.markupParams["format"])
 		case "weight":
 			p.Weight = cast.ToInt(v)
 		case "aliases":
@@ -493,6 +682,17 @@ func (p *Page) update(f interface{}) error {
 			p.Status = cast.ToString(v)
 		case "sitemap":
 			p.Sitemap = parseSitemap(cast.ToStringMap(v))
+		case "robots":
+			p.Robots = parseRobots(v)
+		case "noindex":
+			noIndex = cast.ToBool(v)
+		case "translationkey":
+			p.transKey = cast.ToString(v)
+		case "outputs":
+			p.outputs = cast.ToStringSlice(v)
+			if p.outputs == nil {
+				p.outputs = []string{}
+			}
 		default:
 			// If not one of the explicit values, store in Params
 			switch vv := v.(type) {
@@ -520,6 +720,9 @@ func (p *Page) update(f interface{}) error {
 			}
 		}
 	}
+	if noIndex {
+		p.Robots = addNoIndex(p.Robots)
+	}
 	return nil
 
 }
@@ -554,21 +757,91 @@ func (p *Page) GetParam(key string) interface{} {
 	return nil
 }
 
-func (p *Page) HasMenuCurrent(menu string, me *MenuEntry) bool {
-	menus := p.Menus()
+// Param returns the value of key in the front matter of the page, else in
+// the params of the site config, so a theme setting can be changed for a
+// single page. Dotted keys look into tables as for .Site.GetParam.
+func (p *Page) Param(key string) interface{} {
+	if v := lookupParam(p.Params, key); v != nil {
+		return v
+	}
+	return p.Site.GetParam(key)
+}
 
-	if m, ok := menus[menu]; ok {
-		if me.HasChildren() {
-			for _, child := range me.Children {
-				if child.IsEqual(m) {
-					return true
-				}
+// taxonomyTerms returns the keys of the terms of the page in the given
+// taxonomy. Empty terms and terms given twice, e.g. "Go" and "go", are left
+// out.
+func (p *Page) taxonomyTerms(plural string) ([]string, error) {
+	var vals []string
+	switch v := p.GetParam(plural).(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		vals = v
+	case string:
+		vals = []string{v}
+	default:
+		return nil, fmt.Errorf("%s must be a string or a list of strings", plural)
+	}
+
+	var keys []string
+	for _, v := range vals {
+		key := kp(strings.TrimSpace(v))
+		if key != "" && !helpers.InStringArray(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// taxonomyWeight returns the weight of the page in the term, set either
+// for all the terms of the taxonomy, e.g. tags_weight = 10, or per term,
+// e.g. tags_weight = { go = 10, web = 20 }.
+func (p *Page) taxonomyWeight(plural, term string) int {
+	switch v := p.GetParam(plural + "_weight").(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		for k, w := range cast.ToStringMap(v) {
+			if kp(k) == term {
+				return cast.ToInt(w)
 			}
 		}
+		return 0
+	default:
+		return cast.ToInt(v)
 	}
+}
 
-	return false
+// GetTerms returns the term pages of the page in the given taxonomy, e.g.
+// {{ range .GetTerms "series" }}{{ .Params.cover }}{{ end }}. Terms without
+// an _index file get a bare page with just a title and a URL.
+func (p *Page) GetTerms(plural string) Pages {
+	var terms Pages
+
+	keys, _ := p.taxonomyTerms(plural)
+	for _, key := range keys {
+		if term, ok := p.Site.termPages[plural][key]; ok {
+			terms = append(terms, term)
+			continue
+		}
+		term := &Page{Node: Node{Site: p.Site, Params: make(map[string]interface{})}}
+		term.Title = strings.Replace(strings.Title(key), "-", " ", -1)
+		term.Url = helpers.URLizeAndPrep(plural + "/" + key)
+		terms = append(terms, term)
+	}
 
+	return terms
+}
+
+// HasMenuCurrent returns whether the page is below the menu entry, at any
+// depth, be it added to the menu in its front matter or by its URL in the
+// site config.
+func (p *Page) HasMenuCurrent(menu string, me *MenuEntry) bool {
+	m, inMenu := p.Menus()[menu]
+	link, _ := p.RelPermalink()
+	pme := &MenuEntry{Url: link}
+
+	return me.hasDescendant(func(child *MenuEntry) bool {
+		return (inMenu && child.IsEqual(m)) || (child.Menu == menu && pme.IsSameResource(child))
+	})
 }
 
 func (p *Page) IsMenuCurrent(menu string, inme *MenuEntry) bool {
@@ -676,6 +949,9 @@ func (p *Page) parse(reader io.Reader) error {
 			jww.ERROR.Println(err)
 			return err
 		}
+		if m, ok := meta.(map[string]interface{}); ok {
+			p.transformFrontMatter(m)
+		}
 		if err = p.update(meta); err != nil {
 			return err
 		}
@@ -783,6 +1059,15 @@ func (p *Page) FullFilePath() string {
 	return filepath.Join(p.Source.Dir(), p.Source.Path())
 }
 
+// uglifyPattern turns the directory of an expanded permalink pattern, e.g.
+// /2015/title/, into a file, /2015/title.html, with UglyURLs.
+func (p *Page) uglifyPattern(expanded string) string {
+	if !viper.GetBool("UglyURLs") || len(expanded) < 2 || !strings.HasSuffix(expanded, "/") {
+		return expanded
+	}
+	return strings.TrimSuffix(expanded, "/") + "." + p.Extension()
+}
+
 func (p *Page) TargetPath() (outfile string) {
 
 	// Always use Url if it's specified
@@ -797,10 +1082,11 @@ func (p *Page) TargetPath() (outfile string) {
 	}
 
 	// If there's a Permalink specification, we use that
-	if override, ok := p.Site.Permalinks[p.Section()]; ok {
+	if override, ok := p.Site.Permalinks.patternFor(p); ok {
 		var err error
 		outfile, err = override.Expand(p)
 		if err == nil {
+			outfile = p.uglifyPattern(outfile)
 			if strings.HasSuffix(outfile, "/") {
 				outfile += "index.html"
 			}
@@ -157,13 +157,10 @@ func (p Pages) GroupByParam(key string, order ...string) (PagesGroup, error) {
 	var tmp reflect.Value
 	var keyt reflect.Type
 	for _, e := range p {
-		param := e.GetParam(key)
-		if param != nil {
-			if _, ok := param.([]string); !ok {
-				keyt = reflect.TypeOf(param)
-				tmp = reflect.MakeMap(reflect.MapOf(keyt, reflect.SliceOf(pagePtrType)))
-				break
-			}
+		if values := groupParamValues(e.GetParam(key)); len(values) > 0 {
+			keyt = reflect.TypeOf(values[0])
+			tmp = reflect.MakeMap(reflect.MapOf(keyt, reflect.SliceOf(pagePtrType)))
+			break
 		}
 	}
 	if !tmp.IsValid() {
@@ -171,15 +168,16 @@ func (p Pages) GroupByParam(key string, order ...string) (PagesGroup, error) {
 	}
 
 	for _, e := range p {
-		param := e.GetParam(key)
-		if param == nil || reflect.TypeOf(param) != keyt {
-			continue
-		}
-		v := reflect.ValueOf(param)
-		if !tmp.MapIndex(v).IsValid() {
-			tmp.SetMapIndex(v, reflect.MakeSlice(reflect.SliceOf(pagePtrType), 0, 0))
+		for _, param := range groupParamValues(e.GetParam(key)) {
+			if reflect.TypeOf(param) != keyt {
+				continue
+			}
+			v := reflect.ValueOf(param)
+			if !tmp.MapIndex(v).IsValid() {
+				tmp.SetMapIndex(v, reflect.MakeSlice(reflect.SliceOf(pagePtrType), 0, 0))
+			}
+			tmp.SetMapIndex(v, reflect.Append(tmp.MapIndex(v), reflect.ValueOf(e)))
 		}
-		tmp.SetMapIndex(v, reflect.Append(tmp.MapIndex(v), reflect.ValueOf(e)))
 	}
 
 	var r []PageGroup
@@ -190,6 +188,27 @@ func (p Pages) GroupByParam(key string, order ...string) (PagesGroup, error) {
 	return r, nil
 }
 
+// groupParamValues returns the keys of the groups a param puts its page in:
+// every value of a list, such as the tags of a page, once, else the param
+// itself.
+func groupParamValues(param interface{}) []interface{} {
+	switch v := param.(type) {
+	case nil:
+		return nil
+	case []string:
+		var values []interface{}
+		seen := make(map[string]bool)
+		for _, s := range v {
+			if !seen[s] {
+				seen[s] = true
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return []interface{}{param}
+}
+
 func (p Pages) groupByDateField(sorter func(p Pages) Pages, formatter func(p *Page) string, order ...string) (PagesGroup, error) {
 	if len(p) < 1 {
 		return nil, nil
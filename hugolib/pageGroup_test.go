@@ -239,6 +239,26 @@ func TestGroupByParamCalledWithSomeUnavailableParams(t *testing.T) {
 	}
 }
 
+func TestGroupByParamWithListParams(t *testing.T) {
+	pages := preparePageGroupTestPages(t)
+	pages[0].Params["tags"] = []string{"go", "web"}
+	pages[1].Params["tags"] = []string{"web", "web"}
+	pages[2].Params["tags"] = "go"
+
+	expect := PagesGroup{
+		{Key: "go", Pages: Pages{pages[0], pages[2]}},
+		{Key: "web", Pages: Pages{pages[0], pages[1]}},
+	}
+
+	groups, err := pages.GroupByParam("tags")
+	if err != nil {
+		t.Fatalf("Unable to make PagesGroup array: %s", err)
+	}
+	if !reflect.DeepEqual(groups, expect) {
+		t.Errorf("PagesGroup has unexpected groups. It should be %#v, got %#v", expect, groups)
+	}
+}
+
 func TestGroupByParamCalledWithEmptyPages(t *testing.T) {
 	var pages Pages
 	groups, err := pages.GroupByParam("custom_param")
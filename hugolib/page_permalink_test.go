@@ -84,3 +84,78 @@ func TestPermalink(t *testing.T) {
 		}
 	}
 }
+
+func TestPermalinkPatternUgly(t *testing.T) {
+	viper.Set("DefaultExtension", "html")
+	defer viper.Set("uglyurls", false)
+
+	for _, test := range []struct {
+		uglyURLs   bool
+		expected   string
+		targetPath string
+	}{
+		{false, "/x/boofar/", "/x/boofar/index.html"},
+		{true, "/x/boofar.html", "/x/boofar.html"},
+	} {
+		viper.Set("uglyurls", test.uglyURLs)
+		p := &Page{
+			Node: Node{
+				UrlPath: UrlPath{Section: "z"},
+				Site:    &SiteInfo{Permalinks: PermalinkOverrides{"x": "/:section/:filename/"}},
+			},
+			Source: Source{File: *source.NewFile(filepath.FromSlash("x/y/z/boofar.md"))},
+		}
+
+		if u, _ := p.RelPermalink(); u != test.expected {
+			t.Errorf("UglyURLs %t: expected %s, got %s", test.uglyURLs, test.expected, u)
+		}
+		if target := p.TargetPath(); target != filepath.FromSlash(test.targetPath) {
+			t.Errorf("UglyURLs %t: expected target %s, got %s", test.uglyURLs, test.targetPath, target)
+		}
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		canonical string
+		expected  string
+		elsewhere bool
+	}{
+		{"", "http://barnew/x/y/z/boofar/", false},
+		{"http://elsewhere.org/post/", "http://elsewhere.org/post/", true},
+		{"/x/y/z/boofar/", "http://barnew/x/y/z/boofar/", false},
+		{"/other/", "http://barnew/other/", true},
+	}
+
+	viper.Set("DefaultExtension", "html")
+	viper.Set("uglyurls", false)
+
+	for i, test := range tests {
+		p := &Page{
+			Node: Node{
+				UrlPath: UrlPath{Section: "z"},
+				Site:    &SiteInfo{BaseUrl: "http://barnew/"},
+			},
+			Source: Source{File: *source.NewFile(filepath.FromSlash("x/y/z/boofar.md"))},
+		}
+
+		if test.canonical != "" {
+			p.update(map[string]interface{}{
+				"canonicalURL": test.canonical,
+			})
+		}
+
+		u, err := p.CanonicalURL()
+		if err != nil {
+			t.Errorf("Test %d: Unable to process canonical url: %s", i, err)
+		}
+
+		if u != test.expected {
+			t.Errorf("Test %d: Expected canonical url: %s, got: %s", i, test.expected, u)
+		}
+
+		if p.canonicalElsewhere() != test.elsewhere {
+			t.Errorf("Test %d: Expected canonicalElsewhere to be %t", i, test.elsewhere)
+		}
+	}
+}
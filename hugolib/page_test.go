@@ -10,6 +10,8 @@ import (
 
 	"github.com/spf13/cast"
 	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/tpl"
+	"github.com/spf13/viper"
 )
 
 var EMPTY_PAGE = ""
@@ -386,6 +388,70 @@ func TestPageWithAdditionalExtension(t *testing.T) {
 	checkPageContent(t, p, "<p>first line.<br />\nsecond line.</p>\n\n<p>fourth line.</p>\n")
 }
 
+func TestPageBlackfridayExtensionsReplaceSites(t *testing.T) {
+	viper.Set("blackfriday", map[string]interface{}{"extensions": []string{"hardLineBreak"}, "angledQuotes": true})
+	defer viper.Set("blackfriday", nil)
+
+	p, _ := NewPage("page.md")
+	err := p.ReadFrom(strings.NewReader(`---
+title: Page
+blackfriday:
+  extensions: [footnotes]
+markup:
+  blackfriday:
+    extensionsMask: [autolink]
+---
+Content`))
+	if err != nil {
+		t.Fatalf("Unable to create a page with blackfriday options: %s", err)
+	}
+
+	config := p.getRenderingConfig()
+	if !reflect.DeepEqual(config.Extensions, []string{"footnotes"}) {
+		t.Errorf("The extensions of the page should replace those of the site, got %v", config.Extensions)
+	}
+	if !reflect.DeepEqual(config.ExtensionsMask, []string{"autolink"}) {
+		t.Errorf("Expected the extensions mask of the markup block, got %v", config.ExtensionsMask)
+	}
+	if !config.AngledQuotes {
+		t.Errorf("The options the page doesn't set should be those of the site")
+	}
+}
+
+func TestPageWithMarkupOptions(t *testing.T) {
+	viper.Set("blackfriday", map[string]interface{}{"extensions": []string{"hardLineBreak"}})
+	defer viper.Set("blackfriday", nil)
+
+	p, _ := NewPage("poem.md")
+	err := p.ReadFrom(strings.NewReader(`---
+title: Poem
+markup:
+  format: markdown
+  highlightCodeFences: true
+  pygmentsUseClasses: true
+  blackfriday:
+    angledQuotes: true
+    extensionsMask: [autolink]
+---
+"Roses" are red,
+see http://example.com
+
+` + "```go\nfunc a() {}\n```\n"))
+	if err != nil {
+		t.Fatalf("Unable to create a page with markup options: %s", err)
+	}
+	p.Convert()
+
+	for _, expected := range []string{"&laquo;Roses&raquo; are red,<br />\nsee http://example.com</p>", `<span class="k">func</span>`} {
+		if !strings.Contains(string(p.Content), expected) {
+			t.Errorf("Content should contain %q:\n%s", expected, p.Content)
+		}
+	}
+	if p.guessMarkupType() != "markdown" {
+		t.Errorf("The markup format is %q", p.guessMarkupType())
+	}
+}
+
 func TestTableOfContents(t *testing.T) {
 	p, _ := NewPage("tocpage.md")
 	err := p.ReadFrom(strings.NewReader(PAGE_WITH_TOC))
@@ -397,6 +463,38 @@ func TestTableOfContents(t *testing.T) {
 	checkPageTOC(t, p, "<nav id=\"TableOfContents\">\n<ul>\n<li>\n<ul>\n<li><a href=\"#aa:90b9174a5bdb091a9625b04adac96ca6\">AA</a>\n<ul>\n<li><a href=\"#aaa:90b9174a5bdb091a9625b04adac96ca6\">AAA</a></li>\n<li><a href=\"#bbb:90b9174a5bdb091a9625b04adac96ca6\">BBB</a></li>\n</ul></li>\n</ul></li>\n</ul>\n</nav>")
 }
 
+func TestPageWithRenderHooks(t *testing.T) {
+	tem := tpl.New()
+	tem.AddTemplate("_markup/render-link.html", `<a href="{{ .Destination }}" data-page="{{ .Page.Title }}">{{ .Text }}</a>`)
+	tem.AddTemplate("theme/_markup/render-heading.html", `<h{{ .Level }} id="{{ .Anchor }}">{{ .Text }}</h{{ .Level }}>`)
+
+	p, _ := NewPage("simple.md")
+	p.Tmpl = tem
+	err := p.ReadFrom(strings.NewReader("---\ntitle: Simple\n---\n# Head\n\nSee [the docs](/docs/).\n"))
+	if err != nil {
+		t.Fatalf("Unable to create a page with frontmatter and body content: %s", err)
+	}
+	p.Convert()
+
+	checkPageContent(t, p, "\n\n<h1 id=\"head:bec3ed8ba720b9073ab75abcf3ba5d97\">Head</h1>\n\n<p>See <a href=\"/docs/\" data-page=\"Simple\">the docs</a>.</p>\n")
+}
+
+func TestPageWithCodeBlockRenderHooks(t *testing.T) {
+	tem := tpl.New()
+	tem.AddTemplate("_markup/render-codeblock-goat.html", `<svg>{{ .Code }}</svg>`)
+	tem.AddTemplate("theme/_markup/render-codeblock.html", `<pre data-lang="{{ .Lang }}">{{ .Code }}</pre>`)
+
+	p, _ := NewPage("simple.md")
+	p.Tmpl = tem
+	err := p.ReadFrom(strings.NewReader("---\ntitle: Simple\n---\n```GOAT\n+-->\n```\n\n```go\na < b\n```\n"))
+	if err != nil {
+		t.Fatalf("Unable to create a page with frontmatter and body content: %s", err)
+	}
+	p.Convert()
+
+	checkPageContent(t, p, "<svg>&#43;--&gt;\n</svg>\n\n<pre data-lang=\"go\">a &lt; b\n</pre>\n")
+}
+
 func TestPageWithMoreTag(t *testing.T) {
 	p, _ := NewPage("simple.md")
 	err := p.ReadFrom(strings.NewReader(SIMPLE_PAGE_WITH_SUMMARY_DELIMITER_SAME_LINE))
@@ -571,20 +669,20 @@ func TestLayoutOverride(t *testing.T) {
 		{SIMPLE_PAGE_NOLAYOUT, path_content_no_dir, L("page/single.html", "_default/single.html")},
 		{SIMPLE_PAGE_NOLAYOUT, path_one_directory, L("fub/single.html", "_default/single.html")},
 		{SIMPLE_PAGE_NOLAYOUT, path_no_directory, L("page/single.html", "_default/single.html")},
-		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_content_two_dir, L("dub/foobar.html", "_default/foobar.html")},
-		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_content_one_dir, L("gub/foobar.html", "_default/foobar.html")},
-		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_one_directory, L("fub/foobar.html", "_default/foobar.html")},
-		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_no_directory, L("page/foobar.html", "_default/foobar.html")},
-		{SIMPLE_PAGE_TYPE_FOOBAR, path_content_two_dir, L("foobar/single.html", "_default/single.html")},
-		{SIMPLE_PAGE_TYPE_FOOBAR, path_content_one_dir, L("foobar/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_content_two_dir, L("dub/foobar.html", "_default/foobar.html", "dub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_content_one_dir, L("gub/foobar.html", "_default/foobar.html", "gub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_one_directory, L("fub/foobar.html", "_default/foobar.html", "fub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_LAYOUT_FOOBAR, path_no_directory, L("page/foobar.html", "_default/foobar.html", "page/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_FOOBAR, path_content_two_dir, L("foobar/single.html", "dub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_FOOBAR, path_content_one_dir, L("foobar/single.html", "gub/single.html", "_default/single.html")},
 		{SIMPLE_PAGE_TYPE_FOOBAR, path_content_no_dir, L("foobar/single.html", "_default/single.html")},
-		{SIMPLE_PAGE_TYPE_FOOBAR, path_one_directory, L("foobar/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_FOOBAR, path_one_directory, L("foobar/single.html", "fub/single.html", "_default/single.html")},
 		{SIMPLE_PAGE_TYPE_FOOBAR, path_no_directory, L("foobar/single.html", "_default/single.html")},
-		{SIMPLE_PAGE_TYPE_LAYOUT, path_content_two_dir, L("barfoo/buzfoo.html", "_default/buzfoo.html")},
-		{SIMPLE_PAGE_TYPE_LAYOUT, path_content_one_dir, L("barfoo/buzfoo.html", "_default/buzfoo.html")},
-		{SIMPLE_PAGE_TYPE_LAYOUT, path_content_no_dir, L("barfoo/buzfoo.html", "_default/buzfoo.html")},
-		{SIMPLE_PAGE_TYPE_LAYOUT, path_one_directory, L("barfoo/buzfoo.html", "_default/buzfoo.html")},
-		{SIMPLE_PAGE_TYPE_LAYOUT, path_no_directory, L("barfoo/buzfoo.html", "_default/buzfoo.html")},
+		{SIMPLE_PAGE_TYPE_LAYOUT, path_content_two_dir, L("barfoo/buzfoo.html", "dub/buzfoo.html", "_default/buzfoo.html", "barfoo/single.html", "dub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_LAYOUT, path_content_one_dir, L("barfoo/buzfoo.html", "gub/buzfoo.html", "_default/buzfoo.html", "barfoo/single.html", "gub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_LAYOUT, path_content_no_dir, L("barfoo/buzfoo.html", "_default/buzfoo.html", "barfoo/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_LAYOUT, path_one_directory, L("barfoo/buzfoo.html", "fub/buzfoo.html", "_default/buzfoo.html", "barfoo/single.html", "fub/single.html", "_default/single.html")},
+		{SIMPLE_PAGE_TYPE_LAYOUT, path_no_directory, L("barfoo/buzfoo.html", "_default/buzfoo.html", "barfoo/single.html", "_default/single.html")},
 	}
 	for _, test := range tests {
 		p, _ := NewPage(test.path)
@@ -602,6 +700,22 @@ func TestLayoutOverride(t *testing.T) {
 	}
 }
 
+func TestLayoutOfView(t *testing.T) {
+	p, _ := NewPage(filepath.Join("content", "gub", "file1.md"))
+	if err := p.ReadFrom(strings.NewReader(SIMPLE_PAGE_TYPE_LAYOUT)); err != nil {
+		t.Fatalf("Unable to parse content:\n%s\n", SIMPLE_PAGE_TYPE_LAYOUT)
+	}
+
+	expected := L("barfoo/li.html", "gub/li.html", "_default/li.html",
+		"theme/barfoo/li.html", "theme/gub/li.html", "theme/_default/li.html")
+	if !listEqual(p.Layout("li"), expected) {
+		t.Errorf("Layout mismatch. Expected: %s, got: %s", expected, p.Layout("li"))
+	}
+	if !listEqual(p.Layout(""), p.Layout()) {
+		t.Errorf("An empty layout should be the layout of the page, got: %s", p.Layout(""))
+	}
+}
+
 func TestSliceToLower(t *testing.T) {
 	tests := []struct {
 		value    []string
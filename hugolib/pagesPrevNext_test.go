@@ -14,9 +14,11 @@
 package hugolib
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/cast"
+	"github.com/spf13/hugo/source"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -94,3 +96,30 @@ func TestWeightedPagesNext(t *testing.T) {
 	assert.Equal(t, w.Next(w[1].Page), w[2].Page)
 	assert.Equal(t, w.Next(w[4].Page), w[0].Page)
 }
+
+func TestSitePrevNext(t *testing.T) {
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/_index.md"), []byte("---\ntitle: Posts\ndate: 2015-01-04\n---\n")},
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ndate: 2015-01-01\n---\nOne.")},
+		{filepath.FromSlash("post/two.md"), []byte("---\ntitle: Two\ndate: 2015-01-02\n---\nTwo.")},
+		{filepath.FromSlash("tags/go/_index.md"), []byte("---\ntitle: Go\ndate: 2015-01-05\n---\n")},
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: About\ndate: 2015-01-03\n---\nAbout.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	var titles []string
+	for p := s.Pages[0]; p != nil; p = p.Next {
+		titles = append(titles, p.Title)
+	}
+	assert.Equal(t, []string{"About", "Two", "One"}, titles)
+
+	assert.Nil(t, s.Pages[0].Prev)
+	assert.Equal(t, s.Pages[0], s.Pages[1].Prev)
+	assert.Nil(t, s.Pages[2].Next)
+}
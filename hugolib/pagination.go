@@ -16,11 +16,13 @@ package hugolib
 import (
 	"errors"
 	"fmt"
+	"github.com/spf13/cast"
 	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/viper"
 	"html/template"
 	"math"
 	"path"
+	"reflect"
 )
 
 type pager struct {
@@ -101,6 +103,20 @@ func (p *pager) Last() *pager {
 	return p.pagers[len(p.pagers)-1]
 }
 
+// Window returns the pagers of at most n pages on each side of this one and
+// this one, for a pagination menu that stays short on large sites.
+func (p *pager) Window(n int) pagers {
+	low := p.PageNumber() - 1 - n
+	if low < 0 {
+		low = 0
+	}
+	high := p.PageNumber() + n
+	if high > len(p.pagers) {
+		high = len(p.pagers)
+	}
+	return p.pagers[low:high]
+}
+
 // Pagers returns a list of pagers that can be used to build a pagination menu.
 func (p *paginator) Pagers() pagers {
 	return p.pagers
@@ -142,7 +158,7 @@ func (n *Node) Paginator() (*pager, error) {
 			return
 		}
 
-		pagers, err := paginatePages(n.Data["Pages"], n.Url)
+		pagers, err := paginatePages(n.Data["Pages"], viper.GetInt("paginate"), n.Url)
 
 		if err != nil {
 			initError = err
@@ -169,14 +185,16 @@ func (p *Page) Paginator() (*pager, error) {
 }
 
 // Paginate on Page isn't supported, calling this yields an error.
-func (p *Page) Paginate(seq interface{}) (*pager, error) {
+func (p *Page) Paginate(seq interface{}, options ...interface{}) (*pager, error) {
 	return nil, errors.New("Paginators not supported for content pages.")
 }
 
 // Paginate gets this Node's paginator if it's already created.
-// If it's not, one will be created with the qiven sequence.
+// If it's not, one will be created with the qiven sequence, which may be any
+// list of pages, such as the result of where. An optional page size overrides
+// the paginate setting, e.g. {{ .Paginate (where .Data.Pages "Section" "post") 5 }}.
 // Note that repeated calls will return the same result, even if the sequence is different.
-func (n *Node) Paginate(seq interface{}) (*pager, error) {
+func (n *Node) Paginate(seq interface{}, options ...interface{}) (*pager, error) {
 
 	var initError error
 
@@ -184,7 +202,16 @@ func (n *Node) Paginate(seq interface{}) (*pager, error) {
 		if n.paginator != nil {
 			return
 		}
-		pagers, err := paginatePages(seq, n.Url)
+		pagerSize := viper.GetInt("paginate")
+		if len(options) > 0 {
+			size, err := cast.ToIntE(options[0])
+			if err != nil {
+				initError = fmt.Errorf("invalid page size %v in paginate", options[0])
+				return
+			}
+			pagerSize = size
+		}
+		pagers, err := paginatePages(seq, pagerSize, n.Url)
 
 		if err != nil {
 			initError = err
@@ -205,24 +232,13 @@ func (n *Node) Paginate(seq interface{}) (*pager, error) {
 	return n.paginator, nil
 }
 
-func paginatePages(seq interface{}, section string) (pagers, error) {
-	paginateSize := viper.GetInt("paginate")
-
+func paginatePages(seq interface{}, paginateSize int, section string) (pagers, error) {
 	if paginateSize <= 0 {
 		return nil, errors.New("'paginate' configuration setting must be positive to paginate")
 	}
-	var pages Pages
-	switch seq.(type) {
-	case Pages:
-		pages = seq.(Pages)
-	case *Pages:
-		pages = *(seq.(*Pages))
-	case WeightedPages:
-		pages = (seq.(WeightedPages)).Pages()
-	case PageGroup:
-		pages = (seq.(PageGroup)).Pages
-	default:
-		return nil, errors.New(fmt.Sprintf("unsupported type in paginate, got %T", seq))
+	pages, err := toPages(seq)
+	if err != nil {
+		return nil, err
 	}
 
 	urlFactory := newPaginationURLFactory(section)
@@ -232,6 +248,38 @@ func paginatePages(seq interface{}, section string) (pagers, error) {
 	return pagers, nil
 }
 
+// toPages returns the pages of seq, which may be any list of pages or of
+// weighted pages, e.g. the []interface{} of intersect.
+func toPages(seq interface{}) (Pages, error) {
+	switch v := seq.(type) {
+	case Pages:
+		return v, nil
+	case *Pages:
+		return *v, nil
+	case WeightedPages:
+		return v.Pages(), nil
+	case PageGroup:
+		return v.Pages, nil
+	}
+
+	sv := reflect.ValueOf(seq)
+	if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("unsupported type in paginate, got %T", seq)
+	}
+	pages := make(Pages, sv.Len())
+	for i := range pages {
+		switch e := sv.Index(i).Interface().(type) {
+		case *Page:
+			pages[i] = e
+		case WeightedPage:
+			pages[i] = e.Page
+		default:
+			return nil, fmt.Errorf("unsupported type in paginate, got %T in %T", e, seq)
+		}
+	}
+	return pages, nil
+}
+
 func newPaginator(pages Pages, size int, urlFactory paginationURLFactory) (*paginator, error) {
 
 	if size <= 0 {
@@ -1,11 +1,16 @@
 package hugolib
 
 import (
+	"bytes"
 	"fmt"
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
 	"github.com/spf13/hugo/source"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -67,6 +72,35 @@ func TestPager(t *testing.T) {
 	assert.Equal(t, 5, last.PageNumber())
 }
 
+func TestPagerWindow(t *testing.T) {
+	pages := createTestPages(10)
+	urlFactory := func(page int) string {
+		return fmt.Sprintf("page/%d/", page)
+	}
+
+	paginator, _ := newPaginator(pages, 1, urlFactory)
+	pagers := paginator.Pagers()
+
+	for i, this := range []struct {
+		page     int
+		n        int
+		expected []int
+	}{
+		{1, 2, []int{1, 2, 3}},
+		{2, 2, []int{1, 2, 3, 4}},
+		{5, 2, []int{3, 4, 5, 6, 7}},
+		{10, 2, []int{8, 9, 10}},
+		{5, 0, []int{5}},
+		{5, 20, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	} {
+		var numbers []int
+		for _, p := range pagers[this.page-1].Window(this.n) {
+			numbers = append(numbers, p.PageNumber())
+		}
+		assert.Equal(t, this.expected, numbers, fmt.Sprintf("[%d]", i))
+	}
+}
+
 func TestPagerNoPages(t *testing.T) {
 	pages := createTestPages(0)
 	urlFactory := func(page int) string {
@@ -138,6 +172,32 @@ func TestPaginator(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestPaginationTemplateWindow(t *testing.T) {
+	viper.Set("paginate", 1)
+	defer viper.Set("paginate", 10)
+
+	s := &Site{}
+	s.prepTemplates()
+	n := s.newHomeNode()
+	n.Data["Pages"] = createTestPages(10)
+
+	paginator, err := n.Paginator()
+	assert.Nil(t, err)
+	n.paginator = paginator.Pagers()[4]
+
+	var b bytes.Buffer
+	assert.Nil(t, s.Tmpl.ExecuteTemplate(&b, "_internal/pagination.html", n))
+	html := b.String()
+
+	for _, number := range []string{">3</a>", ">4</a>", ">5</a>", ">6</a>", ">7</a>"} {
+		assert.Contains(t, html, number)
+	}
+	for _, number := range []string{">2</a>", ">8</a>"} {
+		assert.NotContains(t, html, number)
+	}
+	assert.Equal(t, 2, strings.Count(html, "&hellip;"))
+}
+
 func TestPaginatorWithNegativePaginate(t *testing.T) {
 	viper.Set("paginate", -1)
 	s := &Site{}
@@ -182,13 +242,95 @@ func TestPaginateWithNegativePaginate(t *testing.T) {
 func TestPaginatePages(t *testing.T) {
 	viper.Set("paginate", 11)
 	for i, seq := range []interface{}{createTestPages(11), WeightedPages{}, PageGroup{}, &Pages{}} {
-		v, err := paginatePages(seq, "t")
+		v, err := paginatePages(seq, 11, "t")
 		assert.NotNil(t, v, "Val %d", i)
 		assert.Nil(t, err, "Err %d", i)
 	}
-	_, err := paginatePages(Site{}, "t")
+	_, err := paginatePages(Site{}, 11, "t")
+	assert.NotNil(t, err)
+
+}
+
+func TestPaginateCollections(t *testing.T) {
+	viper.Set("paginate", 5)
+	pages := createTestPages(6)
+	s := &Site{}
+
+	for i, seq := range []interface{}{
+		[]interface{}{pages[0], pages[1], pages[2], pages[3], pages[4], pages[5]},
+		[]*Page(pages),
+		WeightedPages{{0, pages[0]}, {0, pages[1]}, {0, pages[2]}, {0, pages[3]}, {0, pages[4]}, {0, pages[5]}},
+		[]WeightedPage{{0, pages[0]}, {0, pages[1]}, {0, pages[2]}, {0, pages[3]}, {0, pages[4]}, {0, pages[5]}},
+	} {
+		paginator, err := s.newHomeNode().Paginate(seq)
+		assert.Nil(t, err, "Err %d", i)
+		assert.Equal(t, 2, paginator.TotalPages(), "Pages %d", i)
+		assert.Equal(t, pages[5], paginator.Next().Pages()[0], "Page %d", i)
+	}
+
+	paginator, err := s.newHomeNode().Paginate(pages, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, paginator.TotalPages())
+	assert.Equal(t, 2, paginator.PageSize())
+
+	for i, seq := range []interface{}{[]interface{}{pages[0], "x"}, "x", 42} {
+		_, err := s.newHomeNode().Paginate(seq)
+		assert.NotNil(t, err, "Err %d", i)
+	}
+
+	_, err = s.newHomeNode().Paginate(pages, "many")
+	assert.NotNil(t, err)
+	_, err = s.newHomeNode().Paginate(pages, 0)
 	assert.NotNil(t, err)
+}
 
+func TestPaginationOnSectionsAndTaxonomies(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("paginate", 1)
+	viper.Set("paginatePath", "page")
+	viper.Set("taxonomies", map[string]string{"tag": "tags"})
+	defer viper.Set("paginate", 10)
+	defer viper.Set("taxonomies", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/a.md"), []byte("---\ntitle: A\ntags: [go]\ndate: 2014-01-01\n---\nA")},
+		{filepath.FromSlash("sect/b.md"), []byte("---\ntitle: B\ntags: [go]\ndate: 2014-01-02\n---\nB")},
+	})
+
+	list := "{{ .Title }} {{ .Permalink }} {{ .Paginator.PageNumber }}:{{ range .Paginator.Pages }}{{ .Title }}{{ end }}"
+	s.addTemplate("_default/list.html", list)
+	s.addTemplate("indexes/tag.html", list)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+	if err := s.RenderTaxonomiesLists(); err != nil {
+		t.Fatalf("Unable to render taxonomy lists: %s", err)
+	}
+
+	for _, test := range []struct {
+		doc      string
+		expected string
+	}{
+		{"sect/index.html", "Sect http://auth/bub/sect/ 1:B"},
+		{"sect/page/2/index.html", "Sect http://auth/bub/sect/page/2/ 2:A"},
+		{"tags/go/index.html", "Go http://auth/bub/tags/go/ 1:B"},
+		{"tags/go/page/2/index.html", "Go http://auth/bub/tags/go/page/2/ 2:A"},
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(test.doc))
+		if err != nil {
+			t.Fatalf("Did not find %s in target: %s", test.doc, err)
+		}
+		assert.Equal(t, test.expected, string(helpers.ReaderToBytes(file)), test.doc)
+	}
 }
 
 func createTestPages(num int) Pages {
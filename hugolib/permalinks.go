@@ -3,6 +3,8 @@ package hugolib
 import (
 	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -20,6 +22,21 @@ type PageToPermaAttribute func(*Page, string) (string, error)
 // PermalinkOverrides maps a section name to a PathPattern
 type PermalinkOverrides map[string]PathPattern
 
+// patternFor returns the PathPattern of the nearest section of the page, so
+// that a nested section, e.g. "docs/api", can be laid out apart from its
+// parent.
+func (po PermalinkOverrides) patternFor(p *Page) (PathPattern, bool) {
+	dir := strings.Trim(filepath.ToSlash(p.Source.Dir()), "/")
+	for dir != "" && dir != "." {
+		if pp, ok := po[dir]; ok {
+			return pp, true
+		}
+		dir = path.Dir(dir)
+	}
+	pp, ok := po[p.Section()]
+	return pp, ok
+}
+
 // knownPermalinkAttributes maps :tags in a permalink specification to a
 // function which, given a page and the tag, returns the resulting string
 // to be used to replace that tag.
@@ -29,6 +46,9 @@ var attributeRegexp *regexp.Regexp
 
 // validate determines if a PathPattern is well-formed
 func (pp PathPattern) validate() bool {
+	if len(pp) == 0 {
+		return false
+	}
 	fragments := strings.Split(string(pp[1:]), "/")
 	var bail = false
 	for i := range fragments {
@@ -170,6 +190,12 @@ func pageToPermalinkSection(p *Page, _ string) (string, error) {
 	return p.Section(), nil
 }
 
+// pageToPermalinkSections returns the path of the sections of the page,
+// e.g. "docs/api"
+func pageToPermalinkSections(p *Page, _ string) (string, error) {
+	return strings.Trim(filepath.ToSlash(p.Source.Dir()), "/"), nil
+}
+
 func init() {
 	knownPermalinkAttributes = map[string]PageToPermaAttribute{
 		"year":        pageToPermalinkDate,
@@ -180,6 +206,7 @@ func init() {
 		"weekdayname": pageToPermalinkDate,
 		"yearday":     pageToPermalinkDate,
 		"section":     pageToPermalinkSection,
+		"sections":    pageToPermalinkSections,
 		"title":       pageToPermalinkTitle,
 		"slug":        pageToPermalinkSlugElseTitle,
 		"filename":    pageToPermalinkFilename,
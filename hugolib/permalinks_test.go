@@ -1,6 +1,7 @@
 package hugolib
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -19,6 +20,7 @@ var testdataPermalinks = []struct {
 	{"/:year-:month-:title", true, "/2012-04-spf13-vim-3.0-release-and-new-website"},
 	{"/blog/:year-:month-:title", true, "/blog/2012-04-spf13-vim-3.0-release-and-new-website"},
 	{"/blog-:year-:month-:title", true, "/blog-2012-04-spf13-vim-3.0-release-and-new-website"},
+	{"", false, ""},
 	//{"/blog/:fred", false, ""},
 	//{"/:year//:title", false, ""},
 	//{
@@ -75,3 +77,35 @@ func TestPermalinkExpansion(t *testing.T) {
 		}
 	}
 }
+
+func TestPermalinkNestedSections(t *testing.T) {
+	overrides := PermalinkOverrides{
+		"docs":     "/manual/:title/",
+		"docs/api": "/reference/:sections/:filename/",
+	}
+
+	for _, this := range []struct {
+		path     string
+		expected string
+	}{
+		{"docs/intro.md", "/manual/intro/"},
+		{"docs/api/v1/client.md", "/reference/docs/api/v1/client/"},
+		{"docs/api/server.md", "/reference/docs/api/server/"},
+		{"post/hello.md", ""},
+	} {
+		page, err := NewPageFrom(strings.NewReader("---\ntitle: "+strings.TrimSuffix(filepath.Base(this.path), ".md")+"\n---\nContent."), filepath.FromSlash(this.path))
+		if err != nil {
+			t.Fatalf("Unable to create %s: %s", this.path, err)
+		}
+		pp, ok := overrides.patternFor(page)
+		if !ok {
+			if this.expected != "" {
+				t.Errorf("%s has no permalink, expected %s", this.path, this.expected)
+			}
+			continue
+		}
+		if result, err := pp.Expand(page); err != nil || result != this.expected {
+			t.Errorf("%s expanded to %q (%v), expected %q", this.path, result, err, this.expected)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// A Redirect is a redirect of the site config, for the URLs no page has an
+// alias for, e.g. an old feed URL:
+//
+//	[[redirects]]
+//	from = "/feed"
+//	to = "/index.xml"
+//	status = 301
+//
+// A From ending with * matches all the paths below it, and the part it
+// matched replaces :splat in To. The Status is 301 by default. Redirects
+// can also be a map of the old paths to the new URLs:
+//
+//	[redirects]
+//	"/feed" = "/index.xml"
+//	"/about-us/" = "/about/"
+type Redirect struct {
+	From   string
+	To     string
+	Status int
+}
+
+func parseRedirect(input map[string]interface{}) (Redirect, error) {
+	r := Redirect{Status: 301}
+
+	for key, value := range input {
+		switch strings.ToLower(key) {
+		case "from":
+			r.From = cast.ToString(value)
+		case "to":
+			r.To = cast.ToString(value)
+		case "status":
+			r.Status = cast.ToInt(value)
+		default:
+			jww.WARN.Printf("Unknown Redirect field: %s\n", key)
+		}
+	}
+
+	if !strings.HasPrefix(r.From, "/") || r.To == "" {
+		return r, fmt.Errorf("from must be a path and to is needed, got %q to %q", r.From, r.To)
+	}
+	if strings.Contains(strings.TrimSuffix(r.From, "*"), "*") {
+		return r, fmt.Errorf("* is only allowed at the end of from, got %q", r.From)
+	}
+	switch r.Status {
+	case 301, 302, 303, 307, 308:
+	default:
+		return r, fmt.Errorf("%d is not a redirect status", r.Status)
+	}
+
+	return r, nil
+}
+
+// Redirects returns the redirects of the site config. The invalid ones are
+// logged and left out.
+func Redirects() []Redirect {
+	configs := cast.ToSlice(viper.Get("Redirects"))
+	if m := cast.ToStringMap(viper.Get("Redirects")); len(m) > 0 {
+		froms := make([]string, 0, len(m))
+		for from := range m {
+			froms = append(froms, from)
+		}
+		sort.Strings(froms)
+		configs = make([]interface{}, len(froms))
+		for i, from := range froms {
+			configs[i] = map[string]interface{}{"from": from, "to": m[from]}
+		}
+	}
+
+	var redirects []Redirect
+	for _, m := range configs {
+		r, err := parseRedirect(cast.ToStringMap(m))
+		if err != nil {
+			jww.ERROR.Printf("Invalid redirect: %s\n", err)
+			continue
+		}
+		redirects = append(redirects, r)
+	}
+	return redirects
+}
+
+// Match returns where the redirect sends the site path p, if it matches.
+// The trailing slashes are not significant to an exact From.
+func (r Redirect) Match(p string) (string, bool) {
+	if strings.HasSuffix(r.From, "*") {
+		prefix := strings.TrimSuffix(r.From, "*")
+		if !strings.HasPrefix(p, prefix) {
+			return "", false
+		}
+		return strings.Replace(r.To, ":splat", p[len(prefix):], -1), true
+	}
+
+	if strings.TrimSuffix(p, "/") != strings.TrimSuffix(r.From, "/") {
+		return "", false
+	}
+	return r.To, true
+}
+
+// A redirectFormat is a host's own format of the redirects.
+type redirectFormat struct {
+	file  string
+	write func(w *bytes.Buffer, r Redirect, from, to string)
+}
+
+var knownRedirectFormats = map[string]redirectFormat{
+	// Netlify's _redirects, which also have * and :splat
+	"netlify": {"_redirects", func(w *bytes.Buffer, r Redirect, from, to string) {
+		fmt.Fprintf(w, "%s %s %d\n", from, to, r.Status)
+	}},
+	// Apache's .htaccess, with mod_alias
+	"apache": {".htaccess", func(w *bytes.Buffer, r Redirect, from, to string) {
+		if strings.HasSuffix(from, "*") {
+			from = "^" + regexp.QuoteMeta(strings.TrimSuffix(from, "*")) + "(.*)$"
+			to = strings.Replace(to, ":splat", "$1", -1)
+		} else {
+			from = "^" + regexp.QuoteMeta(strings.TrimSuffix(from, "/")) + "/?$"
+		}
+		fmt.Fprintf(w, "RedirectMatch %d %s %s\n", r.Status, from, to)
+	}},
+}
+
+// RenderRedirects writes the redirects of the site config in the formats of
+// the hosts in RedirectFormats, Netlify's by default:
+//
+//	redirectFormats = ["netlify", "apache"]
+//
+// The redirects from a page's path are also written as pages redirecting
+// to their URL, as for aliases, for the hosts that read neither format.
+func (s *Site) RenderRedirects() error {
+	redirects := Redirects()
+	if len(redirects) == 0 {
+		return nil
+	}
+
+	baseURL := viper.GetString("BaseURL")
+	for _, r := range redirects {
+		if strings.HasSuffix(r.From, "*") {
+			continue
+		}
+		if ext := path.Ext(r.From); ext != "" && ext != ".html" {
+			continue
+		}
+		to := r.To
+		if strings.HasPrefix(to, "/") {
+			to = helpers.MakePermalink(baseURL, to).String()
+		}
+		if err := s.WriteDestAlias(r.From, template.HTML(to)); err != nil {
+			return err
+		}
+	}
+
+	names := cast.ToStringSlice(viper.Get("RedirectFormats"))
+	if len(names) == 0 {
+		names = []string{"netlify"}
+	}
+
+	for _, name := range names {
+		f, ok := knownRedirectFormats[name]
+		if !ok {
+			jww.ERROR.Printf("Unknown redirect format %q, use netlify or apache\n", name)
+			continue
+		}
+
+		var b bytes.Buffer
+		for _, r := range redirects {
+			from := helpers.AddContextRoot(baseURL, r.From)
+			to := r.To
+			if strings.HasPrefix(to, "/") {
+				to = helpers.AddContextRoot(baseURL, to)
+			}
+			f.write(&b, r, from, to)
+		}
+
+		if err := s.WriteDestFile(filepath.FromSlash(f.file), &b); err != nil {
+			return err
+		}
+		helpers.BuildLog.Rendered(f.file, "redirects")
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+func TestRedirectMatch(t *testing.T) {
+	for i, this := range []struct {
+		from, to string
+		path     string
+		expected string
+		match    bool
+	}{
+		{"/feed", "/index.xml", "/feed", "/index.xml", true},
+		{"/feed", "/index.xml", "/feed/", "/index.xml", true},
+		{"/feed/", "/index.xml", "/feed", "/index.xml", true},
+		{"/feed", "/index.xml", "/feeds", "", false},
+		{"/blog/*", "/post/:splat", "/blog/first/", "/post/first/", true},
+		{"/blog/*", "/post/:splat", "/blog/", "/post/", true},
+		{"/blog/*", "/post/", "/blog/first/", "/post/", true},
+		{"/blog/*", "/post/:splat", "/about/", "", false},
+		{"/old", "http://example.com/new", "/old", "http://example.com/new", true},
+	} {
+		r := Redirect{From: this.from, To: this.to, Status: 301}
+		to, match := r.Match(this.path)
+		if match != this.match || to != this.expected {
+			t.Errorf("[%d] %s -> %s: %s got %q %t, expected %q %t", i, this.from, this.to, this.path, to, match, this.expected, this.match)
+		}
+	}
+}
+
+func TestRedirectsConfig(t *testing.T) {
+	viper.Set("Redirects", []interface{}{
+		map[string]interface{}{"from": "/feed", "to": "/index.xml"},
+		map[string]interface{}{"from": "/blog/*", "to": "/post/:splat", "status": 302},
+		map[string]interface{}{"from": "feed", "to": "/index.xml"},
+		map[string]interface{}{"from": "/a/*/b", "to": "/b"},
+		map[string]interface{}{"from": "/c", "to": "/d", "status": 200},
+	})
+	defer viper.Set("Redirects", nil)
+
+	redirects := Redirects()
+	expected := []Redirect{{"/feed", "/index.xml", 301}, {"/blog/*", "/post/:splat", 302}}
+	if len(redirects) != len(expected) {
+		t.Fatalf("Got %d redirects, expected %d: %v", len(redirects), len(expected), redirects)
+	}
+	for i, r := range redirects {
+		if r != expected[i] {
+			t.Errorf("[%d] got %v, expected %v", i, r, expected[i])
+		}
+	}
+}
+
+func TestRedirectsMapConfig(t *testing.T) {
+	viper.Set("Redirects", map[string]interface{}{
+		"/old/":  "/new/",
+		"/feed":  "/index.xml",
+		"nopath": "/new/",
+	})
+	defer viper.Set("Redirects", nil)
+
+	redirects := Redirects()
+	expected := []Redirect{{"/feed", "/index.xml", 301}, {"/old/", "/new/", 301}}
+	if len(redirects) != len(expected) {
+		t.Fatalf("Got %d redirects, expected %d: %v", len(redirects), len(expected), redirects)
+	}
+	for i, r := range redirects {
+		if r != expected[i] {
+			t.Errorf("[%d] got %v, expected %v", i, r, expected[i])
+		}
+	}
+}
+
+func TestRenderRedirects(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("Redirects", []map[string]interface{}{
+		{"from": "/feed", "to": "/index.xml"},
+		{"from": "/blog/*", "to": "/post/:splat", "status": 302},
+		{"from": "/home", "to": "http://example.com/"},
+	})
+	viper.Set("RedirectFormats", []string{"netlify", "apache"})
+	defer viper.Set("Redirects", nil)
+	defer viper.Set("RedirectFormats", nil)
+
+	s := &Site{}
+	s.initializeSiteInfo()
+
+	if err := s.RenderRedirects(); err != nil {
+		t.Fatalf("Unable to RenderRedirects: %s", err)
+	}
+
+	for _, this := range []struct {
+		file     string
+		expected string
+	}{
+		{"_redirects", "/bub/feed /bub/index.xml 301\n/bub/blog/* /bub/post/:splat 302\n/bub/home http://example.com/ 301\n"},
+		{".htaccess", "RedirectMatch 301 ^/bub/feed/?$ /bub/index.xml\nRedirectMatch 302 ^/bub/blog/(.*)$ /bub/post/$1\nRedirectMatch 301 ^/bub/home/?$ http://example.com/\n"},
+	} {
+		file, err := hugofs.DestinationFS.Open(this.file)
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", this.file)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != this.expected {
+			t.Errorf("%s content is\n%q\nexpected\n%q", this.file, content, this.expected)
+		}
+	}
+}
+
+func TestRenderRedirectPages(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("Redirects", map[string]interface{}{
+		"/feed":          "/index.xml",
+		"/home/":         "http://example.com/",
+		"/old.html":      "/new/",
+		"/blog/*":        "/post/:splat",
+		"/downloads.php": "/downloads/",
+	})
+	defer viper.Set("Redirects", nil)
+
+	s := &Site{}
+	s.initializeSiteInfo()
+
+	if err := s.RenderRedirects(); err != nil {
+		t.Fatalf("Unable to RenderRedirects: %s", err)
+	}
+
+	for _, this := range []struct {
+		file     string
+		expected string
+	}{
+		{filepath.FromSlash("feed/index.html"), "http://auth/bub/index.xml"},
+		{filepath.FromSlash("home/index.html"), "http://example.com/"},
+		{"old.html", "http://auth/bub/new/"},
+	} {
+		file, err := hugofs.DestinationFS.Open(this.file)
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", this.file)
+		}
+		if content := string(helpers.ReaderToBytes(file)); !strings.Contains(content, `content="0;url=`+this.expected+`"`) {
+			t.Errorf("%s should redirect to %s, got\n%s", this.file, this.expected, content)
+		}
+	}
+
+	for _, file := range []string{"blog/index.html", "downloads.php/index.html"} {
+		if _, err := hugofs.DestinationFS.Open(filepath.FromSlash(file)); err == nil {
+			t.Errorf("Expected no redirect page %s", file)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var robotsDirectives = map[string]bool{
+	"all":       true,
+	"index":     true,
+	"follow":    true,
+	"noindex":   true,
+	"nofollow":  true,
+	"none":      true,
+	"noarchive": true,
+	"nosnippet": true,
+}
+
+// parseRobots returns the robots directives of the front matter, given as
+// "noindex, nofollow" or as a list, in the form of the robots meta tag.
+func parseRobots(v interface{}) string {
+	var values []string
+	switch vv := v.(type) {
+	case string:
+		values = strings.Split(vv, ",")
+	default:
+		values = cast.ToStringSlice(vv)
+	}
+
+	var directives []string
+	for _, d := range values {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		if !robotsDirectives[d] {
+			jww.WARN.Printf("Unknown robots directive: %s\n", d)
+		}
+		directives = append(directives, d)
+	}
+	return strings.Join(directives, ", ")
+}
+
+// NoIndex returns whether search engines are asked to not index the node.
+func (n *Node) NoIndex() bool {
+	for _, d := range strings.Split(n.Robots, ",") {
+		if d = strings.TrimSpace(d); d == "noindex" || d == "none" {
+			return true
+		}
+	}
+	return false
+}
+
+// addNoIndex adds noindex to the robots directives, for the noindex
+// shorthand of the front matter, in place of an index or all directive.
+func addNoIndex(robots string) string {
+	directives := []string{"noindex"}
+	for _, d := range strings.Split(robots, ",") {
+		switch d = strings.TrimSpace(d); d {
+		case "", "index", "all", "noindex":
+		case "none":
+			return robots
+		default:
+			directives = append(directives, d)
+		}
+	}
+	return strings.Join(directives, ", ")
+}
@@ -0,0 +1,72 @@
+package hugolib
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/hugo/tpl"
+)
+
+func TestParseRobots(t *testing.T) {
+	for i, this := range []struct {
+		in       interface{}
+		expected string
+		noIndex  bool
+	}{
+		{"noindex", "noindex", true},
+		{"NoIndex,NoFollow", "noindex, nofollow", true},
+		{[]interface{}{"none"}, "none", true},
+		{"index, nofollow", "index, nofollow", false},
+		{"", "", false},
+	} {
+		n := Node{Robots: parseRobots(this.in)}
+		if n.Robots != this.expected || n.NoIndex() != this.noIndex {
+			t.Errorf("[%d] got %q (noindex %t), expected %q (noindex %t)", i, n.Robots, n.NoIndex(), this.expected, this.noIndex)
+		}
+	}
+}
+
+func TestRobotsTemplate(t *testing.T) {
+	for _, this := range []struct {
+		content  string
+		expected string
+	}{
+		{"---\ntitle: Staging\nrobots: [noindex, nofollow]\n---\nNot yet.", `<meta name="robots" content="noindex, nofollow" />`},
+		{"---\ntitle: Utility\nnoindex: true\n---\nUtility.", `<meta name="robots" content="noindex" />`},
+		{"---\ntitle: Archived\nnoindex: true\nrobots: index, nofollow\n---\nOld.", `<meta name="robots" content="noindex, nofollow" />`},
+		{"---\ntitle: Listed\nnoindex: false\n---\nListed.", ""},
+		{"---\ntitle: Public\n---\nHello.", ""},
+	} {
+		p, err := NewPageFrom(strings.NewReader(this.content), filepath.FromSlash("sect/page.md"))
+		if err != nil {
+			t.Fatalf("Unable to create page: %s", err)
+		}
+		var b bytes.Buffer
+		if err := tpl.New().ExecuteTemplate(&b, "_internal/robots.html", p); err != nil {
+			t.Fatalf("Unable to execute the robots template: %s", err)
+		}
+		if b.String() != this.expected {
+			t.Errorf("%s: got %q, expected %q", p.Title, b.String(), this.expected)
+		}
+	}
+}
+
+func TestAddNoIndex(t *testing.T) {
+	for i, this := range []struct {
+		in       string
+		expected string
+	}{
+		{"", "noindex"},
+		{"noindex", "noindex"},
+		{"index, nofollow", "noindex, nofollow"},
+		{"all", "noindex"},
+		{"none", "none"},
+		{"noarchive", "noindex, noarchive"},
+	} {
+		if got := addNoIndex(this.in); got != this.expected {
+			t.Errorf("[%d] got %q, expected %q", i, got, this.expected)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+const ROBOTSTXT_TEMPLATE = `User-agent: Googlebot
+{{ range .Data.Pages }}
+Disallow: {{ .RelPermalink }}
+{{ end }}
+`
+
+func newRobotsTXTSite(t *testing.T) *Site {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: Doc1\n---\nDoc1.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	return s
+}
+
+func TestRobotsTXTOutput(t *testing.T) {
+	viper.Set("EnableRobotsTXT", true)
+	defer viper.Set("EnableRobotsTXT", false)
+
+	s := newRobotsTXTSite(t)
+	s.addTemplate("robots.txt", ROBOTSTXT_TEMPLATE)
+
+	if err := s.RenderRobotsTXT(); err != nil {
+		t.Fatalf("Unable to RenderRobotsTXT: %s", err)
+	}
+
+	robotsFile, err := hugofs.DestinationFS.Open("robots.txt")
+	if err != nil {
+		t.Fatalf("Unable to locate: robots.txt")
+	}
+
+	robots := helpers.ReaderToBytes(robotsFile)
+	expected := "User-agent: Googlebot\n\nDisallow: /bub/sect/doc1/\n\n"
+	if string(robots) != expected {
+		t.Errorf("robots.txt content is %q, expected %q", robots, expected)
+	}
+}
+
+func TestRobotsTXTDefault(t *testing.T) {
+	viper.Set("EnableRobotsTXT", true)
+	defer viper.Set("EnableRobotsTXT", false)
+
+	s := newRobotsTXTSite(t)
+
+	if err := s.RenderRobotsTXT(); err != nil {
+		t.Fatalf("Unable to RenderRobotsTXT: %s", err)
+	}
+
+	robotsFile, err := hugofs.DestinationFS.Open("robots.txt")
+	if err != nil {
+		t.Fatalf("Unable to locate: robots.txt")
+	}
+
+	if robots := string(helpers.ReaderToBytes(robotsFile)); robots != "User-agent: *" {
+		t.Errorf("robots.txt content is %q, expected the default", robots)
+	}
+}
+
+func TestRobotsTXTDisabled(t *testing.T) {
+	s := newRobotsTXTSite(t)
+	s.addTemplate("robots.txt", ROBOTSTXT_TEMPLATE)
+
+	if err := s.RenderRobotsTXT(); err != nil {
+		t.Fatalf("Unable to RenderRobotsTXT: %s", err)
+	}
+
+	if _, err := hugofs.DestinationFS.Open("robots.txt"); err == nil {
+		t.Errorf("robots.txt should not be rendered unless EnableRobotsTXT is set")
+	}
+}
@@ -2,6 +2,10 @@ package hugolib
 
 import (
 	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -67,3 +71,253 @@ func TestRSSOutput(t *testing.T) {
 		t.Errorf("rss feed should start with <?xml. %s", rss)
 	}
 }
+
+func TestRSSConfig(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("RSSTitle", "Bub's Feed")
+	viper.Set("RSSLimit", 2)
+	viper.Set("RSSDescription", "summary")
+	defer func() {
+		viper.Set("RSSTitle", "")
+		viper.Set("RSSLimit", 0)
+		viper.Set("RSSDescription", "")
+	}()
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/one.md"), []byte("---\ntitle: One\ndate: 2015-01-01\n---\nFirst part.\n<!--more-->\nSecond part.")},
+		{filepath.FromSlash("sect/two.md"), []byte("---\ntitle: Two\ndate: 2015-02-01\n---\nTwo.")},
+		{filepath.FromSlash("sect/three.md"), []byte("---\ntitle: Three\ndate: 2015-03-01\n---\nThree.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderHomePage(); err != nil {
+		t.Fatalf("Unable to RenderHomePage: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open("index.xml")
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "index.xml")
+	}
+	rss := string(helpers.ReaderToBytes(file))
+
+	if !strings.Contains(rss, "<title>Bub&#39;s Feed</title>") {
+		t.Errorf("Expected the RSSTitle as the feed title, got %s", rss)
+	}
+	if n := strings.Count(rss, "<item>"); n != 2 {
+		t.Errorf("Expected 2 items, got %d", n)
+	}
+
+	s.Info.RSSLimit = 3
+	if err := s.RenderHomePage(); err != nil {
+		t.Fatalf("Unable to RenderHomePage: %s", err)
+	}
+	file, _ = hugofs.DestinationFS.Open("index.xml")
+	rss = string(helpers.ReaderToBytes(file))
+	if !strings.Contains(rss, "First part.") || strings.Contains(rss, "Second part.") {
+		t.Errorf("Expected the summary as description, got %s", rss)
+	}
+}
+
+func TestSectionAndTermFeeds(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("UglyURLs", true)
+	viper.Set("taxonomies", map[string]string{"tag": "tags"})
+	defer func() {
+		viper.Set("UglyURLs", false)
+		viper.Set("taxonomies", map[string]string{"tag": "tags", "category": "categories"})
+	}()
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ntags: [\"go\"]\n---\nOne.")},
+		{filepath.FromSlash("post/two.md"), []byte("---\ntitle: Two\n---\nTwo.")},
+	})
+	s.addTemplate("_default/list.html", "{{ .RSSLink }}")
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+	if err := s.RenderTaxonomiesLists(); err != nil {
+		t.Fatalf("Unable to render taxonomy lists: %s", err)
+	}
+
+	for _, test := range []struct {
+		list, feed, items string
+	}{
+		{"post.html", "post/index.xml", "OneTwo"},
+		{"tags/go.html", "tags/go/index.xml", "One"},
+	} {
+		link := "http://auth/bub/" + test.feed
+
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(test.list))
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", test.list)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != link {
+			t.Errorf("Expected %s to link to its feed at %s, got %s", test.list, link, content)
+		}
+
+		file, err = hugofs.DestinationFS.Open(filepath.FromSlash(test.feed))
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", test.feed)
+		}
+		rss := string(helpers.ReaderToBytes(file))
+		if !strings.Contains(rss, `<atom:link href="`+link+`"`) {
+			t.Errorf("Expected %s to link to itself, got %s", test.feed, rss)
+		}
+		var items string
+		for _, title := range []string{"One", "Two"} {
+			if strings.Contains(rss, "<title>"+title+"</title>") {
+				items += title
+			}
+		}
+		if items != test.items {
+			t.Errorf("Expected the items %s in %s, got %s", test.items, test.feed, items)
+		}
+	}
+}
+
+func TestAtomFeeds(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("Feeds", []string{"atom", "rdf"})
+	viper.Set("RSSDescription", "summary")
+	defer func() {
+		viper.Set("Feeds", nil)
+		viper.Set("RSSDescription", "")
+	}()
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ndate: 2015-01-01\n---\nFirst part.\n<!--more-->\nSecond part.")},
+	})
+	s.addTemplate("_default/list.html", "{{ .RSSLink }}|{{ .AtomLink }}")
+
+	if len(s.feeds) != 1 || s.feeds[0].name != "atom" {
+		t.Fatalf("Expected only the atom feed, got %v", s.feeds)
+	}
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderHomePage(); err != nil {
+		t.Fatalf("Unable to RenderHomePage: %s", err)
+	}
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+
+	if _, err := hugofs.DestinationFS.Open("index.xml"); err == nil {
+		t.Errorf("Expected no RSS feed")
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/index.html"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "post/index.html")
+	}
+	if link := string(helpers.ReaderToBytes(file)); link != "|http://auth/bub/post/atom.xml" {
+		t.Errorf("Expected the section to link to its Atom feed only, got %s", link)
+	}
+
+	for _, feed := range []string{"atom.xml", "post/atom.xml"} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(feed))
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", feed)
+		}
+		atom := string(helpers.ReaderToBytes(file))
+		for _, expected := range []string{
+			`<feed xmlns="http://www.w3.org/2005/Atom">`,
+			`<link href="http://auth/bub/` + feed + `" rel="self" type="application/atom+xml"/>`,
+			"<title>One</title>",
+			"<updated>2015-01-01T00:00:00Z</updated>",
+			`<summary type="html">`,
+		} {
+			if !strings.Contains(atom, expected) {
+				t.Errorf("Expected %s in %s, got %s", expected, feed, atom)
+			}
+		}
+	}
+}
+
+func TestJSONFeeds(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("Title", "Bub")
+	viper.Set("Feeds", []string{"rss", "json"})
+	defer func() {
+		viper.Set("Title", "")
+		viper.Set("Feeds", nil)
+	}()
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ndate: 2015-01-01T10:00:00Z\ntags: [\"go\"]\n---\nOne <b>&</b> only.")},
+		{filepath.FromSlash("post/two.md"), []byte("---\ntitle: Two\n---\nTwo.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderHomePage(); err != nil {
+		t.Fatalf("Unable to RenderHomePage: %s", err)
+	}
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+
+	if _, err := hugofs.DestinationFS.Open("index.xml"); err != nil {
+		t.Errorf("Expected the RSS feed next to the JSON feed")
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/feed.json"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "post/feed.json")
+	}
+	var feed map[string]interface{}
+	if err := json.Unmarshal(helpers.ReaderToBytes(file), &feed); err != nil {
+		t.Fatalf("Invalid JSON feed: %s", err)
+	}
+
+	for key, expected := range map[string]interface{}{
+		"version":       "https://jsonfeed.org/version/1.1",
+		"title":         "Post on Bub",
+		"home_page_url": "http://auth/bub/post/",
+		"feed_url":      "http://auth/bub/post/feed.json",
+	} {
+		if feed[key] != expected {
+			t.Errorf("Expected %s %q, got %q", key, expected, feed[key])
+		}
+	}
+
+	items, _ := feed["items"].([]interface{})
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %v", feed["items"])
+	}
+	expected := map[string]interface{}{
+		"id":             "http://auth/bub/post/one/",
+		"url":            "http://auth/bub/post/one/",
+		"title":          "One",
+		"content_html":   "<p>One <b>&amp;</b> only.</p>\n",
+		"date_published": "2015-01-01T10:00:00Z",
+		"tags":           []interface{}{"go"},
+	}
+	if !reflect.DeepEqual(items[0], expected) {
+		t.Errorf("Expected the first item %v, got %v", expected, items[0])
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/viper"
+)
+
+// searchIndexEntry is a page in the search index, for client-side search
+// with e.g. Lunr or Fuse.
+type searchIndexEntry struct {
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Section string   `json:"section,omitempty"`
+	Summary string   `json:"summary"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// RenderSearchIndex writes the SearchIndex file with the pages of the site,
+// if EnableSearchIndex is set. Pages search engines shouldn't index are
+// left out.
+func (s *Site) RenderSearchIndex() error {
+	if !viper.GetBool("EnableSearchIndex") {
+		return nil
+	}
+
+	entries := []searchIndexEntry{}
+	for _, p := range s.Pages {
+		if p.NoIndex() {
+			continue
+		}
+		url, err := p.RelPermalink()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, searchIndexEntry{
+			Title:   p.Title,
+			URL:     url,
+			Section: p.Section(),
+			Summary: strings.TrimSpace(helpers.StripHTML(string(p.Summary))),
+			Tags:    cast.ToStringSlice(p.GetParam("tags")),
+		})
+	}
+
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	dest := viper.GetString("SearchIndex")
+	if dest == "" {
+		dest = "search-index.json"
+	}
+	if err := s.WriteDestFile(dest, bytes.NewReader(b)); err != nil {
+		return err
+	}
+	helpers.BuildLog.Rendered(dest, "search index")
+	return nil
+}
@@ -0,0 +1,46 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestRenderSearchIndex(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("EnableSearchIndex", true)
+	defer viper.Set("EnableSearchIndex", false)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\nweight: 1\ntags: [go, hugo]\n---\nThe *first* post.")},
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: About\nweight: 2\n---\nAbout <b>us</b>.")},
+		{filepath.FromSlash("thin.md"), []byte("---\ntitle: Thin\nrobots: noindex\n---\nNothing.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderSearchIndex(); err != nil {
+		t.Fatalf("Unable to render the search index: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open("search-index.json")
+	if err != nil {
+		t.Fatalf("Unable to locate: search-index.json")
+	}
+	expected := `[{"title":"One","url":"/bub/post/one/","section":"post","summary":"The first post.","tags":["go","hugo"]},` +
+		`{"title":"About","url":"/bub/about/","summary":"About us."}]`
+	if content := string(helpers.ReaderToBytes(file)); content != expected {
+		t.Errorf("Search index expected:\n%s\ngot:\n%s", expected, content)
+	}
+}
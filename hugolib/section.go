@@ -0,0 +1,221 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+
+	"bitbucket.org/pkg/inflect"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/viper"
+)
+
+// The sections form a tree following the content directories. Every top
+// level directory is a section, a deeper one is when it has an _index file.
+// That file holds the front matter and the content of the section itself;
+// sections without one get a page with just a title.
+
+// sectionPath returns the content directory of p, such as "post/2015".
+func sectionPath(p *Page) string {
+	return strings.Trim(filepath.ToSlash(p.Source.Dir()), "/")
+}
+
+// parentSectionPath returns the directory above path, "" for the top level.
+func parentSectionPath(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func sectionTitle(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if viper.GetBool("PluralizeListTitles") {
+		return strings.Title(inflect.Pluralize(name))
+	}
+	return strings.Title(name)
+}
+
+func (s *Site) newSectionPage(path string) *Page {
+	p := &Page{Node: Node{Site: &s.Info, Params: make(map[string]interface{})}}
+	if path == "" {
+		p.Title = s.Info.Title
+		p.Url = "/"
+	} else {
+		p.Title = sectionTitle(path)
+		p.Url = helpers.URLizeAndPrep(path)
+	}
+	return p
+}
+
+// assembleSectionPages takes the _index pages out of the site's pages and
+// builds the section tree, with the home page section at its root.
+func (s *Site) assembleSectionPages() {
+	s.Info.sectionPages = make(map[string]*Page)
+
+	pages := s.Pages[:0]
+	for _, p := range s.Pages {
+		if p.Source.BaseFileName() != "_index" {
+			pages = append(pages, p)
+			continue
+		}
+		path := sectionPath(p)
+		if path == "" {
+			p.Url = "/"
+		} else {
+			p.Url = helpers.URLizeAndPrep(path)
+		}
+		s.Info.sectionPages[path] = p
+	}
+	s.Pages = pages
+
+	if _, ok := s.Info.sectionPages[""]; !ok {
+		s.Info.sectionPages[""] = s.newSectionPage("")
+	}
+	for path := range s.Info.sectionPages {
+		s.addTopSection(path)
+	}
+	for _, p := range s.Pages {
+		s.addTopSection(sectionPath(p))
+	}
+
+	for path, sec := range s.Info.sectionPages {
+		sec.section = sec
+		if path == "" {
+			continue
+		}
+		sec.parentSection = s.Info.findSection(parentSectionPath(path))
+		sec.parentSection.subSections = append(sec.parentSection.subSections, sec)
+	}
+	for _, sec := range s.Info.sectionPages {
+		PageBy(sectionSort).Sort(sec.subSections)
+	}
+
+	for _, p := range s.Pages {
+		p.section = s.Info.findSection(sectionPath(p))
+	}
+}
+
+// sectionSort orders the sections by weight and title, as they rarely have
+// a date.
+var sectionSort = func(p1, p2 *Page) bool {
+	if p1.Weight == p2.Weight {
+		return p1.Title < p2.Title
+	}
+	return p1.Weight < p2.Weight
+}
+
+// addTopSection adds a page for the top level section of path if there is
+// no _index file for it.
+func (s *Site) addTopSection(path string) {
+	top := strings.SplitN(path, "/", 2)[0]
+	if _, ok := s.Info.sectionPages[top]; !ok {
+		s.Info.sectionPages[top] = s.newSectionPage(top)
+	}
+}
+
+// findSection returns the section of the content directory path, which is
+// the nearest one with a section page.
+func (s *SiteInfo) findSection(path string) *Page {
+	for {
+		if sec, ok := s.sectionPages[path]; ok || path == "" {
+			return sec
+		}
+		path = parentSectionPath(path)
+	}
+}
+
+// sectionData returns the pages in the nested section path and in the
+// sections below it.
+func (s *Site) sectionData(path string) WeightedPages {
+	var data WeightedPages
+	for _, p := range s.Pages {
+		if dir := sectionPath(p); dir == path || strings.HasPrefix(dir, path+"/") {
+			data = append(data, WeightedPage{p.Weight, p})
+		}
+	}
+	data.Sort()
+	return data
+}
+
+// CurrentSection returns the page of the section this is in, or the page
+// itself for the page of a section.
+func (n *Node) CurrentSection() *Page {
+	return n.section
+}
+
+// Sections returns the sections directly below the section of a list page.
+func (n *Node) Sections() Pages {
+	if n.section == nil {
+		return nil
+	}
+	return n.section.subSections
+}
+
+// Sections returns the sections directly below a section page, and nothing
+// for a regular page.
+func (p *Page) Sections() Pages {
+	return p.subSections
+}
+
+// Parent returns the section above the section of a list page, nil on the
+// home page.
+func (n *Node) Parent() *Page {
+	if n.section == nil {
+		return nil
+	}
+	return n.section.parentSection
+}
+
+// Parent returns the section a regular page is in, or the section above a
+// section page, nil for the home page.
+func (p *Page) Parent() *Page {
+	if p.section == p {
+		return p.parentSection
+	}
+	return p.section
+}
+
+// Ancestors returns the sections above a list page, the nearest first and
+// the home page last, e.g. for breadcrumbs:
+//
+//	{{ range .Ancestors.Reverse }}<a href="{{ .Permalink }}">{{ .Title }}</a> / {{ end }}{{ .Title }}
+func (n *Node) Ancestors() Pages {
+	return ancestors(n.Parent())
+}
+
+// Ancestors returns the sections above a page, the nearest first and the
+// home page last.
+func (p *Page) Ancestors() Pages {
+	return ancestors(p.Parent())
+}
+
+func ancestors(sec *Page) Pages {
+	var pages Pages
+	for ; sec != nil; sec = sec.parentSection {
+		pages = append(pages, sec)
+	}
+	return pages
+}
+
+// FirstSection returns the top level section this is in, the page of the
+// home page on the home page.
+func (n *Node) FirstSection() *Page {
+	sec := n.section
+	for sec != nil && sec.parentSection != nil && sec.parentSection.parentSection != nil {
+		sec = sec.parentSection
+	}
+	return sec
+}
@@ -0,0 +1,165 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"github.com/spf13/viper"
+)
+
+func TestNestedSections(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub")
+	viper.Set("DisableRSS", true)
+	defer viper.Set("DisableRSS", false)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: About\n---\nAbout.")},
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nOne.")},
+		{filepath.FromSlash("post/2015/_index.md"), []byte("---\ntitle: The Year 2015\ndescription: Old posts\n---\nWhat happened.")},
+		{filepath.FromSlash("post/2015/two.md"), []byte("---\ntitle: Two\n---\nTwo.")},
+		{filepath.FromSlash("post/2015/jan/three.md"), []byte("---\ntitle: Three\n---\nThree.")},
+		{filepath.FromSlash("project/hugo.md"), []byte("---\ntitle: Hugo\n---\nHugo.")},
+	})
+	s.Targets.Page = &target.PagePub{UglyURLs: true}
+	s.Info.Title = "Site"
+
+	must(s.addTemplate("_default/single.html", "{{ range .Ancestors.Reverse }}{{ .Title }} / {{ end }}{{ .Title }}"))
+	must(s.addTemplate("section/post.html", "{{ .Title }}, {{ .Description }}:{{ range .Data.Pages }} {{ .Title }}{{ end }} /{{ range .Sections }} {{ .Title }}{{ end }}"))
+
+	createAndRenderPages(t, s)
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+
+	if len(s.Pages) != 5 {
+		t.Fatalf("Expected the _index file to not be a regular page, got %d pages", len(s.Pages))
+	}
+
+	for _, p := range s.Pages {
+		expected := map[string]string{
+			"About": "Site",
+			"One":   "Post",
+			"Two":   "The Year 2015",
+			"Three": "The Year 2015",
+			"Hugo":  "Project",
+		}[p.Title]
+		if sec := p.CurrentSection(); sec == nil || sec.Title != expected {
+			t.Errorf("Expected %s to be in section %q, got %v", p.Title, expected, sec)
+		}
+		if len(p.Sections()) != 0 {
+			t.Errorf("Expected no sections below the regular page %s", p.Title)
+		}
+	}
+
+	home := s.newHomeNode()
+	var titles []string
+	for _, sec := range home.Sections() {
+		titles = append(titles, sec.Title)
+	}
+	if strings.Join(titles, ",") != "Post,Project" {
+		t.Errorf("Expected the top level sections below the home page, got %v", titles)
+	}
+
+	for _, p := range s.Pages {
+		expected := map[string]string{
+			"About": "Site",
+			"One":   "Post,Site",
+			"Two":   "The Year 2015,Post,Site",
+			"Three": "The Year 2015,Post,Site",
+			"Hugo":  "Project,Site",
+		}[p.Title]
+		var titles []string
+		for _, a := range p.Ancestors() {
+			titles = append(titles, a.Title)
+		}
+		if strings.Join(titles, ",") != expected {
+			t.Errorf("Expected the ancestors of %s to be %s, got %v", p.Title, expected, titles)
+		}
+		if p.Parent() != p.Ancestors()[0] {
+			t.Errorf("Expected the parent of %s to be its nearest ancestor", p.Title)
+		}
+	}
+
+	// the pages of The Year 2015 link to each other, not to One in Post
+	pages := make(map[string]*Page)
+	for _, p := range s.Pages {
+		pages[p.Title] = p
+	}
+	if pages["Three"].PrevInSection != pages["Two"] || pages["Two"].NextInSection != pages["Three"] {
+		t.Errorf("Expected Two and Three to be linked in their section")
+	}
+	if pages["Three"].NextInSection != nil || pages["Two"].PrevInSection != nil ||
+		pages["One"].NextInSection != nil || pages["One"].PrevInSection != nil {
+		t.Errorf("Expected the pages to only be linked to the others of their nearest section")
+	}
+
+	year := s.Info.sectionPages["post/2015"]
+	post := s.Info.sectionPages["post"]
+	if year.Parent() != post || year.FirstSection() != post || post.FirstSection() != post {
+		t.Errorf("Expected post to be the parent and first section of %s", year.Title)
+	}
+	if home.Parent() != nil || len(home.Ancestors()) != 0 || home.FirstSection() != s.Info.sectionPages[""] {
+		t.Errorf("Expected the home page to have no ancestors")
+	}
+	if n := s.newSectionListNode("post/2015", s.sectionData("post/2015")); n.Parent() != post || len(n.Ancestors()) != 2 {
+		t.Errorf("Expected the section list to have the ancestors of its section")
+	}
+
+	if year.CurrentSection() != year || string(year.Content) != "<p>What happened.</p>\n" {
+		t.Errorf("Unexpected section page %q with content %q", year.Title, year.Content)
+	}
+	if permalink, _ := year.Permalink(); permalink != "http://auth/bub/post/2015/" {
+		t.Errorf("Unexpected permalink %s of the section page", permalink)
+	}
+
+	for doc, expected := range map[string]string{
+		"/post.html":               "Post, : One Three Two / The Year 2015",
+		"/post/2015.html":          "The Year 2015, Old posts: Three Two /",
+		"post/2015/jan/three.html": "Site / Post / The Year 2015 / Three",
+	} {
+		file, err := hugofs.DestinationFS.Open(filepath.FromSlash(doc))
+		if err != nil {
+			t.Fatalf("Did not find %s in target.", doc)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != expected {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", doc, expected, content)
+		}
+	}
+}
+
+func TestHomePageIndexFile(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub")
+	viper.Set("DisableRSS", true)
+	defer viper.Set("DisableRSS", false)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("_index.md"), []byte("---\ntitle: Welcome\ndescription: All about us\nmotto: Be nice\n---\nHello.")},
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nOne.")},
+	})
+	s.Targets.Page = &target.PagePub{}
+
+	must(s.addTemplate("index.html", "{{ .Title }}, {{ .Description }}, {{ .Params.motto }}: {{ .Data.Section.Content }}{{ range .Data.Pages }}{{ .Title }}{{ end }}"))
+	must(s.addTemplate("404.html", "Not found"))
+
+	createAndRenderPages(t, s)
+	if err := s.RenderHomePage(); err != nil {
+		t.Fatalf("Unable to render the home page: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open("index.html")
+	if err != nil {
+		t.Fatalf("Did not find the home page in target.")
+	}
+	expected := "Welcome, All about us, Be nice: <p>Hello.</p>\nOne"
+	if content := string(helpers.ReaderToBytes(file)); content != expected {
+		t.Errorf("Home page content expected:\n%q\ngot:\n%q", expected, content)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+
+	"github.com/spf13/hugo/helpers"
+)
+
+// seriesTaxonomy is the taxonomy of the multi-part posts, which must be
+// in the taxonomies of the config for Series to find them:
+//
+//	[taxonomies]
+//	  series = "series"
+const seriesTaxonomy = "series"
+
+// A PageSeries is a series a page is part of: the pages of a term of the
+// series taxonomy in reading order, and the position of the page in them.
+type PageSeries struct {
+	Key   string // the key of the term, e.g. "learning-go"
+	Title string // the title of its _index page, else the term in title case
+	Pages Pages
+	index int
+}
+
+// Position returns the position of the page in the series, from 1, e.g.
+// for "Part {{ .Position }} of {{ .Count }}".
+func (s *PageSeries) Position() int {
+	return s.index + 1
+}
+
+// Count returns the number of pages in the series.
+func (s *PageSeries) Count() int {
+	return len(s.Pages)
+}
+
+// Prev returns the part before the page, nil for the first one.
+func (s *PageSeries) Prev() *Page {
+	if s.index > 0 {
+		return s.Pages[s.index-1]
+	}
+	return nil
+}
+
+// Next returns the part after the page, nil for the last one.
+func (s *PageSeries) Next() *Page {
+	if s.index < len(s.Pages)-1 {
+		return s.Pages[s.index+1]
+	}
+	return nil
+}
+
+// IsFirst returns whether the page is the first part of the series.
+func (s *PageSeries) IsFirst() bool {
+	return s.index == 0
+}
+
+// IsLast returns whether the page is the last part of the series.
+func (s *PageSeries) IsLast() bool {
+	return s.index == len(s.Pages)-1
+}
+
+// Url returns the relative url of the list of the series, e.g.
+// /series/learning-go/.
+func (s *PageSeries) Url() string {
+	return helpers.URLizeAndPrep(seriesTaxonomy + "/" + s.Key)
+}
+
+// seriesOrder is the reading order of the parts of a series: by their
+// series_weight, then oldest first, then by title.
+func seriesOrder(wp1, wp2 *WeightedPage) bool {
+	if wp1.Weight == wp2.Weight {
+		if wp1.Page.Date.Equal(wp2.Page.Date) {
+			return wp1.Page.Title < wp2.Page.Title
+		}
+		return wp1.Page.Date.Before(wp2.Page.Date)
+	}
+	return wp1.Weight < wp2.Weight
+}
+
+// Series returns the series the page is part of, from its series front
+// matter, e.g.
+//
+//	{{ range .Series }}
+//	  Part {{ .Position }} of {{ .Count }} of <a href="{{ .Url }}">{{ .Title }}</a>
+//	  {{ with .Prev }}<a href="{{ .Permalink }}">{{ .Title }}</a>{{ end }}
+//	  {{ with .Next }}<a href="{{ .Permalink }}">{{ .Title }}</a>{{ end }}
+//	{{ end }}
+func (p *Page) Series() []*PageSeries {
+	keys, _ := p.taxonomyTerms(seriesTaxonomy)
+
+	var series []*PageSeries
+	for _, key := range keys {
+		parts := p.Site.Taxonomies[seriesTaxonomy][key]
+		if len(parts) == 0 {
+			continue
+		}
+
+		ordered := make(WeightedPages, len(parts))
+		copy(ordered, parts)
+		WeightedPagesBy(seriesOrder).Sort(ordered)
+
+		s := &PageSeries{Key: key, Pages: ordered.Pages(), index: -1}
+		for i, part := range s.Pages {
+			if part == p {
+				s.index = i
+				break
+			}
+		}
+		if s.index < 0 {
+			continue
+		}
+
+		if term, ok := p.Site.termPages[seriesTaxonomy][key]; ok {
+			s.Title = term.Title
+		} else {
+			s.Title = strings.Replace(strings.Title(key), "-", " ", -1)
+		}
+		series = append(series, s)
+	}
+	return series
+}
@@ -0,0 +1,72 @@
+package hugolib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestSeries(t *testing.T) {
+	viper.Set("taxonomies", map[string]string{"series": "series"})
+	defer viper.Set("taxonomies", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/intro.md"), []byte("---\ntitle: Intro\ndate: 2015-01-01\nseries: [Learning Go]\n---\n")},
+		{filepath.FromSlash("post/types.md"), []byte("---\ntitle: Types\ndate: 2015-03-01\nseries: [Learning Go, Go Deep]\n---\n")},
+		{filepath.FromSlash("post/funcs.md"), []byte("---\ntitle: Funcs\ndate: 2015-02-01\nseries: [Learning Go]\n---\n")},
+		{filepath.FromSlash("post/other.md"), []byte("---\ntitle: Other\ndate: 2015-02-01\n---\n")},
+		{filepath.FromSlash("series/go-deep/_index.md"), []byte("---\ntitle: Deep into Go\n---\n")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	pages := make(map[string]*Page)
+	for _, p := range s.Pages {
+		pages[p.Title] = p
+	}
+
+	series := pages["Funcs"].Series()
+	if len(series) != 1 {
+		t.Fatalf("Expected Funcs in 1 series, got %d", len(series))
+	}
+	learning := series[0]
+	if learning.Title != "Learning Go" || learning.Url() != "/series/learning-go/" {
+		t.Errorf("Got the series %q at %s", learning.Title, learning.Url())
+	}
+	if learning.Position() != 2 || learning.Count() != 3 || learning.IsFirst() || learning.IsLast() {
+		t.Errorf("Expected Funcs to be part 2 of 3, got %d of %d", learning.Position(), learning.Count())
+	}
+	if learning.Prev() != pages["Intro"] || learning.Next() != pages["Types"] {
+		t.Errorf("Expected Funcs between Intro and Types, got %v and %v", learning.Prev(), learning.Next())
+	}
+
+	series = pages["Types"].Series()
+	if len(series) != 2 {
+		t.Fatalf("Expected Types in 2 series, got %d", len(series))
+	}
+	for _, s := range series {
+		switch s.Key {
+		case "learning-go":
+			if s.Position() != 3 || !s.IsLast() || s.Next() != nil {
+				t.Errorf("Expected Types to be the last part of Learning Go, got %d", s.Position())
+			}
+		case "go-deep":
+			if s.Title != "Deep into Go" || s.Count() != 1 || s.Prev() != nil || s.Next() != nil {
+				t.Errorf("Expected Types alone in Deep into Go, got %q with %d pages", s.Title, s.Count())
+			}
+		default:
+			t.Errorf("Unexpected series %s", s.Key)
+		}
+	}
+
+	if len(pages["Other"].Series()) != 0 {
+		t.Errorf("Expected no series for a page without one")
+	}
+}
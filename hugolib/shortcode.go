@@ -49,6 +49,10 @@ func (scp *ShortcodeWithPage) RelRef(ref string) (string, error) {
 	return scp.Page.RelRef(ref)
 }
 
+func (scp *ShortcodeWithPage) CrossRef(site, ref string) (string, error) {
+	return scp.Page.CrossRef(site, ref)
+}
+
 func (scp *ShortcodeWithPage) Get(key interface{}) interface{} {
 	if reflect.ValueOf(scp.Params).Len() == 0 {
 		return nil
@@ -203,7 +207,7 @@ func renderShortcode(sc shortcode, p *Page, t tpl.Template) string {
 		if sc.doMarkup {
 			newInner := helpers.RenderBytes(&helpers.RenderingContext{
 				Content: []byte(inner), PageFmt: p.guessMarkupType(),
-				DocumentID: p.UniqueID(), Config: p.getRenderingConfig()})
+				DocumentID: p.UniqueID(), Config: p.getRenderingConfig(), Markup: p.getMarkupConfig(), RenderHook: p.renderHook})
 
 			// If the type is “unknown” or “markdown”, we assume the markdown
 			// generation has been performed. Given the input: `a line`, markdown
@@ -384,7 +388,14 @@ func extractShortcodes(stringToParse string, p *Page, t tpl.Template) (string, m
 	// the parser takes a string;
 	// since this is an internal API, it could make sense to use the mutable []byte all the way, but
 	// it seems that the time isn't really spent in the byte copy operations, and the impl. gets a lot cleaner
-	pt := &pageTokens{lexer: newShortcodeLexer("parse-page", stringToParse, pos(startIdx))}
+	// by default the shortcodes in the code of Markdown are shown as is, e.g.
+	// for documentation about them
+	skipCode := !p.getMarkupConfig().ShortcodesInCode && p.guessMarkupType() == "markdown"
+	if skipCode {
+		startIdx = 0
+	}
+
+	pt := &pageTokens{lexer: newShortcodeLexer("parse-page", stringToParse, pos(startIdx), skipCode)}
 
 	id := 1 // incremented id, will be appended onto temp. shortcode placeholders
 
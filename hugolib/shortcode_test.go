@@ -143,6 +143,35 @@ func TestFigureImgWidth(t *testing.T) {
 	CheckShortCodeMatch(t, `{{% figure src="/found/here" class="bananas orange" alt="apple" width="100px" %}}`, "\n<figure class=\"bananas orange\">\n    \n        <img src=\"/found/here\" alt=\"apple\" width=\"100px\" />\n    \n    \n</figure>\n", tem)
 }
 
+func TestTableSC(t *testing.T) {
+	tem := tpl.New()
+	p, _ := pageFromString(SIMPLE_PAGE, "simple.md")
+	data := map[string]interface{}{"prices": []interface{}{[]interface{}{"name", "price"}, []interface{}{"apple", 1.5}}}
+	p.Site = &SiteInfo{Data: &data}
+
+	output := ShortcodesHandle(`{{< table data="prices" caption="Prices" >}}`, p, tem)
+	expected := `<table>
+  <caption>Prices</caption>
+  <thead>
+    <tr><th>name</th><th>price</th></tr>
+  </thead>
+  <tbody>
+    <tr><td>apple</td><td>1.5</td></tr>
+  </tbody>
+</table>`
+	if output != expected {
+		t.Fatalf("Shortcode render didn't match. Expected: %q, Got: %q", expected, output)
+	}
+}
+
+func TestGistSC(t *testing.T) {
+	tem := tpl.New()
+	CheckShortCodeMatch(t, `{{< gist spf13 7896402 >}}`,
+		"<script src=\"//gist.github.com/spf13/7896402.js\"></script>\n<noscript><a href=\"https://gist.github.com/spf13/7896402\">View the gist on GitHub</a></noscript>", tem)
+	CheckShortCodeMatch(t, `{{< gist spf13 7896402 "img.html" >}}`,
+		"<script src=\"//gist.github.com/spf13/7896402.js?file=img.html\"></script>\n<noscript><a href=\"https://gist.github.com/spf13/7896402#file-img-html\">View the gist on GitHub</a></noscript>", tem)
+}
+
 func TestHighlight(t *testing.T) {
 	if !helpers.HasPygments() {
 		t.Skip("Skip test as Pygments is not installed")
@@ -161,6 +190,33 @@ void do();
 
 const testScPlaceholderRegexp = "{@{@HUGOSHORTCODE-\\d+@}@}"
 
+func TestShortcodesInCode(t *testing.T) {
+	tem := tpl.New()
+	tem.AddInternalShortcode("sc1.html", `sc1`)
+
+	input := "Run `{{< sc1 >}}`:\n\n```\n{{< sc1 >}}\n```\n"
+	for _, this := range []struct {
+		filename string
+		inCode   bool
+		count    int
+	}{
+		{"simple.md", false, 0},
+		{"simple.md", true, 2},
+		{"simple.html", false, 2},
+	} {
+		viper.Set("ShortcodesInCode", this.inCode)
+		p, _ := pageFromString(SIMPLE_PAGE, this.filename)
+		content, shortCodes, err := extractShortcodes(input, p, tem)
+		if err != nil || len(shortCodes) != this.count {
+			t.Errorf("%s: expected %d shortcodes with ShortcodesInCode %t, got %d %v in %q", this.filename, this.count, this.inCode, len(shortCodes), err, content)
+		}
+		if this.count == 0 && content != input {
+			t.Errorf("%s: expected the code as is, got %q", this.filename, content)
+		}
+	}
+	viper.Set("ShortcodesInCode", false)
+}
+
 func TestExtractShortcodes(t *testing.T) {
 	for i, this := range []struct {
 		name             string
@@ -169,13 +169,20 @@ type pagelexer struct {
 	paramElements      int             // number of elements (name + value = 2) found first
 	openShortcodes     map[string]bool // set of shortcodes in open state
 
+	// the code blocks and spans of Markdown are text, but for the comment
+	// markers of escaped shortcodes
+	skipCode      bool
+	codeEndPos    pos          // end of the code at the input position
+	unclosedTicks map[int]bool // lengths of backtick runs never closed after here
+
 	// items delivered to client
 	items chan item
 }
 
 // note: the input position here is normally 0 (start), but
-// can be set if position of first shortcode is known
-func newShortcodeLexer(name, input string, inputPosition pos) *pagelexer {
+// can be set if position of first shortcode is known. It must be 0 with
+// skipCode, to find the code blocks before it.
+func newShortcodeLexer(name, input string, inputPosition pos, skipCode bool) *pagelexer {
 	lexer := &pagelexer{
 		name:               name,
 		input:              input,
@@ -183,6 +190,8 @@ func newShortcodeLexer(name, input string, inputPosition pos) *pagelexer {
 		currRightDelimItem: tRightDelimScNoMarkup,
 		pos:                inputPosition,
 		openShortcodes:     make(map[string]bool),
+		skipCode:           skipCode,
+		unclosedTicks:      make(map[int]bool),
 		items:              make(chan item),
 	}
 	go lexer.runShortcodeLexer()
@@ -282,11 +291,113 @@ func (l *pagelexer) nextItem() item {
 	return item
 }
 
+// findCodeEnd returns the end of the fenced code block or the code span at the
+// input position, or the position itself if there is none.
+func (l *pagelexer) findCodeEnd() pos {
+	if l.pos == 0 || l.input[l.pos-1] == '\n' {
+		if end := fencedCodeEnd(l.input, int(l.pos)); end >= 0 {
+			return pos(end)
+		}
+	}
+	if l.input[l.pos] == '`' {
+		return pos(codeSpanEnd(l.input, int(l.pos), l.unclosedTicks))
+	}
+	return l.pos
+}
+
+// fencedCodeEnd returns the end of the fenced code block starting at the
+// line at i, after its closing fence, or -1 if the line is no fence. A
+// block that is never closed runs to the end of the input.
+func fencedCodeEnd(input string, i int) int {
+	fence, _ := fenceAt(input, i)
+	if fence == "" {
+		return -1
+	}
+	for {
+		i = lineEnd(input, i)
+		if i == len(input) {
+			return i
+		}
+		closing, after := fenceAt(input, i)
+		end := lineEnd(input, i)
+		if strings.HasPrefix(closing, fence) && strings.TrimSpace(input[after:end]) == "" {
+			return end
+		}
+	}
+}
+
+// fenceAt returns the ``` or ~~~ fence, of three or more, that the line at i
+// starts with after at most three spaces, and the position after it.
+func fenceAt(input string, i int) (string, int) {
+	for n := 0; n < 3 && i < len(input) && input[i] == ' '; n++ {
+		i++
+	}
+	if i >= len(input) || (input[i] != '`' && input[i] != '~') {
+		return "", i
+	}
+	end := i
+	for end < len(input) && input[end] == input[i] {
+		end++
+	}
+	if end-i < 3 {
+		return "", i
+	}
+	return input[i:end], end
+}
+
+// lineEnd returns the position after the line at i, including the newline.
+func lineEnd(input string, i int) int {
+	if nl := strings.IndexByte(input[i:], '\n'); nl >= 0 {
+		return i + nl + 1
+	}
+	return len(input)
+}
+
+// codeSpanEnd returns the end of the code span starting with the backticks
+// at i, that is after the next run of as many backticks, or the end of the
+// backticks if there is none, as they are no code span then.
+//
+// A run without a closing one has none after any later run of its length
+// either, so its length is added to unclosed, and later runs of that length
+// aren't scanned for again. Otherwise every stray backtick would scan the
+// rest of the input.
+func codeSpanEnd(input string, i int, unclosed map[int]bool) int {
+	end := i
+	for end < len(input) && input[end] == '`' {
+		end++
+	}
+	if unclosed[end-i] {
+		return end
+	}
+	ticks := input[i:end]
+	for j := end; j < len(input); {
+		k := strings.Index(input[j:], ticks)
+		if k < 0 {
+			break
+		}
+		j += k
+		run := j
+		for run < len(input) && input[run] == '`' {
+			run++
+		}
+		if run-j == len(ticks) {
+			return run
+		}
+		j = run
+	}
+	unclosed[end-i] = true
+	return end
+}
+
 // scans until an opening shortcode opening bracket.
 // if no shortcodes, it will keep on scanning until EOF
 func lexTextOutsideShortcodes(l *pagelexer) stateFunc {
 	for {
-		if strings.HasPrefix(l.input[l.pos:], leftDelimScWithMarkup) || strings.HasPrefix(l.input[l.pos:], leftDelimScNoMarkup) {
+		if l.skipCode && l.pos >= l.codeEndPos && int(l.pos) < len(l.input) {
+			l.codeEndPos = l.findCodeEnd()
+		}
+		if (strings.HasPrefix(l.input[l.pos:], leftDelimScWithMarkup) || strings.HasPrefix(l.input[l.pos:], leftDelimScNoMarkup)) &&
+			(l.pos >= l.codeEndPos || strings.HasPrefix(l.input[l.pos+pos(len(leftDelimScNoMarkup)):], leftComment)) {
 			if l.pos > l.start {
 				l.emit(tText)
 			}
@@ -126,10 +126,54 @@ var shortCodeLexerTests = []shortCodeLexerTest{
 		{tText, 0, "{{<"}, {tText, 0, " sc1 >}}"}, {tError, 0, "comment ends before the right shortcode delimiter"}}},
 }
 
+var shortCodeLexerCodeTests = []shortCodeLexerTest{
+	{"code span", "a `{{< sc1 >}}` b", []item{{tText, 0, "a `{{< sc1 >}}` b"}, tstEOF}},
+	{"double backticks", "``{{< sc1 >}} ` {{< sc1 >}}`` {{< sc1 >}}", []item{
+		{tText, 0, "``{{< sc1 >}} ` {{< sc1 >}}`` "}, tstLeftNoMD, tstSC1, tstRightNoMD, tstEOF}},
+	{"unmatched backtick", "a ` {{< sc1 >}}", []item{{tText, 0, "a ` "}, tstLeftNoMD, tstSC1, tstRightNoMD, tstEOF}},
+	{"unmatched backtick, then a span", "a ` b `` {{< sc1 >}} `` {{< sc1 >}}", []item{
+		{tText, 0, "a ` b `` {{< sc1 >}} `` "}, tstLeftNoMD, tstSC1, tstRightNoMD, tstEOF}},
+	{"fenced", "```go\n{{< sc1 >}}\n```\n{{< sc1 >}}", []item{
+		{tText, 0, "```go\n{{< sc1 >}}\n```\n"}, tstLeftNoMD, tstSC1, tstRightNoMD, tstEOF}},
+	{"fenced with tildes, shorter fence inside", "~~~~\n~~~\n{{% sc1 %}}\n~~~~\n", []item{
+		{tText, 0, "~~~~\n~~~\n{{% sc1 %}}\n~~~~\n"}, tstEOF}},
+	{"fence not closed", "  ```\n{{< sc1 >}}", []item{{tText, 0, "  ```\n{{< sc1 >}}"}, tstEOF}},
+	{"no fence in a line", "a ```\n{{< sc1 >}}", []item{{tText, 0, "a ```\n"}, tstLeftNoMD, tstSC1, tstRightNoMD, tstEOF}},
+	{"commented out in code", "`{{</* sc1 */>}}`", []item{
+		{tText, 0, "`"}, {tText, 0, "{{<"}, {tText, 0, " sc1 "}, {tText, 0, ">}}"}, {tText, 0, "`"}, tstEOF}},
+}
+
+func TestShortcodeLexerSkipsCode(t *testing.T) {
+	for _, test := range shortCodeLexerCodeTests {
+		items := collect(&test, true)
+		if !equal(items, test.items) {
+			t.Errorf("%s: got\n\t%v\nexpected\n\t%v", test.name, items, test.items)
+		}
+	}
+}
+
+func TestCodeSpanEndRemembersUnclosed(t *testing.T) {
+	unclosed := make(map[int]bool)
+	input := "a ` b ``c`` d"
+
+	if end := codeSpanEnd(input, 2, unclosed); end != 3 || !unclosed[1] {
+		t.Errorf("Expected the backtick at 2 to be unclosed, got %d, %v", end, unclosed)
+	}
+	if end := codeSpanEnd(input, 6, unclosed); end != 11 || unclosed[2] {
+		t.Errorf("Expected the span at 6 to end at 11, got %d, %v", end, unclosed)
+	}
+
+	// a run of a length known to be unclosed isn't scanned for again
+	unclosed[2] = true
+	if end := codeSpanEnd(input, 6, unclosed); end != 8 {
+		t.Errorf("Expected the backticks at 6 to be taken as unclosed, got %d", end)
+	}
+}
+
 func TestShortcodeLexer(t *testing.T) {
 	for _, test := range shortCodeLexerTests {
 
-		items := collect(&test)
+		items := collect(&test, false)
 		if !equal(items, test.items) {
 			t.Errorf("%s: got\n\t%v\nexpected\n\t%v", test.name, items, test.items)
 		}
@@ -140,7 +184,7 @@ func BenchmarkShortcodeLexer(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		for _, test := range shortCodeLexerTests {
-			items := collect(&test)
+			items := collect(&test, false)
 			if !equal(items, test.items) {
 				b.Errorf("%s: got\n\t%v\nexpected\n\t%v", test.name, items, test.items)
 			}
@@ -148,8 +192,8 @@ func BenchmarkShortcodeLexer(b *testing.B) {
 	}
 }
 
-func collect(t *shortCodeLexerTest) (items []item) {
-	l := newShortcodeLexer(t.name, t.input, 0)
+func collect(t *shortCodeLexerTest, skipCode bool) (items []item) {
+	l := newShortcodeLexer(t.name, t.input, 0, skipCode)
 	for {
 		item := l.nextItem()
 		items = append(items, item)
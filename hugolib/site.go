@@ -22,14 +22,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"sync/atomic"
 
-	"bitbucket.org/pkg/inflect"
 	"github.com/spf13/cast"
 	bp "github.com/spf13/hugo/bufferpool"
 	"github.com/spf13/hugo/helpers"
@@ -84,6 +82,14 @@ type Site struct {
 	draftCount     int
 	futureCount    int
 	Data           map[string]interface{}
+	Translations   map[string]map[string]interface{} // by language and id, for the i18n func
+	Calendar       Calendar
+	stepStart      time.Time
+	buildLimits    *buildLimits
+	limitsInit     sync.Once
+	feeds          []feedFormat
+	glossary       glossary
+	Language       *Language // nil unless the site is multilingual
 }
 
 type targetList struct {
@@ -103,19 +109,70 @@ type SiteInfo struct {
 	Files               []*source.File
 	Recent              *Pages // legacy, should be identical to Pages
 	Menus               *Menus
+	Calendar            *Calendar
 	Hugo                *HugoInfo
 	Title               string
 	Author              map[string]interface{}
 	LanguageCode        string
 	DisqusShortname     string
+	RSSTitle            string
+	RSSLimit            int
+	RSSDescription      string
 	Copyright           string
 	LastChange          time.Time
 	Permalinks          PermalinkOverrides
 	Params              map[string]interface{}
 	BuildDrafts         bool
-	canonifyURLs        bool
 	paginationPageCount uint64
 	Data                *map[string]interface{}
+	refIndex            *pageRefIndex
+	refIndexInit        sync.Once
+	termPages           map[string]map[string]*Page
+	sectionPages        map[string]*Page
+	outputFormats       []OutputFormat
+	formatDefs          map[string]OutputFormat
+	Language            *Language
+	Languages           []*Language
+	siblings            map[string]*SiteInfo // the sites of a multi-site build, by language
+}
+
+// pageRefIndex is used to look up the target of a ref or relref by the
+// path of the content file relative to the content directory (blog/post.md)
+// or by its logical name (post.md).
+type pageRefIndex struct {
+	byPath map[string]*Page
+	byName map[string]Pages
+}
+
+func newPageRefIndex(pages Pages) *pageRefIndex {
+	idx := &pageRefIndex{
+		byPath: make(map[string]*Page),
+		byName: make(map[string]Pages),
+	}
+	for _, p := range pages {
+		idx.byPath[filepath.ToSlash(p.Source.Path())] = p
+		idx.byName[p.Source.LogicalName()] = append(idx.byName[p.Source.LogicalName()], p)
+	}
+	return idx
+}
+
+func (idx *pageRefIndex) get(ref string) (*Page, error) {
+	if p, ok := idx.byPath[ref]; ok {
+		return p, nil
+	}
+
+	switch candidates := idx.byName[ref]; len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("No page found with path or logical name \"%s\".\n", ref)
+	case 1:
+		return candidates[0], nil
+	default:
+		var paths []string
+		for _, p := range candidates {
+			paths = append(paths, filepath.ToSlash(p.Source.Path()))
+		}
+		return nil, fmt.Errorf("Logical name \"%s\" is ambiguous, use one of %s.\n", ref, strings.Join(paths, ", "))
+	}
 }
 
 // SiteSocial is a place to put social details on a site level. These are the
@@ -133,8 +190,14 @@ type SiteInfo struct {
 // linkedin
 type SiteSocial map[string]string
 
+// GetParam returns the value of the params of the site config for key, in
+// any case. A dotted key looks into the tables of the params, e.g.
+// "social.twitter" for
+//
+//	[params.social]
+//	  twitter = "spf13"
 func (s *SiteInfo) GetParam(key string) interface{} {
-	v := s.Params[strings.ToLower(key)]
+	v := lookupParam(s.Params, key)
 
 	if v == nil {
 		return nil
@@ -151,12 +214,53 @@ func (s *SiteInfo) GetParam(key string) interface{} {
 		return cast.ToFloat64(v)
 	case time.Time:
 		return cast.ToTime(v)
-	case []string:
+	case []string, []interface{}:
+		return v
+	case map[string]interface{}: // JSON and TOML
 		return v
+	case map[interface{}]interface{}: // YAML
+		return cast.ToStringMap(v)
 	}
 	return nil
 }
 
+// lookupParam returns the value of the dotted key in params, matching the
+// keys in any case as the config may keep or lower them.
+func lookupParam(params map[string]interface{}, key string) interface{} {
+	var v interface{} = params
+	for _, k := range strings.Split(key, ".") {
+		m := cast.ToStringMap(v)
+		if m == nil {
+			return nil
+		}
+		if found, ok := m[k]; ok {
+			v = found
+			continue
+		}
+		v = nil
+		for mk, mv := range m {
+			if strings.EqualFold(mk, k) {
+				v = mv
+				break
+			}
+		}
+		if v == nil {
+			return nil
+		}
+	}
+	return v
+}
+
+// RegularPages returns the content pages of the site in the order of
+// .Site.Pages, which are the regular pages only: the _index files of the
+// sections and the home page are not among them.
+func (s *SiteInfo) RegularPages() Pages {
+	if s.Pages == nil {
+		return nil
+	}
+	return *s.Pages
+}
+
 func (s *SiteInfo) refLink(ref string, page *Page, relative bool) (string, error) {
 	var refURL *url.URL
 	var err error
@@ -171,15 +275,13 @@ func (s *SiteInfo) refLink(ref string, page *Page, relative bool) (string, error
 	var link string
 
 	if refURL.Path != "" {
-		for _, page := range []*Page(*s.Pages) {
-			if page.Source.Path() == refURL.Path || page.Source.LogicalName() == refURL.Path {
-				target = page
-				break
-			}
-		}
+		s.refIndexInit.Do(func() {
+			s.refIndex = newPageRefIndex(*s.Pages)
+		})
 
-		if target == nil {
-			return "", fmt.Errorf("No page found with path or logical name \"%s\".\n", refURL.Path)
+		target, err = s.refIndex.get(refURL.Path)
+		if err != nil {
+			return "", err
 		}
 
 		if relative {
@@ -214,6 +316,71 @@ func (s *SiteInfo) RelRef(ref string, page *Page) (string, error) {
 	return s.refLink(ref, page, true)
 }
 
+// GetPage returns the page of a content file, by its path in the content
+// directory or its logical name as for Ref, e.g. "post/my-article.md", or
+// the page of a kind: "home", "section" with the section path or
+// "taxonomy" with the plural and the term of a term page:
+//
+//	{{ with .Site.GetPage "post/my-article.md" }}{{ .Title }}{{ end }}
+//	{{ with .Site.GetPage "section" "post" }}{{ .Summary }}{{ end }}
+//
+// It returns nil if there is no such page, and an error if the logical
+// name is ambiguous.
+func (s *SiteInfo) GetPage(ref string, path ...string) (*Page, error) {
+	switch ref {
+	case "home":
+		return s.sectionPages[""], nil
+	case "section":
+		if len(path) == 1 {
+			return s.sectionPages[strings.Trim(path[0], "/")], nil
+		}
+		return nil, fmt.Errorf("GetPage \"section\" takes the path of the section")
+	case "taxonomy":
+		if len(path) == 2 {
+			return s.termPages[path[0]][kp(path[1])], nil
+		}
+		return nil, fmt.Errorf("GetPage \"taxonomy\" takes the plural and the term")
+	}
+	if len(path) > 0 {
+		return nil, fmt.Errorf("Unknown kind of page %q, must be home, section or taxonomy", ref)
+	}
+
+	ref = strings.TrimPrefix(filepath.ToSlash(ref), "/")
+	for _, sec := range s.sectionPages {
+		if sec.Source.Path() != "" && filepath.ToSlash(sec.Source.Path()) == ref {
+			return sec, nil
+		}
+	}
+
+	s.refIndexInit.Do(func() {
+		s.refIndex = newPageRefIndex(*s.Pages)
+	})
+	if _, ok := s.refIndex.byPath[ref]; !ok && len(s.refIndex.byName[ref]) == 0 {
+		return nil, nil
+	}
+	return s.refIndex.get(ref)
+}
+
+// CrossRef returns the permalink of the page ref, as for Ref, in the
+// sibling site of the same multi-site build, by its language. It is an
+// error if there is no such site or page, so broken links between the
+// sites fail the build.
+func (s *SiteInfo) CrossRef(site, ref string) (string, error) {
+	sibling, ok := s.siblings[strings.ToLower(site)]
+	if !ok {
+		return "", fmt.Errorf("No site %q to link to %s in", site, ref)
+	}
+	if strings.HasPrefix(ref, "#") {
+		return "", fmt.Errorf("No page in the link %s to the site %q", ref, site)
+	}
+
+	link, err := sibling.refLink(ref, nil, false)
+	if err != nil {
+		return "", fmt.Errorf("Unable to link to %s in the site %q: %s", ref, site, err)
+	}
+	return link, nil
+}
+
 func (s *SiteInfo) addToPaginationPageCount(cnt uint64) {
 	atomic.AddUint64(&s.paginationPageCount, cnt)
 }
@@ -235,12 +402,27 @@ func (s *Site) timerStep(step string) {
 		s.timer = DefaultTimer
 	}
 	s.timer.Step(step)
+
+	now := time.Now()
+	if !s.stepStart.IsZero() {
+		helpers.BuildLog.Timing(step, now.Sub(s.stepStart))
+	}
+	s.stepStart = now
 }
 
 func (s *Site) Build() (err error) {
+	s.stepStart = time.Now()
 	if err = s.Process(); err != nil {
 		return
 	}
+	return s.renderProcessed()
+}
+
+// renderProcessed renders the site once processed and checks the result.
+func (s *Site) renderProcessed() (err error) {
+	if err = s.CheckTargetCollisions(); err != nil {
+		return
+	}
 	if err = s.Render(); err != nil {
 		jww.ERROR.Printf("Error rendering site: %s\nAvailable templates:\n", err)
 		for _, template := range s.Tmpl.Templates() {
@@ -248,9 +430,36 @@ func (s *Site) Build() (err error) {
 		}
 		return
 	}
+	if err = s.CheckBudgets(); err != nil {
+		return
+	}
+	if err = s.Precompress(); err != nil {
+		return
+	}
+	if err = s.WriteManifest(); err != nil {
+		return
+	}
 	return nil
 }
 
+// ProcessSource reads and converts the pages of s.Source, with the
+// templates already in s.Tmpl, without the directories and data files of
+// a site on disk. It is Process for content held in memory, e.g. by the
+// hugotest package.
+func (s *Site) ProcessSource() error {
+	s.Menus = Menus{}
+	s.Shortcodes = make(map[string]ShortcodeFunc)
+	s.initializeSiteInfo()
+	if s.Tmpl == nil {
+		s.Tmpl = tpl.InitializeT()
+	}
+
+	if err := s.CreatePages(); err != nil {
+		return err
+	}
+	return s.BuildSiteMeta()
+}
+
 func (s *Site) Analyze() {
 	s.Process()
 	s.ShowPlan(os.Stdout)
@@ -290,10 +499,17 @@ func (s *Site) loadData(sources []source.Input) (err error) {
 			}
 
 			// Copy content from current to data when needed
-			if _, ok := current[r.BaseFileName()]; ok {
-				data := data.(map[string]interface{})
+			if existing, ok := current[r.BaseFileName()]; ok {
+				existingMap, ok1 := existing.(map[string]interface{})
+				data, ok2 := data.(map[string]interface{})
+				if !ok1 || !ok2 {
+					// a list can't be merged with the keys of a map; the
+					// earlier file or sub folder wins
+					jww.WARN.Printf("Data in path '%s' is not merged with the data of key '%s', as one of them is a list\n", r.Path(), r.BaseFileName())
+					continue
+				}
 
-				for key, value := range current[r.BaseFileName()].(map[string]interface{}) {
+				for key, value := range existingMap {
 					if _, override := data[key]; override {
 						// filepath.Walk walks the files in lexical order, '/' comes before '.'
 						// this warning could happen if
@@ -316,7 +532,7 @@ func (s *Site) loadData(sources []source.Input) (err error) {
 func readData(f *source.File) (interface{}, error) {
 	switch f.Extension() {
 	case "yaml", "yml":
-		return parser.HandleYAMLMetaData(f.Bytes())
+		return parser.HandleYAMLData(f.Bytes())
 	case "json":
 		return parser.HandleJSONMetaData(f.Bytes())
 	case "toml":
@@ -330,6 +546,7 @@ func (s *Site) Process() (err error) {
 	if err = s.initialize(); err != nil {
 		return
 	}
+	helpers.ResetHighlightWarnings()
 	s.prepTemplates()
 	s.Tmpl.PrintErrors()
 	s.timerStep("initialize & template prep")
@@ -349,10 +566,18 @@ func (s *Site) Process() (err error) {
 	}
 	s.timerStep("load data")
 
+	i18nSources := []source.Input{&source.Filesystem{Base: s.absI18nDir()}}
+	if themeI18nDir, err := helpers.GetThemeI18nDirPath(); err == nil && themeI18nDir != "" {
+		i18nSources = append(i18nSources, &source.Filesystem{Base: themeI18nDir})
+	}
+	if err = s.loadTranslations(i18nSources); err != nil {
+		return
+	}
+	s.timerStep("load translations")
+
 	if err = s.CreatePages(); err != nil {
 		return
 	}
-	s.setupPrevNext()
 	s.timerStep("import pages")
 	if err = s.BuildSiteMeta(); err != nil {
 		return
@@ -361,6 +586,9 @@ func (s *Site) Process() (err error) {
 	return
 }
 
+// setupPrevNext links the regular pages in the order of s.Pages, by weight
+// and then newest first: .Prev is the page before a page and .Next the one
+// after it. The pages of sections and terms are not in it.
 func (s *Site) setupPrevNext() {
 	for i, page := range s.Pages {
 		if i < len(s.Pages)-1 {
@@ -374,32 +602,71 @@ func (s *Site) setupPrevNext() {
 }
 
 func (s *Site) Render() (err error) {
+	tpl.SetTranslations(s.translationLanguage(), s.Translations)
+
 	if err = s.RenderAliases(); err != nil {
 		return
 	}
 	s.timerStep("render and write aliases")
-	if err = s.RenderTaxonomiesLists(); err != nil {
+	if err = s.RenderRedirects(); err != nil {
 		return
 	}
-	s.timerStep("render and write taxonomies")
-	s.RenderListsOfTaxonomyTerms()
-	s.timerStep("render & write taxonomy lists")
-	if err = s.RenderSectionLists(); err != nil {
+	s.timerStep("render and write redirects")
+	checkDisabledKinds()
+	if !kindDisabled(kindTaxonomy) {
+		if err = s.RenderTaxonomiesLists(); err != nil {
+			return
+		}
+		s.timerStep("render and write taxonomies")
+	}
+	if !kindDisabled(kindTaxonomyTerm) {
+		s.RenderListsOfTaxonomyTerms()
+		s.timerStep("render & write taxonomy lists")
+	}
+	if !kindDisabled(kindSection) {
+		if err = s.RenderSectionLists(); err != nil {
+			return
+		}
+		s.timerStep("render and write lists")
+	}
+	if err = s.RenderArchives(); err != nil {
 		return
 	}
-	s.timerStep("render and write lists")
+	s.timerStep("render and write archives")
 	if err = s.RenderPages(); err != nil {
 		return
 	}
 	s.timerStep("render and write pages")
-	if err = s.RenderHomePage(); err != nil {
-		return
+	if !kindDisabled(kindHome) {
+		if err = s.RenderHomePage(); err != nil {
+			return
+		}
+		s.timerStep("render and write homepage")
 	}
-	s.timerStep("render and write homepage")
 	if err = s.RenderSitemap(); err != nil {
 		return
 	}
 	s.timerStep("render and write Sitemap")
+	if err = s.RenderRobotsTXT(); err != nil {
+		return
+	}
+	s.timerStep("render and write robots.txt")
+	if err = s.RenderSearchIndex(); err != nil {
+		return
+	}
+	s.timerStep("render and write search index")
+	if err = s.RenderCorpus(); err != nil {
+		return
+	}
+	s.timerStep("render and write corpus")
+	if err = s.RenderSocialCards(); err != nil {
+		return
+	}
+	s.timerStep("render and write social cards")
+	if err = s.RenderIconSprite(); err != nil {
+		return
+	}
+	s.timerStep("render and write icon sprite")
 	return
 }
 
@@ -430,6 +697,7 @@ func (s *Site) initialize() (err error) {
 	s.Menus = Menus{}
 
 	s.initializeSiteInfo()
+	registerContentFormats()
 
 	s.Shortcodes = make(map[string]ShortcodeFunc)
 	return
@@ -441,8 +709,13 @@ func (s *Site) initializeSiteInfo() {
 	permalinks := make(PermalinkOverrides)
 	for k, v := range viper.GetStringMapString("Permalinks") {
 		permalinks[k] = PathPattern(v)
+		if !permalinks[k].validate() {
+			jww.ERROR.Printf("Invalid permalink %q for section %q\n", v, k)
+		}
 	}
 
+	formats := definedOutputFormats()
+
 	s.Info = SiteInfo{
 		BaseUrl:         template.URL(helpers.SanitizeURLKeepTrailingSlash(viper.GetString("BaseURL"))),
 		Title:           viper.GetString("Title"),
@@ -450,15 +723,32 @@ func (s *Site) initializeSiteInfo() {
 		LanguageCode:    viper.GetString("languagecode"),
 		Copyright:       viper.GetString("copyright"),
 		DisqusShortname: viper.GetString("DisqusShortname"),
+		RSSTitle:        viper.GetString("RSSTitle"),
+		RSSLimit:        viper.GetInt("RSSLimit"),
+		RSSDescription:  viper.GetString("RSSDescription"),
 		BuildDrafts:     viper.GetBool("BuildDrafts"),
-		canonifyURLs:    viper.GetBool("CanonifyURLs"),
 		Pages:           &s.Pages,
 		Recent:          &s.Pages,
 		Menus:           &s.Menus,
+		Calendar:        &s.Calendar,
 		Params:          params,
 		Permalinks:      permalinks,
 		Data:            &s.Data,
+		outputFormats:   outputFormats(formats),
+		formatDefs:      formats,
+		Language:        s.Language,
 	}
+
+	if s.Info.RSSTitle == "" {
+		s.Info.RSSTitle = s.Info.Title
+	}
+	if s.Info.RSSLimit <= 0 {
+		s.Info.RSSLimit = 15
+	}
+	if s.Info.RSSDescription != "summary" {
+		s.Info.RSSDescription = "content"
+	}
+	s.feeds = feedFormats()
 }
 
 func (s *Site) hasTheme() bool {
@@ -485,6 +775,30 @@ func (s *Site) absPublishDir() string {
 	return helpers.AbsPathify(viper.GetString("PublishDir"))
 }
 
+// rootRelativeURLs returns whether the links are relative to the root of
+// the site, without its context root, as they are made absolute with
+// CanonifyURLs or relative to the page with RelativeURLs.
+func rootRelativeURLs() bool {
+	return viper.GetBool("CanonifyURLs") || viper.GetBool("RelativeURLs")
+}
+
+// relativeRoot returns the path from the page written to dest to the root
+// of the site, e.g. "../.." for post/first/index.html.
+func (s *Site) relativeRoot(dest string) (string, error) {
+	target, err := s.PageTarget().Translate(dest)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(s.absPublishDir(), target); err == nil && !strings.HasPrefix(rel, "..") {
+		target = rel
+	}
+	depth := strings.Count(strings.TrimPrefix(filepath.ToSlash(target), "/"), "/")
+	if depth == 0 {
+		return ".", nil
+	}
+	return strings.TrimSuffix(strings.Repeat("../", depth), "/"), nil
+}
+
 func (s *Site) checkDirectories() (err error) {
 	if b, _ := helpers.DirExists(s.absContentDir(), hugofs.SourceFs); !b {
 		return fmt.Errorf("No source directory found, expecting to find it at " + s.absContentDir())
@@ -510,12 +824,12 @@ func (s *Site) CreatePages() error {
 	results := make(chan HandledResult)
 	filechan := make(chan *source.File)
 
-	procs := getGoMaxProcs()
+	workers := s.limits().workers
 
 	wg := &sync.WaitGroup{}
 
-	wg.Add(procs * 4)
-	for i := 0; i < procs*4; i++ {
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
 		go sourceReader(s, filechan, results, wg)
 	}
 
@@ -537,14 +851,21 @@ func (s *Site) CreatePages() error {
 
 	readErrs := <-errs
 
+	// the conversion replaces the content as written, which the checkers
+	// need to find positions in the source files
+	checkers := activeContentCheckers()
+	sources := s.contentSources(checkers)
+
+	s.glossary = s.loadGlossary()
+
 	results = make(chan HandledResult)
 	pageChan := make(chan *Page)
 	fileConvChan := make(chan *source.File)
 
 	wg = &sync.WaitGroup{}
 
-	wg.Add(2 * procs * 4)
-	for i := 0; i < procs*4; i++ {
+	wg.Add(2 * workers)
+	for i := 0; i < workers; i++ {
 		go fileConverter(s, fileConvChan, results, wg)
 		go pageConverter(s, pageChan, results, wg)
 	}
@@ -568,6 +889,10 @@ func (s *Site) CreatePages() error {
 
 	renderErrs := <-errs
 
+	if err := s.checkContent(checkers, sources); err != nil {
+		return err
+	}
+
 	if renderErrs == nil && readErrs == nil {
 		return nil
 	}
@@ -602,7 +927,9 @@ func pageConverter(s *Site, pages <-chan *Page, results HandleResults, wg *sync.
 			h = NewMetaHandler(page.File.Extension())
 		}
 		if h != nil {
+			s.limits().acquire()
 			h.Convert(page, s, results)
+			s.limits().release()
 		}
 	}
 }
@@ -674,13 +1001,35 @@ func (s *Site) BuildSiteMeta() (err error) {
 		return
 	}
 
+	s.assembleTermPages()
+	s.assembleSectionPages()
 	s.assembleTaxonomies()
 	s.assembleSections()
-	s.Info.LastChange = s.Pages[0].Date
+	s.setupPrevNext()
+	s.Calendar = newCalendar(s.Pages)
+	s.Info.LastChange = s.lastChange()
 
 	return
 }
 
+// lastChange is the newest date of the content, of the regular pages and
+// of the _index files. The pages are sorted by weight first, so the first
+// of them need not be the newest.
+func (s *Site) lastChange() time.Time {
+	var last time.Time
+	for _, p := range s.Pages {
+		if p.Date.After(last) {
+			last = p.Date
+		}
+	}
+	for _, p := range s.Info.sectionPages {
+		if p.Date.After(last) {
+			last = p.Date
+		}
+	}
+	return last
+}
+
 func (s *Site) getMenusFromConfig() Menus {
 
 	ret := Menus{}
@@ -708,7 +1057,7 @@ func (s *Site) getMenusFromConfig() Menus {
 						// make it match the nodes
 						menuEntryURL := menuEntry.Url
 						menuEntryURL = helpers.URLizeAndPrep(menuEntryURL)
-						if !s.Info.canonifyURLs {
+						if !rootRelativeURLs() {
 							menuEntryURL = helpers.AddContextRoot(string(s.Info.BaseUrl), menuEntryURL)
 						}
 						menuEntry.Url = menuEntryURL
@@ -781,38 +1130,56 @@ func (s *Site) assembleMenus() {
 	}
 }
 
+// assembleTermPages takes the _index files of the taxonomy terms, e.g.
+// content/series/golang/_index.md, out of the regular pages. Their front
+// matter holds settings shared by all the pages of the term.
+func (s *Site) assembleTermPages() {
+	s.Info.termPages = make(map[string]map[string]*Page)
+
+	taxonomies := getTaxonomies()
+	plurals := make(map[string]bool)
+	for _, plural := range taxonomies {
+		plurals[plural] = true
+		s.Info.termPages[plural] = make(map[string]*Page)
+	}
+
+	pages := s.Pages[:0]
+	for _, p := range s.Pages {
+		dirs := strings.Split(strings.Trim(filepath.ToSlash(p.Source.Dir()), "/"), "/")
+		if p.Source.BaseFileName() != "_index" || len(dirs) != 2 || !plurals[dirs[0]] {
+			pages = append(pages, p)
+			continue
+		}
+		key := kp(dirs[1])
+		p.Url = helpers.URLizeAndPrep(dirs[0] + "/" + key)
+		s.Info.termPages[dirs[0]][key] = p
+	}
+	s.Pages = pages
+}
+
 func (s *Site) assembleTaxonomies() {
 	s.Taxonomies = make(TaxonomyList)
 	s.Sections = make(Taxonomy)
 
-	taxonomies := viper.GetStringMapString("Taxonomies")
+	taxonomies := getTaxonomies()
 	jww.INFO.Printf("found taxonomies: %#v\n", taxonomies)
 
 	for _, plural := range taxonomies {
 		s.Taxonomies[plural] = make(Taxonomy)
 		for _, p := range s.Pages {
-			vals := p.GetParam(plural)
-			weight := p.GetParam(plural + "_weight")
-			if weight == nil {
-				weight = 0
+			terms, err := p.taxonomyTerms(plural)
+			if err != nil {
+				jww.ERROR.Printf("Invalid %s in %s\n", plural, p.File.Path())
+				continue
 			}
 
-			if vals != nil {
-				if v, ok := vals.([]string); ok {
-					for _, idx := range v {
-						x := WeightedPage{weight.(int), p}
-						s.Taxonomies[plural].Add(idx, x)
-					}
-				} else if v, ok := vals.(string); ok {
-					x := WeightedPage{weight.(int), p}
-					s.Taxonomies[plural].Add(v, x)
-				} else {
-					jww.ERROR.Printf("Invalid %s in %s\n", plural, p.File.Path())
-				}
+			for _, term := range terms {
+				s.Taxonomies[plural].Add(term, WeightedPage{p.taxonomyWeight(plural, term), p})
 			}
 		}
+		by := weightedPagesOrders[getTaxonomyOrder(plural).pages]
 		for k := range s.Taxonomies[plural] {
-			s.Taxonomies[plural][k].Sort()
+			by.Sort(s.Taxonomies[plural][k])
 		}
 	}
 
@@ -828,13 +1195,23 @@ func (s *Site) assembleSections() {
 
 	for k := range s.Sections {
 		s.Sections[k].Sort()
+	}
+
+	// the pages link to the others of their nearest section, so that the
+	// pages of a nested section only link to each other
+	siblings := make(map[*Page]WeightedPages)
+	for _, p := range s.Pages {
+		siblings[p.section] = append(siblings[p.section], WeightedPage{p.Weight, p})
+	}
+	for _, pages := range siblings {
+		pages.Sort()
 
-		for i, wp := range s.Sections[k] {
+		for i, wp := range pages {
 			if i > 0 {
-				wp.Page.NextInSection = s.Sections[k][i-1].Page
+				wp.Page.NextInSection = pages[i-1].Page
 			}
-			if i < len(s.Sections[k])-1 {
-				wp.Page.PrevInSection = s.Sections[k][i+1].Page
+			if i < len(pages)-1 {
+				wp.Page.PrevInSection = pages[i+1].Page
 			}
 		}
 	}
@@ -873,11 +1250,9 @@ func (s *Site) RenderPages() error {
 	results := make(chan error)
 	pages := make(chan *Page)
 
-	procs := getGoMaxProcs()
-
 	wg := &sync.WaitGroup{}
 
-	for i := 0; i < procs*4; i++ {
+	for i := 0; i < s.limits().workers; i++ {
 		wg.Add(1)
 		go pageRenderer(s, pages, results, wg)
 	}
@@ -900,7 +1275,7 @@ func (s *Site) RenderPages() error {
 	if err != nil {
 		return fmt.Errorf("Error(s) rendering pages: %s", err)
 	}
-	return nil
+	return s.Render404()
 }
 
 func pageRenderer(s *Site, pages <-chan *Page, results chan<- error, wg *sync.WaitGroup) {
@@ -918,13 +1293,23 @@ func pageRenderer(s *Site, pages <-chan *Page, results chan<- error, wg *sync.Wa
 			layouts = append(layouts, self)
 		} else {
 			layouts = append(layouts, p.Layout()...)
-			layouts = append(layouts, "_default/single.html")
 		}
 
 		err := s.renderAndWritePage("page "+p.FullFilePath(), p.TargetPath(), p, s.appendThemeTemplates(layouts)...)
 		if err != nil {
 			results <- err
 		}
+
+		if p.IsRenderable() {
+			for _, f := range p.outputFormats() {
+				if f.Name == "html" {
+					continue
+				}
+				if err := s.renderPageFormat(p, f); err != nil {
+					results <- err
+				}
+			}
+		}
 	}
 }
 
@@ -986,9 +1371,7 @@ func (s *Site) RenderTaxonomiesLists() error {
 	taxes := make(chan taxRenderInfo)
 	results := make(chan error)
 
-	procs := getGoMaxProcs()
-
-	for i := 0; i < procs*4; i++ {
+	for i := 0; i < s.limits().workers; i++ {
 		wg.Add(1)
 		go taxonomyRenderer(s, taxes, results, wg)
 	}
@@ -997,7 +1380,7 @@ func (s *Site) RenderTaxonomiesLists() error {
 
 	go errorCollator(results, errs)
 
-	taxonomies := viper.GetStringMapString("Taxonomies")
+	taxonomies := getTaxonomies()
 	for singular, plural := range taxonomies {
 		for key, pages := range s.Taxonomies[plural] {
 			taxes <- taxRenderInfo{key, pages, singular, plural}
@@ -1024,6 +1407,12 @@ func (s *Site) newTaxonomyNode(t taxRenderInfo) (*Node, string) {
 	if len(t.pages) > 0 {
 		n.Date = t.pages[0].Page.Date
 	}
+	if term, ok := s.Info.termPages[t.plural][t.key]; ok {
+		n.Title = term.Title
+		n.Description = term.Description
+		n.Params = term.Params
+		n.Data["Term"] = term
+	}
 	n.Data[t.singular] = t.pages
 	n.Data["Singular"] = t.singular
 	n.Data["Plural"] = t.plural
@@ -1071,6 +1460,7 @@ func taxonomyRenderer(s *Site, taxes <-chan taxRenderInfo, results chan<- error,
 				}
 				pageNumber := i + 1
 				htmlBase := fmt.Sprintf("/%s/%s/%d", base, paginatePath, pageNumber)
+				taxonomyPagerNode.Permalink = s.permalink(htmlBase)
 				if err := s.renderAndWritePage(fmt.Sprintf("taxononomy_%s_%d", t.singular, pageNumber), htmlBase, taxonomyPagerNode, layouts...); err != nil {
 					results <- err
 					continue
@@ -1078,23 +1468,18 @@ func taxonomyRenderer(s *Site, taxes <-chan taxRenderInfo, results chan<- error,
 			}
 		}
 
-		if !viper.GetBool("DisableRSS") {
-			// XML Feed
-			n.Url = s.permalinkStr(base + "/index.xml")
-			n.Permalink = s.permalink(base)
-			rssLayouts := []string{"taxonomy/" + t.singular + ".rss.xml", "_default/rss.xml", "rss.xml", "_internal/_default/rss.xml"}
-
-			if err := s.renderAndWriteXML("taxonomy "+t.singular+" rss", base+"/index.xml", n, s.appendThemeTemplates(rssLayouts)...); err != nil {
-				results <- err
-				continue
-			}
+		// XML Feeds
+		n.Permalink = s.permalink(base)
+		if err := s.renderFeeds("taxonomy "+t.singular, base, n, "taxonomy/"+t.singular+".%s.xml", "_default/%s.xml", "%s.xml", "_internal/_default/%s.xml"); err != nil {
+			results <- err
+			continue
 		}
 	}
 }
 
 // RenderListsOfTaxonomyTerms renders a page per taxonomy that lists the terms for that taxonomy
 func (s *Site) RenderListsOfTaxonomyTerms() (err error) {
-	taxonomies := viper.GetStringMapString("Taxonomies")
+	taxonomies := getTaxonomies()
 	for singular, plural := range taxonomies {
 		n := s.NewNode()
 		n.Title = strings.Title(plural)
@@ -1102,6 +1487,7 @@ func (s *Site) RenderListsOfTaxonomyTerms() (err error) {
 		n.Data["Singular"] = singular
 		n.Data["Plural"] = plural
 		n.Data["Terms"] = s.Taxonomies[plural]
+		n.Data["OrderedTerms"] = s.Info.OrderedTerms(plural)
 		// keep the following just for legacy reasons
 		n.Data["OrderedIndex"] = n.Data["Terms"]
 		n.Data["Index"] = n.Data["Terms"]
@@ -1119,14 +1505,20 @@ func (s *Site) RenderListsOfTaxonomyTerms() (err error) {
 
 func (s *Site) newSectionListNode(section string, data WeightedPages) *Node {
 	n := s.NewNode()
-	if viper.GetBool("PluralizeListTitles") {
-		n.Title = strings.Title(inflect.Pluralize(section))
-	} else {
-		n.Title = strings.Title(section)
-	}
+	n.Title = sectionTitle(section)
 	s.setUrls(n, section)
 	n.Date = data[0].Page.Date
 	n.Data["Pages"] = data.Pages()
+	if isCalendarSection(section) {
+		n.CalendarLink = template.HTML(s.feedPermalinkStr(section, calendarFile))
+	}
+	if sec := s.Info.sectionPages[section]; sec != nil && section != "" {
+		n.Title = sec.Title
+		n.Description = sec.Description
+		n.Params = sec.Params
+		n.section = sec
+		n.Data["Section"] = sec
+	}
 
 	return n
 }
@@ -1134,54 +1526,76 @@ func (s *Site) newSectionListNode(section string, data WeightedPages) *Node {
 // RenderSectionLists renders a page for each section
 func (s *Site) RenderSectionLists() error {
 	for section, data := range s.Sections {
+		if err := s.renderSectionList(section, data); err != nil {
+			return err
+		}
+	}
 
-		layouts := s.appendThemeTemplates(
-			[]string{"section/" + section + ".html", "_default/section.html", "_default/list.html", "indexes/" + section + ".html", "_default/indexes.html"})
+	for path := range s.Info.sectionPages {
+		if !strings.Contains(path, "/") {
+			continue
+		}
+		if data := s.sectionData(path); len(data) > 0 {
+			if err := s.renderSectionList(path, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-		n := s.newSectionListNode(section, data)
+// renderSectionList renders the list and the feed of a section. Nested
+// sections use the layouts of the top level section they are in.
+func (s *Site) renderSectionList(section string, data WeightedPages) error {
+	top := strings.SplitN(section, "/", 2)[0]
+	layouts := s.appendThemeTemplates(
+		[]string{"section/" + top + ".html", "section/list.html", "_default/section.html", "_default/list.html", "indexes/" + top + ".html", "_default/indexes.html"})
 
-		if err := s.renderAndWritePage(fmt.Sprintf("section%s_%d", section, 1), fmt.Sprintf("/%s", section), n, s.appendThemeTemplates(layouts)...); err != nil {
-			return err
-		}
+	n := s.newSectionListNode(section, data)
 
-		if n.paginator != nil {
+	if err := s.renderAndWritePage(fmt.Sprintf("section%s_%d", section, 1), fmt.Sprintf("/%s", section), n, layouts...); err != nil {
+		return err
+	}
 
-			paginatePath := viper.GetString("paginatePath")
+	if n.paginator != nil {
 
-			// write alias for page 1
-			s.WriteDestAlias(filepath.FromSlash(fmt.Sprintf("/%s/%s/%d", section, paginatePath, 1)), s.permalink(section))
+		paginatePath := viper.GetString("paginatePath")
 
-			pagers := n.paginator.Pagers()
+		// write alias for page 1
+		s.WriteDestAlias(filepath.FromSlash(fmt.Sprintf("/%s/%s/%d", section, paginatePath, 1)), s.permalink(section))
 
-			for i, pager := range pagers {
-				if i == 0 {
-					// already created
-					continue
-				}
+		pagers := n.paginator.Pagers()
 
-				sectionPagerNode := s.newSectionListNode(section, data)
-				sectionPagerNode.paginator = pager
-				if pager.TotalPages() > 0 {
-					sectionPagerNode.Date = pager.Pages()[0].Date
-				}
-				pageNumber := i + 1
-				htmlBase := fmt.Sprintf("/%s/%s/%d", section, paginatePath, pageNumber)
-				if err := s.renderAndWritePage(fmt.Sprintf("section_%s_%d", section, pageNumber), filepath.FromSlash(htmlBase), sectionPagerNode, layouts...); err != nil {
-					return err
-				}
+		for i, pager := range pagers {
+			if i == 0 {
+				// already created
+				continue
 			}
-		}
 
-		if !viper.GetBool("DisableRSS") && section != "" {
-			// XML Feed
-			n.Url = s.permalinkStr(section + "/index.xml")
-			n.Permalink = s.permalink(section)
-			rssLayouts := []string{"section/" + section + ".rss.xml", "_default/rss.xml", "rss.xml", "_internal/_default/rss.xml"}
-			if err := s.renderAndWriteXML("section "+section+" rss", section+"/index.xml", n, s.appendThemeTemplates(rssLayouts)...); err != nil {
+			sectionPagerNode := s.newSectionListNode(section, data)
+			sectionPagerNode.paginator = pager
+			if pager.TotalPages() > 0 {
+				sectionPagerNode.Date = pager.Pages()[0].Date
+			}
+			pageNumber := i + 1
+			htmlBase := fmt.Sprintf("/%s/%s/%d", section, paginatePath, pageNumber)
+			sectionPagerNode.Permalink = s.permalink(htmlBase)
+			if err := s.renderAndWritePage(fmt.Sprintf("section_%s_%d", section, pageNumber), filepath.FromSlash(htmlBase), sectionPagerNode, layouts...); err != nil {
 				return err
 			}
 		}
 	}
+
+	if section != "" {
+		// XML Feeds
+		n.Permalink = s.permalink(section)
+		if err := s.renderFeeds("section "+section, section, n, "section/"+top+".%s.xml", "_default/%s.xml", "%s.xml", "_internal/_default/%s.xml"); err != nil {
+			return err
+		}
+		if n.CalendarLink != "" {
+			return s.renderAndWriteCalendar("section "+section+" calendar", filepath.Join(section, calendarFile), n)
+		}
+	}
 	return nil
 }
 
@@ -1190,6 +1604,14 @@ func (s *Site) newHomeNode() *Node {
 	n.Title = n.Site.Title
 	s.setUrls(n, "/")
 	n.Data["Pages"] = s.Pages
+	if home := s.Info.sectionPages[""]; home != nil {
+		// the content/_index file, if any
+		n.Title = home.Title
+		n.Description = home.Description
+		n.Params = home.Params
+		n.section = home
+		n.Data["Section"] = home
+	}
 	return n
 }
 
@@ -1223,17 +1645,20 @@ func (s *Site) RenderHomePage() error {
 			}
 			pageNumber := i + 1
 			htmlBase := fmt.Sprintf("/%s/%d", paginatePath, pageNumber)
+			homePagerNode.Permalink = s.permalink(htmlBase)
 			if err := s.renderAndWritePage(fmt.Sprintf("homepage_%d", pageNumber), filepath.FromSlash(htmlBase), homePagerNode, layouts...); err != nil {
 				return err
 			}
 		}
 	}
 
-	if !viper.GetBool("DisableRSS") {
-		// XML Feed
-		n.Url = s.permalinkStr("index.xml")
+	if len(s.feeds) > 0 {
+		// XML Feeds
 		n.Title = ""
 		high := 50
+		if s.Info.RSSLimit > high {
+			high = s.Info.RSSLimit
+		}
 		if len(s.Pages) < high {
 			high = len(s.Pages)
 		}
@@ -1242,27 +1667,37 @@ func (s *Site) RenderHomePage() error {
 			n.Date = s.Pages[0].Date
 		}
 
-		rssLayouts := []string{"rss.xml", "_default/rss.xml", "_internal/_default/rss.xml"}
-
-		if err := s.renderAndWriteXML("homepage rss", "index.xml", n, s.appendThemeTemplates(rssLayouts)...); err != nil {
+		if err := s.renderFeeds("homepage", "", n, "%s.xml", "_default/%s.xml", "_internal/_default/%s.xml"); err != nil {
 			return err
 		}
 	}
 
+	return nil
+}
+
+// Render404 renders the 404.html layout, if there is one, to 404.html in
+// the root of the site, where hosts like GitHub Pages and S3 look for it.
+func (s *Site) Render404() error {
+	if kindDisabled(kind404) {
+		return nil
+	}
+	nfLayouts := s.appendThemeTemplates([]string{"404.html"})
+	if !s.layoutExists(nfLayouts...) {
+		return nil
+	}
+
+	n := s.NewNode()
 	n.Url = helpers.URLize("404.html")
 	n.Title = "404 Page not found"
 	n.Permalink = s.permalink("404.html")
+	n.Date = s.Info.LastChange
+	n.Data["Pages"] = s.Pages
 
-	nfLayouts := []string{"404.html"}
-	if nfErr := s.renderAndWritePage("404 page", "404.html", n, s.appendThemeTemplates(nfLayouts)...); nfErr != nil {
-		return nfErr
-	}
-
-	return nil
+	return s.renderAndWritePage("404 page", "404.html", n, nfLayouts...)
 }
 
 func (s *Site) RenderSitemap() error {
-	if viper.GetBool("DisableSitemap") {
+	if kindDisabled(kindSitemap) {
 		return nil
 	}
 
@@ -1277,9 +1712,18 @@ func (s *Site) RenderSitemap() error {
 	page.Date = s.Info.LastChange
 	page.Site = &s.Info
 	page.Url = "/"
+	page.Sitemap = Sitemap{Priority: -1}
 
-	pages = append(pages, page)
-	pages = append(pages, s.Pages...)
+	if !kindDisabled(kindHome) {
+		pages = append(pages, page)
+	}
+	for _, p := range s.Pages {
+		// leave the listing of syndicated content to the original site, and
+		// don't list the pages search engines shouldn't index
+		if !p.canonicalElsewhere() && !p.Sitemap.Exclude && !p.NoIndex() {
+			pages = append(pages, p)
+		}
+	}
 
 	n.Data["Pages"] = pages
 
@@ -1295,29 +1739,181 @@ func (s *Site) RenderSitemap() error {
 
 	smLayouts := []string{"sitemap.xml", "_default/sitemap.xml", "_internal/_default/sitemap.xml"}
 
+	if limit := sitemapLimit(); len(pages) > limit {
+		return s.renderSitemapIndex(pages, limit, s.appendThemeTemplates(smLayouts))
+	}
+
 	if err := s.renderAndWriteXML("sitemap", "sitemap.xml", n, s.appendThemeTemplates(smLayouts)...); err != nil {
 		return err
 	}
 
+	return s.removeStaleSitemaps(false, 0)
+}
+
+// RenderRobotsTXT renders robots.txt from the robots.txt template of the
+// site or of its theme, if EnableRobotsTXT is set.
+func (s *Site) RenderRobotsTXT() error {
+	if !viper.GetBool("EnableRobotsTXT") || kindDisabled(kindRobotsTXT) {
+		return nil
+	}
+
+	n := s.NewNode()
+	n.Data["Pages"] = s.Pages
+
+	rLayouts := []string{"robots.txt", "_default/robots.txt", "_internal/_default/robots.txt"}
+
+	outBuffer := bp.GetBuffer()
+	defer bp.PutBuffer(outBuffer)
+
+	if err := s.render("robots", n, outBuffer, s.appendThemeTemplates(rLayouts)...); err != nil {
+		if err == errRenderSkipped {
+			return nil
+		}
+		return err
+	}
+
+	if outBuffer.Len() == 0 {
+		return nil
+	}
+
+	if err := s.WriteDestFile("robots.txt", outBuffer); err != nil {
+		return err
+	}
+	helpers.BuildLog.Rendered("robots.txt", "robots")
+
 	return nil
 }
 
+// RenderIconSprite combines the SVG icons in the IconDir of the site and of
+// its theme into the IconSprite, for the icon template function.
+func (s *Site) RenderIconSprite() error {
+	if viper.GetString("IconDir") == "" || viper.GetString("IconSprite") == "" {
+		return nil
+	}
+
+	var iconSources []source.Input
+
+	// the site's icons win over the theme's of the same name
+	if themeIconDir, err := helpers.GetThemeIconDirPath(); err == nil && themeIconDir != "" {
+		iconSources = append(iconSources, &source.Filesystem{Base: themeIconDir})
+	}
+	iconSources = append(iconSources, &source.Filesystem{Base: helpers.AbsPathify(viper.GetString("IconDir"))})
+
+	return s.renderIconSprite(iconSources)
+}
+
+func (s *Site) renderIconSprite(sources []source.Input) error {
+	icons := make(map[string][]byte)
+	for _, currentSource := range sources {
+		for _, r := range currentSource.Files() {
+			if r.Extension() != "svg" {
+				continue
+			}
+			var name []string
+			for _, dir := range strings.Split(r.Dir(), helpers.FilePathSeparator) {
+				if dir != "" {
+					name = append(name, dir)
+				}
+			}
+			icons[strings.Join(append(name, r.BaseFileName()), "-")] = r.Bytes()
+		}
+	}
+	if len(icons) == 0 {
+		return nil
+	}
+
+	sprite, err := helpers.SVGSprite(icons)
+	if err != nil {
+		return fmt.Errorf("Unable to create the icon sprite: %s", err)
+	}
+	return s.WriteDestFile(filepath.FromSlash(viper.GetString("IconSprite")), bytes.NewReader(sprite))
+}
+
+// SourceMap maps the content files, relative to the content directory and
+// with forward slashes, to the permalinks of their pages.
+func (s *Site) SourceMap() map[string]string {
+	m := make(map[string]string, len(s.Pages))
+	for _, p := range s.Pages {
+		permalink, err := p.Permalink()
+		if err != nil {
+			continue
+		}
+		m[filepath.ToSlash(p.Source.Path())] = permalink
+	}
+	return m
+}
+
+// WriteManifest writes the Manifest file, listing the files in the publish
+// directory with their hashes. With a ManifestKey it writes its signature
+// next to it.
+func (s *Site) WriteManifest() error {
+	name := viper.GetString("Manifest")
+	if name == "" {
+		return nil
+	}
+
+	m, err := helpers.NewManifest(s.absPublishDir(), hugofs.DestinationFS, name, name+".sig")
+	if err != nil {
+		return fmt.Errorf("Unable to create the manifest: %s", err)
+	}
+	content, err := m.JSON()
+	if err != nil {
+		return err
+	}
+	if err := s.WriteDestFile(filepath.FromSlash(name), bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	keyFile := viper.GetString("ManifestKey")
+	if keyFile == "" {
+		return nil
+	}
+	f, err := hugofs.SourceFs.Open(helpers.AbsPathify(keyFile))
+	if err != nil {
+		return fmt.Errorf("Unable to read the manifest key: %s", err)
+	}
+	defer f.Close()
+	sig, err := helpers.SignManifest(content, helpers.ReaderToBytes(f))
+	if err != nil {
+		return fmt.Errorf("Unable to sign the manifest with %s: %s", keyFile, err)
+	}
+	return s.WriteDestFile(filepath.FromSlash(name+".sig"), bytes.NewReader(sig))
+}
+
 func (s *Site) Stats() {
-	jww.FEEDBACK.Println(s.draftStats())
-	jww.FEEDBACK.Println(s.futureStats())
-	jww.FEEDBACK.Printf("%d pages created\n", len(s.Pages))
-	jww.FEEDBACK.Printf("%d paginator pages created\n", s.Info.paginationPageCount)
-	taxonomies := viper.GetStringMapString("Taxonomies")
+	feedback := jww.FEEDBACK.Printf
+	if helpers.BuildLog != nil {
+		feedback = func(format string, v ...interface{}) {
+			helpers.BuildLog.Event(helpers.BuildEvent{Event: "info", Message: strings.TrimSpace(fmt.Sprintf(format, v...))})
+		}
+	}
+
+	if s.Language != nil {
+		feedback("%s:\n", s.Language.LanguageName)
+	}
+	feedback("%s\n", s.draftStats())
+	feedback("%s\n", s.futureStats())
+	feedback("%d pages created\n", len(s.Pages))
+	feedback("%d paginator pages created\n", s.Info.paginationPageCount)
+	taxonomies := getTaxonomies()
 
 	for _, pl := range taxonomies {
-		jww.FEEDBACK.Printf("%d %s created\n", len(s.Taxonomies[pl]), pl)
+		feedback("%d %s created\n", len(s.Taxonomies[pl]), pl)
 	}
 }
 
 func (s *Site) setUrls(n *Node, in string) {
 	n.Url = helpers.URLizeAndPrep(in)
 	n.Permalink = s.permalink(n.Url)
-	n.RSSLink = s.permalink(in + ".xml")
+	if s.hasFeed("rss") {
+		n.RSSLink = template.HTML(s.feedPermalinkStr(in, "index.xml"))
+	}
+	if s.hasFeed("atom") {
+		n.AtomLink = template.HTML(s.feedPermalinkStr(in, "atom.xml"))
+	}
+	if s.hasFeed("json") {
+		n.JSONFeedLink = template.HTML(s.feedPermalinkStr(in, "feed.json"))
+	}
 }
 
 func (s *Site) permalink(plink string) template.HTML {
@@ -1347,6 +1943,9 @@ func (s *Site) renderAndWriteXML(name string, dest string, d interface{}, layout
 	renderBuffer.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\" standalone=\"yes\" ?>\n")
 
 	err := s.render(name, d, renderBuffer, layouts...)
+	if err == errRenderSkipped {
+		return nil
+	}
 
 	absURLInXML, err := transform.AbsURLInXML(viper.GetString("BaseURL"))
 	if err != nil {
@@ -1356,28 +1955,52 @@ func (s *Site) renderAndWriteXML(name string, dest string, d interface{}, layout
 	outBuffer := bp.GetBuffer()
 	defer bp.PutBuffer(outBuffer)
 
-	transformer := transform.NewChain(absURLInXML...)
+	transformLinks := append(transform.NewEmptyTransforms(), absURLInXML...)
+	if m := minifier("application/xml"); m != nil {
+		transformLinks = append(transformLinks, m)
+	}
+
+	transformer := transform.NewChain(transformLinks...)
 	transformer.Apply(outBuffer, renderBuffer)
 
 	if err == nil {
 		err = s.WriteDestFile(dest, outBuffer)
 	}
 
+	if err == nil {
+		helpers.BuildLog.Rendered(dest, name)
+	}
 	return err
 }
 
 func (s *Site) renderAndWritePage(name string, dest string, d interface{}, layouts ...string) error {
+	s.limits().acquire()
+	defer s.limits().release()
+
 	renderBuffer := bp.GetBuffer()
 	defer bp.PutBuffer(renderBuffer)
 
 	err := s.render(name, d, renderBuffer, layouts...)
+	if err == errRenderSkipped {
+		return nil
+	}
 
 	outBuffer := bp.GetBuffer()
 	defer bp.PutBuffer(outBuffer)
 
 	transformLinks := transform.NewEmptyTransforms()
 
-	if viper.GetBool("CanonifyURLs") {
+	if viper.GetBool("RelativeURLs") {
+		root, err := s.relativeRoot(dest)
+		if err != nil {
+			return err
+		}
+		relURL, err := transform.RelativeURL(root)
+		if err != nil {
+			return err
+		}
+		transformLinks = append(transformLinks, relURL...)
+	} else if viper.GetBool("CanonifyURLs") {
 		absURL, err := transform.AbsURL(viper.GetString("BaseURL"))
 		if err != nil {
 			return err
@@ -1385,6 +2008,12 @@ func (s *Site) renderAndWritePage(name string, dest string, d interface{}, layou
 		transformLinks = append(transformLinks, absURL...)
 	}
 
+	if m := minifier("text/html"); m != nil {
+		transformLinks = append(transformLinks, m)
+	} else if viper.GetBool("Beautify") {
+		transformLinks = append(transformLinks, transform.BeautifyHTML)
+	}
+
 	if viper.GetBool("watch") && !viper.GetBool("DisableLiveReload") {
 		transformLinks = append(transformLinks, transform.LiveReloadInject)
 	}
@@ -1396,10 +2025,15 @@ func (s *Site) renderAndWritePage(name string, dest string, d interface{}, layou
 		if err = s.WriteDestPage(dest, outBuffer); err != nil {
 			return err
 		}
+		helpers.BuildLog.Rendered(dest, name)
 	}
 	return err
 }
 
+// errRenderSkipped is returned by render for output not to be written, as it
+// was cut short by an error that was only warned about.
+var errRenderSkipped = errors.New("rendering skipped")
+
 func (s *Site) render(name string, d interface{}, renderBuffer *bytes.Buffer, layouts ...string) error {
 	layout, found := s.findFirstLayout(layouts...)
 	if found == false {
@@ -1408,6 +2042,13 @@ func (s *Site) render(name string, d interface{}, renderBuffer *bytes.Buffer, la
 	}
 
 	if err := s.renderThing(d, layout, renderBuffer); err != nil {
+		if nilErr := asNilAccessError(err); nilErr != nil {
+			if viper.GetString("TemplateNilAccess") == "warn" {
+				jww.WARN.Printf("Not writing %s: %s\n", name, nilErr)
+				return errRenderSkipped
+			}
+			err = nilErr
+		}
 		// Behavior here should be dependent on if running in server or watch mode.
 		jww.ERROR.Println(fmt.Errorf("Error while rendering %s: %v", name, err))
 		if !s.Running() {
@@ -1462,20 +2103,63 @@ func (s *Site) initTargetList() {
 				PublishDir: s.absPublishDir(),
 				UglyURLs:   viper.GetBool("UglyURLs"),
 			}
+			if t := publishThrottle("pages"); t != nil {
+				s.Targets.Page = &target.ThrottledOutput{Output: s.Targets.Page, Throttle: t}
+			}
 		}
 		if s.Targets.File == nil {
 			s.Targets.File = &target.Filesystem{
 				PublishDir: s.absPublishDir(),
 			}
+			if t := publishThrottle("files"); t != nil {
+				s.Targets.File = &target.ThrottledOutput{Output: s.Targets.File, Throttle: t}
+			}
 		}
 		if s.Targets.Alias == nil {
 			s.Targets.Alias = &target.HTMLRedirectAlias{
 				PublishDir: s.absPublishDir(),
 			}
+			if t := publishThrottle("aliases"); t != nil {
+				s.Targets.Alias = &target.ThrottledAliasPublisher{AliasPublisher: s.Targets.Alias, Throttle: t}
+			}
 		}
 	})
 }
 
+// publishThrottle returns the throttle set in the Publish config for the
+// pages, files or aliases, or nil if publishing isn't limited. Settings of
+// the targets override the ones shared by all of them:
+//
+//	[publish]
+//	parallel = 8
+//	rate = 50
+//	[publish.aliases]
+//	rate = 10
+func publishThrottle(name string) *target.Throttle {
+	conf := make(map[string]interface{})
+	for k, v := range cast.ToStringMap(viper.Get("Publish")) {
+		conf[strings.ToLower(k)] = v
+	}
+	for k, v := range cast.ToStringMap(conf[name]) {
+		conf[strings.ToLower(k)] = v
+	}
+
+	t := &target.Throttle{
+		Parallel: cast.ToInt(conf["parallel"]),
+		Rate:     cast.ToFloat64(conf["rate"]),
+		Retries:  cast.ToInt(conf["retries"]),
+		Backoff:  time.Second,
+	}
+	if backoff, ok := conf["backoff"]; ok {
+		t.Backoff = cast.ToDuration(backoff)
+	}
+
+	if t.Parallel <= 0 && t.Rate <= 0 && t.Retries <= 0 {
+		return nil
+	}
+	return t
+}
+
 func (s *Site) WriteDestFile(path string, reader io.Reader) (err error) {
 	jww.DEBUG.Println("creating file:", path)
 	return s.FileTarget().Publish(path, reader)
@@ -1528,12 +2212,3 @@ func (s *Site) futureStats() string {
 
 	return "0 of " + msg
 }
-
-func getGoMaxProcs() int {
-	if gmp := os.Getenv("GOMAXPROCS"); gmp != "" {
-		if p, err := strconv.Atoi(gmp); err != nil {
-			return p
-		}
-	}
-	return 1
-}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/hugo/helpers"
@@ -72,6 +73,17 @@ func templatePrep(s *Site) {
 	}
 }
 
+// newTestSite returns a site with the content files held in memory and its
+// templates prepared, for the test to add its own. It does for the tests of
+// this package what hugotest.NewSite does for those of sites and themes,
+// which can't be used here as hugotest imports hugolib.
+func newTestSite(sources []source.ByteSource) *Site {
+	s := &Site{Source: &source.InMemorySource{ByteSource: sources}}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	return s
+}
+
 func pageMust(p *Page, err error) *Page {
 	if err != nil {
 		panic(err)
@@ -378,6 +390,46 @@ func doTest404ShouldAlwaysHaveUglyUrls(t *testing.T, uglyURLs bool) {
 
 }
 
+func Test404Page(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub")
+
+	sources := []source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: doc1\n---\ndoc1")},
+		{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: doc2\n---\ndoc2")},
+	}
+
+	s := newTestSite(sources)
+	s.Targets.Page = &target.PagePub{}
+
+	must(s.addTemplate("_default/single.html", "{{.Content}}"))
+	must(s.addTemplate("404.html", "{{ .Title }}: {{ len .Data.Pages }} pages"))
+
+	createAndRenderPages(t, s)
+
+	file, err := hugofs.DestinationFS.Open("404.html")
+	if err != nil {
+		t.Fatalf("Did not find 404.html in target: %s", err)
+	}
+	if content := string(helpers.ReaderToBytes(file)); content != "404 Page not found: 2 pages" {
+		t.Errorf("404.html content is %q", content)
+	}
+}
+
+func Test404PageWithoutLayout(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	s := newTestSite([]source.ByteSource{{filepath.FromSlash("sect/doc1.md"), []byte("doc1")}})
+	s.Targets.Page = &target.PagePub{}
+	must(s.addTemplate("_default/single.html", "{{.Content}}"))
+
+	createAndRenderPages(t, s)
+
+	if _, err := hugofs.DestinationFS.Open("404.html"); err == nil {
+		t.Errorf("404.html should not be written without a 404.html layout")
+	}
+}
+
 func TestSkipRender(t *testing.T) {
 	hugofs.DestinationFS = new(afero.MemMapFs)
 	sources := []source.ByteSource{
@@ -435,6 +487,42 @@ func TestSkipRender(t *testing.T) {
 	}
 }
 
+func TestSectionListTemplate(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub")
+	viper.Set("DisableRSS", true)
+	defer viper.Set("DisableRSS", false)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nOne.")},
+		{filepath.FromSlash("post/two.md"), []byte("---\ntitle: Two\n---\nTwo.")},
+		{filepath.FromSlash("project/hugo.md"), []byte("---\ntitle: Hugo\n---\nHugo.")},
+	})
+	s.Targets.Page = &target.PagePub{UglyURLs: true}
+
+	must(s.addTemplate("_default/list.html", "default"))
+	must(s.addTemplate("section/list.html", "{{ .Title }}:{{ range .Data.Pages }} {{ .Title }}{{ end }}"))
+	must(s.addTemplate("section/project.html", "projects"))
+
+	createAndRenderPages(t, s)
+	if err := s.RenderSectionLists(); err != nil {
+		t.Fatalf("Unable to render section lists: %s", err)
+	}
+
+	for doc, expected := range map[string]string{
+		filepath.FromSlash("/post.html"):    "Post: One Two",
+		filepath.FromSlash("/project.html"): "projects",
+	} {
+		file, err := hugofs.DestinationFS.Open(doc)
+		if err != nil {
+			t.Fatalf("Did not find %s in target.", doc)
+		}
+		if content := string(helpers.ReaderToBytes(file)); content != expected {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", doc, expected, content)
+		}
+	}
+}
+
 func TestAbsUrlify(t *testing.T) {
 	hugofs.DestinationFS = new(afero.MemMapFs)
 	sources := []source.ByteSource{
@@ -491,6 +579,46 @@ func TestAbsUrlify(t *testing.T) {
 	}
 }
 
+func TestRelativeURLs(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("RelativeURLs", true)
+	defer viper.Set("RelativeURLs", false)
+	viper.Set("DefaultExtension", "html")
+	viper.Set("CanonifyURLs", false)
+	viper.Set("BaseURL", "http://auth/bub")
+	defer viper.Set("BaseURL", "")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/first.md"), []byte("---\ntitle: First\n---\nFirst.")},
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: About\n---\nAbout.")},
+	})
+	s.Targets.Page = &target.PagePub{}
+	must(s.addTemplate("_default/single.html", `<a href="{{ .RelPermalink }}">{{ .Title }}</a><img src='/img/logo.png'><a href="//cdn/x">x</a>`))
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+	if err := s.RenderPages(); err != nil {
+		t.Fatalf("Unable to render pages. %s", err)
+	}
+
+	for file, expected := range map[string]string{
+		"post/first/index.html": `<a href="../../post/first/">First</a><img src='../../img/logo.png'><a href="//cdn/x">x</a>`,
+		"about/index.html":      `<a href="../about/">About</a><img src='../img/logo.png'><a href="//cdn/x">x</a>`,
+	} {
+		f, err := hugofs.DestinationFS.Open(filepath.FromSlash(file))
+		if err != nil {
+			t.Fatalf("Unable to locate rendered content: %s", file)
+		}
+		if content := string(helpers.ReaderToBytes(f)); content != expected {
+			t.Errorf("%s content expected:\n%q\ngot\n%q", file, expected, content)
+		}
+	}
+}
+
 var WEIGHTED_PAGE_1 = []byte(`+++
 weight = "2"
 title = "One"
@@ -591,6 +719,14 @@ func TestOrderedPages(t *testing.T) {
 	if rbylength[0].Title != "Four" {
 		t.Errorf("Pages in unexpected order. First should be '%s', got '%s'", "Four", rbylength[0].Title)
 	}
+
+	if lastChange := time.Date(2012, 4, 6, 0, 0, 0, 0, time.UTC); !s.Info.LastChange.Equal(lastChange) {
+		t.Errorf("LastChange should be the date of the newest page %s, got %s", lastChange, s.Info.LastChange)
+	}
+
+	if regular := s.Info.RegularPages(); len(regular) != 4 || regular[0] != s.Pages[0] {
+		t.Errorf("RegularPages should be the 4 pages of the site, got %d", len(regular))
+	}
 }
 
 var GROUPED_SOURCES = []source.ByteSource{
@@ -600,6 +736,97 @@ var GROUPED_SOURCES = []source.ByteSource{
 	{filepath.FromSlash("sect3/doc4.md"), WEIGHTED_PAGE_4},
 }
 
+func TestSourceMap(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub")
+	viper.Set("DefaultExtension", "html")
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\n---\nOne.")},
+		{filepath.FromSlash("about.md"), []byte("---\ntitle: About\nurl: /me/\n---\nMe.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	expected := map[string]string{
+		"sect/doc1.md": "http://auth/bub/sect/doc1/",
+		"about.md":     "http://auth/bub/me/",
+	}
+	if m := s.SourceMap(); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected %v, got %v", expected, m)
+	}
+}
+
+func TestEncryptedPages(t *testing.T) {
+	viper.Set("EncryptPassword", "site secret")
+	defer viper.Set("EncryptPassword", "")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/own.md"), []byte("---\ntitle: Own\npassword: page secret\n---\n# Plans\n\nThe plans.")},
+		{filepath.FromSlash("sect/site.md"), []byte("---\ntitle: Site\nencrypt: true\n---\nThe other plans.")},
+		{filepath.FromSlash("sect/open.md"), []byte("---\ntitle: Open\n---\nOpen plans.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	for _, p := range s.Pages {
+		encrypted := strings.Contains(string(p.Content), `class="hugo-encrypted"`)
+		if p.Title == "Open" {
+			if encrypted || p.Plain() == "" {
+				t.Errorf("Expected %s not to be encrypted", p.Title)
+			}
+			continue
+		}
+		if !encrypted || strings.Contains(string(p.Content), "plans") {
+			t.Errorf("Expected %s to be encrypted, got %s", p.Title, p.Content)
+		}
+		if p.Summary != "" || p.TableOfContents != "" || p.Plain() != "" || len(p.PlainWords()) != 0 {
+			t.Errorf("Expected nothing of %s outside of the encrypted content", p.Title)
+		}
+		if p.WordCount != 0 || p.FuzzyWordCount != 0 || p.ReadingTime != 0 {
+			t.Errorf("Expected no word count or reading time for %s, got %d, %d, %d", p.Title, p.WordCount, p.FuzzyWordCount, p.ReadingTime)
+		}
+		if _, ok := p.Params["password"]; ok {
+			t.Errorf("Expected the password of %s not to be a param", p.Title)
+		}
+	}
+
+	viper.Set("EncryptPassword", "")
+	s = newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/site.md"), []byte("---\ntitle: Site\nencrypt: true\n---\nThe other plans.")},
+	})
+	if err := s.CreatePages(); err == nil {
+		t.Error("Expected an error for a page to encrypt without a password")
+	}
+}
+
+func TestPublishThrottle(t *testing.T) {
+	defer viper.Set("Publish", nil)
+
+	viper.Set("Publish", nil)
+	if th := publishThrottle("pages"); th != nil {
+		t.Errorf("Expected no throttle by default, got %v", th)
+	}
+
+	viper.Set("Publish", map[string]interface{}{
+		"parallel": 4,
+		"retries":  2,
+		"backoff":  "2s",
+		"aliases":  map[string]interface{}{"Rate": 10},
+	})
+
+	pages := publishThrottle("pages")
+	if pages == nil || pages.Parallel != 4 || pages.Rate != 0 || pages.Retries != 2 || pages.Backoff != 2*time.Second {
+		t.Errorf("Unexpected pages throttle %+v", pages)
+	}
+	aliases := publishThrottle("aliases")
+	if aliases == nil || aliases.Parallel != 4 || aliases.Rate != 10 {
+		t.Errorf("Unexpected aliases throttle %+v", aliases)
+	}
+}
+
 func TestGroupedPages(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -884,6 +1111,41 @@ func TestDataDirMultipleSources(t *testing.T) {
 
 }
 
+func TestDataDirYAMLList(t *testing.T) {
+	sources := []source.ByteSource{
+		{filepath.FromSlash("speakers.yaml"), []byte("- name: Ada\n  talk: Engines\n- name: Grace\n  talk: Compilers")},
+		{filepath.FromSlash("empty.yaml"), []byte("")},
+	}
+
+	expected := map[string]interface{}{
+		"speakers": []interface{}{
+			map[interface{}]interface{}{"name": "Ada", "talk": "Engines"},
+			map[interface{}]interface{}{"name": "Grace", "talk": "Compilers"},
+		},
+		"empty": map[string]interface{}{},
+	}
+
+	doTestDataDir(t, expected, []source.Input{&source.InMemorySource{ByteSource: sources}})
+}
+
+func TestDataDirListNotMerged(t *testing.T) {
+	site := []source.ByteSource{
+		{filepath.FromSlash("test/v1.yaml"), []byte("v1: 1")},
+		{filepath.FromSlash("test.yaml"), []byte("- 1\n- 2")},
+		{filepath.FromSlash("prices.json"), []byte(`[ { "plan": "free" } ]`)},
+	}
+	theme := []source.ByteSource{
+		{filepath.FromSlash("prices.json"), []byte(`{ "plan": "pro" }`)},
+	}
+
+	expected := map[string]interface{}{
+		"test":   map[string]interface{}{"v1": map[string]interface{}{"v1": 1}},
+		"prices": []interface{}{map[string]interface{}{"plan": "free"}},
+	}
+
+	doTestDataDir(t, expected, []source.Input{&source.InMemorySource{ByteSource: site}, &source.InMemorySource{ByteSource: theme}})
+}
+
 func TestDataDirUnknownFormat(t *testing.T) {
 	sources := []source.ByteSource{
 		{filepath.FromSlash("test.roml"), []byte("boo")},
@@ -905,3 +1167,40 @@ func doTestDataDir(t *testing.T, expected interface{}, sources []source.Input) {
 		t.Errorf("Expected structure\n%#v got\n%#v", expected, s.Data)
 	}
 }
+
+func TestIconSprite(t *testing.T) {
+	viper.Set("IconSprite", "img/icons.svg")
+	defer viper.Set("IconSprite", "")
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	theme := []source.ByteSource{
+		{filepath.FromSlash("star.svg"), []byte(`<svg viewBox="0 0 8 8"><path d="M0 0"/></svg>`)},
+		{filepath.FromSlash("social/rss.svg"), []byte(`<svg viewBox="0 0 16 16"><circle r="2"/></svg>`)},
+		{filepath.FromSlash("README.md"), []byte("Icons")},
+	}
+	site := []source.ByteSource{
+		{filepath.FromSlash("star.svg"), []byte(`<svg viewBox="0 0 24 24"><path d="M1 1"/></svg>`)},
+	}
+
+	s := &Site{}
+	if err := s.renderIconSprite([]source.Input{&source.InMemorySource{ByteSource: theme}, &source.InMemorySource{ByteSource: site}}); err != nil {
+		t.Fatalf("Unable to render the icon sprite: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("img/icons.svg"))
+	if err != nil {
+		t.Fatalf("Unable to locate: %s", "img/icons.svg")
+	}
+	sprite := string(helpers.ReaderToBytes(file))
+	for _, expected := range []string{
+		`<symbol id="social-rss" viewBox="0 0 16 16"><circle r="2"/></symbol>`,
+		`<symbol id="star" viewBox="0 0 24 24"><path d="M1 1"/></symbol>`,
+	} {
+		if !strings.Contains(sprite, expected) {
+			t.Errorf("Expected %s in the sprite, got %s", expected, sprite)
+		}
+	}
+	if strings.Count(sprite, "<symbol") != 2 {
+		t.Errorf("Expected 2 symbols, got %s", sprite)
+	}
+}
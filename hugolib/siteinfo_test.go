@@ -2,8 +2,10 @@ package hugolib
 
 import (
 	"bytes"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/hugo/source"
 	"github.com/spf13/viper"
 )
 
@@ -42,3 +44,143 @@ func TestSiteInfoPermalinks(t *testing.T) {
 		t.Errorf("Could not set permalink (%#v)", permalink)
 	}
 }
+
+func TestSiteInfoRefs(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub/")
+	defer viper.Set("baseurl", "")
+	viper.Set("DefaultExtension", "html")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("blog/post.md"), []byte("---\ntitle: Post\n---\ncontent")},
+		{filepath.FromSlash("blog/unique.md"), []byte("---\ntitle: Unique\n---\ncontent")},
+		{filepath.FromSlash("link/post.md"), []byte("---\ntitle: Link\n---\ncontent")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	for i, this := range []struct {
+		ref    string
+		expect string
+	}{
+		{"blog/post.md", "/bub/blog/post/"},
+		{"link/post.md", "/bub/link/post/"},
+		{"unique.md", "/bub/blog/unique/"},
+		{"post.md", ""},
+		{"missing.md", ""},
+	} {
+		link, err := s.Info.RelRef(this.ref, nil)
+		if this.expect == "" {
+			if err == nil {
+				t.Errorf("[%d] Expected error for ref %q, got %q", i, this.ref, link)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%d] Unable to resolve ref %q: %s", i, this.ref, err)
+			continue
+		}
+		if link != this.expect {
+			t.Errorf("[%d] Expected %q for ref %q, got %q", i, this.expect, this.ref, link)
+		}
+	}
+}
+
+func TestSiteInfoGetParam(t *testing.T) {
+	s := &Site{}
+	s.initializeSiteInfo()
+	s.Info.Params = map[string]interface{}{
+		"analyticsID": "UA-1",
+		"social":      map[string]interface{}{"Twitter": "spf13"},
+		"links":       []interface{}{"a", "b"},
+		"footer":      map[interface{}]interface{}{"text": "Bye"},
+	}
+
+	for i, this := range []struct {
+		key    string
+		expect interface{}
+	}{
+		{"analyticsID", "UA-1"},
+		{"AnalyticsId", "UA-1"},
+		{"social.twitter", "spf13"},
+		{"footer.text", "Bye"},
+		{"social.facebook", nil},
+		{"analyticsID.nope", nil},
+		{"nope", nil},
+	} {
+		if v := s.Info.GetParam(this.key); v != this.expect {
+			t.Errorf("[%d] GetParam(%q) got %v but expected %v", i, this.key, v, this.expect)
+		}
+	}
+	if links, ok := s.Info.GetParam("links").([]interface{}); !ok || len(links) != 2 {
+		t.Errorf("Expected the list of links, got %v", s.Info.GetParam("links"))
+	}
+
+	p, _ := NewPage("about.md")
+	p.Site = &s.Info
+	p.Params["analyticsid"] = "UA-2"
+	if v := p.Param("analyticsID"); v != "UA-2" {
+		t.Errorf("Expected the front matter over the site params, got %v", v)
+	}
+	if v := p.Param("social.twitter"); v != "spf13" {
+		t.Errorf("Expected the site params without front matter, got %v", v)
+	}
+}
+
+func TestSiteInfoGetPage(t *testing.T) {
+	viper.Set("taxonomies", map[string]string{"tag": "tags"})
+	defer viper.Set("taxonomies", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("_index.md"), []byte("---\ntitle: Home\n---\n")},
+		{filepath.FromSlash("post/_index.md"), []byte("---\ntitle: Posts\n---\n")},
+		{filepath.FromSlash("post/featured.md"), []byte("---\ntitle: Featured\ntags: [go]\n---\nRead me.")},
+		{filepath.FromSlash("post/intro.md"), []byte("---\ntitle: Post Intro\n---\n")},
+		{filepath.FromSlash("docs/intro.md"), []byte("---\ntitle: Docs Intro\n---\n")},
+		{filepath.FromSlash("tags/go/_index.md"), []byte("---\ntitle: The Go Language\n---\n")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	for i, this := range []struct {
+		ref    string
+		path   []string
+		expect string
+	}{
+		{"post/featured.md", nil, "Featured"},
+		{"/post/featured.md", nil, "Featured"},
+		{"featured.md", nil, "Featured"},
+		{"post/_index.md", nil, "Posts"},
+		{"home", nil, "Home"},
+		{"section", []string{"post"}, "Posts"},
+		{"taxonomy", []string{"tags", "Go"}, "The Go Language"},
+		{"post/nope.md", nil, ""},
+		{"section", []string{"nope"}, ""},
+	} {
+		p, err := s.Info.GetPage(this.ref, this.path...)
+		if err != nil {
+			t.Errorf("[%d] GetPage failed: %s", i, err)
+			continue
+		}
+		title := ""
+		if p != nil {
+			title = p.Title
+		}
+		if title != this.expect {
+			t.Errorf("[%d] GetPage(%q, %v) got %q but expected %q", i, this.ref, this.path, title, this.expect)
+		}
+	}
+
+	if _, err := s.Info.GetPage("intro.md"); err == nil {
+		t.Errorf("Expected an error for an ambiguous logical name")
+	}
+	if _, err := s.Info.GetPage("chapter", "one"); err == nil {
+		t.Errorf("Expected an error for an unknown kind")
+	}
+}
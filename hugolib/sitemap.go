@@ -1,13 +1,21 @@
 package hugolib
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
 	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
 )
 
 type Sitemap struct {
 	ChangeFreq string
 	Priority   float64
+	// Exclude leaves the page out of the sitemap.
+	Exclude bool
 }
 
 func parseSitemap(input map[string]interface{}) Sitemap {
@@ -19,6 +27,8 @@ func parseSitemap(input map[string]interface{}) Sitemap {
 			sitemap.ChangeFreq = cast.ToString(value)
 		case "priority":
 			sitemap.Priority = cast.ToFloat64(value)
+		case "exclude":
+			sitemap.Exclude = cast.ToBool(value)
 		default:
 			jww.WARN.Printf("Unknown Sitemap field: %s\n", key)
 		}
@@ -26,3 +36,102 @@ func parseSitemap(input map[string]interface{}) Sitemap {
 
 	return sitemap
 }
+
+// maxSitemapURLs is the number of URLs the sitemap protocol allows in a
+// sitemap.
+const maxSitemapURLs = 50000
+
+// sitemapLimit is the number of pages in a sitemap above which it is split
+// in several, the SitemapLimit config or the protocol's maximum.
+func sitemapLimit() int {
+	limit := viper.GetInt("SitemapLimit")
+	if limit <= 0 || limit > maxSitemapURLs {
+		return maxSitemapURLs
+	}
+	return limit
+}
+
+// A SitemapFile is one of the sitemaps of a site too large for a single
+// one, as listed in its sitemap index.
+type SitemapFile struct {
+	Permalink string
+	LastMod   time.Time // the newest date of its pages
+}
+
+// renderSitemapIndex writes the pages in sitemap1.xml, sitemap2.xml and so
+// on, of at most limit pages each, and the index of these sitemaps to
+// sitemapindex.xml.
+func (s *Site) renderSitemapIndex(pages Pages, limit int, layouts []string) error {
+	var files []SitemapFile
+	for i := 0; i*limit < len(pages); i++ {
+		high := (i + 1) * limit
+		if high > len(pages) {
+			high = len(pages)
+		}
+		chunk := pages[i*limit : high]
+
+		name := fmt.Sprintf("sitemap%d.xml", i+1)
+		n := s.NewNode()
+		n.Data["Pages"] = chunk
+		if err := s.renderAndWriteXML("sitemap "+name, name, n, layouts...); err != nil {
+			return err
+		}
+
+		f := SitemapFile{Permalink: helpers.MakePermalink(viper.GetString("BaseURL"), name).String()}
+		for _, p := range chunk {
+			if p.Date.After(f.LastMod) {
+				f.LastMod = p.Date
+			}
+		}
+		files = append(files, f)
+	}
+
+	n := s.NewNode()
+	n.Data["Sitemaps"] = files
+	indexLayouts := []string{"sitemapindex.xml", "_default/sitemapindex.xml", "_internal/_default/sitemapindex.xml"}
+	if err := s.renderAndWriteXML("sitemap index", "sitemapindex.xml", n, s.appendThemeTemplates(indexLayouts)...); err != nil {
+		return err
+	}
+
+	return s.removeStaleSitemaps(true, len(files))
+}
+
+// removeStaleSitemaps removes the sitemap files a previous build wrote and
+// this one didn't: the sitemaps past the count written, and sitemap.xml or
+// sitemapindex.xml, whichever isn't used with or without an index.
+func (s *Site) removeStaleSitemaps(index bool, count int) error {
+	stale := []string{"sitemapindex.xml"}
+	if index {
+		stale = []string{"sitemap.xml"}
+	}
+	for i := count + 1; ; i++ {
+		name := fmt.Sprintf("sitemap%d.xml", i)
+		if !s.destFileExists(name) {
+			break
+		}
+		stale = append(stale, name)
+	}
+
+	for _, name := range stale {
+		if !s.destFileExists(name) {
+			continue
+		}
+		path, _ := s.FileTarget().Translate(name)
+		if err := hugofs.DestinationFS.Remove(path); err != nil {
+			return err
+		}
+		jww.INFO.Println("Removed stale", name)
+	}
+	return nil
+}
+
+// destFileExists reports whether the file at the path relative to the
+// publish directory exists.
+func (s *Site) destFileExists(name string) bool {
+	path, err := s.FileTarget().Translate(name)
+	if err != nil {
+		return false
+	}
+	_, err = hugofs.DestinationFS.Stat(path)
+	return err == nil
+}
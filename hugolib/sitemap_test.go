@@ -2,6 +2,8 @@ package hugolib
 
 import (
 	"bytes"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -63,3 +65,173 @@ func TestSitemapOutput(t *testing.T) {
 		t.Errorf("Sitemap file should start with <?xml. %s", sitemap)
 	}
 }
+
+func TestSitemapSkipsSyndicatedPages(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/own.md"), []byte("---\ntitle: Own\n---\nMine.")},
+		{filepath.FromSlash("sect/syndicated.md"), []byte("---\ntitle: Syndicated\ncanonicalURL: http://elsewhere.org/post/\n---\nTheirs.")},
+	})
+	s.addTemplate("sitemap.xml", SITEMAP_TEMPLATE)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if err := s.RenderSitemap(); err != nil {
+		t.Fatalf("Unable to RenderSitemap: %s", err)
+	}
+
+	sitemapFile, err := hugofs.DestinationFS.Open("sitemap.xml")
+
+	if err != nil {
+		t.Fatalf("Unable to locate: sitemap.xml")
+	}
+
+	sitemap := helpers.ReaderToBytes(sitemapFile)
+	if !bytes.Contains(sitemap, []byte("sect/own")) {
+		t.Errorf("Sitemap should list sect/own. %s", sitemap)
+	}
+	if bytes.Contains(sitemap, []byte("syndicated")) || bytes.Contains(sitemap, []byte("elsewhere.org")) {
+		t.Errorf("Sitemap should not list syndicated content. %s", sitemap)
+	}
+}
+
+func TestSitemapDefaultsAndExclusion(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("Sitemap", map[string]interface{}{"changefreq": "weekly", "priority": 0.5})
+	defer viper.Set("Sitemap", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/plain.md"), []byte("---\ntitle: Plain\n---\nPlain.")},
+		{filepath.FromSlash("sect/important.md"), []byte("---\ntitle: Important\nsitemap:\n  priority: 0.9\n---\nImportant.")},
+		{filepath.FromSlash("sect/hidden.md"), []byte("---\ntitle: Hidden\nsitemap:\n  exclude: true\n---\nHidden.")},
+		{filepath.FromSlash("sect/thin.md"), []byte("---\ntitle: Thin\nrobots: noindex, follow\n---\nThin.")},
+	})
+	s.addTemplate("sitemap.xml", `{{ range .Data.Pages }}{{ .Permalink }} {{ .Sitemap.ChangeFreq }} {{ .Sitemap.Priority }}
+{{ end }}`)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if err := s.RenderSitemap(); err != nil {
+		t.Fatalf("Unable to RenderSitemap: %s", err)
+	}
+
+	sitemapFile, err := hugofs.DestinationFS.Open("sitemap.xml")
+
+	if err != nil {
+		t.Fatalf("Unable to locate: sitemap.xml")
+	}
+
+	sitemap := string(helpers.ReaderToBytes(sitemapFile))
+	for _, expected := range []string{"http://auth/bub/ weekly 0.5\n", "http://auth/bub/sect/plain/ weekly 0.5\n", "http://auth/bub/sect/important/ weekly 0.9\n"} {
+		if !strings.Contains(sitemap, expected) {
+			t.Errorf("Sitemap should contain %q. %s", expected, sitemap)
+		}
+	}
+	if strings.Contains(sitemap, "hidden") || strings.Contains(sitemap, "thin") {
+		t.Errorf("Sitemap should not list excluded or noindex pages. %s", sitemap)
+	}
+}
+
+func TestSitemapIndexForLargeSites(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("SitemapLimit", 2)
+	defer viper.Set("SitemapLimit", nil)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/a.md"), []byte("---\ntitle: A\ndate: 2014-01-01\n---\nA.")},
+		{filepath.FromSlash("sect/b.md"), []byte("---\ntitle: B\ndate: 2014-02-01\n---\nB.")},
+		{filepath.FromSlash("sect/c.md"), []byte("---\ntitle: C\ndate: 2014-03-01\n---\nC.")},
+	})
+	s.addTemplate("sitemap.xml", `{{ range .Data.Pages }}{{ .Permalink }}
+{{ end }}`)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	// left by previous builds
+	for _, name := range []string{"sitemap.xml", "sitemap3.xml"} {
+		helpers.WriteToDisk(name, strings.NewReader("stale"), hugofs.DestinationFS)
+	}
+
+	if err := s.RenderSitemap(); err != nil {
+		t.Fatalf("Unable to RenderSitemap: %s", err)
+	}
+
+	// The home page and three pages make two sitemaps of two pages.
+	var listed []string
+	for _, name := range []string{"sitemap1.xml", "sitemap2.xml"} {
+		file, err := hugofs.DestinationFS.Open(name)
+		if err != nil {
+			t.Fatalf("Unable to locate: %s", name)
+		}
+		var urls []string
+		for _, field := range strings.Fields(string(helpers.ReaderToBytes(file))) {
+			if strings.HasPrefix(field, "http://auth/bub/") {
+				urls = append(urls, field)
+			}
+		}
+		if len(urls) != 2 {
+			t.Errorf("%s should list 2 pages, got %v", name, urls)
+		}
+		listed = append(listed, urls...)
+	}
+	if len(listed) != 4 {
+		t.Errorf("The sitemaps should list the home page and 3 pages, got %v", listed)
+	}
+
+	for _, name := range []string{"sitemap.xml", "sitemap3.xml"} {
+		if _, err := hugofs.DestinationFS.Open(name); err == nil {
+			t.Errorf("The stale %s should be removed", name)
+		}
+	}
+
+	indexFile, err := hugofs.DestinationFS.Open("sitemapindex.xml")
+	if err != nil {
+		t.Fatalf("Unable to locate: sitemapindex.xml")
+	}
+
+	index := string(helpers.ReaderToBytes(indexFile))
+	for _, expected := range []string{"<sitemapindex", "<loc>http://auth/bub/sitemap1.xml</loc>", "<loc>http://auth/bub/sitemap2.xml</loc>", "<lastmod>2014-03-01"} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("Sitemap index should contain %q. %s", expected, index)
+		}
+	}
+
+	// a build small enough again for a single sitemap removes the others
+	viper.Set("SitemapLimit", nil)
+	if err := s.RenderSitemap(); err != nil {
+		t.Fatalf("Unable to RenderSitemap: %s", err)
+	}
+	if _, err := hugofs.DestinationFS.Open("sitemap.xml"); err != nil {
+		t.Errorf("Unable to locate: sitemap.xml")
+	}
+	for _, name := range []string{"sitemapindex.xml", "sitemap1.xml", "sitemap2.xml"} {
+		if _, err := hugofs.DestinationFS.Open(name); err == nil {
+			t.Errorf("The stale %s should be removed", name)
+		}
+	}
+}
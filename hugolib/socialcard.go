@@ -0,0 +1,115 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+// socialCardFormat places the social card of a page next to its HTML, e.g.
+// post/first/card.png, or post/first.png with UglyURLs.
+var socialCardFormat = OutputFormat{Name: "card", MediaType: "image/png", Suffix: "png", BaseName: "card"}
+
+// hasSocialCard returns whether a social card is generated for the page,
+// which is when EnableSocialCards is set and the page has no images of its
+// own.
+func (p *Page) hasSocialCard() bool {
+	return viper.GetBool("EnableSocialCards") && p.Site != nil && p.Params["images"] == nil
+}
+
+// SocialCard returns the permalink of the generated social card of the
+// page, for the Open Graph and Twitter Card images, or "" if it has none.
+func (p *Page) SocialCard() string {
+	if !p.hasSocialCard() {
+		return ""
+	}
+	return helpers.MakePermalink(string(p.Site.BaseUrl), filepath.ToSlash(socialCardFormat.targetPath(p.htmlTargetPath()))).String()
+}
+
+// RenderSocialCards writes the social card of every page, with its title
+// and the site title in SocialCardColor over SocialCardBackground, or over
+// the SocialCardImage in the static directory.
+func (s *Site) RenderSocialCards() error {
+	if !viper.GetBool("EnableSocialCards") {
+		return nil
+	}
+
+	card, err := socialCardTemplate()
+	if err != nil {
+		return err
+	}
+	card.SiteName = s.Info.Title
+
+	for _, p := range s.Pages {
+		if !p.hasSocialCard() {
+			continue
+		}
+		card.Title = p.Title
+		b, err := card.PNG()
+		if err != nil {
+			return fmt.Errorf("Unable to draw the social card of %s: %s", p.FullFilePath(), err)
+		}
+		dest := socialCardFormat.targetPath(p.htmlTargetPath())
+		if err := s.WriteDestFile(dest, bytes.NewReader(b)); err != nil {
+			return err
+		}
+		helpers.BuildLog.Rendered(dest, "social card of "+p.FullFilePath())
+	}
+	return nil
+}
+
+// socialCardTemplate returns the card of the site config, without the
+// title of a page.
+func socialCardTemplate() (card helpers.SocialCard, err error) {
+	if card.Color, err = socialCardColor("SocialCardColor", "#ffffff"); err != nil {
+		return card, err
+	}
+	if card.Background, err = socialCardColor("SocialCardBackground", "#1e293b"); err != nil {
+		return card, err
+	}
+
+	if name := viper.GetString("SocialCardImage"); name != "" {
+		f, err := hugofs.SourceFs.Open(filepath.Join(helpers.AbsPathify(viper.GetString("StaticDir")), filepath.FromSlash(name)))
+		if err != nil {
+			return card, fmt.Errorf("Unable to read the SocialCardImage: %s", err)
+		}
+		defer f.Close()
+		if card.BackgroundImage, _, err = image.Decode(f); err != nil {
+			return card, fmt.Errorf("Unable to decode the SocialCardImage %s: %s", name, err)
+		}
+	}
+	return card, nil
+}
+
+func socialCardColor(key, def string) (color.RGBA, error) {
+	v := viper.GetString(key)
+	if v == "" {
+		v = def
+	}
+	c, err := helpers.ParseHexColor(v)
+	if err != nil {
+		return c, fmt.Errorf("%s: %s", key, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package hugolib
+
+import (
+	"bytes"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestRenderSocialCards(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+	viper.Set("EnableSocialCards", true)
+	defer viper.Set("EnableSocialCards", false)
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nOne.")},
+		{filepath.FromSlash("post/two.md"), []byte("---\ntitle: Two\nimages: [/two.png]\n---\nTwo.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.RenderSocialCards(); err != nil {
+		t.Fatalf("Unable to render the social cards: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/one/card.png"))
+	if err != nil {
+		t.Fatalf("Unable to locate: post/one/card.png")
+	}
+	if _, err := png.Decode(bytes.NewReader(helpers.ReaderToBytes(file))); err != nil {
+		t.Errorf("The social card is not a PNG image: %s", err)
+	}
+	if _, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/two/card.png")); err == nil {
+		t.Errorf("A page with images of its own should not get a social card")
+	}
+
+	for _, p := range s.Pages {
+		expected := "http://auth/bub/post/one/card.png"
+		if p.Title == "Two" {
+			expected = ""
+		}
+		if card := p.SocialCard(); card != expected {
+			t.Errorf("%s: SocialCard is %q, expected %q", p.Title, card, expected)
+		}
+	}
+
+	viper.Set("SocialCardBackground", "navy")
+	defer viper.Set("SocialCardBackground", "")
+	if err := s.RenderSocialCards(); err == nil {
+		t.Errorf("An invalid SocialCardBackground should fail")
+	}
+}
+
+func TestRenderSocialMetaTemplates(t *testing.T) {
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	viper.Set("baseurl", "http://auth/bub/")
+	viper.Set("DefaultExtension", "html")
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\ndate: 2015-01-02\nimages: [http://auth/bub/one.png]\nvideos: [http://auth/bub/one.mp4]\n---\nOne.")},
+	})
+	s.Info.Authors = AuthorList{"jo": Author{Social: AuthorSocial{"twitter": "jotweets", "facebook": "jobook"}}}
+	s.Info.Social = SiteSocial{"facebook": "sitebook"}
+	s.prepTemplates()
+	s.addTemplate("_default/single.html", `{{ template "_internal/opengraph.html" . }}{{ template "_internal/twitter_cards.html" . }}`)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.RenderPages(); err != nil {
+		t.Fatalf("Unable to render the pages: %s", err)
+	}
+
+	file, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/one/index.html"))
+	if err != nil {
+		t.Fatalf("Unable to locate: post/one/index.html")
+	}
+	content := string(helpers.ReaderToBytes(file))
+	for _, expected := range []string{
+		`<meta property="og:image" content="http://auth/bub/one.png" />`,
+		`<meta property="og:video" content="http://auth/bub/one.mp4" />`,
+		`<meta property="article:author" content="https://www.facebook.com/jobook" />`,
+		`<meta property="article:publisher" content="https://www.facebook.com/sitebook" />`,
+		`<meta property="article:modified_time" content="2015-01-02T00:00:00&#43;00:00" />`,
+		`<meta name="twitter:card" content="summary_large_image"/>`,
+		`<meta name="twitter:creator" content="@jotweets"/>`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %s in:\n%s", expected, content)
+		}
+	}
+}
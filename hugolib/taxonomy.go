@@ -15,8 +15,13 @@ package hugolib
 
 import (
 	"sort"
+	"strings"
 
+	"bitbucket.org/pkg/inflect"
+	"github.com/spf13/cast"
 	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
 )
 
 /*
@@ -58,6 +63,43 @@ type OrderedTaxonomyEntry struct {
 	WeightedPages WeightedPages
 }
 
+// getTaxonomies returns the configured taxonomies, singular to plural.
+// Besides the map form, e.g. author = "authors", taxonomies may be given as
+// a list of plural names, whose singulars are then derived.
+func getTaxonomies() map[string]string {
+	taxonomies := make(map[string]string)
+
+	switch v := viper.Get("Taxonomies").(type) {
+	case []string, []interface{}:
+		for _, plural := range cast.ToStringSlice(v) {
+			taxonomies[inflect.Singularize(plural)] = plural
+		}
+	default:
+		for singular, plural := range viper.GetStringMapString("Taxonomies") {
+			taxonomies[singular] = plural
+		}
+	}
+
+	for singular, plural := range taxonomies {
+		if strings.TrimSpace(singular) == "" || strings.TrimSpace(plural) == "" {
+			jww.ERROR.Printf("Invalid taxonomy %q = %q, both the singular and the plural name are needed\n", singular, plural)
+			delete(taxonomies, singular)
+		}
+	}
+
+	return taxonomies
+}
+
+// TaxonomyNames returns the plural names of the configured taxonomies.
+func TaxonomyNames() []string {
+	var plurals []string
+	for _, plural := range getTaxonomies() {
+		plurals = append(plurals, plural)
+	}
+	sort.Strings(plurals)
+	return plurals
+}
+
 // KeyPrep... Taxonomies should be case insensitive. Can make it easily conditional later.
 func kp(in string) string {
 	return helpers.MakePathToLower(in)
@@ -103,6 +145,43 @@ func (i Taxonomy) ByCount() OrderedTaxonomy {
 	return ia
 }
 
+// A TaxonomyCloudEntry is a term with a weight relative to the number of
+// its pages.
+type TaxonomyCloudEntry struct {
+	OrderedTaxonomyEntry
+	Weight int
+}
+
+// Cloud returns the terms sorted by name, weighted from 1 for the terms
+// with the fewest pages to steps for the ones with the most, e.g. to size
+// the terms of a tag cloud.
+func (i Taxonomy) Cloud(steps int) []TaxonomyCloudEntry {
+	ia := i.Alphabetical()
+	if len(ia) == 0 {
+		return nil
+	}
+
+	min, max := ia[0].Count(), ia[0].Count()
+	for _, e := range ia {
+		if e.Count() < min {
+			min = e.Count()
+		}
+		if e.Count() > max {
+			max = e.Count()
+		}
+	}
+
+	cloud := make([]TaxonomyCloudEntry, len(ia))
+	for j, e := range ia {
+		weight := 1
+		if max > min && steps > 1 {
+			weight += (e.Count() - min) * (steps - 1) / (max - min)
+		}
+		cloud[j] = TaxonomyCloudEntry{e, weight}
+	}
+	return cloud
+}
+
 // Helper to move the page access up a level
 func (ie OrderedTaxonomyEntry) Pages() Pages {
 	return ie.WeightedPages.Pages()
@@ -189,13 +268,124 @@ func (wp WeightedPages) Swap(i, j int) { wp[i], wp[j] = wp[j], wp[i] }
 func (wp WeightedPages) Sort()         { sort.Stable(wp) }
 func (wp WeightedPages) Count() int    { return len(wp) }
 func (wp WeightedPages) Less(i, j int) bool {
-	if wp[i].Weight == wp[j].Weight {
-		if wp[i].Page.Date.Equal(wp[j].Page.Date) {
-			return wp[i].Page.Title < wp[j].Page.Title
+	return byWeight(&wp[i], &wp[j])
+}
+
+// byWeight is the default order of WeightedPages: by weight, then newest
+// first, then by title.
+func byWeight(wp1, wp2 *WeightedPage) bool {
+	if wp1.Weight == wp2.Weight {
+		if wp1.Page.Date.Equal(wp2.Page.Date) {
+			return wp1.Page.Title < wp2.Page.Title
 		}
-		return wp[i].Page.Date.After(wp[i].Page.Date)
+		return wp1.Page.Date.After(wp2.Page.Date)
 	}
-	return wp[i].Weight < wp[j].Weight
+	return wp1.Weight < wp2.Weight
 }
 
-// TODO mimic PagesSorter for WeightedPages
+// WeightedPagesBy is a closure used in the Sort.Less method of
+// WeightedPages, like PageBy for Pages.
+type WeightedPagesBy func(wp1, wp2 *WeightedPage) bool
+
+func (by WeightedPagesBy) Sort(wp WeightedPages) {
+	sort.Stable(&weightedPagesSorter{wp, by})
+}
+
+type weightedPagesSorter struct {
+	pages WeightedPages
+	by    WeightedPagesBy
+}
+
+func (s *weightedPagesSorter) Len() int      { return len(s.pages) }
+func (s *weightedPagesSorter) Swap(i, j int) { s.pages[i], s.pages[j] = s.pages[j], s.pages[i] }
+func (s *weightedPagesSorter) Less(i, j int) bool {
+	return s.by(&s.pages[i], &s.pages[j])
+}
+
+// the page orders of the taxonomyOrder config, by name
+var weightedPagesOrders = map[string]WeightedPagesBy{
+	"weight": byWeight,
+	"date": func(wp1, wp2 *WeightedPage) bool {
+		if wp1.Page.Date.Equal(wp2.Page.Date) {
+			return wp1.Page.Title < wp2.Page.Title
+		}
+		return wp1.Page.Date.After(wp2.Page.Date)
+	},
+	"title": func(wp1, wp2 *WeightedPage) bool {
+		if wp1.Page.Title == wp2.Page.Title {
+			return wp1.Page.Date.After(wp2.Page.Date)
+		}
+		return wp1.Page.Title < wp2.Page.Title
+	},
+}
+
+var termOrders = []string{"alphabetical", "count", "weight"}
+
+// A taxonomyOrder is the order of the terms of a taxonomy and of the pages
+// of its terms, set per taxonomy in the config:
+//
+//	[taxonomyOrder.tags]
+//	terms = "count"
+//	pages = "date"
+//
+// Terms are ordered alphabetically, by count or by the weight of their
+// _index page, and pages by weight, date or title.
+type taxonomyOrder struct {
+	terms string
+	pages string
+}
+
+func getTaxonomyOrder(plural string) taxonomyOrder {
+	order := taxonomyOrder{terms: "alphabetical", pages: "weight"}
+	config := cast.ToStringMapString(viper.GetStringMap("TaxonomyOrder")[plural])
+
+	for key, value := range config {
+		value = strings.ToLower(value)
+		switch strings.ToLower(key) {
+		case "terms":
+			if !helpers.InStringArray(termOrders, value) {
+				jww.ERROR.Printf("Invalid order %q of the %s terms, must be one of %s\n", value, plural, strings.Join(termOrders, ", "))
+				continue
+			}
+			order.terms = value
+		case "pages":
+			if _, ok := weightedPagesOrders[value]; !ok {
+				jww.ERROR.Printf("Invalid order %q of the %s pages, must be one of date, title, weight\n", value, plural)
+				continue
+			}
+			order.pages = value
+		default:
+			jww.ERROR.Printf("Unknown taxonomyOrder setting %q of %s\n", key, plural)
+		}
+	}
+	return order
+}
+
+// OrderedTerms returns the terms of the taxonomy in the order of its
+// taxonomyOrder config, alphabetical by default.
+func (s *SiteInfo) OrderedTerms(plural string) OrderedTaxonomy {
+	plural = strings.ToLower(plural)
+	terms := s.Taxonomies[plural]
+
+	switch getTaxonomyOrder(plural).terms {
+	case "count":
+		ia := terms.Alphabetical()
+		OIby(func(i1, i2 *OrderedTaxonomyEntry) bool {
+			return len(i1.WeightedPages) > len(i2.WeightedPages)
+		}).Sort(ia)
+		return ia
+	case "weight":
+		weight := func(name string) int {
+			if p, ok := s.termPages[plural][name]; ok {
+				return p.Weight
+			}
+			return 0
+		}
+		ia := terms.Alphabetical()
+		OIby(func(i1, i2 *OrderedTaxonomyEntry) bool {
+			return weight(i1.Name) < weight(i2.Name)
+		}).Sort(ia)
+		return ia
+	}
+	return terms.Alphabetical()
+}
@@ -1,8 +1,13 @@
 package hugolib
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
 )
 
 func TestSitePossibleTaxonomies(t *testing.T) {
@@ -16,3 +21,258 @@ func TestSitePossibleTaxonomies(t *testing.T) {
 		}
 	}
 }
+
+func TestWeightedPagesOfEqualWeightNewestFirst(t *testing.T) {
+	page := func(title string, year int) *Page {
+		p := &Page{}
+		p.Title = title
+		p.Date = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		return p
+	}
+	wp := WeightedPages{
+		{Weight: 1, Page: page("Old", 2013)},
+		{Weight: 1, Page: page("New", 2015)},
+		{Weight: 0, Page: page("First", 2012)},
+		{Weight: 1, Page: page("Mid", 2014)},
+	}
+	wp.Sort()
+
+	var titles []string
+	for _, p := range wp.Pages() {
+		titles = append(titles, p.Title)
+	}
+	if !compareStringSlice(titles, []string{"First", "New", "Mid", "Old"}) {
+		t.Errorf("Expected the pages of equal weight newest first, got %v", titles)
+	}
+}
+
+func TestGetTerms(t *testing.T) {
+	viper.Set("baseurl", "http://auth/bub")
+	viper.Set("taxonomies", map[string]string{"serie": "series", "tag": "tags"})
+	defer viper.Set("taxonomies", map[string]string{"tag": "tags", "category": "categories"})
+
+	sources := []source.ByteSource{
+		{filepath.FromSlash("series/learn-go/_index.md"), []byte("---\ntitle: Learning Go\ncover: gopher.png\n---\nA series on Go.")},
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\nseries: Learn-Go\ntags: [\"go\", \"intro\"]\n---\nOne.")},
+	}
+
+	s := newTestSite(sources)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if len(s.Pages) != 1 {
+		t.Fatalf("Expected the term page to be left out of the pages, got %d pages", len(s.Pages))
+	}
+
+	series := s.Pages[0].GetTerms("series")
+	if len(series) != 1 {
+		t.Fatalf("Expected 1 series, got %d", len(series))
+	}
+
+	if series[0].Title != "Learning Go" || series[0].GetParam("cover") != "gopher.png" {
+		t.Errorf("Expected the params of the term page, got %q %v", series[0].Title, series[0].Params)
+	}
+
+	if permalink, _ := series[0].Permalink(); permalink != "http://auth/bub/series/learn-go/" {
+		t.Errorf("Expected the term page to link to its taxonomy list, got %s", permalink)
+	}
+
+	tags := s.Pages[0].GetTerms("tags")
+	if len(tags) != 2 || tags[1].Title != "Intro" {
+		t.Errorf("Expected bare term pages for the tags, got %v", tags)
+	}
+
+	if permalink, _ := tags[1].Permalink(); permalink != "http://auth/bub/tags/intro/" {
+		t.Errorf("Unexpected permalink for a bare term page: %s", permalink)
+	}
+}
+
+func TestAssembleTaxonomiesCleansTerms(t *testing.T) {
+	viper.Set("taxonomies", map[string]string{"tag": "tags"})
+	defer viper.Set("taxonomies", map[string]string{"tag": "tags", "category": "categories"})
+
+	sources := []source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\ntags: [\"Go\", \"go\", \" \"]\ntags_weight: 2.5\n---\nOne.")},
+		{filepath.FromSlash("sect/doc2.md"), []byte("---\ntitle: Two\ntags: \"\"\n---\nTwo.")},
+	}
+
+	s := newTestSite(sources)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if len(s.Taxonomies["tags"]) != 1 {
+		t.Fatalf("Expected only the go tag, got %v", s.Taxonomies["tags"])
+	}
+
+	if pages := s.Taxonomies["tags"].Get("go"); len(pages) != 1 || pages[0].Weight != 2 {
+		t.Errorf("Expected doc1 once with weight 2, got %v", pages)
+	}
+}
+
+func TestUserDefinedTaxonomies(t *testing.T) {
+	defer viper.Set("taxonomies", map[string]string{"tag": "tags", "category": "categories"})
+
+	viper.Set("taxonomies", []interface{}{"tags", "authors"})
+	taxonomies := getTaxonomies()
+	if len(taxonomies) != 2 || taxonomies["tag"] != "tags" || taxonomies["author"] != "authors" {
+		t.Errorf("Expected the singulars to be derived from the list, got %v", taxonomies)
+	}
+
+	viper.Set("taxonomies", map[string]string{"serie": "series", "": "broken"})
+	taxonomies = getTaxonomies()
+	if len(taxonomies) != 1 || taxonomies["serie"] != "series" {
+		t.Errorf("Expected the invalid taxonomy to be left out, got %v", taxonomies)
+	}
+
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("sect/doc1.md"), []byte("---\ntitle: One\nseries: Go Basics\n---\nOne.")},
+	})
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	if len(s.Taxonomies["series"].Get("go-basics")) != 1 {
+		t.Errorf("Expected doc1 in the go-basics series, got %v", s.Taxonomies["series"])
+	}
+}
+
+func TestTaxonomyCloud(t *testing.T) {
+	p := &Page{}
+	tags := make(Taxonomy)
+	for term, count := range map[string]int{"go": 9, "hugo": 5, "web": 1, "css": 1} {
+		for i := 0; i < count; i++ {
+			tags.Add(term, WeightedPage{0, p})
+		}
+	}
+
+	cloud := tags.Cloud(5)
+	expected := []struct {
+		term   string
+		weight int
+	}{{"css", 1}, {"go", 5}, {"hugo", 3}, {"web", 1}}
+
+	if len(cloud) != len(expected) {
+		t.Fatalf("Expected %d terms, got %d", len(expected), len(cloud))
+	}
+	for i, e := range expected {
+		if cloud[i].Term() != e.term || cloud[i].Weight != e.weight {
+			t.Errorf("[%d] Expected %s with weight %d, got %s with %d", i, e.term, e.weight, cloud[i].Term(), cloud[i].Weight)
+		}
+	}
+
+	if cloud := (Taxonomy{"go": tags["go"]}).Cloud(5); cloud[0].Weight != 1 {
+		t.Errorf("Expected weight 1 when all terms have as many pages, got %d", cloud[0].Weight)
+	}
+}
+
+func TestPerTermTaxonomyWeights(t *testing.T) {
+	viper.Set("taxonomies", map[string]string{"tag": "tags"})
+	defer viper.Set("taxonomies", map[string]string{"tag": "tags", "category": "categories"})
+
+	sources := []source.ByteSource{
+		{filepath.FromSlash("sect/yaml.md"), []byte("---\ntitle: YAML\ntags: [\"Go\", \"web\"]\ntags_weight:\n  go: 30\n  Web: 10\n---\nYAML.")},
+		{filepath.FromSlash("sect/toml.md"), []byte("+++\ntitle = \"TOML\"\ntags = [\"go\", \"web\"]\n[tags_weight]\ngo = 20\n+++\nTOML.")},
+		{filepath.FromSlash("sect/all.md"), []byte("---\ntitle: All\ntags: [\"go\", \"web\"]\ntags_weight: 15\n---\nAll.")},
+	}
+
+	s := newTestSite(sources)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	for term, expected := range map[string][]string{
+		"go":  {"All", "TOML", "YAML"},
+		"web": {"TOML", "YAML", "All"},
+	} {
+		pages := s.Taxonomies["tags"].Get(term)
+		if len(pages) != len(expected) {
+			t.Fatalf("Expected %d pages for %s, got %d", len(expected), term, len(pages))
+		}
+		for i, title := range expected {
+			if pages[i].Page.Title != title {
+				t.Errorf("Expected %s at %d in %s, got %s (weight %d)", title, i, term, pages[i].Page.Title, pages[i].Weight)
+			}
+		}
+	}
+}
+
+func TestTaxonomyOrder(t *testing.T) {
+	viper.Set("taxonomies", map[string]string{"tag": "tags", "serie": "series"})
+	viper.Set("TaxonomyOrder", map[string]interface{}{
+		"tags":   map[string]interface{}{"terms": "count", "pages": "title"},
+		"series": map[string]interface{}{"terms": "weight", "pages": "nope"},
+	})
+	defer viper.Set("taxonomies", map[string]string{"tag": "tags", "category": "categories"})
+	defer viper.Set("TaxonomyOrder", nil)
+
+	sources := []source.ByteSource{
+		{filepath.FromSlash("series/go/_index.md"), []byte("---\ntitle: Go\nweight: 2\n---\n")},
+		{filepath.FromSlash("series/rust/_index.md"), []byte("---\ntitle: Rust\nweight: 1\n---\n")},
+		{filepath.FromSlash("sect/a.md"), []byte("---\ntitle: Banana\ndate: 2015-01-01\ntags: [\"b\", \"a\"]\nseries: go\n---\n")},
+		{filepath.FromSlash("sect/b.md"), []byte("---\ntitle: Apple\ndate: 2015-02-01\ntags: [\"b\"]\nseries: [\"go\", \"rust\"]\nseries_weight: 1\n---\n")},
+		{filepath.FromSlash("sect/c.md"), []byte("---\ntitle: Cherry\ndate: 2015-03-01\ntags: [\"c\", \"b\"]\nseries: [\"go\", \"elm\"]\n---\n")},
+	}
+
+	s := newTestSite(sources)
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+	if err := s.BuildSiteMeta(); err != nil {
+		t.Fatalf("Unable to build site metadata: %s", err)
+	}
+
+	for i, this := range []struct {
+		plural string
+		terms  []string
+	}{
+		{"tags", []string{"b", "a", "c"}},
+		{"series", []string{"elm", "rust", "go"}},
+	} {
+		var terms []string
+		for _, e := range s.Info.OrderedTerms(this.plural) {
+			terms = append(terms, e.Name)
+		}
+		if !compareStringSlice(terms, this.terms) {
+			t.Errorf("[%d] Got the %s terms %v, expected %v", i, this.plural, terms, this.terms)
+		}
+	}
+
+	for i, this := range []struct {
+		plural, term string
+		titles       []string
+	}{
+		{"tags", "b", []string{"Apple", "Banana", "Cherry"}},
+		{"series", "go", []string{"Cherry", "Banana", "Apple"}},
+	} {
+		var titles []string
+		for _, p := range s.Taxonomies[this.plural].Get(this.term).Pages() {
+			titles = append(titles, p.Title)
+		}
+		if !compareStringSlice(titles, this.titles) {
+			t.Errorf("[%d] Got the %s pages %v, expected %v", i, this.term, titles, this.titles)
+		}
+	}
+}
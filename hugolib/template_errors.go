@@ -0,0 +1,76 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A NilAccessError is a template error of a field read from a nil value,
+// e.g. .Next.Title on the last page, which has no next page.
+type NilAccessError struct {
+	Template string
+	Line     int
+	Column   int
+
+	// Expr is the expression that failed, e.g. .Next.Title, and Value the
+	// part of it that is nil, e.g. .Next.
+	Expr  string
+	Value string
+
+	// Field is the field that could not be read, e.g. Title.
+	Field string
+}
+
+func (e *NilAccessError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s is nil, so its %s can't be read in %s; check it with \"with\" or \"if\" first",
+		e.Template, e.Line, e.Column, e.Value, e.Field, e.Expr)
+}
+
+var nilAccessRe = regexp.MustCompile(`^template: (.+?):(\d+):(\d+): executing "[^"]*" at <(.*)>: nil pointer evaluating .+\.(\w+)$`)
+
+// asNilAccessError returns the nil access of the template execution error,
+// the innermost one for the errors of partials, or nil if it is another
+// error. text/template has no error type for it, so this reads the message;
+// messages worded differently, e.g. by another Go version, are left as
+// they are, errors rather than warnings.
+func asNilAccessError(err error) *NilAccessError {
+	msg := err.Error()
+	i := strings.LastIndex(msg, "template: ")
+	if i < 0 {
+		return nil
+	}
+	m := nilAccessRe.FindStringSubmatch(msg[i:])
+	if m == nil {
+		return nil
+	}
+
+	e := &NilAccessError{Template: m[1], Expr: m[4], Value: m[4], Field: m[5]}
+	e.Line, _ = strconv.Atoi(m[2])
+	e.Column, _ = strconv.Atoi(m[3])
+
+	// the value is what comes before the field, after its first element:
+	// in .Next.Next.Title, a nil .Next has no Next
+	for j := 1; j < len(e.Expr); j++ {
+		rest := e.Expr[j:]
+		if strings.HasPrefix(rest, "."+e.Field) && (len(rest) == len(e.Field)+1 || rest[len(e.Field)+1] == '.') {
+			e.Value = e.Expr[:j]
+			break
+		}
+	}
+	return e
+}
@@ -0,0 +1,77 @@
+package hugolib
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/viper"
+)
+
+func TestAsNilAccessError(t *testing.T) {
+	for i, this := range []struct {
+		tpl      string
+		expected *NilAccessError
+	}{
+		{"{{ .Next.Title }}", &NilAccessError{"single.html", 1, 8, ".Next.Title", ".Next", "Title"}},
+		{"\n{{ .Prev.Next.Title }}", &NilAccessError{"single.html", 2, 8, ".Prev.Next.Title", ".Prev", "Next"}},
+		{"{{ $p := .Next }}{{ $p.Title }}", &NilAccessError{"single.html", 1, 22, "$p.Title", "$p", "Title"}},
+		{"{{ .Nope }}", nil},
+	} {
+		tmpl := template.Must(template.New("single.html").Parse(this.tpl))
+		err := tmpl.Execute(new(bytes.Buffer), &Page{})
+		if err == nil {
+			t.Fatalf("[%d] Expected an error executing %s", i, this.tpl)
+		}
+		nilErr := asNilAccessError(err)
+		if this.expected == nil {
+			if nilErr != nil {
+				t.Errorf("[%d] Expected no nil access in %s, got %v", i, err, nilErr)
+			}
+			continue
+		}
+		if nilErr == nil || *nilErr != *this.expected {
+			t.Errorf("[%d] Got %#v from %s, expected %#v", i, nilErr, err, this.expected)
+		}
+	}
+
+	for _, msg := range []string{
+		"some error",
+		`template: single.html:1:8: executing "single.html" at <.Next.Title>: nil pointer dereference`,
+		`template: single.html:1:8: executing "single.html" at <.Next.Title>: can't evaluate field Title in type *hugolib.Page`,
+		`template: single.html:x:8: executing "single.html" at <.Next.Title>: nil pointer evaluating *hugolib.Page.Title`,
+	} {
+		if nilErr := asNilAccessError(errors.New(msg)); nilErr != nil {
+			t.Errorf("Expected no nil access in %q, got %v", msg, nilErr)
+		}
+	}
+
+	e := &NilAccessError{"_default/single.html", 3, 5, ".Next.Title", ".Next", "Title"}
+	expected := `_default/single.html:3:5: .Next is nil, so its Title can't be read in .Next.Title; check it with "with" or "if" first`
+	if e.Error() != expected {
+		t.Errorf("Got the message %q, expected %q", e.Error(), expected)
+	}
+}
+
+func TestRenderNilAccessAsWarning(t *testing.T) {
+	viper.Set("DefaultExtension", "html")
+	viper.Set("TemplateNilAccess", "warn")
+	defer viper.Set("TemplateNilAccess", "")
+
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	s := newTestSite([]source.ByteSource{
+		{filepath.FromSlash("post/one.md"), []byte("---\ntitle: One\n---\nOne.")},
+	})
+	s.addTemplate("_default/single.html", "{{ .Title }}{{ .Next.Title }}")
+
+	createAndRenderPages(t, s)
+
+	if _, err := hugofs.DestinationFS.Open(filepath.FromSlash("post/one/index.html")); err == nil {
+		t.Errorf("Expected the page not to be written")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hugotest runs the layouts and partials of a site or theme against
+// fixture pages held in memory, so that they can be tested with go test:
+//
+//	func TestSummary(t *testing.T) {
+//		site := hugotest.NewSite(t, hugotest.Files{
+//			"post/first.md": "---\ntitle: First\n---\nHello.",
+//		})
+//		defer site.Close()
+//		site.LoadLayouts("../layouts")
+//
+//		out := site.Render("post/summary.html", site.Page("post/first.md"))
+//		if !strings.Contains(out, "<h2>First</h2>") {
+//			t.Errorf("No title in %s", out)
+//		}
+//	}
+//
+// The site config is read from viper, as in a build, and nothing is written
+// to disk.
+package hugotest
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/hugo/hugolib"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"github.com/spf13/hugo/tpl"
+)
+
+// Files are the content files of a fixture site, by their path relative to
+// the content directory.
+type Files map[string]string
+
+// A Site is a site built from fixture content, for the templates to run
+// against. Any error fails the test.
+type Site struct {
+	*hugolib.Site
+	t      testing.TB
+	built  bool
+	destFS afero.Fs
+}
+
+// NewSite returns a site with the content files. The pages are read and
+// converted the first time they are used, so that the templates of
+// shortcodes can be added or loaded before. The site writes to memory in
+// place of hugofs.DestinationFS until it is closed.
+func NewSite(t testing.TB, content Files) *Site {
+	names := make([]string, 0, len(content))
+	for name := range content {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]source.ByteSource, len(names))
+	for i, name := range names {
+		files[i] = source.ByteSource{Name: filepath.FromSlash(name), Content: []byte(content[name])}
+	}
+
+	destFS := hugofs.DestinationFS
+	hugofs.DestinationFS = new(afero.MemMapFs)
+
+	s := &hugolib.Site{Source: &source.InMemorySource{ByteSource: files}}
+	s.Targets.Page = &target.PagePub{}
+	s.Tmpl = tpl.InitializeT()
+	return &Site{Site: s, t: t, destFS: destFS}
+}
+
+// Close restores the hugofs.DestinationFS replaced by NewSite.
+func (s *Site) Close() {
+	hugofs.DestinationFS = s.destFS
+}
+
+// AddTemplate adds the template text as name, e.g. "partials/header.html".
+func (s *Site) AddTemplate(name, text string) {
+	if err := s.Tmpl.AddTemplate(name, text); err != nil {
+		s.t.Fatalf("Unable to add template %s: %s", name, err)
+	}
+}
+
+// LoadLayouts loads the templates of a layouts directory, such as the one of
+// a theme, named relative to it, e.g. "_default/single.html".
+func (s *Site) LoadLayouts(dir string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		s.t.Fatalf("Unable to load the layouts in %s: %s", dir, err)
+	}
+	if exists, _ := helpers.DirExists(abs, hugofs.SourceFs); !exists {
+		s.t.Fatalf("No layouts directory %s", abs)
+	}
+	s.Tmpl.LoadTemplates(abs)
+	s.Tmpl.PrintErrors()
+}
+
+func (s *Site) build() {
+	if s.built {
+		return
+	}
+	s.built = true
+	if err := s.ProcessSource(); err != nil {
+		s.t.Fatalf("Unable to build the site: %s", err)
+	}
+}
+
+// Pages returns the pages of the site, sorted as in a build.
+func (s *Site) Pages() hugolib.Pages {
+	s.build()
+	return s.Site.Pages
+}
+
+// Page returns the page of the content file at path.
+func (s *Site) Page(path string) *hugolib.Page {
+	path = filepath.FromSlash(path)
+	for _, p := range s.Pages() {
+		if p.Source.Path() == path {
+			return p
+		}
+	}
+	s.t.Fatalf("No page for %s", path)
+	return nil
+}
+
+// Render executes the template, such as "_default/single.html", with the
+// data, usually a page, and returns its output.
+func (s *Site) Render(layout string, data interface{}) string {
+	s.build()
+	if s.Tmpl.Lookup(layout) == nil {
+		s.t.Fatalf("No template %s", layout)
+	}
+	var b bytes.Buffer
+	if err := s.Tmpl.ExecuteTemplate(&b, layout, data); err != nil {
+		s.t.Fatalf("Unable to render %s: %s", layout, err)
+	}
+	return b.String()
+}
+
+// Partial executes the partial as the partial template function does, e.g.
+// Partial("header.html", page) for partials/header.html.
+func (s *Site) Partial(name string, data interface{}) string {
+	return s.Render("partials/"+name, data)
+}
@@ -0,0 +1,72 @@
+package hugotest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+func TestRender(t *testing.T) {
+	viper.Set("baseurl", "http://example.com/")
+	viper.Set("DefaultExtension", "html")
+	defer viper.Set("DefaultExtension", nil)
+
+	site := NewSite(t, Files{
+		"post/first.md":  "---\ntitle: First\nweight: 2\ntags: [go]\n---\nHello {{< hi >}}.",
+		"post/second.md": "---\ntitle: Second\nweight: 1\n---\nBye.",
+	})
+	defer site.Close()
+	site.AddTemplate("shortcodes/hi.html", "<b>world</b>")
+	site.AddTemplate("partials/tags.html", "{{ range .Params.tags }}#{{ . }}{{ end }}")
+	site.AddTemplate("_default/single.html", `<h1>{{ .Title }}</h1>{{ .Content }}{{ partial "tags.html" . }}`)
+
+	if n := len(site.Pages()); n != 2 {
+		t.Fatalf("Expected 2 pages, got %d", n)
+	}
+	if site.Pages()[0].Title != "Second" {
+		t.Errorf("Expected the pages sorted by weight, got %s first", site.Pages()[0].Title)
+	}
+
+	first := site.Page("post/first.md")
+	if out := site.Render("_default/single.html", first); out != "<h1>First</h1><p>Hello <b>world</b>.</p>\n#go" {
+		t.Errorf("Unexpected output %q", out)
+	}
+	if out := site.Partial("tags.html", first); out != "#go" {
+		t.Errorf("Unexpected partial output %q", out)
+	}
+	if out := site.Render("_default/single.html", map[string]string{"Title": "Data"}); !strings.Contains(out, "<h1>Data</h1>") {
+		t.Errorf("Unexpected output for data %q", out)
+	}
+}
+
+func TestLoadLayouts(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "hugotest-layouts")
+	defer os.RemoveAll(dir)
+	if err := helpers.WriteToDisk(filepath.Join(dir, "_default", "summary.html"), strings.NewReader("<h2>{{ .Title }}</h2>"), hugofs.SourceFs); err != nil {
+		t.Fatal(err)
+	}
+
+	site := NewSite(t, Files{"about.md": "---\ntitle: About\n---\nUs."})
+	defer site.Close()
+	site.LoadLayouts(dir)
+	if out := site.Render("_default/summary.html", site.Page("about.md")); out != "<h2>About</h2>" {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
+
+func TestClose(t *testing.T) {
+	destFS := hugofs.DestinationFS
+	site := NewSite(t, Files{})
+	if hugofs.DestinationFS == destFS {
+		t.Fatalf("Expected the site to write to memory")
+	}
+	site.Close()
+	if hugofs.DestinationFS != destFS {
+		t.Errorf("Expected the DestinationFS restored, got %T", hugofs.DestinationFS)
+	}
+}
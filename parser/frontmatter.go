@@ -182,6 +182,16 @@ func HandleYAMLMetaData(datum []byte) (interface{}, error) {
 	return m, nil
 }
 
+// HandleYAMLData reads a YAML data file, which unlike front matter may
+// be a list as well as a map, e.g. a list of speakers.
+func HandleYAMLData(datum []byte) (interface{}, error) {
+	var list []interface{}
+	if err := yaml.Unmarshal(datum, &list); err == nil && list != nil {
+		return list, nil
+	}
+	return HandleYAMLMetaData(datum)
+}
+
 func HandleJSONMetaData(datum []byte) (interface{}, error) {
 	var f interface{}
 	if err := json.Unmarshal(datum, &f); err != nil {
@@ -0,0 +1,104 @@
+package target
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// A Throttle limits the publishing to a destination that can't keep up with
+// Hugo, such as a network drive or a mounted cloud storage bucket that
+// throttles its clients. The zero value publishes without limits.
+type Throttle struct {
+	// Parallel is the number of files published at the same time.
+	Parallel int
+	// Rate is the number of files published per second.
+	Rate float64
+	// Retries is the number of times a failed publish is tried again.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each next one.
+	Backoff time.Duration
+
+	init  sync.Once
+	slots chan struct{}
+	mu    sync.Mutex
+	next  time.Time
+}
+
+// Do calls publish within the limits of the throttle, retrying it when it
+// fails.
+func (t *Throttle) Do(publish func() error) (err error) {
+	t.init.Do(func() {
+		if t.Parallel > 0 {
+			t.slots = make(chan struct{}, t.Parallel)
+		}
+	})
+
+	if t.slots != nil {
+		t.slots <- struct{}{}
+		defer func() { <-t.slots }()
+	}
+
+	backoff := t.Backoff
+	for try := 0; ; try++ {
+		t.wait()
+		if err = publish(); err == nil || try >= t.Retries {
+			return
+		}
+		jww.WARN.Printf("Publishing failed, retrying in %s: %s\n", backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// wait blocks until the rate allows the next publish.
+func (t *Throttle) wait() {
+	if t.Rate <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	at := t.next
+	t.next = t.next.Add(time.Duration(float64(time.Second) / t.Rate))
+	t.mu.Unlock()
+
+	time.Sleep(at.Sub(now))
+}
+
+// ThrottledOutput publishes to Output within the limits of Throttle.
+type ThrottledOutput struct {
+	Output
+	Throttle *Throttle
+}
+
+func (o *ThrottledOutput) Publish(path string, r io.Reader) error {
+	// read it all for the retries
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return o.Throttle.Do(func() error {
+		return o.Output.Publish(path, bytes.NewReader(content))
+	})
+}
+
+// ThrottledAliasPublisher publishes aliases within the limits of Throttle.
+type ThrottledAliasPublisher struct {
+	AliasPublisher
+	Throttle *Throttle
+}
+
+func (a *ThrottledAliasPublisher) Publish(path string, permalink template.HTML) error {
+	return a.Throttle.Do(func() error {
+		return a.AliasPublisher.Publish(path, permalink)
+	})
+}
@@ -0,0 +1,100 @@
+package target
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleRetries(t *testing.T) {
+	throttle := &Throttle{Retries: 2, Backoff: time.Millisecond}
+
+	calls := 0
+	err := throttle.Do(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("throttled")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success on the third try, got %v after %d tries", err, calls)
+	}
+
+	calls = 0
+	err = throttle.Do(func() error {
+		calls++
+		return errors.New("down")
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("Expected to give up after 3 tries, got %v after %d tries", err, calls)
+	}
+}
+
+func TestThrottleParallelAndRate(t *testing.T) {
+	throttle := &Throttle{Parallel: 2, Rate: 200}
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			throttle.Do(func() error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 publishes at a time, got %d", maxRunning)
+	}
+	// 10 publishes at 200 per second take at least 45ms
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected the rate to be limited, took %s", elapsed)
+	}
+}
+
+type flakyOutput struct {
+	Filesystem
+	fails    int
+	received []string
+}
+
+func (f *flakyOutput) Publish(path string, r io.Reader) error {
+	b, _ := ioutil.ReadAll(r)
+	f.received = append(f.received, string(b))
+	if f.fails > 0 {
+		f.fails--
+		return errors.New("flaky")
+	}
+	return nil
+}
+
+func TestThrottledOutputRetriesWithContent(t *testing.T) {
+	out := &flakyOutput{fails: 1}
+	o := &ThrottledOutput{Output: out, Throttle: &Throttle{Retries: 1, Backoff: time.Millisecond}}
+
+	if err := o.Publish("a.html", strings.NewReader("content")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(out.received) != 2 || out.received[1] != "content" {
+		t.Errorf("Expected the content to be published again, got %q", out.received)
+	}
+}
@@ -15,6 +15,7 @@ package tpl
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
@@ -24,19 +25,24 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/eknkc/amber"
 	"github.com/spf13/cast"
 	bp "github.com/spf13/hugo/bufferpool"
 	"github.com/spf13/hugo/helpers"
 	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
 	"github.com/yosssi/ace"
 )
 
 var localTemplates *template.Template
+var localOverlays map[string]*template.Template
 var tmpl Template
 var funcMap template.FuncMap
 
@@ -61,6 +67,17 @@ type templateErr struct {
 type GoHTMLTemplate struct {
 	template.Template
 	errors []*templateErr
+
+	// overridden are the internal templates the site's own layouts replace,
+	// which its theme may not replace again
+	overridden map[string]bool
+
+	// baseSources are the sources of the base templates loaded, pending the
+	// sources of the templates waiting for one and overlays the templates
+	// filled in over their base template
+	baseSources map[string]string
+	pending     map[string]string
+	overlays    map[string]*template.Template
 }
 
 // The "Global" Template System
@@ -82,11 +99,16 @@ func InitializeT() Template {
 // With all the additional features, templates & functions
 func New() Template {
 	var templates = &GoHTMLTemplate{
-		Template: *template.New(""),
-		errors:   make([]*templateErr, 0),
+		Template:    *template.New(""),
+		errors:      make([]*templateErr, 0),
+		overridden:  make(map[string]bool),
+		baseSources: make(map[string]string),
+		pending:     make(map[string]string),
+		overlays:    make(map[string]*template.Template),
 	}
 
 	localTemplates = &templates.Template
+	localOverlays = templates.overlays
 
 	templates.Funcs(funcMap)
 	templates.LoadEmbedded()
@@ -399,42 +421,64 @@ func checkCondition(v, mv reflect.Value, op string) (bool, error) {
 		return false, nil
 	}
 
+	v, mv = whereValue(v), whereValue(mv)
+	if v.Kind() == reflect.Float64 && mv.Kind() == reflect.Int64 {
+		mv = reflect.ValueOf(float64(mv.Int()))
+	} else if v.Kind() == reflect.Int64 && mv.Kind() == reflect.Float64 {
+		v = reflect.ValueOf(float64(v.Int()))
+	}
+
 	var ivp, imvp *int64
+	var fvp, fmvp *float64
 	var svp, smvp *string
 	var ima []int64
 	var sma []string
 	if mv.Type() == v.Type() {
 		switch v.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Int64:
 			iv := v.Int()
 			ivp = &iv
 			imv := mv.Int()
 			imvp = &imv
+		case reflect.Float64:
+			fv := v.Float()
+			fvp = &fv
+			fmv := mv.Float()
+			fmvp = &fmv
 		case reflect.String:
 			sv := v.String()
 			svp = &sv
 			smv := mv.String()
 			smvp = &smv
+		case reflect.Bool:
+			if op == "" || op == "=" || op == "==" || op == "eq" {
+				return v.Bool() == mv.Bool(), nil
+			} else if op == "!=" || op == "<>" || op == "ne" {
+				return v.Bool() != mv.Bool(), nil
+			}
 		}
 	} else {
 		if mv.Kind() != reflect.Array && mv.Kind() != reflect.Slice {
 			return false, nil
 		}
-		if mv.Type().Elem() != v.Type() {
-			return false, nil
-		}
 		switch v.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Int64:
 			iv := v.Int()
 			ivp = &iv
 			for i := 0; i < mv.Len(); i++ {
-				ima = append(ima, mv.Index(i).Int())
+				if e, isNil := indirect(mv.Index(i)); !isNil {
+					if e = whereValue(e); e.Kind() == reflect.Int64 {
+						ima = append(ima, e.Int())
+					}
+				}
 			}
 		case reflect.String:
 			sv := v.String()
 			svp = &sv
 			for i := 0; i < mv.Len(); i++ {
-				sma = append(sma, mv.Index(i).String())
+				if e, isNil := indirect(mv.Index(i)); !isNil && e.Kind() == reflect.String {
+					sma = append(sma, e.String())
+				}
 			}
 		}
 	}
@@ -443,36 +487,48 @@ func checkCondition(v, mv reflect.Value, op string) (bool, error) {
 	case "", "=", "==", "eq":
 		if ivp != nil && imvp != nil {
 			return *ivp == *imvp, nil
+		} else if fvp != nil && fmvp != nil {
+			return *fvp == *fmvp, nil
 		} else if svp != nil && smvp != nil {
 			return *svp == *smvp, nil
 		}
 	case "!=", "<>", "ne":
 		if ivp != nil && imvp != nil {
 			return *ivp != *imvp, nil
+		} else if fvp != nil && fmvp != nil {
+			return *fvp != *fmvp, nil
 		} else if svp != nil && smvp != nil {
 			return *svp != *smvp, nil
 		}
 	case ">=", "ge":
 		if ivp != nil && imvp != nil {
 			return *ivp >= *imvp, nil
+		} else if fvp != nil && fmvp != nil {
+			return *fvp >= *fmvp, nil
 		} else if svp != nil && smvp != nil {
 			return *svp >= *smvp, nil
 		}
 	case ">", "gt":
 		if ivp != nil && imvp != nil {
 			return *ivp > *imvp, nil
+		} else if fvp != nil && fmvp != nil {
+			return *fvp > *fmvp, nil
 		} else if svp != nil && smvp != nil {
 			return *svp > *smvp, nil
 		}
 	case "<=", "le":
 		if ivp != nil && imvp != nil {
 			return *ivp <= *imvp, nil
+		} else if fvp != nil && fmvp != nil {
+			return *fvp <= *fmvp, nil
 		} else if svp != nil && smvp != nil {
 			return *svp <= *smvp, nil
 		}
 	case "<", "lt":
 		if ivp != nil && imvp != nil {
 			return *ivp < *imvp, nil
+		} else if fvp != nil && fmvp != nil {
+			return *fvp < *fmvp, nil
 		} else if svp != nil && smvp != nil {
 			return *svp < *smvp, nil
 		}
@@ -500,6 +556,27 @@ func checkCondition(v, mv reflect.Value, op string) (bool, error) {
 	return false, nil
 }
 
+// whereValue normalizes a value compared by where: integers of any size
+// to int64, floats to float64 and times to their Unix nanoseconds, so that
+// front matter values compare with the literals of templates.
+func whereValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.ValueOf(int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return reflect.ValueOf(v.Float())
+	case reflect.Struct:
+		if v.CanInterface() {
+			if t, ok := v.Interface().(time.Time); ok {
+				return reflect.ValueOf(t.UnixNano())
+			}
+		}
+	}
+	return v
+}
+
 func Where(seq, key interface{}, args ...interface{}) (r interface{}, err error) {
 	seqv := reflect.ValueOf(seq)
 	kv := reflect.ValueOf(key)
@@ -838,7 +915,9 @@ func ReturnWhenSet(a, k interface{}) interface{} {
 	return ""
 }
 
-func Highlight(in interface{}, lang string) template.HTML {
+// Highlight highlights the code in the given language. Options such as
+// "linenos=inline,hl_lines=2 3" may be passed as further arguments.
+func Highlight(in interface{}, lang string, opts ...string) template.HTML {
 	var str string
 	av := reflect.ValueOf(in)
 	switch av.Kind() {
@@ -846,7 +925,16 @@ func Highlight(in interface{}, lang string) template.HTML {
 		str = av.String()
 	}
 
-	return template.HTML(helpers.Highlight(html.UnescapeString(str), lang))
+	return template.HTML(helpers.Highlight(html.UnescapeString(str), lang, strings.Join(opts, ",")))
+}
+
+// Icon shows the icon name of the icon sprite, with the CSS classes
+// "icon icon-name" and any further classes given.
+func Icon(name string, classes ...string) template.HTML {
+	sprite := helpers.MakePermalink(viper.GetString("BaseURL"), "/"+viper.GetString("IconSprite")).Path
+	class := strings.Join(append([]string{"icon", "icon-" + name}, classes...), " ")
+	return template.HTML(fmt.Sprintf(`<svg class="%s" aria-hidden="true"><use xlink:href="%s#%s"></use></svg>`,
+		html.EscapeString(class), html.EscapeString(sprite), html.EscapeString(name)))
 }
 
 func Markdownify(text string) template.HTML {
@@ -888,6 +976,25 @@ func RelRef(page interface{}, ref string) template.HTML {
 	return refPage(page, ref, "RelRef")
 }
 
+type crossReferencer interface {
+	CrossRef(site, ref string) (string, error)
+}
+
+// CrossRef returns the permalink of the page ref in another site of a
+// multi-site build, e.g. {{ crossref . "fr" "post/first.md" }}. Unlike
+// ref, a link that can't be resolved is an error.
+func CrossRef(page interface{}, site, ref string) (template.HTML, error) {
+	p, ok := page.(crossReferencer)
+	if !ok {
+		return "", fmt.Errorf("crossref needs a page, got %T", page)
+	}
+	link, err := p.CrossRef(site, ref)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(link), nil
+}
+
 func Chomp(text interface{}) (string, error) {
 	s, err := cast.ToStringE(text)
 	if err != nil {
@@ -923,21 +1030,107 @@ func Replace(a, b, c interface{}) (string, error) {
 	return strings.Replace(aStr, bStr, cStr, -1), nil
 }
 
+// dateLayouts are the layouts that may be given to dateFormat by name, for
+// the standard formats of feeds and sitemaps.
+var dateLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"ISO8601":     "2006-01-02T15:04:05-07:00",
+	"Kitchen":     time.Kitchen,
+}
+
 // DateFormat converts the textual representation of the datetime string into
 // the other form or returns it of the time.Time value. These are formatted
-// with the layout string
-func DateFormat(layout string, v interface{}) (string, error) {
+// with the layout string, or the layout named such as "RFC3339", with month
+// and day names in the optional language.
+func DateFormat(layout string, v interface{}, lang ...string) (string, error) {
 	t, err := cast.ToTimeE(v)
 	if err != nil {
 		return "", err
 	}
+	if l, ok := dateLayouts[layout]; ok {
+		layout = l
+	}
+	if len(lang) > 0 {
+		return helpers.FormatDate(t, layout, lang[0]), nil
+	}
 	return t.Format(layout), nil
 }
 
+// TimeAgo returns the datetime relative to the time of the build in words,
+// e.g. "3 days ago", in the optional language.
+func TimeAgo(v interface{}, lang ...string) (string, error) {
+	t, err := cast.ToTimeE(v)
+	if err != nil {
+		return "", err
+	}
+	var l string
+	if len(lang) > 0 {
+		l = lang[0]
+	}
+	return helpers.RelativeTime(t, time.Now(), l), nil
+}
+
 func SafeHTML(text string) template.HTML {
 	return template.HTML(text)
 }
 
+// HTMLEscape escapes the special characters of HTML in s, e.g. < becomes &lt;.
+func HTMLEscape(in interface{}) (string, error) {
+	s, err := cast.ToStringE(in)
+	if err != nil {
+		return "", err
+	}
+	return html.EscapeString(s), nil
+}
+
+// HTMLUnescape turns entities such as &lt; and &#34; back into characters.
+func HTMLUnescape(in interface{}) (string, error) {
+	s, err := cast.ToStringE(in)
+	if err != nil {
+		return "", err
+	}
+	return html.UnescapeString(s), nil
+}
+
+// Plainify strips all HTML tags from s, keeping its entities as they are
+// rather than escaping them again.
+func Plainify(in interface{}) (template.HTML, error) {
+	s, err := cast.ToStringE(in)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(helpers.StripHTML(s)), nil
+}
+
+// Jsonify encodes v as JSON, e.g. for the templates of the JSON output
+// format, where the template's own escaping would get in the way.
+func Jsonify(v interface{}) (template.HTML, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(b), nil
+}
+
+// StripTags strips the HTML tags from s except the allowed ones, which are
+// kept without their attributes, e.g. {{ stripTags .Content "em" "strong" }}.
+func StripTags(in interface{}, allowed ...string) (template.HTML, error) {
+	s, err := cast.ToStringE(in)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(helpers.StripHTMLTags(s, allowed...)), nil
+}
+
 // "safeHtmlAttr" is currently disabled, pending further discussion
 // on its use case.  2015-01-19
 func SafeHTMLAttr(text string) template.HTMLAttr {
@@ -1105,10 +1298,57 @@ func ModBool(a, b interface{}) (bool, error) {
 	return res == int64(0), nil
 }
 
-func Partial(name string, context_list ...interface{}) template.HTML {
-	if strings.HasPrefix("partials/", name) {
-		name = name[8:]
+var (
+	partialReturnsMu  sync.Mutex
+	partialReturns    = make(map[uint64]interface{})
+	lastPartialReturn uint64
+)
+
+// partialReturn is the error return stops a partial with, as template
+// functions have no other way to end the execution. Older Go versions keep
+// only the message of such errors, so the value is held in partialReturns
+// under the id the message carries, for Partial to take it back.
+type partialReturn struct {
+	id uint64
+}
+
+func (r partialReturn) Error() string {
+	return fmt.Sprintf("return can only be used in partials (return #%d)", r.id)
+}
+
+var partialReturnRe = regexp.MustCompile(`\(return #(\d+)\)`)
+
+// Return stops the execution of a partial, making partial return the given
+// value instead of the rendered text.
+func Return(value interface{}) (interface{}, error) {
+	partialReturnsMu.Lock()
+	defer partialReturnsMu.Unlock()
+	lastPartialReturn++
+	partialReturns[lastPartialReturn] = value
+	return nil, partialReturn{lastPartialReturn}
+}
+
+// takePartialReturn returns the value given to return if that is what
+// stopped the execution with err.
+func takePartialReturn(err error) (interface{}, bool) {
+	m := partialReturnRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return nil, false
 	}
+	id, _ := strconv.ParseUint(m[1], 10, 64)
+
+	partialReturnsMu.Lock()
+	defer partialReturnsMu.Unlock()
+	value, ok := partialReturns[id]
+	delete(partialReturns, id)
+	return value, ok
+}
+
+// Partial renders the partial template of the given name, looked up in the
+// layouts/partials of the site and then of the theme, with the optional
+// context.
+func Partial(name string, context_list ...interface{}) interface{} {
+	name = strings.TrimPrefix(name, "partials/")
 	var context interface{}
 
 	if len(context_list) == 0 {
@@ -1116,11 +1356,26 @@ func Partial(name string, context_list ...interface{}) template.HTML {
 	} else {
 		context = context_list[0]
 	}
-	return ExecuteTemplateToHTML(context, "partials/"+name, "theme/partials/"+name)
+
+	b := bp.GetBuffer()
+	defer bp.PutBuffer(b)
+	if err := executeTemplate(context, b, "partials/"+name, "theme/partials/"+name); err != nil {
+		if value, ok := takePartialReturn(err); ok {
+			return value
+		}
+		jww.ERROR.Println(err, "in partial", name)
+	}
+	return template.HTML(b.String())
 }
 
 func ExecuteTemplate(context interface{}, buffer *bytes.Buffer, layouts ...string) {
-	worked := false
+	if err := executeTemplate(context, buffer, layouts...); err != nil {
+		jww.ERROR.Println(err, "in", layouts)
+	}
+}
+
+// executeTemplate executes the first of the layouts found.
+func executeTemplate(context interface{}, buffer *bytes.Buffer, layouts ...string) error {
 	for _, layout := range layouts {
 
 		name := layout
@@ -1129,19 +1384,17 @@ func ExecuteTemplate(context interface{}, buffer *bytes.Buffer, layouts ...strin
 			name = layout + ".html"
 		}
 
+		if overlay, ok := localOverlays[name]; ok {
+			return overlay.Execute(buffer, context)
+		}
 		if localTemplates.Lookup(name) != nil {
-			err := localTemplates.ExecuteTemplate(buffer, name, context)
-			if err != nil {
-				jww.ERROR.Println(err, "in", name)
-			}
-			worked = true
-			break
+			return localTemplates.ExecuteTemplate(buffer, name, context)
 		}
 	}
-	if !worked {
-		jww.ERROR.Println("Unable to render", layouts)
-		jww.ERROR.Println("Expecting to find a template in either the theme/layouts or /layouts in one of the following relative locations", layouts)
-	}
+
+	jww.ERROR.Println("Unable to render", layouts)
+	jww.ERROR.Println("Expecting to find a template in either the theme/layouts or /layouts in one of the following relative locations", layouts)
+	return nil
 }
 
 func ExecuteTemplateToHTML(context interface{}, layouts ...string) template.HTML {
@@ -1264,17 +1517,41 @@ func (t *GoHTMLTemplate) loadTemplates(absPath string, prefix string) {
 
 			tplName := t.GenerateTemplateNameFrom(absPath, path)
 
+			// A layouts/_internal/opengraph.html or the like replaces the
+			// embedded template, the site's own over its theme's.
+			if strings.HasPrefix(tplName, "_internal/") {
+				if prefix == "" {
+					t.overridden[tplName] = true
+				} else if !t.overridden[tplName] {
+					t.overridden[tplName] = true
+					t.AddTemplateFile(tplName, path)
+				}
+			}
+
 			if prefix != "" {
 				tplName = strings.Trim(prefix, "/") + "/" + tplName
 			}
 
 			t.AddTemplateFile(tplName, path)
 
+			// Base templates and the templates filling in their blocks
+			// are compiled together once all are loaded.
+			if ext := filepath.Ext(path); ext != ".amber" && ext != ".ace" {
+				if b, err := ioutil.ReadFile(path); err == nil {
+					if isBaseTemplate(tplName) {
+						t.baseSources[tplName] = string(b)
+					} else if usesBaseTemplate(tplName, string(b)) {
+						t.pending[tplName] = string(b)
+					}
+				}
+			}
+
 		}
 		return nil
 	}
 
 	filepath.Walk(absPath, walker)
+	t.applyBaseTemplates(prefix != "")
 }
 
 func (t *GoHTMLTemplate) LoadTemplatesWithPrefix(absPath string, prefix string) {
@@ -1293,54 +1570,67 @@ func (t *GoHTMLTemplate) PrintErrors() {
 
 func init() {
 	funcMap = template.FuncMap{
-		"urlize":      helpers.URLize,
-		"sanitizeURL": helpers.SanitizeURL,
-		"sanitizeurl": helpers.SanitizeURL,
-		"eq":          Eq,
-		"ne":          Ne,
-		"gt":          Gt,
-		"ge":          Ge,
-		"lt":          Lt,
-		"le":          Le,
-		"in":          In,
-		"intersect":   Intersect,
-		"isSet":       IsSet,
-		"isset":       IsSet,
-		"echoParam":   ReturnWhenSet,
-		"safeHTML":    SafeHTML,
-		"safeHtml":    SafeHTML,
-		"safeCSS":     SafeCSS,
-		"safeCss":     SafeCSS,
-		"safeURL":     SafeURL,
-		"safeUrl":     SafeURL,
-		"markdownify": Markdownify,
-		"first":       First,
-		"where":       Where,
-		"delimit":     Delimit,
-		"sort":        Sort,
-		"highlight":   Highlight,
-		"add":         func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '+') },
-		"sub":         func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '-') },
-		"div":         func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '/') },
-		"mod":         Mod,
-		"mul":         func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '*') },
-		"modBool":     ModBool,
-		"lower":       func(a string) string { return strings.ToLower(a) },
-		"upper":       func(a string) string { return strings.ToUpper(a) },
-		"title":       func(a string) string { return strings.Title(a) },
-		"partial":     Partial,
-		"ref":         Ref,
-		"relref":      RelRef,
-		"apply":       Apply,
-		"chomp":       Chomp,
-		"replace":     Replace,
-		"trim":        Trim,
-		"dateFormat":  DateFormat,
-		"getJSON":     GetJSON,
-		"getJson":     GetJSON,
-		"getCSV":      GetCSV,
-		"getCsv":      GetCSV,
-		"seq":         helpers.Seq,
+		"urlize":       helpers.URLize,
+		"sanitizeURL":  helpers.SanitizeURL,
+		"sanitizeurl":  helpers.SanitizeURL,
+		"eq":           Eq,
+		"ne":           Ne,
+		"gt":           Gt,
+		"ge":           Ge,
+		"lt":           Lt,
+		"le":           Le,
+		"in":           In,
+		"intersect":    Intersect,
+		"isSet":        IsSet,
+		"isset":        IsSet,
+		"echoParam":    ReturnWhenSet,
+		"safeHTML":     SafeHTML,
+		"safeHtml":     SafeHTML,
+		"safeCSS":      SafeCSS,
+		"safeCss":      SafeCSS,
+		"safeURL":      SafeURL,
+		"safeUrl":      SafeURL,
+		"markdownify":  Markdownify,
+		"htmlEscape":   HTMLEscape,
+		"htmlUnescape": HTMLUnescape,
+		"plainify":     Plainify,
+		"jsonify":      Jsonify,
+		"stripTags":    StripTags,
+		"first":        First,
+		"where":        Where,
+		"delimit":      Delimit,
+		"sort":         Sort,
+		"highlight":    Highlight,
+		"icon":         Icon,
+		"fingerprint":  Fingerprint,
+		"add":          func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '+') },
+		"sub":          func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '-') },
+		"div":          func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '/') },
+		"mod":          Mod,
+		"mul":          func(a, b interface{}) (interface{}, error) { return doArithmetic(a, b, '*') },
+		"modBool":      ModBool,
+		"lower":        func(a string) string { return strings.ToLower(a) },
+		"upper":        func(a string) string { return strings.ToUpper(a) },
+		"title":        func(a string) string { return strings.Title(a) },
+		"partial":      Partial,
+		"return":       Return,
+		"ref":          Ref,
+		"relref":       RelRef,
+		"crossref":     CrossRef,
+		"apply":        Apply,
+		"chomp":        Chomp,
+		"replace":      Replace,
+		"trim":         Trim,
+		"dateFormat":   DateFormat,
+		"timeAgo":      TimeAgo,
+		"getJSON":      GetJSON,
+		"getJson":      GetJSON,
+		"getCSV":       GetCSV,
+		"getCsv":       GetCSV,
+		"dataTable":    DataTable,
+		"seq":          helpers.Seq,
+		"i18n":         I18n,
+		"T":            I18n,
 	}
 
 }
@@ -0,0 +1,116 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// An assetTransform makes the published form of the asset name, and the
+// name it is published under, relative to the publish directory.
+type assetTransform func(name string, content []byte) (string, []byte, error)
+
+type publishedAsset struct {
+	modTime time.Time
+	size    int64
+	target  string
+}
+
+// assetCache remembers the assets published by every transformation, so an
+// asset used by many pages is processed and written once, and a rebuild
+// reuses it while its source is unchanged.
+type assetCache struct {
+	sync.Mutex
+	m map[string]publishedAsset
+}
+
+var publishedAssets = &assetCache{m: make(map[string]publishedAsset)}
+
+// publish publishes the asset name in the AssetDir, transformed by the
+// transformation of that kind, and returns its path relative to the publish
+// directory, with forward slashes.
+func (c *assetCache) publish(kind, name string, transform assetTransform) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	src := filepath.Join(helpers.AbsPathify(viper.GetString("AssetDir")), filepath.FromSlash(name))
+	fi, err := hugofs.SourceFs.Stat(src)
+	if err != nil {
+		return "", fmt.Errorf("Unable to find the asset %s: %s", name, err)
+	}
+
+	key := kind + ":" + name
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir"))
+	if a, ok := c.m[key]; ok && a.modTime.Equal(fi.ModTime()) && a.size == fi.Size() {
+		if exists, _ := helpers.Exists(filepath.Join(publishDir, filepath.FromSlash(a.target)), hugofs.DestinationFS); exists {
+			return a.target, nil
+		}
+	}
+
+	f, err := hugofs.SourceFs.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read the asset %s: %s", name, err)
+	}
+	content, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("Unable to read the asset %s: %s", name, err)
+	}
+
+	target, out, err := transform(name, content)
+	if err != nil {
+		return "", fmt.Errorf("Unable to %s the asset %s: %s", kind, name, err)
+	}
+
+	// the target names the content, so a file already there, e.g. from an
+	// earlier build, is the same and is not written again
+	dest := filepath.Join(publishDir, filepath.FromSlash(target))
+	if exists, _ := helpers.Exists(dest, hugofs.DestinationFS); !exists {
+		jww.DEBUG.Println("publishing asset:", dest)
+		if err := helpers.WriteToDisk(dest, bytes.NewReader(out), hugofs.DestinationFS); err != nil {
+			return "", fmt.Errorf("Unable to publish the asset %s: %s", name, err)
+		}
+	}
+
+	c.m[key] = publishedAsset{modTime: fi.ModTime(), size: fi.Size(), target: target}
+	return target, nil
+}
+
+func fingerprintAsset(name string, content []byte) (string, []byte, error) {
+	ext := path.Ext(name)
+	return name[:len(name)-len(ext)] + "." + helpers.Md5String(string(content)) + ext, content, nil
+}
+
+// Fingerprint publishes the file name in the AssetDir with the MD5 hash
+// of its content in its name, e.g. css/main.<hash>.css, and returns its URL.
+// The file is published once for all the pages using it.
+func Fingerprint(name string) (string, error) {
+	target, err := publishedAssets.publish("fingerprint", strings.TrimPrefix(path.Clean("/"+name), "/"), fingerprintAsset)
+	if err != nil {
+		return "", err
+	}
+	return helpers.MakePermalink(viper.GetString("BaseURL"), "/"+target).Path, nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/spf13/viper"
+)
+
+func TestFingerprint(t *testing.T) {
+	defer func(source, destination afero.Fs, assets *assetCache) {
+		hugofs.SourceFs, hugofs.DestinationFS, publishedAssets = source, destination, assets
+	}(hugofs.SourceFs, hugofs.DestinationFS, publishedAssets)
+	defer viper.Set("AssetDir", viper.Get("AssetDir"))
+	defer viper.Set("PublishDir", viper.Get("PublishDir"))
+
+	hugofs.SourceFs = new(afero.MemMapFs)
+	hugofs.DestinationFS = new(afero.MemMapFs)
+	publishedAssets = &assetCache{m: make(map[string]publishedAsset)}
+	viper.Set("BaseURL", "http://auth/bub/")
+	viper.Set("AssetDir", "assets")
+	viper.Set("PublishDir", "public")
+	defer viper.Set("BaseURL", "")
+
+	src := filepath.FromSlash("assets/css/main.css")
+	helpers.WriteToDisk(src, bytes.NewReader([]byte("body {}")), hugofs.SourceFs)
+
+	hash := helpers.Md5String("body {}")
+	for _, name := range []string{"css/main.css", "/css/main.css", "css/../css/main.css"} {
+		url, err := Fingerprint(name)
+		if err != nil {
+			t.Fatalf("Fingerprint(%q) failed: %s", name, err)
+		}
+		if expected := "/bub/css/main." + hash + ".css"; url != expected {
+			t.Errorf("Fingerprint(%q): got %s, expected %s", name, url, expected)
+		}
+	}
+
+	// an unchanged asset is not published again
+	dest := filepath.FromSlash("public/css/main." + hash + ".css")
+	helpers.WriteToDisk(dest, bytes.NewReader([]byte("published")), hugofs.DestinationFS)
+	if _, err := Fingerprint("css/main.css"); err != nil {
+		t.Fatalf("Fingerprint failed: %s", err)
+	}
+	if content := readDestFile(t, dest); content != "published" {
+		t.Errorf("Unchanged asset published again: %q", content)
+	}
+
+	// a changed one is published under its new hash
+	helpers.WriteToDisk(src, bytes.NewReader([]byte("body { color: red }")), hugofs.SourceFs)
+	hash = helpers.Md5String("body { color: red }")
+	url, err := Fingerprint("css/main.css")
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %s", err)
+	}
+	if expected := "/bub/css/main." + hash + ".css"; url != expected {
+		t.Errorf("Fingerprint of the changed asset: got %s, expected %s", url, expected)
+	}
+	if content := readDestFile(t, filepath.FromSlash("public/css/main."+hash+".css")); content != "body { color: red }" {
+		t.Errorf("Changed asset published as %q", content)
+	}
+
+	if _, err := Fingerprint("css/missing.css"); err == nil {
+		t.Error("Fingerprint of a missing asset should fail")
+	}
+}
+
+func readDestFile(t *testing.T, name string) string {
+	f, err := hugofs.DestinationFS.Open(name)
+	if err != nil {
+		t.Fatalf("Unable to open %s: %s", name, err)
+	}
+	defer f.Close()
+	return string(helpers.ReaderToBytes(f))
+}
@@ -0,0 +1,134 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"html/template"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// baseTemplateName is the name of the base templates, such as
+// _default/baseof.html, whose blocks the other templates fill in.
+const baseTemplateName = "baseof"
+
+// defineRe matches a template starting with a define, after whitespace and
+// comments.
+var defineRe = regexp.MustCompile(`^(?s:\s*{{-?\s*/\*.*?\*/\s*-?}})*\s*{{-?\s*define\s`)
+
+// isBaseTemplate reports whether the template of the given name is a base
+// template.
+func isBaseTemplate(name string) bool {
+	return strings.Contains(path.Base(name), baseTemplateName)
+}
+
+// usesBaseTemplate reports whether the template of the given name and
+// source starts with a define, and so only fills in the blocks of a base
+// template. A complete layout may define helper templates further down.
+func usesBaseTemplate(name, src string) bool {
+	name = strings.TrimPrefix(name, "theme/")
+	for _, dir := range []string{"partials/", "shortcodes/", "_internal/"} {
+		if strings.HasPrefix(name, dir) {
+			return false
+		}
+	}
+	return !isBaseTemplate(name) && defineRe.MatchString(src)
+}
+
+// baseTemplateCandidates returns the base templates looked up for the
+// template of the given name, e.g. for post/single.html
+// post/single-baseof.html, post/baseof.html, _default/single-baseof.html
+// and _default/baseof.html, of the site and then of its theme.
+func baseTemplateCandidates(name string) []string {
+	dir, file := path.Split(strings.TrimPrefix(name, "theme/"))
+	ext := path.Ext(file)
+	bases := []string{strings.TrimSuffix(file, ext) + "-" + baseTemplateName + ext, baseTemplateName + ext}
+
+	var candidates []string
+	for _, prefix := range []string{"", "theme/"} {
+		for _, d := range []string{dir, "_default/"} {
+			for _, b := range bases {
+				candidates = append(candidates, prefix+d+b)
+			}
+			if d == "_default/" {
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+// applyBaseTemplates compiles the templates waiting for a base template
+// found since, each in its own copy of the template set so their blocks
+// don't clash. The last layouts directory loaded is final: templates still
+// without a base template are then left as they are.
+func (t *GoHTMLTemplate) applyBaseTemplates(final bool) {
+	for name, src := range t.pending {
+		var baseName string
+		for _, candidate := range baseTemplateCandidates(name) {
+			if _, ok := t.baseSources[candidate]; ok {
+				baseName = candidate
+				break
+			}
+		}
+		if baseName == "" {
+			if final {
+				delete(t.pending, name)
+			}
+			continue
+		}
+		delete(t.pending, name)
+
+		overlay, err := t.compileOverlay(name, t.baseSources[baseName], src)
+		if err != nil {
+			t.errors = append(t.errors, &templateErr{name: name, err: err})
+			continue
+		}
+		t.overlays[name] = overlay
+	}
+}
+
+// compileOverlay parses the base template, then the blocks the template of
+// the given name defines over it, in a clone of the template set.
+func (t *GoHTMLTemplate) compileOverlay(name, base, src string) (*template.Template, error) {
+	clone, err := t.Template.Clone()
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := clone.New(name).Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	return overlay.Parse(src)
+}
+
+// Lookup returns the template of the given name, filled in over its base
+// template if it has one.
+func (t *GoHTMLTemplate) Lookup(name string) *template.Template {
+	if overlay, ok := t.overlays[name]; ok {
+		return overlay
+	}
+	return t.Template.Lookup(name)
+}
+
+// ExecuteTemplate executes the template of the given name, filled in over
+// its base template if it has one.
+func (t *GoHTMLTemplate) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
+	if overlay, ok := t.overlays[name]; ok {
+		return overlay.Execute(wr, data)
+	}
+	return t.Template.ExecuteTemplate(wr, name, data)
+}
@@ -21,8 +21,21 @@ type Tmpl struct {
 func (t *GoHTMLTemplate) EmbedShortcodes() {
 	t.AddInternalShortcode("ref.html", `{{ .Get 0 | ref .Page }}`)
 	t.AddInternalShortcode("relref.html", `{{ .Get 0 | relref .Page }}`)
-	t.AddInternalShortcode("highlight.html", `{{ .Get 0 | highlight .Inner  }}`)
+	t.AddInternalShortcode("crossref.html", `{{ crossref .Page (.Get 0) (.Get 1) }}`)
+	t.AddInternalShortcode("highlight.html", `{{ if len .Params | lt 1 }}{{ highlight .Inner (.Get 0) (.Get 1) }}{{ else }}{{ .Get 0 | highlight .Inner }}{{ end }}`)
 	t.AddInternalShortcode("test.html", `This is a simple Test`)
+	t.AddInternalShortcode("include.html", `{{ .Include }}`)
+	t.AddInternalShortcode("gist.html", `<script src="//gist.github.com/{{ index .Params 0 }}/{{ index .Params 1 }}.js{{if len .Params | eq 3 }}?file={{ index .Params 2 }}{{end}}"></script>
+<noscript><a href="https://gist.github.com/{{ index .Params 0 }}/{{ index .Params 1 }}{{if len .Params | eq 3 }}#file-{{ replace (index .Params 2 | lower) "." "-" }}{{end}}">View the gist on GitHub</a></noscript>`)
+	t.AddInternalShortcode("table.html", `{{ with dataTable .Page.Site.Data .Params }}<table{{ with $.Get "class" }} class="{{.}}"{{ end }}>{{ with $.Get "caption" }}
+  <caption>{{.}}</caption>{{ end }}{{ if .Header }}
+  <thead>
+    <tr>{{ range .Header }}<th>{{.}}</th>{{ end }}</tr>
+  </thead>{{ end }}
+  <tbody>{{ range .Rows }}
+    <tr>{{ range . }}<td>{{.}}</td>{{ end }}</tr>{{ end }}
+  </tbody>
+</table>{{ end }}`)
 	t.AddInternalShortcode("figure.html", `<!-- image -->
 <figure {{ with .Get "class" }}class="{{.}}"{{ end }}>
     {{ with .Get "link"}}<a href="{{.}}">{{ end }}
@@ -47,7 +60,7 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
 
 	t.AddInternalTemplate("_default", "rss.xml", `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
   <channel>
-    <title>{{ with .Title }}{{.}} on {{ end }}{{ .Site.Title }}</title>
+    <title>{{ with .Title }}{{.}} on {{ $.Site.Title }}{{ else }}{{ .Site.RSSTitle }}{{ end }}</title>
     <link>{{ .Permalink }}</link>
     <description>Recent content {{ with .Title }}in {{.}} {{ end }}on {{ .Site.Title }}</description>
     <generator>Hugo -- gohugo.io</generator>{{ with .Site.LanguageCode }}
@@ -55,32 +68,73 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
     <managingEditor>{{.}}{{ with $.Site.Author.name }} ({{.}}){{end}}</managingEditor>{{end}}{{ with .Site.Author.email }}
     <webMaster>{{.}}{{ with $.Site.Author.name }} ({{.}}){{end}}</webMaster>{{end}}{{ with .Site.Copyright }}
     <copyright>{{.}}</copyright>{{end}}{{ if not .Date.IsZero }}
-    <lastBuildDate>{{ .Date.Format "Mon, 02 Jan 2006 15:04:05 -0700" | safeHtml }}</lastBuildDate>{{ end }}
+    <lastBuildDate>{{ dateFormat "RFC1123Z" .Date | safeHtml }}</lastBuildDate>{{ end }}
     <atom:link href="{{.Url}}" rel="self" type="application/rss+xml" />
-    {{ range first 15 .Data.Pages }}
+    {{ range first .Site.RSSLimit .Data.Pages }}
     <item>
       <title>{{ .Title }}</title>
       <link>{{ .Permalink }}</link>
-      <pubDate>{{ .Date.Format "Mon, 02 Jan 2006 15:04:05 -0700" | safeHtml }}</pubDate>
+      <pubDate>{{ dateFormat "RFC1123Z" .Date | safeHtml }}</pubDate>
       {{ with .Site.Author.email }}<author>{{.}}{{ with $.Site.Author.name }} ({{.}}){{end}}</author>{{end}}
       <guid>{{ .Permalink }}</guid>
-      <description>{{ .Content | html }}</description>
+      <description>{{ if eq .Site.RSSDescription "summary" }}{{ .Summary | html }}{{ else }}{{ .Content | html }}{{ end }}</description>
     </item>
     {{ end }}
   </channel>
 </rss>`)
 
+	t.AddInternalTemplate("_default", "atom.xml", `<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>{{ with .Title }}{{.}} on {{ $.Site.Title }}{{ else }}{{ .Site.RSSTitle }}{{ end }}</title>
+  <subtitle>Recent content {{ with .Title }}in {{.}} {{ end }}on {{ .Site.Title }}</subtitle>
+  <link href="{{ .Permalink }}"/>
+  <link href="{{ .Url }}" rel="self" type="application/atom+xml"/>
+  <id>{{ .Permalink }}</id>
+  <generator uri="https://gohugo.io/">Hugo</generator>
+  <updated>{{ dateFormat "RFC3339" .Date | safeHtml }}</updated>{{ with .Site.Author.name }}
+  <author>
+    <name>{{.}}</name>{{ with $.Site.Author.email }}
+    <email>{{.}}</email>{{end}}
+  </author>{{end}}{{ with .Site.Copyright }}
+  <rights>{{.}}</rights>{{end}}
+  {{ range first .Site.RSSLimit .Data.Pages }}
+  <entry>
+    <title>{{ .Title }}</title>
+    <link href="{{ .Permalink }}"/>
+    <id>{{ .Permalink }}</id>
+    <published>{{ dateFormat "RFC3339" .Date | safeHtml }}</published>
+    <updated>{{ dateFormat "RFC3339" .Date | safeHtml }}</updated>
+    {{ if eq .Site.RSSDescription "summary" }}<summary type="html">{{ .Summary | html }}</summary>{{ else }}<content type="html">{{ .Content | html }}</content>{{ end }}
+  </entry>
+  {{ end }}
+</feed>`)
+
 	t.AddInternalTemplate("_default", "sitemap.xml", `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
   {{ range .Data.Pages }}
   <url>
     <loc>{{ .Permalink }}</loc>{{ if not .Date.IsZero }}
-    <lastmod>{{ safeHtml ( .Date.Format "2006-01-02T15:04:05-07:00" ) }}</lastmod>{{ end }}{{ with .Sitemap.ChangeFreq }}
+    <lastmod>{{ dateFormat "ISO8601" .Date | safeHtml }}</lastmod>{{ end }}{{ with .Sitemap.ChangeFreq }}
     <changefreq>{{ . }}</changefreq>{{ end }}{{ if ge .Sitemap.Priority 0.0 }}
     <priority>{{ .Sitemap.Priority }}</priority>{{ end }}
   </url>
   {{ end }}
 </urlset>`)
 
+	t.AddInternalTemplate("_default", "sitemapindex.xml", `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  {{ range .Data.Sitemaps }}
+  <sitemap>
+    <loc>{{ .Permalink }}</loc>{{ if not .LastMod.IsZero }}
+    <lastmod>{{ dateFormat "ISO8601" .LastMod | safeHtml }}</lastmod>{{ end }}
+  </sitemap>
+  {{ end }}
+</sitemapindex>`)
+
+	t.AddInternalTemplate("_default", "robots.txt", "User-agent: *")
+
+	t.AddInternalTemplate("", "menu.html", `<ul>{{ range . }}
+<li class="level-{{ .Level }}{{ if .Active }} active{{ end }}{{ if .InTrail }} active-trail{{ end }}">{{ .Pre }}<a href="{{ .Url }}">{{ .Name }}</a>{{ .Post }}{{ if .Children }}
+{{ template "_internal/menu.html" .Children }}{{ end }}</li>{{ end }}
+</ul>`)
+
 	t.AddInternalTemplate("", "pagination.html", `{{ $pag := $.Paginator }}
     {{ if gt $pag.TotalPages 1 }}
     <ul class="pagination">
@@ -93,10 +147,17 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
         {{ if not $pag.HasPrev }}class="disabled"{{ end }}>
         <a href="{{ if $pag.HasPrev }}{{ $pag.Prev.Url }}{{ end }}" aria-label="Previous"><span aria-hidden="true">&laquo;</span></a>
         </li>
-        {{ range $pag.Pagers }}
+        {{ $window := $pag.Window 2 }}
+        {{ if gt (index $window 0).PageNumber 1 }}
+        <li class="disabled"><span>&hellip;</span></li>
+        {{ end }}
+        {{ range $window }}
         <li
         {{ if eq . $pag }}class="active"{{ end }}><a href="{{ .Url }}">{{ .PageNumber }}</a></li>
         {{ end }}
+        {{ if lt (index $window (sub (len $window) 1)).PageNumber $pag.TotalPages }}
+        <li class="disabled"><span>&hellip;</span></li>
+        {{ end }}
         <li
         {{ if not $pag.HasNext }}class="disabled"{{ end }}>
         <a href="{{ if $pag.HasNext }}{{ $pag.Next.Url }}{{ end }}" aria-label="Next"><span aria-hidden="true">&raquo;</span></a>
@@ -129,16 +190,20 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
 	t.AddInternalTemplate("", "opengraph.html", `<meta property="og:title" content="{{ .Title }}" />
 <meta property="og:description" content="{{ with .Description }}{{ . }}{{ else }}{{if .IsPage}}{{ .Summary }}{{ else }}{{ with .Site.Params.description }}{{ . }}{{ end }}{{ end }}{{ end }}" />
 <meta property="og:type" content="{{ if .IsPage }}article{{ else }}website{{ end }}" />
-<meta property="og:url" content="{{ .Permalink }}" />
+<meta property="og:url" content="{{ .CanonicalURL }}" />
 {{ with .Params.images }}{{ range first 6 . }}
   <meta property="og:image" content="{{ . }}" />
-{{ end }}{{ end }}
+{{ end }}{{ else }}{{ if .IsPage }}{{ with .SocialCard }}
+  <meta property="og:image" content="{{ . }}" />
+  <meta property="og:image:width" content="1200" />
+  <meta property="og:image:height" content="630" />
+{{ end }}{{ end }}{{ end }}
 
-{{ if not .Date.IsZero }}<meta property="og:updated_time" content="{{ .Date.Format "2006-01-02T15:04:05-07:00" | safeHtml }}"/>{{ end }}{{ with .Params.audio }}
+{{ if not .Date.IsZero }}<meta property="og:updated_time" content="{{ dateFormat "ISO8601" .Date | safeHtml }}"/>{{ end }}{{ with .Params.audio }}
 <meta property="og:audio" content="{{ . }}" />{{ end }}{{ with .Params.locale }}
 <meta property="og:locale" content="{{ . }}" />{{ end }}{{ with .Site.Params.title }}
 <meta property="og:site_name" content="{{ . }}" />{{ end }}{{ with .Params.videos }}
-{{ range .Params.videos }}
+{{ range . }}
   <meta property="og:video" content="{{ . }}" />
 {{ end }}{{ end }}
 
@@ -154,26 +219,33 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
 
 {{ if .IsPage }}
 {{ range .Site.Authors }}{{ with .Social.facebook }}
-<meta property="article:author" content="https://www.facebook.com/{{ . }}" />{{ end }}{{ with .Site.Social.facebook }}
+<meta property="article:author" content="https://www.facebook.com/{{ . }}" />{{ end }}{{ end }}{{ with .Site.Social.facebook }}
 <meta property="article:publisher" content="https://www.facebook.com/{{ . }}" />{{ end }}
-<meta property="article:published_time" content="{{ .PublishDate }}" />
-<meta property="article:modified_time" content="{{ .Date }}" />
+{{ if not .PublishDate.IsZero }}<meta property="article:published_time" content="{{ dateFormat "ISO8601" .PublishDate | safeHtml }}" />{{ end }}
+{{ if not .Date.IsZero }}<meta property="article:modified_time" content="{{ dateFormat "ISO8601" .Date | safeHtml }}" />{{ end }}
 <meta property="article:section" content="{{ .Section }}" />
 {{ with .Params.tags }}{{ range first 6 . }}
   <meta property="article:tag" content="{{ . }}" />{{ end }}{{ end }}
-{{ end }}{{ end }}
+{{ end }}
 
 <!-- Facebook Page Admin ID for Domain Insights -->
 {{ with .Site.Social.facebook_admin }}<meta property="fb:admins" content="{{ . }}" />{{ end }}`)
 
+	t.AddInternalTemplate("", "canonical.html", `<link rel="canonical" href="{{ .CanonicalURL }}" />`)
+
+	t.AddInternalTemplate("", "robots.html", `{{ with .Robots }}<meta name="robots" content="{{ . }}" />{{ end }}`)
+
 	t.AddInternalTemplate("", "twitter_cards.html", `{{ if .IsPage }}
 {{ with .Params.images }}
 <!-- Twitter summary card with large image must be at least 280x150px -->
   <meta name="twitter:card" content="summary_large_image"/>
   <meta name="twitter:image:src" content="{{ index . 0 }}"/>
+{{ else }}{{ with .SocialCard }}
+  <meta name="twitter:card" content="summary_large_image"/>
+  <meta name="twitter:image:src" content="{{ . }}"/>
 {{ else }}
   <meta name="twitter:card" content="summary"/>
-{{ end }}
+{{ end }}{{ end }}
 
 <!-- Twitter Card data -->
 <meta name="twitter:title" content="{{ .Title }}"/>
@@ -181,9 +253,11 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
 {{ with .Site.Social.twitter }}<meta name="twitter:site" content="@{{ . }}"/>{{ end }}
 {{ with .Site.Social.twitter_domain }}<meta name="twitter:domain" content="{{ . }}"/>{{ end }}
 {{ range .Site.Authors }}
-  {{ with .twitter }}<meta name="twitter:creator" content="@{{ . }}"/>{{ end }}
+  {{ with .Social.twitter }}<meta name="twitter:creator" content="@{{ . }}"/>{{ end }}
 {{ end }}{{ end }}`)
 
+	t.AddInternalTemplate("", "jsonld.html", `{{ with .JSONLD }}<script type="application/ld+json">{{ . }}</script>{{ end }}`)
+
 	t.AddInternalTemplate("", "google_news.html", `{{ if .IsPage }}{{ with .Params.news_keywords }}
   <meta name="news_keywords" content="{{ range $i, $kw := first 10 . }}{{ if $i }},{{ end }}{{ $kw }}{{ end }}" />
 {{ end }}{{ end }}`)
@@ -192,9 +266,9 @@ func (t *GoHTMLTemplate) EmbedTemplates() {
 <meta itemprop="name" content="{{ .Title }}">
 <meta itemprop="description" content="{{ with .Description }}{{ . }}{{ else }}{{if .IsPage}}{{ .Summary }}{{ else }}{{ with .Site.Params.description }}{{ . }}{{ end }}{{ end }}{{ end }}">
 
-{{if .IsPage}}{{ $ISO8601 := "2006-01-02T15:04:05-07:00" }}{{ if not .PublishDate.IsZero }}
-<meta itemprop="datePublished" content="{{ .PublishDate.Format $ISO8601 | safeHtml }}" />{{ end }}
-{{ if not .Date.IsZero }}<meta itemprop="dateModified" content="{{ .Date.Format $ISO8601 | safeHtml }}" />{{ end }}
+{{if .IsPage}}{{ if not .PublishDate.IsZero }}
+<meta itemprop="datePublished" content="{{ dateFormat "ISO8601" .PublishDate | safeHtml }}" />{{ end }}
+{{ if not .Date.IsZero }}<meta itemprop="dateModified" content="{{ dateFormat "ISO8601" .Date | safeHtml }}" />{{ end }}
 <meta itemprop="wordCount" content="{{ .WordCount }}">
 {{ with .Params.images }}{{ range first 6 . }}
   <meta itemprop="image" content="{{ . }}">
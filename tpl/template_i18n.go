@@ -0,0 +1,138 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// translations holds the strings of the i18n func, by language and id, and
+// the language the site being rendered is in.
+type translations struct {
+	sync.Mutex
+	lang    string
+	byLang  map[string]map[string]interface{}
+	parsed  map[string]*template.Template
+	missing map[string]bool
+}
+
+var i18nStrings = &translations{
+	parsed:  make(map[string]*template.Template),
+	missing: make(map[string]bool),
+}
+
+// SetTranslations sets the strings the i18n func translates ids to, by
+// language and id, and the language to translate to.
+func SetTranslations(lang string, byLang map[string]map[string]interface{}) {
+	i18nStrings.Lock()
+	defer i18nStrings.Unlock()
+
+	i18nStrings.lang = lang
+	i18nStrings.byLang = byLang
+	i18nStrings.parsed = make(map[string]*template.Template)
+	i18nStrings.missing = make(map[string]bool)
+}
+
+// lookup returns the translation of id, in the language, its base language
+// or else the DefaultContentLanguage.
+func (t *translations) lookup(id string) (interface{}, bool) {
+	langs := []string{t.lang}
+	if i := strings.IndexAny(t.lang, "-_"); i > 0 {
+		langs = append(langs, t.lang[:i])
+	}
+	langs = append(langs, strings.ToLower(viper.GetString("DefaultContentLanguage")))
+
+	for _, lang := range langs {
+		if s, ok := t.byLang[lang][id]; ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// I18n returns the translation of id in the language of the site, from the
+// i18n/<lang>.yaml files:
+//
+//	readMore: "Read more"
+//	wordCount:
+//	  one: "One word"
+//	  other: "{{ .Count }} words"
+//
+// A number as the optional argument picks the plural form of the
+// translation, and is its .Count; any other argument is its data, e.g.
+// {{ i18n "postedBy" . }} for "Posted by {{ .Params.author }}". Missing
+// translations are empty.
+func I18n(id string, args ...interface{}) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("i18n takes an id and at most one argument, got %d", len(args))
+	}
+
+	i18nStrings.Lock()
+	defer i18nStrings.Unlock()
+
+	s, ok := i18nStrings.lookup(id)
+	if !ok {
+		if !i18nStrings.missing[id] {
+			i18nStrings.missing[id] = true
+			jww.WARN.Printf("No %q translation of %q\n", i18nStrings.lang, id)
+		}
+		return "", nil
+	}
+
+	var data interface{}
+	if len(args) == 1 {
+		data = args[0]
+		if n, err := cast.ToIntE(args[0]); err == nil {
+			data = map[string]interface{}{"Count": n}
+			if forms, ok := s.(map[string]interface{}); ok {
+				s = forms[helpers.PluralCategory(i18nStrings.lang, n)]
+				if s == nil {
+					s = forms["other"]
+				}
+			}
+		}
+	}
+	if forms, ok := s.(map[string]interface{}); ok {
+		s = forms["other"]
+	}
+
+	text := cast.ToString(s)
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, ok := i18nStrings.parsed[text]
+	if !ok {
+		var err error
+		if tmpl, err = template.New(id).Parse(text); err != nil {
+			return "", fmt.Errorf("Unable to parse the translation of %q: %s", id, err)
+		}
+		i18nStrings.parsed[text] = tmpl
+	}
+
+	b := new(bytes.Buffer)
+	if err := tmpl.Execute(b, data); err != nil {
+		return "", fmt.Errorf("Unable to translate %q: %s", id, err)
+	}
+	return b.String(), nil
+}
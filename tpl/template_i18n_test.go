@@ -0,0 +1,92 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestI18n(t *testing.T) {
+	viper.Set("DefaultContentLanguage", "en")
+	defer viper.Set("DefaultContentLanguage", nil)
+
+	byLang := map[string]map[string]interface{}{
+		"en": {
+			"readMore": "Read more",
+			"home":     "Home",
+		},
+		"ru": {
+			"readMore":  "Читать далее",
+			"wordCount": map[string]interface{}{"one": "{{ .Count }} слово", "few": "{{ .Count }} слова", "many": "{{ .Count }} слов"},
+			"postedBy":  "Автор: {{ .author }}",
+		},
+		"pt": {
+			"wordCount": map[string]interface{}{"one": "{{ .Count }} palavra", "other": "{{ .Count }} palavras"},
+		},
+	}
+
+	for i, this := range []struct {
+		lang   string
+		id     string
+		args   []interface{}
+		expect string
+	}{
+		{"ru", "readMore", nil, "Читать далее"},
+		{"ru", "home", nil, "Home"},
+		{"ru", "nope", nil, ""},
+		{"ru", "wordCount", []interface{}{21}, "21 слово"},
+		{"ru", "wordCount", []interface{}{"3"}, "3 слова"},
+		{"ru", "wordCount", []interface{}{11}, "11 слов"},
+		{"ru", "postedBy", []interface{}{map[string]interface{}{"author": "Лев"}}, "Автор: Лев"},
+		{"pt-br", "wordCount", []interface{}{0}, "0 palavra"},
+		{"pt-br", "wordCount", []interface{}{2}, "2 palavras"},
+		{"pt-br", "readMore", nil, "Read more"},
+	} {
+		SetTranslations(this.lang, byLang)
+		result, err := I18n(this.id, this.args...)
+		if err != nil {
+			t.Errorf("[%d] I18n failed: %s", i, err)
+			continue
+		}
+		if result != this.expect {
+			t.Errorf("[%d] I18n got %q but expected %q", i, result, this.expect)
+		}
+	}
+
+	if _, err := I18n("readMore", 1, 2); err == nil {
+		t.Errorf("Expected an error for two arguments")
+	}
+}
+
+func TestI18nInTemplate(t *testing.T) {
+	SetTranslations("fr", map[string]map[string]interface{}{
+		"fr": {"comments": map[string]interface{}{"one": "{{ .Count }} commentaire", "other": "{{ .Count }} commentaires"}},
+	})
+	defer SetTranslations("", nil)
+
+	templ := New()
+	if err := templ.AddTemplate("test", `{{ i18n "comments" 1 }}, {{ T "comments" 2 }}`); err != nil {
+		t.Fatalf("Unable to add the template: %s", err)
+	}
+	b := new(bytes.Buffer)
+	if err := templ.ExecuteTemplate(b, "test", nil); err != nil {
+		t.Fatalf("Unable to execute the template: %s", err)
+	}
+	if b.String() != "1 commentaire, 2 commentaires" {
+		t.Errorf("Got %q", b.String())
+	}
+}
@@ -18,11 +18,13 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/hugo/helpers"
@@ -62,8 +64,39 @@ func getCacheFileID(id string) string {
 	return viper.GetString("CacheDir") + url.QueryEscape(id)
 }
 
+// remoteCacheMaxAge is how long a downloaded file is used from the cache,
+// from the RemoteCacheMaxAge config, e.g. "1h"; 0, the default, is forever.
+func remoteCacheMaxAge() time.Duration {
+	maxAge := viper.GetString("RemoteCacheMaxAge")
+	if maxAge == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(maxAge)
+	if err != nil {
+		jww.ERROR.Printf("Invalid remoteCacheMaxAge %q: %s\n", maxAge, err)
+		return 0
+	}
+	return d
+}
+
+// remoteClient is the HTTP client of the downloads, which gives up after
+// the RemoteTimeout config, e.g. "10s"; 0 is no timeout.
+func remoteClient() *http.Client {
+	timeout := viper.GetString("RemoteTimeout")
+	if timeout == "" {
+		return http.DefaultClient
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		jww.ERROR.Printf("Invalid remoteTimeout %q: %s\n", timeout, err)
+		return http.DefaultClient
+	}
+	return &http.Client{Timeout: d}
+}
+
 // resGetCache returns the content for an ID from the file cache or an error
-// if the file is not found returns nil,nil
+// if the file is not found, or older than the remoteCacheMaxAge, returns
+// nil,nil
 func resGetCache(id string, fs afero.Fs, ignoreCache bool) ([]byte, error) {
 	if ignoreCache {
 		return nil, nil
@@ -77,6 +110,17 @@ func resGetCache(id string, fs afero.Fs, ignoreCache bool) ([]byte, error) {
 		return nil, nil
 	}
 
+	if maxAge := remoteCacheMaxAge(); maxAge > 0 {
+		fi, err := fs.Stat(fID)
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(fi.ModTime()) > maxAge {
+			jww.INFO.Printf("Cache of %s expired", id)
+			return nil, nil
+		}
+	}
+
 	f, err := fs.Open(fID)
 	if err != nil {
 		return nil, err
@@ -133,6 +177,10 @@ func resGetRemote(url string, fs afero.Fs, hc *http.Client) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	// an error page is not the resource, so it isn't cached
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("Failed to download %s: %s", url, res.Status)
+	}
 	err = resWriteCache(url, c, fs)
 	if err != nil {
 		return nil, err
@@ -168,7 +216,7 @@ func resGetResource(url string) ([]byte, error) {
 		return nil, nil
 	}
 	if strings.Contains(url, "://") {
-		return resGetRemote(url, hugofs.SourceFs, http.DefaultClient)
+		return resGetRemote(url, hugofs.SourceFs, remoteClient())
 	}
 	return resGetLocal(url, hugofs.SourceFs)
 }
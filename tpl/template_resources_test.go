@@ -20,9 +20,11 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/viper"
 )
 
 func TestScpCache(t *testing.T) {
@@ -157,6 +159,59 @@ func TestScpGetRemote(t *testing.T) {
 	}
 }
 
+func TestScpGetRemoteError(t *testing.T) {
+	fs := new(afero.MemMapFs)
+	srv, cl := getTestServer(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	defer srv.Close()
+
+	if c, err := resGetRemote("http://Foo.Bar/missing", fs, cl); err == nil {
+		t.Errorf("Expected an error for a 404, got %s", c)
+	}
+	if cc, _ := resGetCache("http://Foo.Bar/missing", fs, false); cc != nil {
+		t.Errorf("Expected the error page not to be cached, got %s", cc)
+	}
+}
+
+func TestScpCacheMaxAge(t *testing.T) {
+	viper.Set("RemoteCacheMaxAge", "1h")
+	defer viper.Set("RemoteCacheMaxAge", "")
+
+	fs := new(afero.MemMapFs)
+	if err := resWriteCache("http://Foo.Bar/fresh", []byte("fresh"), fs); err != nil {
+		t.Fatal(err)
+	}
+	if cc, _ := resGetCache("http://Foo.Bar/fresh", fs, false); string(cc) != "fresh" {
+		t.Errorf("Expected the fresh content from the cache, got %s", cc)
+	}
+
+	viper.Set("RemoteCacheMaxAge", "1ns")
+	time.Sleep(time.Millisecond)
+	if cc, _ := resGetCache("http://Foo.Bar/fresh", fs, false); cc != nil {
+		t.Errorf("Expected the expired content not to be used, got %s", cc)
+	}
+}
+
+func TestRemoteClientTimeout(t *testing.T) {
+	viper.Set("RemoteTimeout", "50ms")
+	defer viper.Set("RemoteTimeout", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	if _, err := remoteClient().Get(srv.URL); err == nil {
+		t.Errorf("Expected the download to time out")
+	}
+
+	viper.Set("RemoteTimeout", "")
+	if remoteClient() != http.DefaultClient {
+		t.Errorf("Expected the default client without a timeout")
+	}
+}
+
 func TestParseCSV(t *testing.T) {
 
 	tests := []struct {
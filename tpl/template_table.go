@@ -0,0 +1,220 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/hugo/helpers"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// A Table is a data table, as shown by the table shortcode.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// DataTable reads the table for the table shortcode, with its params:
+//
+//	src     a CSV or JSON file, local or remote, as for getCSV and getJSON
+//	data    or the dotted key of a table in the data files, e.g. "shop.prices"
+//	columns the columns to show, by header or by number from 1, e.g. "name,price"
+//	header  "false" if the first row of a CSV file is not a header
+//	sep     the separator of a CSV file, "," by default
+//
+// A table is a list of rows, each a list of cells or an object with the
+// columns as keys. siteData is .Site.Data, or a pointer to it.
+func DataTable(siteData interface{}, params interface{}) *Table {
+	p := tableParams(params)
+
+	t, err := readTable(siteData, p)
+	if err == nil && p["columns"] != "" {
+		err = t.selectColumns(strings.Split(p["columns"], ","))
+	}
+	if err != nil {
+		jww.ERROR.Printf("Unable to read the table %s: %s", p["src"]+p["data"], err)
+		return nil
+	}
+	return t
+}
+
+// tableParams returns the named params of the shortcode, the first
+// positional param being the src.
+func tableParams(params interface{}) map[string]string {
+	if named, ok := params.(map[string]string); ok {
+		return named
+	}
+	p := make(map[string]string)
+	if positional, ok := params.([]string); ok && len(positional) > 0 {
+		p["src"] = positional[0]
+	}
+	return p
+}
+
+func readTable(siteData interface{}, p map[string]string) (*Table, error) {
+	hasHeader := p["header"] != "false"
+
+	if key := p["data"]; key != "" {
+		var data interface{} = siteData
+		if v := reflect.ValueOf(siteData); v.Kind() == reflect.Ptr && !v.IsNil() {
+			data = v.Elem().Interface()
+		}
+		for _, k := range strings.Split(key, ".") {
+			data = cast.ToStringMap(data)[k]
+		}
+		if data == nil {
+			return nil, errors.New("no such data")
+		}
+		return tableFromData(data, hasHeader)
+	}
+
+	src := p["src"]
+	if src == "" {
+		return nil, errors.New("src or data is needed")
+	}
+	c, err := resGetResource(src)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, errors.New("no such file")
+	}
+
+	if strings.ToLower(path.Ext(src)) == ".json" {
+		var data interface{}
+		if err := json.Unmarshal(c, &data); err != nil {
+			return nil, err
+		}
+		return tableFromData(data, hasHeader)
+	}
+
+	sep := p["sep"]
+	if sep == "" {
+		sep = ","
+	}
+	records, err := parseCSV(c, sep)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{Rows: records}
+	if hasHeader && len(records) > 0 {
+		t.Header, t.Rows = records[0], records[1:]
+	}
+	return t, nil
+}
+
+// tableFromData makes a table of a list of lists or of objects. The header
+// of a list of objects is made of the keys of the objects, sorted.
+func tableFromData(data interface{}, hasHeader bool) (*Table, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%T is not a list of rows", data)
+	}
+
+	t := &Table{}
+	var objects []map[string]interface{}
+	for i := 0; i < v.Len(); i++ {
+		row := v.Index(i).Interface()
+		switch rv := reflect.ValueOf(row); rv.Kind() {
+		case reflect.Map:
+			objects = append(objects, cast.ToStringMap(row))
+		case reflect.Slice:
+			cells := make([]string, rv.Len())
+			for j := range cells {
+				cells[j] = cast.ToString(rv.Index(j).Interface())
+			}
+			t.Rows = append(t.Rows, cells)
+		default:
+			return nil, fmt.Errorf("row %d is not a list or an object", i+1)
+		}
+	}
+
+	if objects == nil {
+		if hasHeader && len(t.Rows) > 0 {
+			t.Header, t.Rows = t.Rows[0], t.Rows[1:]
+		}
+		return t, nil
+	}
+	if t.Rows != nil {
+		return nil, errors.New("rows are both lists and objects")
+	}
+
+	for _, o := range objects {
+		for k := range o {
+			if !helpers.InStringArray(t.Header, k) {
+				t.Header = append(t.Header, k)
+			}
+		}
+	}
+	sort.Strings(t.Header)
+	for _, o := range objects {
+		cells := make([]string, len(t.Header))
+		for j, k := range t.Header {
+			cells[j] = cast.ToString(o[k])
+		}
+		t.Rows = append(t.Rows, cells)
+	}
+	return t, nil
+}
+
+// selectColumns keeps the columns, given by header or by number from 1,
+// in their order.
+func (t *Table) selectColumns(columns []string) error {
+	indices := make([]int, len(columns))
+	for i, c := range columns {
+		c = strings.TrimSpace(c)
+		indices[i] = -1
+		for j, h := range t.Header {
+			if h == c {
+				indices[i] = j
+				break
+			}
+		}
+		if indices[i] < 0 {
+			n, err := strconv.Atoi(c)
+			if err != nil || n < 1 {
+				return fmt.Errorf("no column %q", c)
+			}
+			indices[i] = n - 1
+		}
+	}
+
+	pick := func(row []string) []string {
+		if row == nil {
+			return nil
+		}
+		picked := make([]string, len(indices))
+		for i, j := range indices {
+			if j < len(row) {
+				picked[i] = row[j]
+			}
+		}
+		return picked
+	}
+
+	t.Header = pick(t.Header)
+	for i, row := range t.Rows {
+		t.Rows[i] = pick(row)
+	}
+	return nil
+}
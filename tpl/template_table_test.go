@@ -0,0 +1,79 @@
+package tpl
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+)
+
+func TestDataTable(t *testing.T) {
+	defer func(fs afero.Fs) { hugofs.SourceFs = fs }(hugofs.SourceFs)
+	hugofs.SourceFs = new(afero.MemMapFs)
+
+	for name, content := range map[string]string{
+		"prices.csv":   "name;price;stock\napple;1.5;10\npear;2;0\n",
+		"prices.json":  `[{"name": "apple", "price": 1.5}, {"name": "pear", "price": 2, "stock": 0}]`,
+		"matrix.json":  `[[1, 2], [3, 4]]`,
+		"broken.json":  `{"name": "apple"}`,
+		"numbers.csv":  "1,2,3\n4,5,6\n",
+		"mixed.json":   `[[1, 2], {"name": "apple"}]`,
+		"notjson.json": `[`,
+	} {
+		helpers.WriteToDisk(name, bytes.NewReader([]byte(content)), hugofs.SourceFs)
+	}
+
+	siteData := map[string]interface{}{
+		"shop": map[string]interface{}{
+			"prices": []interface{}{
+				map[interface{}]interface{}{"name": "apple", "price": 1.5},
+				map[interface{}]interface{}{"name": "pear", "price": 2},
+			},
+		},
+	}
+
+	for i, this := range []struct {
+		params interface{}
+		expect *Table
+	}{
+		{map[string]string{"src": "prices.csv", "sep": ";"}, &Table{
+			[]string{"name", "price", "stock"},
+			[][]string{{"apple", "1.5", "10"}, {"pear", "2", "0"}},
+		}},
+		{map[string]string{"src": "prices.csv", "sep": ";", "columns": "price, name"}, &Table{
+			[]string{"price", "name"},
+			[][]string{{"1.5", "apple"}, {"2", "pear"}},
+		}},
+		{map[string]string{"src": "numbers.csv", "header": "false", "columns": "3,1"}, &Table{
+			nil,
+			[][]string{{"3", "1"}, {"6", "4"}},
+		}},
+		{[]string{"prices.json"}, &Table{
+			[]string{"name", "price", "stock"},
+			[][]string{{"apple", "1.5", ""}, {"pear", "2", "0"}},
+		}},
+		{map[string]string{"src": "matrix.json"}, &Table{
+			[]string{"1", "2"},
+			[][]string{{"3", "4"}},
+		}},
+		{map[string]string{"data": "shop.prices", "columns": "name"}, &Table{
+			[]string{"name"},
+			[][]string{{"apple"}, {"pear"}},
+		}},
+		{map[string]string{"src": "missing.csv"}, nil},
+		{map[string]string{"src": "broken.json"}, nil},
+		{map[string]string{"src": "mixed.json"}, nil},
+		{map[string]string{"src": "notjson.json"}, nil},
+		{map[string]string{"src": "prices.csv", "sep": ";", "columns": "cost"}, nil},
+		{map[string]string{"data": "shop.costs"}, nil},
+		{map[string]string{}, nil},
+	} {
+		result := DataTable(siteData, this.params)
+		if !reflect.DeepEqual(result, this.expect) {
+			t.Errorf("[%d] DataTable(%v): got %v, expected %v", i, this.params, result, this.expect)
+		}
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"html/template"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"testing"
@@ -453,6 +458,16 @@ func TestCheckCondition(t *testing.T) {
 		{reflect.ValueOf("foo"), reflect.ValueOf([]int{1, 2}), "", expect{false, false}},
 		{reflect.ValueOf(123), reflect.ValueOf([]int{}), "in", expect{false, false}},
 		{reflect.ValueOf(123), reflect.ValueOf(123), "op", expect{false, true}},
+		{reflect.ValueOf(int64(5)), reflect.ValueOf(5), "ge", expect{true, false}},
+		{reflect.ValueOf(5.5), reflect.ValueOf(5), "gt", expect{true, false}},
+		{reflect.ValueOf(4), reflect.ValueOf(4.5), "lt", expect{true, false}},
+		{reflect.ValueOf(1.5), reflect.ValueOf(1.5), "", expect{true, false}},
+		{reflect.ValueOf(true), reflect.ValueOf(true), "", expect{true, false}},
+		{reflect.ValueOf(true), reflect.ValueOf(false), "ne", expect{true, false}},
+		{reflect.ValueOf(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)), reflect.ValueOf(time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)), "ge", expect{true, false}},
+		{reflect.ValueOf(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)), reflect.ValueOf(time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)), "lt", expect{false, false}},
+		{reflect.ValueOf(int64(45)), reflect.ValueOf([]interface{}{123, 45}), "in", expect{true, false}},
+		{reflect.ValueOf("foo"), reflect.ValueOf([]interface{}{"bar", "foo"}), "in", expect{true, false}},
 	} {
 		result, err := checkCondition(this.value, this.match, this.op)
 		if this.expect.isError {
@@ -843,6 +858,93 @@ func TestReturnWhenSet(t *testing.T) {
 	}
 }
 
+func TestPartial(t *testing.T) {
+	templ := New()
+	templ.AddTemplate("partials/header.html", `<h1>{{ .Title }}</h1>`)
+	templ.AddTemplate("theme/partials/header.html", `<h1>theme</h1>`)
+	templ.AddTemplate("theme/partials/footer.html", `<footer>{{ .Title }}</footer>`)
+	templ.AddTemplate("page.html", `{{ partial "header.html" . }}{{ partial "partials/header.html" . }}{{ partial "footer.html" . }}`)
+
+	var b bytes.Buffer
+	if err := localTemplates.ExecuteTemplate(&b, "page.html", map[string]string{"Title": "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "<h1>Hi</h1><h1>Hi</h1><footer>Hi</footer>" {
+		t.Errorf("Unexpected output: %s", b.String())
+	}
+}
+
+func TestPartialReturn(t *testing.T) {
+	templ := New()
+	templ.AddTemplate("partials/double.html", `ignored{{ return (mul . 2) }}more`)
+	templ.AddTemplate("partials/upto.html", `{{ return (seq .) }}`)
+	templ.AddTemplate("partials/text.html", `<b>{{ . }}</b>`)
+	templ.AddTemplate("page.html", `{{ partial "double.html" 21 }} {{ index (partial "upto.html" 3) 1 }} {{ partial "text.html" "x" }}`)
+
+	if v := Partial("double.html", 4); v != int64(8) {
+		t.Errorf("Expected 8, got %#v", v)
+	}
+
+	var b bytes.Buffer
+	if err := localTemplates.ExecuteTemplate(&b, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "42 2 <b>x</b>" {
+		t.Errorf("Unexpected output: %s", b.String())
+	}
+
+	if len(partialReturns) != 0 {
+		t.Errorf("Expected the returned values to be taken, got %v", partialReturns)
+	}
+
+	// older Go versions keep only the message of the errors of functions
+	_, err := Return("old")
+	if v, ok := takePartialReturn(errors.New(`template: p.html:1:2: executing "p.html" at <return "old">: error calling return: ` + err.Error())); !ok || v != "old" {
+		t.Errorf("Expected the value from the message, got %#v", v)
+	}
+	if _, ok := takePartialReturn(errors.New("some error")); ok {
+		t.Errorf("Expected no value for another error")
+	}
+
+	templ.AddTemplate("notapartial.html", `a{{ return 1 }}`)
+	b.Reset()
+	if err := localTemplates.ExecuteTemplate(&b, "notapartial.html", nil); err == nil {
+		t.Errorf("Expected return outside of a partial to fail")
+	}
+}
+
+func TestHTMLFuncs(t *testing.T) {
+	content := template.HTML(`<p>Tom &amp; <em onclick="x()">Jerry</em></p>`)
+
+	for i, this := range []struct {
+		templ  string
+		expect string
+	}{
+		{`{{ htmlEscape "<b>\"Tom\" & Jerry</b>" }}`, "&amp;lt;b&amp;gt;&amp;#34;Tom&amp;#34; &amp;amp; Jerry&amp;lt;/b&amp;gt;"},
+		{`{{ htmlEscape "<b>" | safeHtml }}`, "&lt;b&gt;"},
+		{`{{ htmlUnescape "Tom &amp; Jerry &lt;3" | safeHtml }}`, "Tom & Jerry <3"},
+		{`{{ plainify . }}`, "Tom &amp; Jerry\n"},
+		{`<meta name="description" content="{{ plainify . }}">`, "<meta name=\"description\" content=\"Tom &amp; Jerry\n\">"},
+		{`{{ jsonify "Tom & \"Jerry\"" }}`, `"Tom \u0026 \"Jerry\""`},
+		{`{{ jsonify (seq 2) }}`, "[1,2]"},
+		{`{{ stripTags . "em" }}`, "Tom &amp; <em>Jerry</em>"},
+		{`<meta name="description" content="{{ stripTags . }}">`, `<meta name="description" content="Tom &amp; Jerry">`},
+	} {
+		templ, err := New().New("test").Parse(this.templ)
+		if err != nil {
+			t.Fatalf("[%d] Unable to parse template: %s", i, err)
+		}
+		var b bytes.Buffer
+		if err := templ.Execute(&b, content); err != nil {
+			t.Errorf("[%d] Unable to execute template: %s", i, err)
+			continue
+		}
+		if b.String() != this.expect {
+			t.Errorf("[%d] Expected %q, got %q", i, this.expect, b.String())
+		}
+	}
+}
+
 func TestMarkdownify(t *testing.T) {
 
 	result := Markdownify("Hello **World!**")
@@ -854,6 +956,42 @@ func TestMarkdownify(t *testing.T) {
 	}
 }
 
+func TestIcon(t *testing.T) {
+	viper.Set("BaseURL", "http://auth/bub/")
+	viper.Set("IconSprite", "img/icons.svg")
+	defer viper.Set("BaseURL", "")
+	defer viper.Set("IconSprite", "")
+
+	for _, this := range []struct {
+		name    string
+		classes []string
+		expect  template.HTML
+	}{
+		{"star", nil, `<svg class="icon icon-star" aria-hidden="true"><use xlink:href="/bub/img/icons.svg#star"></use></svg>`},
+		{"social-rss", []string{"big"}, `<svg class="icon icon-social-rss big" aria-hidden="true"><use xlink:href="/bub/img/icons.svg#social-rss"></use></svg>`},
+		{`"><b>`, nil, `<svg class="icon icon-&#34;&gt;&lt;b&gt;" aria-hidden="true"><use xlink:href="/bub/img/icons.svg#&#34;&gt;&lt;b&gt;"></use></svg>`},
+	} {
+		if result := Icon(this.name, this.classes...); result != this.expect {
+			t.Errorf("Icon(%q): got '%s', expected '%s'", this.name, result, this.expect)
+		}
+	}
+}
+
+func TestHighlightWithOptions(t *testing.T) {
+	if helpers.HasPygments() {
+		t.Skip("Skip test as Pygments is installed")
+	}
+	viper.Set("PygmentsUseClasses", true)
+	defer viper.Set("PygmentsUseClasses", false)
+
+	result := Highlight("x = &amp;y", "go", "linenos=inline", "hl_lines=1")
+	expect := template.HTML(`<div class="highlight"><pre><span class="hll"><span class="lineno">1 </span>x <span class="o">=</span> <span class="o">&amp;</span>y</span></pre></div>` + "\n")
+
+	if result != expect {
+		t.Errorf("Highlight: got '%s', expected '%s'", result, expect)
+	}
+}
+
 func TestApply(t *testing.T) {
 	strings := []interface{}{"a\n", "b\n"}
 	noStringers := []interface{}{tstNoStringer{}, tstNoStringer{}}
@@ -954,6 +1092,11 @@ func TestDateFormat(t *testing.T) {
 		{"This isn't a date layout string", "2015-01-21", "This isn't a date layout string"},
 		{"Monday, Jan 2, 2006", 1421733600, false},
 		{"Monday, Jan 2, 2006", 1421733600.123, false},
+		{"RFC3339", "2015-01-21", "2015-01-21T00:00:00Z"},
+		{"RFC3339", "2015-01-21T10:00:00+08:00", "2015-01-21T10:00:00+08:00"},
+		{"RFC1123Z", "2015-01-21T10:00:00+08:00", "Wed, 21 Jan 2015 10:00:00 +0800"},
+		{"RFC1123Z", time.Date(2015, time.January, 21, 10, 0, 0, 0, time.UTC), "Wed, 21 Jan 2015 10:00:00 +0000"},
+		{"ISO8601", "2015-01-21", "2015-01-21T00:00:00+00:00"},
 	} {
 		result, err := DateFormat(this.layout, this.value)
 		if b, ok := this.expect.(bool); ok && !b {
@@ -972,6 +1115,41 @@ func TestDateFormat(t *testing.T) {
 	}
 }
 
+func TestDateFormatLocalized(t *testing.T) {
+	result, err := DateFormat("Monday, 2 January 2006", "2015-01-21", "es")
+	if err != nil {
+		t.Fatalf("DateFormat failed: %s", err)
+	}
+	if result != "miércoles, 21 enero 2015" {
+		t.Errorf("DateFormat got %v but expected %v", result, "miércoles, 21 enero 2015")
+	}
+}
+
+func TestTimeAgo(t *testing.T) {
+	for i, this := range []struct {
+		value  interface{}
+		lang   []string
+		expect string
+	}{
+		{time.Now().Add(-3 * 24 * time.Hour), nil, "3 days ago"},
+		{time.Now().Add(-50 * time.Hour), []string{"it"}, "2 giorni fa"},
+		{time.Now().Add(time.Second), nil, "just now"},
+	} {
+		result, err := TimeAgo(this.value, this.lang...)
+		if err != nil {
+			t.Errorf("[%d] TimeAgo failed: %s", i, err)
+			continue
+		}
+		if result != this.expect {
+			t.Errorf("[%d] TimeAgo got %v but expected %v", i, result, this.expect)
+		}
+	}
+
+	if _, err := TimeAgo("not a date"); err == nil {
+		t.Errorf("TimeAgo didn't return an expected error")
+	}
+}
+
 func TestSafeHTML(t *testing.T) {
 	for i, this := range []struct {
 		str                 string
@@ -1111,3 +1289,101 @@ func TestSafeURL(t *testing.T) {
 		}
 	}
 }
+
+func TestOverrideInternalTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-layouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"site/_internal/opengraph.html":      "site opengraph",
+		"theme/_internal/opengraph.html":     "theme opengraph",
+		"theme/_internal/twitter_cards.html": "theme twitter",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ := New()
+	templ.LoadTemplates(filepath.Join(dir, "site"))
+	templ.LoadTemplatesWithPrefix(filepath.Join(dir, "theme"), "theme")
+
+	for name, expected := range map[string]string{
+		"_internal/opengraph.html":       "site opengraph",
+		"_internal/twitter_cards.html":   "theme twitter",
+		"theme/_internal/opengraph.html": "theme opengraph",
+	} {
+		b := new(bytes.Buffer)
+		if err := templ.ExecuteTemplate(b, name, nil); err != nil {
+			t.Errorf("Unable to execute %s: %s", name, err)
+			continue
+		}
+		if b.String() != expected {
+			t.Errorf("%s got %q but expected %q", name, b.String(), expected)
+		}
+	}
+}
+
+func TestBaseTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-layouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"site/_default/baseof.html":    `<html>{{ block "main" . }}main{{ end }}|{{ block "side" . }}side{{ end }}</html>`,
+		"site/_default/single.html":    `{{ define "main" }}single {{ . }}{{ end }}`,
+		"site/_default/list.html":      `{{ define "side" }}list side{{ end }}`,
+		"site/post/single-baseof.html": `<article>{{ block "main" . }}{{ end }}</article>`,
+		"site/post/single.html":        `{{ define "main" }}post {{ . }}{{ end }}`,
+		"site/plain/single.html":       `plain {{ . }}`,
+		"site/full/single.html":        "<p>{{ template \"full/helper\" . }}</p>\n{{ define \"full/helper\" }}full {{ . }}{{ end }}",
+		"site/commented/single.html":   "{{/* fills in baseof.html */}}\n{{- define \"main\" }}commented {{ . }}{{ end }}",
+		"theme/_default/baseof.html":   `<body>{{ block "main" . }}{{ end }}</body>`,
+		"theme/section/single.html":    `{{ define "main" }}theme section{{ end }}`,
+		"theme/_default/terms.html":    `{{ define "main" }}theme terms{{ end }}`,
+		"theme/partials/block.html":    `{{ define "x" }}partial{{ end }}`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templ := New()
+	templ.LoadTemplates(filepath.Join(dir, "site"))
+	templ.LoadTemplatesWithPrefix(filepath.Join(dir, "theme"), "theme")
+
+	for name, expected := range map[string]string{
+		"_default/single.html":      "<html>single x|side</html>",
+		"_default/list.html":        "<html>main|list side</html>",
+		"post/single.html":          "<article>post x</article>",
+		"plain/single.html":         "plain x",
+		"full/single.html":          "<p>full x</p>\n",
+		"commented/single.html":     "<html>commented x|side</html>",
+		"theme/section/single.html": "<html>theme section|side</html>",
+		"theme/_default/terms.html": "<html>theme terms|side</html>",
+	} {
+		b := new(bytes.Buffer)
+		if err := templ.ExecuteTemplate(b, name, "x"); err != nil {
+			t.Errorf("Unable to execute %s: %s", name, err)
+			continue
+		}
+		if b.String() != expected {
+			t.Errorf("%s got %q but expected %q", name, b.String(), expected)
+		}
+	}
+
+	b := new(bytes.Buffer)
+	ExecuteTemplate("x", b, "post/single.html")
+	if b.String() != "<article>post x</article>" {
+		t.Errorf("ExecuteTemplate got %q", b.String())
+	}
+}
@@ -23,6 +23,18 @@ func AbsURL(absURL string) (trs []link, err error) {
 	return
 }
 
+// RelativeURL makes the root-relative URLs of the src and href attributes
+// relative to the page, with root the path from the page to the root of the
+// site, e.g. "../..".
+func RelativeURL(root string) (trs []link, err error) {
+	r := newAbsurlReplacer(root)
+
+	trs = append(trs, func(content []byte) []byte {
+		return r.replaceInHTML(content)
+	})
+	return
+}
+
 func AbsURLInXML(absURL string) (trs []link, err error) {
 	initAbsurlReplacer(absURL)
 
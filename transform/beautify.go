@@ -0,0 +1,227 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+const beautifyIndent = "  "
+
+// elements that start on a line of their own and indent their children
+var blockElements = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true,
+	"link": true, "base": true, "div": true, "p": true, "ul": true,
+	"ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"nav": true, "header": true, "footer": true, "main": true,
+	"section": true, "article": true, "aside": true, "figure": true,
+	"figcaption": true, "blockquote": true, "form": true, "fieldset": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true,
+	"td": true, "th": true, "caption": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "hr": true,
+	"noscript": true, "pre": true, "script": true, "style": true,
+	"textarea": true, "select": true, "option": true,
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// elements whose content is whitespace sensitive or not HTML at all
+var rawElements = map[string]bool{
+	"pre": true, "script": true, "style": true, "textarea": true,
+}
+
+// BeautifyHTML re-indents an HTML document with one block element per line
+// and two spaces per level, which makes the generated output easy to diff.
+// Whitespace in text is collapsed, except in pre, textarea, script and
+// style elements, which are written untouched.
+func BeautifyHTML(content []byte) (beautified []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			jww.ERROR.Println("Recovered in BeautifyHTML", r)
+			beautified = content
+		}
+	}()
+
+	b := &beautifier{htmlScanner: htmlScanner{in: content}}
+	b.run()
+	return b.out.Bytes()
+}
+
+// htmlScanner reads an HTML document a tag or a run of text at a time.
+type htmlScanner struct {
+	in  []byte
+	pos int
+}
+
+type beautifier struct {
+	htmlScanner
+	depth int
+	line  bytes.Buffer
+	out   bytes.Buffer
+}
+
+func (b *beautifier) run() {
+	for b.pos < len(b.in) {
+		i := bytes.IndexByte(b.in[b.pos:], '<')
+		if i != 0 {
+			end := len(b.in)
+			if i > 0 {
+				end = b.pos + i
+			}
+			b.text(b.in[b.pos:end])
+			b.pos = end
+			continue
+		}
+
+		switch {
+		case bytes.HasPrefix(b.in[b.pos:], []byte("<!--")):
+			b.own(b.until("-->"))
+		case bytes.HasPrefix(b.in[b.pos:], []byte("<!")), bytes.HasPrefix(b.in[b.pos:], []byte("<?")):
+			b.own(b.until(">"))
+		default:
+			b.tag(b.tagEnd())
+		}
+	}
+	b.flush()
+}
+
+// text adds text to the current line, collapsing its whitespace.
+func (b *beautifier) text(t []byte) {
+	fields := bytes.Fields(t)
+	if len(fields) == 0 {
+		if len(t) > 0 && b.line.Len() > 0 {
+			b.line.WriteByte(' ')
+		}
+		return
+	}
+	if isSpace(t[0]) && b.line.Len() > 0 {
+		b.line.WriteByte(' ')
+	}
+	b.line.Write(bytes.Join(fields, []byte(" ")))
+	if isSpace(t[len(t)-1]) {
+		b.line.WriteByte(' ')
+	}
+}
+
+func (b *beautifier) tag(t []byte) {
+	name, closing := tagName(t)
+
+	if !blockElements[name] {
+		b.line.Write(t)
+		return
+	}
+
+	if closing {
+		b.flush()
+		if b.depth > 0 {
+			b.depth--
+		}
+		b.own(t)
+		return
+	}
+
+	if rawElements[name] {
+		b.flush()
+		b.writeIndent()
+		b.out.Write(t)
+		b.out.Write(b.rawContent(name))
+		b.out.WriteByte('\n')
+		return
+	}
+
+	b.own(t)
+	if !voidElements[name] && !bytes.HasSuffix(t, []byte("/>")) {
+		b.depth++
+	}
+}
+
+// own writes t on a line of its own.
+func (b *beautifier) own(t []byte) {
+	b.flush()
+	b.writeIndent()
+	b.out.Write(t)
+	b.out.WriteByte('\n')
+}
+
+func (b *beautifier) flush() {
+	l := bytes.TrimSpace(b.line.Bytes())
+	if len(l) > 0 {
+		b.writeIndent()
+		b.out.Write(l)
+		b.out.WriteByte('\n')
+	}
+	b.line.Reset()
+}
+
+func (b *beautifier) writeIndent() {
+	b.out.WriteString(strings.Repeat(beautifyIndent, b.depth))
+}
+
+// until consumes the input up to and including sep.
+func (b *htmlScanner) until(sep string) []byte {
+	start := b.pos
+	i := bytes.Index(b.in[b.pos:], []byte(sep))
+	if i < 0 {
+		b.pos = len(b.in)
+	} else {
+		b.pos += i + len(sep)
+	}
+	return b.in[start:b.pos]
+}
+
+// tagEnd consumes a tag, minding '>' in quoted attribute values.
+func (b *htmlScanner) tagEnd() []byte {
+	start := b.pos
+	var quote byte
+	for b.pos++; b.pos < len(b.in); b.pos++ {
+		c := b.in[b.pos]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			b.pos++
+			return b.in[start:b.pos]
+		}
+	}
+	return b.in[start:]
+}
+
+// rawContent consumes everything up to and including the closing tag
+// of the named element.
+func (b *htmlScanner) rawContent(name string) []byte {
+	start := b.pos
+	i := bytes.Index(bytes.ToLower(b.in[b.pos:]), []byte("</"+name))
+	if i < 0 {
+		b.pos = len(b.in)
+		return b.in[start:]
+	}
+	b.pos += i
+	b.tagEnd()
+	return b.in[start:b.pos]
+}
+
+func tagName(t []byte) (string, bool) {
+	t = bytes.TrimPrefix(t, []byte("<"))
+	closing := bytes.HasPrefix(t, []byte("/"))
+	if closing {
+		t = t[1:]
+	}
+	end := bytes.IndexAny(t, " \t\r\n/>")
+	if end >= 0 {
+		t = t[:end]
+	}
+	return strings.ToLower(string(t)), closing
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,68 @@
+package transform
+
+import (
+	"bytes"
+	"testing"
+)
+
+const BEAUTIFY_IN = "<!DOCTYPE html>\n<html><head><title>Title</title>\n<meta charset=\"utf-8\"><script>var a = 1;\n  if (a > 0) {}</script></head>" +
+	"<body><nav><ul><li><a href=\"/a\">A</a></li>   <li><a href='/b>c'>B</a></li></ul></nav>\n\n" +
+	"<article><p>Some   <em>emphasized</em>\n text.</p><pre><code>  keep\n    this</code></pre><hr/></article><!-- note --></body></html>"
+
+const BEAUTIFY_OUT = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>
+      Title
+    </title>
+    <meta charset="utf-8">
+    <script>var a = 1;
+  if (a > 0) {}</script>
+  </head>
+  <body>
+    <nav>
+      <ul>
+        <li>
+          <a href="/a">A</a>
+        </li>
+        <li>
+          <a href='/b>c'>B</a>
+        </li>
+      </ul>
+    </nav>
+    <article>
+      <p>
+        Some <em>emphasized</em> text.
+      </p>
+      <pre><code>  keep
+    this</code></pre>
+      <hr/>
+    </article>
+    <!-- note -->
+  </body>
+</html>
+`
+
+func TestBeautifyHTML(t *testing.T) {
+	out := BeautifyHTML([]byte(BEAUTIFY_IN))
+	if string(out) != BEAUTIFY_OUT {
+		t.Errorf("Expected\n%s\ngot\n%s", BEAUTIFY_OUT, out)
+	}
+
+	if again := BeautifyHTML(out); !bytes.Equal(again, out) {
+		t.Errorf("Beautifying twice should be stable, got\n%s", again)
+	}
+}
+
+func TestBeautifyHTMLInChain(t *testing.T) {
+	tr := NewChain(BeautifyHTML)
+	out := new(bytes.Buffer)
+	if err := tr.Apply(out, bytes.NewBufferString("<div><p>a</p></div>")); err != nil {
+		t.Fatalf("Apply failed: %s", err)
+	}
+
+	expected := "<div>\n  <p>\n    a\n  </p>\n</div>\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
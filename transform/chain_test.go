@@ -72,6 +72,17 @@ func TestAbsURL(t *testing.T) {
 
 }
 
+func TestRelativeURL(t *testing.T) {
+	for _, root := range []string{".", "../.."} {
+		relURL, _ := RelativeURL(root)
+		tr := NewChain(relURL...)
+		apply(t.Errorf, tr, []test{
+			{REPLACE_SCHEMALESS_HTML, strings.Replace(REPLACE_SCHEMALESS_HTML_CORRECT, "http://base", root, -1)},
+			{H5_JS_CONTENT_ABS_URL, H5_JS_CONTENT_ABS_URL},
+		})
+	}
+}
+
 func BenchmarkXMLAbsURL(b *testing.B) {
 	absURLInXML, _ := AbsURLInXML("http://base")
 	tr := NewChain(absURLInXML...)
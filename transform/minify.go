@@ -0,0 +1,162 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// Minifier returns the minification of the media type: HTML for
+// text/html, XML for application/xml and the other XML types such as
+// application/rss+xml, and JSON for application/json and the like. It is
+// nil for the others.
+func Minifier(mediaType string) func([]byte) []byte {
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	switch {
+	case mediaType == "text/html":
+		return MinifyHTML
+	case strings.HasSuffix(mediaType, "/xml"), strings.HasSuffix(mediaType, "+xml"):
+		return MinifyXML
+	case strings.HasSuffix(mediaType, "/json"), strings.HasSuffix(mediaType, "+json"):
+		return MinifyJSON
+	}
+	return nil
+}
+
+// MinifyHTML removes the comments of an HTML document, except conditional
+// comments, and collapses the whitespace of its text to a single space, or
+// none around block elements. pre, textarea, script and style elements are
+// written untouched.
+func MinifyHTML(content []byte) (minified []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			jww.ERROR.Println("Recovered in MinifyHTML", r)
+			minified = content
+		}
+	}()
+
+	m := &htmlMinifier{htmlScanner: htmlScanner{in: content}, afterBlock: true}
+	m.run()
+	return m.out.Bytes()
+}
+
+type htmlMinifier struct {
+	htmlScanner
+	out bytes.Buffer
+
+	// space is whitespace read and not yet written, which is dropped next
+	// to block elements
+	space      bool
+	afterBlock bool
+}
+
+func (m *htmlMinifier) run() {
+	for m.pos < len(m.in) {
+		i := bytes.IndexByte(m.in[m.pos:], '<')
+		if i != 0 {
+			end := len(m.in)
+			if i > 0 {
+				end = m.pos + i
+			}
+			m.text(m.in[m.pos:end])
+			m.pos = end
+			continue
+		}
+
+		switch {
+		case bytes.HasPrefix(m.in[m.pos:], []byte("<!--")):
+			c := m.until("-->")
+			if bytes.HasPrefix(c, []byte("<!--[if")) || bytes.HasPrefix(c, []byte("<!--<![endif")) {
+				m.write(c, true)
+			}
+		case bytes.HasPrefix(m.in[m.pos:], []byte("<!")), bytes.HasPrefix(m.in[m.pos:], []byte("<?")):
+			m.write(m.until(">"), true)
+		default:
+			t := m.tagEnd()
+			name, closing := tagName(t)
+			m.write(t, blockElements[name])
+			if !closing && rawElements[name] {
+				m.out.Write(m.rawContent(name))
+			}
+		}
+	}
+}
+
+func (m *htmlMinifier) text(t []byte) {
+	fields := bytes.Fields(t)
+	if len(fields) == 0 {
+		m.space = m.space || len(t) > 0
+		return
+	}
+	if isSpace(t[0]) {
+		m.space = true
+	}
+	m.write(bytes.Join(fields, []byte(" ")), false)
+	m.space = isSpace(t[len(t)-1])
+}
+
+// write writes t, after the pending whitespace unless t or what comes
+// before it is a block element.
+func (m *htmlMinifier) write(t []byte, block bool) {
+	if m.space && !block && !m.afterBlock {
+		m.out.WriteByte(' ')
+	}
+	m.space = false
+	m.out.Write(t)
+	m.afterBlock = block
+}
+
+// MinifyXML removes the comments of an XML document and the whitespace
+// between its elements. Text and CDATA sections are written untouched.
+func MinifyXML(content []byte) (minified []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			jww.ERROR.Println("Recovered in MinifyXML", r)
+			minified = content
+		}
+	}()
+
+	s := &htmlScanner{in: content}
+	out := new(bytes.Buffer)
+	for s.pos < len(s.in) {
+		i := bytes.IndexByte(s.in[s.pos:], '<')
+		if i != 0 {
+			end := len(s.in)
+			if i > 0 {
+				end = s.pos + i
+			}
+			if len(bytes.TrimSpace(s.in[s.pos:end])) > 0 {
+				out.Write(s.in[s.pos:end])
+			}
+			s.pos = end
+			continue
+		}
+
+		switch {
+		case bytes.HasPrefix(s.in[s.pos:], []byte("<!--")):
+			s.until("-->")
+		case bytes.HasPrefix(s.in[s.pos:], []byte("<![CDATA[")):
+			out.Write(s.until("]]>"))
+		default:
+			out.Write(s.tagEnd())
+		}
+	}
+	return out.Bytes()
+}
+
+// MinifyJSON removes the insignificant whitespace of a JSON document. Invalid
+// JSON is written untouched.
+func MinifyJSON(content []byte) []byte {
+	out := new(bytes.Buffer)
+	if err := json.Compact(out, content); err != nil {
+		jww.ERROR.Println("Unable to minify JSON:", err)
+		return content
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,65 @@
+package transform
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMinifyHTML(t *testing.T) {
+	out := MinifyHTML([]byte(BEAUTIFY_IN))
+	expected := "<!DOCTYPE html><html><head><title>Title</title><meta charset=\"utf-8\"><script>var a = 1;\n  if (a > 0) {}</script></head>" +
+		"<body><nav><ul><li><a href=\"/a\">A</a></li><li><a href='/b>c'>B</a></li></ul></nav>" +
+		"<article><p>Some <em>emphasized</em> text.</p><pre><code>  keep\n    this</code></pre><hr/></article></body></html>"
+	if string(out) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out)
+	}
+
+	if again := MinifyHTML(out); !bytes.Equal(again, out) {
+		t.Errorf("Minifying twice should be stable, got\n%s", again)
+	}
+
+	for i, this := range []struct {
+		in, expected string
+	}{
+		{"<p>a <a href=\"/\">b</a> <b>c</b>\n</p>", "<p>a <a href=\"/\">b</a> <b>c</b></p>"},
+		{"<span>  a </span>\n <em>b</em>", "<span> a </span> <em>b</em>"},
+		{"<!--[if lt IE 9]><script src=\"x.js\"></script><![endif]--> <!-- gone -->", "<!--[if lt IE 9]><script src=\"x.js\"></script><![endif]-->"},
+		{"<textarea>\n  a  b\n</textarea>", "<textarea>\n  a  b\n</textarea>"},
+	} {
+		if out := MinifyHTML([]byte(this.in)); string(out) != this.expected {
+			t.Errorf("[%d] Expected %q, got %q", i, this.expected, out)
+		}
+	}
+}
+
+func TestMinifyXML(t *testing.T) {
+	in := "<?xml version=\"1.0\" encoding=\"utf-8\" ?>\n<rss>\n  <!-- feed -->\n  <channel>\n    <title>A  &amp; B</title>\n" +
+		"    <description><![CDATA[  <p>x</p>  ]]></description>\n  </channel>\n</rss>\n"
+	expected := "<?xml version=\"1.0\" encoding=\"utf-8\" ?><rss><channel><title>A  &amp; B</title>" +
+		"<description><![CDATA[  <p>x</p>  ]]></description></channel></rss>"
+	if out := MinifyXML([]byte(in)); string(out) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out)
+	}
+}
+
+func TestMinifyJSON(t *testing.T) {
+	if out := MinifyJSON([]byte("{\n  \"a\": [1, 2],\n  \"b\": \"x  y\"\n}\n")); string(out) != `{"a":[1,2],"b":"x  y"}` {
+		t.Errorf("Unexpected minified JSON %s", out)
+	}
+	if out := MinifyJSON([]byte("{ nope")); string(out) != "{ nope" {
+		t.Errorf("Expected invalid JSON untouched, got %s", out)
+	}
+}
+
+func TestMinifier(t *testing.T) {
+	for _, mediaType := range []string{"text/html", "text/html; charset=utf-8", "application/xml", "application/rss+xml", "application/json", "application/feed+json"} {
+		if Minifier(mediaType) == nil {
+			t.Errorf("Expected a minifier for %s", mediaType)
+		}
+	}
+	for _, mediaType := range []string{"text/plain", "text/css", "html"} {
+		if Minifier(mediaType) != nil {
+			t.Errorf("Expected no minifier for %s", mediaType)
+		}
+	}
+}